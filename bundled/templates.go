@@ -23,6 +23,25 @@ func TemplateOSCAL() (pkging.File, error) {
 	return pkger.Open("/bundled/templates/FedRAMP-SSP-OSCAL-Template.xml")
 }
 
+// TemplateSCNDOCX returns the Word document the SCN DOCX renderer fills in
+func TemplateSCNDOCX() (pkging.File, error) {
+	return pkger.Open("/bundled/templates/FedRAMP-SCN-Template.docx")
+}
+
+// TemplateSCN returns the default Go template for rendering an SCN in the
+// given format (markdown, html or docx)
+func TemplateSCN(format string) (pkging.File, error) {
+	switch format {
+	case "markdown":
+		return pkger.Open("/bundled/templates/scn/scn.md.tmpl")
+	case "html":
+		return pkger.Open("/bundled/templates/scn/scn.html.tmpl")
+	case "docx":
+		return pkger.Open("/bundled/templates/scn/scn.docx.tmpl")
+	}
+	return nil, errors.New("Not supported")
+}
+
 func CatalogOSCAL(baseline common.BaselineLevel) (pkging.File, error) {
 	switch baseline {
 	case common.LevelLow:
@@ -31,7 +31,12 @@ func Execute() error {
 		scnCommand,
 		ksiCommand,
 		masCommand,
+		sapCommand,
+		sarCommand,
+		evidenceCommand,
 		ssadCommand,
+		conmonCommand,
+		poamCommand,
 		FRMR(),
 	}
 
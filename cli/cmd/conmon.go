@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/urfave/cli"
+)
+
+var conmonCommand = cli.Command{
+	Name:  "conmon",
+	Usage: "Continuous Monitoring deliverables",
+	Subcommands: []cli.Command{
+		conmonPackageCommand,
+	},
+}
+
+var conmonPackageCommand = cli.Command{
+	Name:  "package",
+	Usage: "Assemble the monthly Continuous Monitoring package",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "month",
+			Usage: "Reporting month, e.g. 2026-09",
+		},
+		cli.StringFlag{
+			Name:  "service-id",
+			Usage: "Service offering ID",
+		},
+		cli.StringFlag{
+			Name:  "records",
+			Usage: "CRS records JSON holding the normalized scan results",
+		},
+		cli.StringFlag{
+			Name:  "poam",
+			Usage: "POA&M JSON to reconcile with the scan results; the reconciled POA&M is written back to it",
+		},
+		cli.StringFlag{
+			Name:  "inventory",
+			Usage: "Inventory file to include in the package",
+		},
+		cli.StringFlag{
+			Name:  "scn-store",
+			Usage: "Directory of the SCN store",
+			Value: "data/scn",
+		},
+		cli.StringFlag{
+			Name:  "author",
+			Usage: "Author of the package documents",
+		},
+		cli.StringFlag{
+			Name:  "output-dir, o",
+			Usage: "Output directory (defaults to conmon-<service-id>-<month>)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("month") == "" || c.String("service-id") == "" {
+			return cli.NewExitError("--month and --service-id are required", 1)
+		}
+		period, err := fedramp.ParseReportingPeriod(c.String("month"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing month: %v", err), 1)
+		}
+		serviceID := c.String("service-id")
+
+		inputs := fedramp.ConMonInputs{
+			ServiceID:     serviceID,
+			Label:         c.String("month"),
+			Period:        period,
+			InventoryPath: c.String("inventory"),
+			Author:        c.String("author"),
+		}
+
+		if path := c.String("records"); path != "" {
+			records, err := fedramp.LoadCRSRecords(path)
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading records: %v", err), 1)
+			}
+			inputs.Scans = records.Scans
+		}
+
+		if path := c.String("poam"); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error reading POA&M: %v", err), 1)
+			}
+			var poam fedramp.PlanOfActionMilestones
+			if err := json.Unmarshal(data, &poam); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error parsing POA&M: %v", err), 1)
+			}
+			inputs.POAM = &poam
+		}
+
+		if _, err := os.Stat(c.String("scn-store")); err == nil {
+			store, err := fedramp.NewFileSCNStore(c.String("scn-store"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error opening SCN store: %v", err), 1)
+			}
+			inputs.SCNs, err = store.GetSCNsByCSOID(serviceID)
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error listing SCNs: %v", err), 1)
+			}
+		}
+
+		outputDir := c.String("output-dir")
+		if outputDir == "" {
+			outputDir = fmt.Sprintf("conmon-%s-%s", serviceID, inputs.Label)
+		}
+		pkg, summary, err := fedramp.BuildConMonPackage(inputs, outputDir)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error building package: %v", err), 1)
+		}
+
+		data, err := json.MarshalIndent(pkg, "", "  ")
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error generating package: %v", err), 1)
+		}
+		packageFile := filepath.Join(outputDir, fmt.Sprintf("conmon-%s-%s-package.json", serviceID, inputs.Label))
+		if err := os.WriteFile(packageFile, data, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing file: %v", err), 1)
+		}
+		if path := c.String("poam"); path != "" {
+			poamData, err := inputs.POAM.ToJSON()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			if err := os.WriteFile(path, poamData, 0644); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error writing POA&M: %v", err), 1)
+			}
+		}
+
+		fmt.Printf("Continuous Monitoring package created:\n")
+		fmt.Printf("  Package ID: %s\n", pkg.PackageID)
+		fmt.Printf("  Scans: %d\n", summary.ScansCompleted)
+		fmt.Printf("  POA&M: %d opened, %d closed, %d reopened, %d suppressed by deviation, %d open\n",
+			len(summary.Reconciliation.Added), len(summary.Reconciliation.Closed),
+			len(summary.Reconciliation.Reopened), len(summary.Reconciliation.Suppressed), summary.POAM.OpenItems)
+		fmt.Printf("  Open SCNs: %d\n", len(summary.OpenSCNs))
+		fmt.Printf("  Documents: %d\n", len(summary.Documents))
+		fmt.Printf("  Package hash: %s\n", pkg.IntegrityCheck.PackageHash)
+		fmt.Printf("  Saved to: %s\n", packageFile)
+		if path := c.String("poam"); path != "" {
+			fmt.Printf("  POA&M updated: %s\n", path)
+		}
+
+		return nil
+	},
+}
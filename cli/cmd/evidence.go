@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/urfave/cli"
+)
+
+var evidenceCommand = cli.Command{
+	Name:  "evidence",
+	Usage: "Evidence locker operations",
+	Subcommands: []cli.Command{
+		evidenceIngestCommand,
+		evidenceListCommand,
+		evidenceShowCommand,
+		evidenceExportCommand,
+		evidenceVerifyCommand,
+		evidenceCustodyCommand,
+		evidenceDisposeCommand,
+	},
+}
+
+var evidenceLockerFlag = cli.StringFlag{
+	Name:  "locker",
+	Usage: "Directory of the evidence locker",
+	Value: "data/evidence",
+}
+
+var evidenceActorFlag = cli.StringFlag{
+	Name:   "actor",
+	Usage:  "Person recorded in the chain of custody",
+	EnvVar: "USER",
+}
+
+var evidencePurposeFlag = cli.StringFlag{
+	Name:  "purpose",
+	Usage: "Reason for the access, recorded in the chain of custody",
+}
+
+var evidenceIngestCommand = cli.Command{
+	Name:      "ingest",
+	Usage:     "Store evidence files in the locker",
+	ArgsUsage: "[file...]",
+	Description: `Files are stored once under the SHA-256 digest of their content and
+   referenced by locker ID (sha256:<digest>). Ingesting a file already held
+   keeps its original provenance and only extends its retention.`,
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+		evidenceActorFlag,
+		cli.StringFlag{
+			Name:  "collector",
+			Usage: "Person or tool that collected the evidence (defaults to --actor)",
+		},
+		cli.StringFlag{
+			Name:  "collected-at",
+			Usage: "Collection time (RFC 3339, defaults to now)",
+		},
+		cli.StringFlag{
+			Name:  "source",
+			Usage: "System the evidence was collected from",
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Usage: "Evidence type (screenshot, configuration, scan_report, ...)",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "Evidence description",
+		},
+		cli.IntFlag{
+			Name:  "retain-years",
+			Usage: "Years to retain the evidence",
+			Value: 3,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return cli.NewExitError("At least one evidence file is required", 1)
+		}
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		template := fedramp.EvidenceRecord{
+			Type:         c.String("type"),
+			Description:  c.String("description"),
+			Collector:    c.String("collector"),
+			SourceSystem: c.String("source"),
+		}
+		if template.Collector == "" {
+			template.Collector = c.String("actor")
+		}
+		if c.String("collected-at") != "" {
+			if template.CollectedAt, err = time.Parse(time.RFC3339, c.String("collected-at")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error parsing collection time: %v", err), 1)
+			}
+		}
+		if c.Int("retain-years") <= 0 {
+			return cli.NewExitError("--retain-years must be positive", 1)
+		}
+		template.RetainUntil = time.Now().AddDate(c.Int("retain-years"), 0, 0)
+
+		for _, path := range c.Args() {
+			record, err := ingestEvidenceFile(locker, path, template, c.String("actor"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error ingesting %s: %v", path, err), 1)
+			}
+			fmt.Printf("%s  %s\n", record.LockerID, path)
+		}
+		return nil
+	},
+}
+
+var evidenceListCommand = cli.Command{
+	Name:  "list",
+	Usage: "List the evidence held in the locker",
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+	},
+	Action: func(c *cli.Context) error {
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		records, err := locker.List()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error listing evidence: %v", err), 1)
+		}
+		for _, record := range records {
+			fmt.Printf("%s  %-20s %-16s %s", record.LockerID, record.Filename, record.Collector, record.RetainUntil.Format("2006-01-02"))
+			if record.DisposedAt != nil {
+				fmt.Printf("  disposed %s", record.DisposedAt.Format("2006-01-02"))
+			}
+			fmt.Println()
+		}
+		fmt.Printf("%d evidence items\n", len(records))
+		return nil
+	},
+}
+
+var evidenceShowCommand = cli.Command{
+	Name:      "show",
+	Usage:     "Show the record of an evidence item",
+	ArgsUsage: "[locker-id]",
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Locker ID is required", 1)
+		}
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		record, err := locker.Get(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading evidence: %v", err), 1)
+		}
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+var evidenceExportCommand = cli.Command{
+	Name:      "export",
+	Usage:     "Copy evidence out of the locker, recording the export",
+	ArgsUsage: "[locker-id]",
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+		evidenceActorFlag,
+		evidencePurposeFlag,
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file (defaults to the ingested file name)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Locker ID is required", 1)
+		}
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		record, err := locker.Get(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading evidence: %v", err), 1)
+		}
+		output := c.String("output")
+		if output == "" {
+			output = filepath.Base(record.Filename)
+		}
+		if output == "" || output == "." {
+			output = record.Digest
+		}
+		file, err := os.Create(output)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing file: %v", err), 1)
+		}
+		err = locker.Export(record.LockerID, file, c.String("actor"), c.String("purpose"))
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(output)
+			return cli.NewExitError(fmt.Sprintf("Error exporting evidence: %v", err), 1)
+		}
+		fmt.Printf("Exported %s to %s\n", record.LockerID, output)
+		return nil
+	},
+}
+
+var evidenceVerifyCommand = cli.Command{
+	Name:      "verify",
+	Usage:     "Check evidence content against its digest",
+	ArgsUsage: "[locker-id...]",
+	Description: `Verifies the given evidence, or everything in the locker when no locker
+   ID is given. Every check is recorded in the chain of custody.`,
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+		evidenceActorFlag,
+		evidencePurposeFlag,
+	},
+	Action: func(c *cli.Context) error {
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		ids := []string(c.Args())
+		if len(ids) == 0 {
+			records, err := locker.List()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error listing evidence: %v", err), 1)
+			}
+			for _, record := range records {
+				ids = append(ids, record.LockerID)
+			}
+		}
+		failed := 0
+		for _, id := range ids {
+			if _, err := locker.Verify(id, c.String("actor"), c.String("purpose")); err != nil {
+				fmt.Printf("FAIL  %v\n", err)
+				failed++
+				continue
+			}
+			fmt.Printf("OK    %s\n", id)
+		}
+		if failed > 0 {
+			return cli.NewExitError(fmt.Sprintf("%d of %d evidence items failed verification", failed, len(ids)), 1)
+		}
+		return nil
+	},
+}
+
+var evidenceCustodyCommand = cli.Command{
+	Name:      "custody",
+	Usage:     "Show the chain of custody of an evidence item or of the locker",
+	ArgsUsage: "[locker-id]",
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Output the events as JSON",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		events, err := locker.Custody(c.Args().First())
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading chain of custody: %v", err), 1)
+		}
+		if c.Bool("json") {
+			data, err := json.MarshalIndent(events, "", "  ")
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		for _, event := range events {
+			fmt.Printf("%4d  %s  %-7s %-16s %s", event.Sequence, event.Time.Format(time.RFC3339), event.Action, event.Actor, event.LockerID)
+			if event.Outcome != "" {
+				fmt.Printf("  [%s]", event.Outcome)
+			}
+			if event.Purpose != "" {
+				fmt.Printf("  %s", event.Purpose)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var evidenceDisposeCommand = cli.Command{
+	Name:      "dispose",
+	Usage:     "Delete evidence whose retention period has ended",
+	ArgsUsage: "[locker-id...]",
+	Description: `Deletes the content of the given evidence, or of every item past its
+   retention date with --expired. Evidence still under retention is never
+   deleted. Records are kept and every disposal is recorded in the chain of
+   custody.`,
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+		evidenceActorFlag,
+		evidencePurposeFlag,
+		cli.BoolFlag{
+			Name:  "expired",
+			Usage: "Dispose of every item past its retention date",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		ids := []string(c.Args())
+		if c.Bool("expired") {
+			records, err := locker.List()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error listing evidence: %v", err), 1)
+			}
+			now := time.Now()
+			for _, record := range records {
+				if record.DisposedAt == nil && !now.Before(record.RetainUntil) {
+					ids = append(ids, record.LockerID)
+				}
+			}
+			if len(ids) == 0 {
+				fmt.Println("No evidence is past its retention date")
+				return nil
+			}
+		} else if len(ids) == 0 {
+			return cli.NewExitError("Locker IDs or --expired is required", 1)
+		}
+		failed := 0
+		for _, id := range ids {
+			if _, err := locker.Dispose(id, c.String("actor"), c.String("purpose")); err != nil {
+				fmt.Printf("KEPT      %v\n", err)
+				failed++
+				continue
+			}
+			fmt.Printf("DISPOSED  %s\n", id)
+		}
+		if failed > 0 {
+			return cli.NewExitError(fmt.Sprintf("%d of %d evidence items were not disposed of", failed, len(ids)), 1)
+		}
+		return nil
+	},
+}
+
+// openEvidenceLocker opens the locker named by the --locker flag
+func openEvidenceLocker(c *cli.Context) (*fedramp.FileEvidenceLocker, error) {
+	return fedramp.NewFileEvidenceLocker(c.String("locker"))
+}
+
+// ingestEvidenceFile stores a file in the locker under its base name
+func ingestEvidenceFile(locker fedramp.EvidenceLocker, path string, record fedramp.EvidenceRecord, actor string) (*fedramp.EvidenceRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	record.Filename = filepath.Base(path)
+	return locker.Ingest(file, record, actor)
+}
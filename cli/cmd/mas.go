@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gocomply/fedramp/pkg/fedramp"
@@ -16,11 +17,18 @@ var masCommand = cli.Command{
 	Subcommands: []cli.Command{
 		masCreateCommand,
 		masAddMethodCommand,
+		masAddEvidenceCommand,
 		masCompleteCommand,
 		masSummaryCommand,
+		masValidateCommand,
 	},
 }
 
+var masFRMRFlag = cli.StringFlag{
+	Name:  "frmr",
+	Usage: "FRMR MAS document to take the requirements from (see 'frmr fetch mas')",
+}
+
 var masCreateCommand = cli.Command{
 	Name:      "create",
 	Usage:     "Create a new MAS assessment",
@@ -36,6 +44,11 @@ var masCreateCommand = cli.Command{
 			Usage: "Lead assessor name",
 			Value: "Jane Doe",
 		},
+		cli.StringFlag{
+			Name:  "impact",
+			Usage: "Impact level of the service offering (Low, Moderate, High)",
+		},
+		masFRMRFlag,
 		cli.StringFlag{
 			Name:  "output, o",
 			Usage: "Output file",
@@ -69,6 +82,12 @@ var masCreateCommand = cli.Command{
 		
 		// Create assessment
 		assessment := fedramp.NewMASAssessment(serviceID, masType)
+		assessment.ImpactLevel = c.String("impact")
+		
+		requirements, err := loadMASRequirements(c, assessment)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error loading MAS requirements: %v", err), 1)
+		}
 		
 		// Set 3PAO information
 		assessment.ThreePAO = fedramp.AssessmentOrganization{
@@ -106,8 +125,8 @@ var masCreateCommand = cli.Command{
 		
 		// Show requirements
 		fmt.Printf("\nRequired assessment activities:\n")
-		for _, req := range assessment.GetRequirements() {
-			fmt.Printf("  - %s: %s\n", req.Name, req.Description)
+		for _, req := range requirements {
+			fmt.Printf("  - %s: %s\n", req.ID, req.Description)
 			fmt.Printf("    Methods: %v\n", req.RequiredMethods)
 			fmt.Printf("    Evidence: %v\n", req.MinimumEvidence)
 		}
@@ -190,6 +209,120 @@ var masAddMethodCommand = cli.Command{
 	},
 }
 
+var masAddEvidenceCommand = cli.Command{
+	Name:      "add-evidence",
+	Usage:     "Ingest evidence into the evidence locker and add it to a MAS assessment",
+	ArgsUsage: "[assessment-file] [evidence-file]",
+	Description: `The evidence file is stored in the locker and the assessment cites it by
+   locker ID. Use --locker-id instead of an evidence file to cite evidence
+   already held in the locker.`,
+	Flags: []cli.Flag{
+		evidenceLockerFlag,
+		evidenceActorFlag,
+		cli.StringFlag{
+			Name:  "locker-id",
+			Usage: "Locker ID of evidence already in the locker",
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Usage: "Evidence type (screenshots, configurations, test_results, ...)",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "Evidence description",
+		},
+		cli.StringFlag{
+			Name:  "source",
+			Usage: "System the evidence was collected from",
+		},
+		cli.StringSliceFlag{
+			Name:  "controls",
+			Usage: "Controls covered by this evidence",
+		},
+		cli.BoolFlag{
+			Name:  "automated",
+			Usage: "Whether the evidence was collected automatically",
+		},
+		cli.IntFlag{
+			Name:  "retain-years",
+			Usage: "Years to retain the evidence",
+			Value: 3,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if (c.NArg() != 2 || c.String("locker-id") != "") && (c.NArg() != 1 || c.String("locker-id") == "") {
+			return cli.NewExitError("Assessment file and either an evidence file or --locker-id are required", 1)
+		}
+		
+		data, err := os.ReadFile(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading assessment: %v", err), 1)
+		}
+		
+		var assessment fedramp.MASAssessment
+		if err := json.Unmarshal(data, &assessment); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing assessment: %v", err), 1)
+		}
+		
+		locker, err := openEvidenceLocker(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		var record *fedramp.EvidenceRecord
+		if c.String("locker-id") != "" {
+			record, err = locker.Get(c.String("locker-id"))
+		} else if c.Int("retain-years") <= 0 {
+			return cli.NewExitError("--retain-years must be positive", 1)
+		} else {
+			record, err = ingestEvidenceFile(locker, c.Args()[1], fedramp.EvidenceRecord{
+				Type:         c.String("type"),
+				Description:  c.String("description"),
+				Collector:    c.String("actor"),
+				SourceSystem: c.String("source"),
+				RetainUntil:  time.Now().AddDate(c.Int("retain-years"), 0, 0),
+			}, c.String("actor"))
+		}
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error storing evidence: %v", err), 1)
+		}
+		
+		evidence := fedramp.AssessmentEvidence{
+			EvidenceID:      fmt.Sprintf("EV-%03d", len(assessment.Evidence)+1),
+			Type:            c.String("type"),
+			Description:     c.String("description"),
+			CollectionDate:  record.CollectedAt,
+			CollectedBy:     record.Collector,
+			Location:        record.Filename,
+			LockerID:        record.LockerID,
+			ControlsCovered: c.StringSlice("controls"),
+			Automated:       c.Bool("automated"),
+		}
+		if evidence.Type == "" {
+			evidence.Type = record.Type
+		}
+		if evidence.Description == "" {
+			evidence.Description = record.Description
+		}
+		assessment.AddEvidence(evidence)
+		
+		updatedData, err := assessment.ToJSON()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+		}
+		
+		if err := os.WriteFile(c.Args()[0], updatedData, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing file: %v", err), 1)
+		}
+		
+		fmt.Printf("Added assessment evidence:\n")
+		fmt.Printf("  Evidence ID: %s\n", evidence.EvidenceID)
+		fmt.Printf("  Locker ID: %s\n", evidence.LockerID)
+		fmt.Printf("  Controls: %v\n", evidence.ControlsCovered)
+		
+		return nil
+	},
+}
+
 var masCompleteCommand = cli.Command{
 	Name:      "complete",
 	Usage:     "Mark a MAS assessment as complete",
@@ -314,4 +447,101 @@ var masSummaryCommand = cli.Command{
 		
 		return nil
 	},
-} 
\ No newline at end of file
+} 
+
+var masValidateCommand = cli.Command{
+	Name:      "validate",
+	Usage:     "Check a MAS assessment against each applicable MAS requirement",
+	ArgsUsage: "[assessment-file]",
+	Description: `Methods and evidence count for a requirement when their controls_covered
+   lists the requirement ID (for example FRR-MAS-01) or a related control.
+   Applicability conditions are answered by scope.conditions (condition text to
+   true or false), or evaluated from the impact level and assessment type they
+   name. Requirements whose condition cannot be evaluated are assessed unless
+   they are listed with a justification in scope.inapplicable_requirements.
+   Methods and evidence required by FRMR requirements are inferred from their
+   wording and marked as such. Exits with status 1 unless every applicable MUST
+   requirement is satisfied.`,
+	Flags: []cli.Flag{
+		masFRMRFlag,
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Output the report as JSON",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Assessment file path is required", 1)
+		}
+		
+		data, err := os.ReadFile(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading assessment: %v", err), 1)
+		}
+		var assessment fedramp.MASAssessment
+		if err := json.Unmarshal(data, &assessment); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing assessment: %v", err), 1)
+		}
+		requirements, err := loadMASRequirements(c, &assessment)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error loading MAS requirements: %v", err), 1)
+		}
+		
+		report := assessment.Validate(requirements)
+		if c.Bool("json") {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			fmt.Println(string(out))
+		} else {
+			fmt.Printf("MAS validation of %s against %s\n", report.AssessmentID, report.Source)
+			for _, result := range report.Results {
+				fmt.Printf("\n[%s] %s %s\n", result.Status, result.RequirementID, result.Name)
+				if result.KeyWord != "" {
+					fmt.Printf("  Key word: %s\n", result.KeyWord)
+				}
+				if result.Condition != "" {
+					evaluated := "not evaluated"
+					if result.ConditionMet != nil {
+						evaluated = fmt.Sprintf("holds: %v", *result.ConditionMet)
+					}
+					fmt.Printf("  Applies when: %s (%s)\n", result.Condition, evaluated)
+				}
+				if result.Reason != "" {
+					fmt.Printf("  Not applicable: %s\n", result.Reason)
+					continue
+				}
+				if len(result.Methods) > 0 {
+					fmt.Printf("  Methods: %s\n", strings.Join(result.Methods, ", "))
+				}
+				if len(result.Evidence) > 0 {
+					fmt.Printf("  Evidence: %s\n", strings.Join(result.Evidence, ", "))
+				}
+				if len(result.Findings) > 0 {
+					fmt.Printf("  Findings: %s (%d open)\n", strings.Join(result.Findings, ", "), result.OpenFindings)
+				}
+				for _, gap := range result.Gaps {
+					fmt.Printf("  - %s\n", gap)
+				}
+			}
+			fmt.Printf("\nSatisfied: %d, partially satisfied: %d, unaddressed: %d, not applicable: %d\n",
+				report.Summary[fedramp.MASRequirementSatisfied], report.Summary[fedramp.MASRequirementPartial],
+				report.Summary[fedramp.MASRequirementUnaddressed], report.Summary[fedramp.MASRequirementNotApplicable])
+		}
+		
+		if !report.Complete {
+			return cli.NewExitError("Assessment does not satisfy every mandatory MAS requirement", 1)
+		}
+		return nil
+	},
+}
+
+// loadMASRequirements returns the requirements of the --frmr MAS document,
+// falling back to the built-in requirements for the assessment type
+func loadMASRequirements(c *cli.Context, assessment *fedramp.MASAssessment) ([]fedramp.MASRequirement, error) {
+	if c.String("frmr") != "" {
+		return fedramp.LoadMASRequirementsFromFRMR(c.String("frmr"))
+	}
+	return assessment.GetRequirements(), nil
+}
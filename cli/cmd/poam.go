@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/urfave/cli"
+)
+
+var poamCommand = cli.Command{
+	Name:  "poam",
+	Usage: "Plan of Action and Milestones tools",
+	Subcommands: []cli.Command{
+		poamForecastCommand,
+	},
+}
+
+var poamForecastCommand = cli.Command{
+	Name:      "forecast",
+	Usage:     "List open POA&M items that will pass their remediation deadline soon",
+	ArgsUsage: "[poam.json]",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "days",
+			Usage: "Number of days to look ahead",
+			Value: 30,
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format (text, json)",
+			Value: "text",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Exactly 1 argument is required: path to POA&M JSON file", 1)
+		}
+		if c.Int("days") < 0 {
+			return cli.NewExitError("--days cannot be negative", 1)
+		}
+		return nil
+	},
+	Action: func(c *cli.Context) error {
+		data, err := os.ReadFile(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading POA&M: %v", err), 1)
+		}
+		var poam fedramp.PlanOfActionMilestones
+		if err := json.Unmarshal(data, &poam); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing POA&M: %v", err), 1)
+		}
+
+		days := c.Int("days")
+		items := poam.ForecastOverdueItems(days)
+		if c.String("format") == "json" {
+			out, err := json.MarshalIndent(items, "", "  ")
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Printf("POA&M items going late within %d days: %d\n", days, len(items))
+		policy := poam.RemediationPolicy
+		if policy == nil {
+			policy = fedramp.DefaultRemediationPolicy()
+		}
+		for _, item := range items {
+			fmt.Printf("  %s  %-8s  due %s  %s\n", item.ItemID, item.Severity, policy.Deadline(item).Format("2006-01-02"), item.Weakness)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/gocomply/fedramp/pkg/fedramp/common"
+	"github.com/urfave/cli"
+)
+
+var sapCommand = cli.Command{
+	Name:  "sap",
+	Usage: "Security Assessment Plan operations",
+	Subcommands: []cli.Command{
+		sapCreateCommand,
+		sapSampleSizeCommand,
+		sapSampleCommand,
+		sapProceduresCommand,
+	},
+}
+
+var sapCreateCommand = cli.Command{
+	Name:      "create",
+	Usage:     "Create a new Security Assessment Plan",
+	ArgsUsage: "[service-id] [assessment-type]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "baseline",
+			Usage: "Control baseline (Low, Moderate, High)",
+			Value: "Moderate",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file",
+			Value: "sap.json",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Service ID and assessment type are required", 1)
+		}
+		sap := fedramp.NewSecurityAssessmentPlan(c.Args()[0], c.Args()[1])
+		sap.Methodology.Framework = "NIST SP 800-53A Rev 5"
+		sap.ControlSelection.Baseline = c.String("baseline")
+
+		if err := writeSAP(c.String("output"), sap); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		fmt.Printf("Security Assessment Plan created:\n")
+		fmt.Printf("  Plan ID: %s\n", sap.PlanID)
+		fmt.Printf("  Type: %s\n", sap.AssessmentType)
+		fmt.Printf("  Saved to: %s\n", c.String("output"))
+		return nil
+	},
+}
+
+var samplingFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "preset",
+		Usage: "Sampling parameters for an impact level: low (90%, 10%), moderate (95%, 5%) or high (95%, 3%)",
+		Value: "moderate",
+	},
+	cli.Float64Flag{
+		Name:  "confidence",
+		Usage: "Confidence level, overriding the preset (e.g. 0.95)",
+	},
+	cli.Float64Flag{
+		Name:  "tolerable",
+		Usage: "Tolerable deviation rate, overriding the preset (e.g. 0.05)",
+	},
+	cli.Float64Flag{
+		Name:  "expected",
+		Usage: "Expected deviation rate (e.g. 0.01)",
+	},
+}
+
+var sapSampleSizeCommand = cli.Command{
+	Name:      "sample-size",
+	Usage:     "Compute the attribute sample size for a population size",
+	ArgsUsage: "[population-size]",
+	Flags:     samplingFlags,
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Population size is required", 1)
+		}
+		var population int
+		if _, err := fmt.Sscanf(c.Args()[0], "%d", &population); err != nil || population < 0 {
+			return cli.NewExitError(fmt.Sprintf("Invalid population size: %s", c.Args()[0]), 1)
+		}
+		params, err := samplingParameters(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		size, err := fedramp.SampleSize(population, params)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error computing sample size: %v", err), 1)
+		}
+		fmt.Printf("Population: %d\n", population)
+		fmt.Printf("Confidence: %g%%, tolerable deviation: %g%%, expected deviation: %g%%\n",
+			params.Confidence*100, params.TolerableDeviation*100, params.ExpectedDeviation*100)
+		fmt.Printf("Sample size: %d\n", size)
+		return nil
+	},
+}
+
+var sapSampleCommand = cli.Command{
+	Name:      "sample",
+	Usage:     "Draw a reproducible sample from a population and record it in the SAP",
+	ArgsUsage: "[population-file]",
+	Description: `The population is a CSV file with a header row (asset inventory, change
+   tickets, user accounts) or a JSON file with "name", "type" and "items". Items
+   are ranked by SHA-256 of "<seed>:<id>" and the lowest ranks are selected, so
+   the same seed and population always give the same sample. The seed, method,
+   parameters and a hash of the population are recorded in the SAP.`,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "sap",
+			Usage: "SAP file to record the sample in",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "Population name (defaults to the file name)",
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Usage: "Population type (assets, changes, accounts, controls)",
+		},
+		cli.StringFlag{
+			Name:  "id-column",
+			Usage: "CSV column holding item IDs (defaults to the first column)",
+		},
+		cli.StringFlag{
+			Name:  "stratify-by",
+			Usage: "Column or attribute to draw a stratified sample by",
+		},
+		cli.StringFlag{
+			Name:  "seed",
+			Usage: "Seed to reproduce a sample (defaults to a new random seed)",
+		},
+		cli.StringFlag{
+			Name:  "mas",
+			Usage: "MAS assessment file whose sampled controls are set to the sample (control populations only)",
+		},
+	}, samplingFlags...),
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Population file is required", 1)
+		}
+		population, err := fedramp.LoadSamplingPopulation(c.Args()[0], c.String("id-column"), c.String("stratify-by"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error loading population: %v", err), 1)
+		}
+		if c.String("name") != "" {
+			population.Name = c.String("name")
+		}
+		if c.String("type") != "" {
+			population.Type = c.String("type")
+		}
+		if c.String("mas") != "" && population.Type != "controls" {
+			return cli.NewExitError(fmt.Sprintf("--mas requires a population of type controls, not %q", population.Type), 1)
+		}
+		params, err := samplingParameters(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		seed := c.String("seed")
+		if seed == "" {
+			if seed, err = fedramp.NewSampleSeed(); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating seed: %v", err), 1)
+			}
+		}
+		method := fedramp.SamplingRandom
+		if c.String("stratify-by") != "" {
+			method = fedramp.SamplingStratified
+		}
+
+		record, err := fedramp.DrawSample(population, params, method, seed)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error drawing sample: %v", err), 1)
+		}
+
+		if c.String("sap") != "" {
+			sap, err := readSAP(c.String("sap"))
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			sap.RecordSample(record)
+			if err := writeSAP(c.String("sap"), sap); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+		}
+		if c.String("mas") != "" {
+			data, err := os.ReadFile(c.String("mas"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error reading assessment: %v", err), 1)
+			}
+			var assessment fedramp.MASAssessment
+			if err := json.Unmarshal(data, &assessment); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error parsing assessment: %v", err), 1)
+			}
+			assessment.Scope.SampledControls = record.Items
+			updated, err := assessment.ToJSON()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			if err := os.WriteFile(c.String("mas"), updated, 0644); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error writing file: %v", err), 1)
+			}
+		}
+
+		fmt.Printf("Sample drawn from %s:\n", record.Population)
+		fmt.Printf("  Method: %s (%s)\n", record.Method, record.Algorithm)
+		fmt.Printf("  Seed: %s\n", record.Seed)
+		fmt.Printf("  Population: %d items (%s)\n", record.PopulationSize, record.PopulationHash[:16])
+		fmt.Printf("  Sample size: %d\n", record.SampleSize)
+		for _, stratum := range record.Strata {
+			fmt.Printf("  %s: %d of %d\n", stratum.Stratum, stratum.SampleSize, stratum.PopulationSize)
+		}
+		fmt.Printf("  Items: %s\n", strings.Join(record.Items, ", "))
+		if c.String("sap") != "" {
+			fmt.Printf("  Recorded in: %s\n", c.String("sap"))
+		}
+		return nil
+	},
+}
+
+var sapProceduresCommand = cli.Command{
+	Name:      "procedures",
+	Usage:     "Generate control test procedures from the catalog assessment objectives",
+	ArgsUsage: "[sap-file]",
+	Description: `Writes one test procedure per NIST SP 800-53A assessment objective of each
+   selected control (or of --controls), with the examine, interview and test
+   methods and objects from the FedRAMP resolved catalog. Parameter values set
+   in the SSP, or else the FedRAMP constraints, are substituted into the
+   objectives. Organization procedures in the --library file replace the
+   catalog procedure of the objective they name, or every catalog procedure
+   of their control when they name no objective.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "baseline",
+			Usage: "Baseline catalog to use (defaults to the SAP baseline)",
+		},
+		cli.StringFlag{
+			Name:  "catalog",
+			Usage: "Resolved profile catalog (OSCAL XML) to use instead of the bundled one",
+		},
+		cli.StringFlag{
+			Name:  "ssp",
+			Usage: "OSCAL SSP whose parameter values are substituted",
+		},
+		cli.StringFlag{
+			Name:  "library",
+			Usage: "JSON file of organization-specific test procedures",
+		},
+		cli.StringFlag{
+			Name:  "controls",
+			Usage: "Comma-separated control IDs (defaults to the SAP selected controls, or the whole baseline)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("SAP file is required", 1)
+		}
+		sap, err := readSAP(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		baseline := c.String("baseline")
+		if baseline == "" {
+			baseline = sap.ControlSelection.Baseline
+		}
+		var controls []fedramp.ControlAssessment
+		if c.String("catalog") != "" {
+			controls, err = fedramp.OpenControlAssessments(c.String("catalog"))
+		} else {
+			level := common.BaselineLevelFromName(baseline)
+			if level == common.LevelUnknown {
+				return cli.NewExitError(fmt.Sprintf("Unknown baseline: %s", baseline), 1)
+			}
+			controls, err = fedramp.BundledControlAssessments(level)
+		}
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error loading catalog: %v", err), 1)
+		}
+		library := fedramp.NewTestProcedureLibrary(controls)
+
+		if c.String("ssp") != "" {
+			plan, err := fedramp.OpenSSP(c.String("ssp"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error opening SSP: %v", err), 1)
+			}
+			library.Parameters = plan.ParameterValues()
+		}
+		if c.String("library") != "" {
+			if err := library.LoadOverrides(c.String("library")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading test procedures: %v", err), 1)
+			}
+		}
+
+		var controlIDs []string
+		switch {
+		case c.String("controls") != "":
+			for _, id := range strings.Split(c.String("controls"), ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					controlIDs = append(controlIDs, id)
+				}
+			}
+		case len(sap.ControlSelection.SelectedControls) > 0:
+			for _, selected := range sap.ControlSelection.SelectedControls {
+				controlIDs = append(controlIDs, selected.ControlID)
+			}
+		default:
+			for _, control := range controls {
+				controlIDs = append(controlIDs, control.ControlID)
+			}
+		}
+
+		procedures := library.GenerateTestProcedures(controlIDs)
+		sap.SetTestProcedures(procedures)
+		if err := writeSAP(c.Args()[0], sap); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		sources := make(map[string]int)
+		var generic []string
+		for _, procedure := range procedures {
+			sources[procedure.Source]++
+			if procedure.Source == fedramp.ProcedureSourceGeneric {
+				generic = append(generic, procedure.ControlID)
+			}
+		}
+		fmt.Printf("Test procedures written to %s:\n", c.Args()[0])
+		fmt.Printf("  Controls: %d\n", len(controlIDs))
+		fmt.Printf("  Procedures: %d (catalog %d, organization %d, generic %d)\n", len(procedures),
+			sources[fedramp.ProcedureSourceCatalog], sources[fedramp.ProcedureSourceOrganization], sources[fedramp.ProcedureSourceGeneric])
+		fmt.Printf("  SSP parameter values: %d\n", len(library.Parameters))
+		if len(generic) > 0 {
+			fmt.Printf("  Not in the catalog: %s\n", strings.Join(generic, ", "))
+		}
+		return nil
+	},
+}
+
+// samplingParameters returns the --preset parameters with the explicit
+// overrides applied
+func samplingParameters(c *cli.Context) (fedramp.SamplingParameters, error) {
+	params, ok := fedramp.SamplingPresets[strings.ToLower(c.String("preset"))]
+	if !ok {
+		return params, fmt.Errorf("unknown sampling preset %q", c.String("preset"))
+	}
+	if c.IsSet("confidence") {
+		params.Confidence = c.Float64("confidence")
+		params.Preset = ""
+	}
+	if c.IsSet("tolerable") {
+		params.TolerableDeviation = c.Float64("tolerable")
+		params.Preset = ""
+	}
+	if c.IsSet("expected") {
+		params.ExpectedDeviation = c.Float64("expected")
+		params.Preset = ""
+	}
+	return params, params.Validate()
+}
+
+// readSAP reads a SAP file
+func readSAP(path string) (*fedramp.SecurityAssessmentPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SAP: %v", err)
+	}
+	var sap fedramp.SecurityAssessmentPlan
+	if err := json.Unmarshal(data, &sap); err != nil {
+		return nil, fmt.Errorf("failed to parse SAP: %v", err)
+	}
+	return &sap, nil
+}
+
+// writeSAP saves a SAP file
+func writeSAP(path string, sap *fedramp.SecurityAssessmentPlan) error {
+	data, err := sap.ToJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write SAP: %v", err)
+	}
+	return nil
+}
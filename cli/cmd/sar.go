@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/gocomply/fedramp/pkg/fedramp/common"
+	"github.com/urfave/cli"
+)
+
+var sarCommand = cli.Command{
+	Name:  "sar",
+	Usage: "Security Assessment Report operations",
+	Subcommands: []cli.Command{
+		sarGenerateCommand,
+	},
+}
+
+var sarGenerateCommand = cli.Command{
+	Name:  "generate",
+	Usage: "Generate a SAR and POA&M items from a completed MAS assessment and SAP test results",
+	Description: `MAS findings become control findings and executed SAP test procedures
+   become test cases. Failed procedures that cite no MAS finding become findings
+   of their own, and controls whose procedures all passed are reported
+   Satisfied. The risk exposure table and executive summary are computed from
+   the findings, and every Other Than Satisfied finding not yet tracked gets a
+   POA&M item in the --poam file. Evidence held in the --evidence-locker is
+   verified against its digest before it is cited.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "from-mas",
+			Usage: "Completed MAS assessment file",
+		},
+		cli.StringFlag{
+			Name:  "sap",
+			Usage: "SAP file with the executed test procedures",
+		},
+		cli.StringFlag{
+			Name:  "results",
+			Usage: "JSON file of test procedure results keyed by procedure ID, if not recorded in the SAP",
+		},
+		cli.StringFlag{
+			Name:  "catalog",
+			Usage: "Resolved profile catalog (OSCAL XML) for control titles instead of the bundled one",
+		},
+		cli.StringFlag{
+			Name:  "poam",
+			Usage: "POA&M file to add items to (created if missing)",
+			Value: "poam.json",
+		},
+		cli.StringFlag{
+			Name:  "remediation-policy",
+			Usage: "Remediation policy for a new POA&M (defaults to the FedRAMP timelines; an error when --poam exists)",
+		},
+		cli.StringFlag{
+			Name:  "evidence-locker",
+			Usage: "Evidence locker directory to verify locker evidence against",
+		},
+		cli.StringFlag{
+			Name:  "actor",
+			Usage: "Person recorded in the chain of custody (defaults to the lead assessor)",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file",
+			Value: "sar.json",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("from-mas") == "" || c.String("sap") == "" {
+			return cli.NewExitError("--from-mas and --sap are required", 1)
+		}
+		data, err := os.ReadFile(c.String("from-mas"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading assessment: %v", err), 1)
+		}
+		var assessment fedramp.MASAssessment
+		if err := json.Unmarshal(data, &assessment); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing assessment: %v", err), 1)
+		}
+		sap, err := readSAP(c.String("sap"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if c.String("results") != "" {
+			if err := sap.LoadTestProcedureResults(c.String("results")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading test results: %v", err), 1)
+			}
+		}
+
+		opts := fedramp.SARGenerationOptions{Actor: c.String("actor")}
+		if c.String("evidence-locker") != "" {
+			if opts.Locker, err = fedramp.NewFileEvidenceLocker(c.String("evidence-locker")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error opening evidence locker: %v", err), 1)
+			}
+		}
+		if c.String("catalog") != "" {
+			if opts.Controls, err = fedramp.OpenControlAssessments(c.String("catalog")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading catalog: %v", err), 1)
+			}
+		} else if level := common.BaselineLevelFromName(sap.ControlSelection.Baseline); level != common.LevelUnknown {
+			if opts.Controls, err = fedramp.BundledControlAssessments(level); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: control titles omitted: %v\n", err)
+			}
+		}
+
+		sar, err := fedramp.GenerateSARFromMAS(&assessment, sap, opts)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error generating SAR: %v", err), 1)
+		}
+
+		poam, err := readOrCreatePOAM(c.String("poam"), assessment.ServiceOfferingID, c.String("remediation-policy"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		added, err := poam.AddSARFindings(sar)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error updating POA&M: %v", err), 1)
+		}
+
+		sarData, err := sar.ToJSON()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+		}
+		if err := os.WriteFile(c.String("output"), sarData, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing file: %v", err), 1)
+		}
+		poamData, err := poam.ToJSON()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+		}
+		if err := os.WriteFile(c.String("poam"), poamData, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing file: %v", err), 1)
+		}
+
+		summary := sar.ExecutiveSummary
+		satisfied, other := 0, 0
+		for _, finding := range sar.ControlFindings {
+			switch finding.Status {
+			case fedramp.SARSatisfied:
+				satisfied++
+			case fedramp.SAROtherThanSatisfied:
+				other++
+			}
+		}
+		fmt.Printf("Security Assessment Report generated:\n")
+		fmt.Printf("  Report ID: %s\n", sar.ReportID)
+		fmt.Printf("  Control findings: %d (%d satisfied, %d other than satisfied)\n", len(sar.ControlFindings), satisfied, other)
+		fmt.Printf("  Test cases: %d\n", len(sar.TestCases))
+		fmt.Printf("  Findings by severity: %d critical, %d high, %d moderate, %d low\n",
+			summary.CriticalFindings, summary.HighFindings, summary.ModerateFindings, summary.LowFindings)
+		fmt.Printf("  Overall risk: %s (%s)\n", summary.OverallRisk, summary.ComplianceStatus)
+		fmt.Printf("  Recommended action: %s\n", summary.RecommendedAction)
+		fmt.Printf("  Saved to: %s\n", c.String("output"))
+		fmt.Printf("  POA&M items added: %d (%s)\n", len(added), c.String("poam"))
+		return nil
+	},
+}
+
+// readOrCreatePOAM reads a POA&M file, or creates a POA&M when the file does
+// not exist. A remediation policy only applies to a new POA&M.
+func readOrCreatePOAM(path, serviceID, policyFile string) (*fedramp.PlanOfActionMilestones, error) {
+	data, err := os.ReadFile(path)
+	if err == nil && policyFile != "" {
+		return nil, fmt.Errorf("--remediation-policy only applies to a new POA&M, but %s exists", path)
+	}
+	if os.IsNotExist(err) {
+		poam := fedramp.NewPOAM(serviceID)
+		if policyFile != "" {
+			policy, err := fedramp.LoadRemediationPolicy(policyFile)
+			if err != nil {
+				return nil, err
+			}
+			if err := poam.SetRemediationPolicy(policy); err != nil {
+				return nil, err
+			}
+		}
+		return poam, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read POA&M: %v", err)
+	}
+	var poam fedramp.PlanOfActionMilestones
+	if err := json.Unmarshal(data, &poam); err != nil {
+		return nil, fmt.Errorf("failed to parse POA&M: %v", err)
+	}
+	return &poam, nil
+}
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/gocomply/fedramp/pkg/templater"
+	"github.com/gocomply/fedramp/pkg/templater/template"
 	"github.com/urfave/cli"
 )
 
@@ -16,18 +23,22 @@ var scnCommand = cli.Command{
 		scnValidateCommand,
 		scnExportCommand,
 		scnListCommand,
+		scnTransitionCommand,
+		scnClassifyCommand,
+		scnDraftCommand,
+		scnImpactCommand,
 	},
 }
 
 var scnCreateCommand = cli.Command{
 	Name:      "create",
-	Usage:     "Create a new Significant Change Notification",
+	Usage:     "Create a new Significant Change Notification in the SCN store",
 	ArgsUsage: "[service-id] [change-type] [description] [reason]",
 	Flags: []cli.Flag{
+		scnStoreFlag,
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output file for the SCN JSON",
-			Value: "scn.json",
+			Usage: "Also write the SCN JSON to this file",
 		},
 		cli.StringFlag{
 			Name:  "3pao",
@@ -53,6 +64,12 @@ var scnCreateCommand = cli.Command{
 			Name:  "affected-components",
 			Usage: "List of affected components (can be specified multiple times)",
 		},
+		cli.StringSliceFlag{
+			Name:  "attr",
+			Usage: "Change attribute used for classification, e.g. new_data_type or new_impact_level=high (can be specified multiple times)",
+		},
+		scnRulesFlag,
+		scnFRMRFlag,
 	},
 	Before: func(c *cli.Context) error {
 		if c.NArg() != 4 {
@@ -94,24 +111,43 @@ var scnCreateCommand = cli.Command{
 		}
 
 		// Classify SCN type
-		if err := scn.ClassifySCNType(); err != nil {
+		if attrs := c.StringSlice("attr"); len(attrs) > 0 {
+			changeAttributes, err := parseSCNAttributes(attrs)
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			scn.ChangeAttributes = changeAttributes
+		}
+		classifier, err := loadSCNClassifier(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if err := scn.ClassifyWith(classifier); err != nil {
 			return cli.NewExitError(fmt.Sprintf("Error classifying SCN type: %v", err), 1)
 		}
 
-		// Export to JSON
-		jsonData, err := scn.ToJSON()
+		// Save to the store read by scn list and the API server
+		store, err := fedramp.NewFileSCNStore(c.String("store"))
 		if err != nil {
-			return cli.NewExitError(fmt.Sprintf("Error converting SCN to JSON: %v", err), 1)
+			return cli.NewExitError(fmt.Sprintf("Error opening SCN store: %v", err), 1)
 		}
-
-		// Write to file
-		outputFile := c.String("output")
-		if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
-			return cli.NewExitError(fmt.Sprintf("Error writing SCN to file: %v", err), 1)
+		if err := store.SaveSCN(scn); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error saving SCN: %v", err), 1)
+		}
+		fmt.Printf("SCN %s created successfully in %s\n", scn.ID, c.String("store"))
+
+		if outputFile := c.String("output"); outputFile != "" {
+			jsonData, err := scn.ToJSON()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error converting SCN to JSON: %v", err), 1)
+			}
+			if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error writing SCN to file: %v", err), 1)
+			}
+			fmt.Printf("SCN written to %s\n", outputFile)
 		}
-
-		fmt.Printf("SCN created successfully and saved to %s\n", outputFile)
 		fmt.Printf("SCN Type: %s\n", scn.SCNType)
+		fmt.Printf("Classification: %s\n", scn.Classification.Explanation)
 		fmt.Printf("Service ID: %s\n", scn.ServiceOfferingID)
 		fmt.Printf("Change Type: %s\n", scn.ChangeType)
 
@@ -202,13 +238,82 @@ var scnExportCommand = cli.Command{
 				return nil
 			},
 		},
+		scnRenderCommand(fedramp.SCNFormatMarkdown, "Export SCN as a Markdown notification"),
+		scnRenderCommand(fedramp.SCNFormatHTML, "Export SCN as a standalone HTML notification"),
+		scnRenderCommand(fedramp.SCNFormatDOCX, "Export SCN as a Word notification letter"),
 	},
 }
 
+// scnRenderCommand builds the export subcommand for a templated format
+func scnRenderCommand(format, usage string) cli.Command {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:  "template",
+			Usage: "Go template to use instead of the bundled default",
+		},
+	}
+	if format == fedramp.SCNFormatDOCX {
+		flags = append(flags, cli.StringFlag{
+			Name:  "docx-template",
+			Usage: "Word document containing a paragraph with the text " + template.SCNContentMarker,
+		})
+	}
+
+	return cli.Command{
+		Name:      format,
+		Usage:     usage,
+		ArgsUsage: "[scn-file.json] [output]",
+		Flags:     flags,
+		Before: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return cli.NewExitError("Exactly 2 arguments are required: SCN file and output file", 1)
+			}
+			return nil
+		},
+		Action: func(c *cli.Context) error {
+			scnFile := c.Args()[0]
+			outputFile := c.Args()[1]
+
+			data, err := os.ReadFile(scnFile)
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error reading SCN file: %v", err), 1)
+			}
+
+			var scn fedramp.SignificantChangeNotification
+			if err := scn.FromJSON(data); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error parsing SCN JSON: %v", err), 1)
+			}
+
+			templateText, err := fedramp.LoadSCNTemplate(format, c.String("template"))
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+
+			if format == fedramp.SCNFormatDOCX {
+				if err := templater.ConvertSCN(&scn, templateText, c.String("docx-template"), outputFile); err != nil {
+					return cli.NewExitError(fmt.Sprintf("Error rendering SCN: %v", err), 1)
+				}
+			} else {
+				rendered, err := fedramp.RenderSCN(&scn, format, templateText)
+				if err != nil {
+					return cli.NewExitError(fmt.Sprintf("Error rendering SCN: %v", err), 1)
+				}
+				if err := os.WriteFile(outputFile, rendered, 0644); err != nil {
+					return cli.NewExitError(fmt.Sprintf("Error writing SCN: %v", err), 1)
+				}
+			}
+
+			fmt.Printf("SCN exported to %s\n", outputFile)
+			return nil
+		},
+	}
+}
+
 var scnListCommand = cli.Command{
 	Name:  "list",
 	Usage: "List and manage multiple SCNs",
 	Flags: []cli.Flag{
+		scnStoreFlag,
 		cli.StringFlag{
 			Name:  "service-id",
 			Usage: "Filter by service ID",
@@ -221,14 +326,457 @@ var scnListCommand = cli.Command{
 			Name:  "status",
 			Usage: "Filter by status",
 		},
+		cli.BoolFlag{
+			Name:  "due",
+			Usage: "List upcoming and overdue notification deadlines instead of SCNs",
+		},
+		cli.StringFlag{
+			Name:  "policy",
+			Usage: "JSON file overriding the SCN deadline policy",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		store, err := fedramp.NewFileSCNStore(c.String("store"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error opening SCN store: %v", err), 1)
+		}
+
+		scns, err := store.GetSCNsByCSOID(c.String("service-id"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading SCN store: %v", err), 1)
+		}
+
+		var filtered []*fedramp.SignificantChangeNotification
+		for _, scn := range scns {
+			if t := c.String("type"); t != "" && string(scn.SCNType) != t {
+				continue
+			}
+			if status := c.String("status"); status != "" && string(scn.Status) != status {
+				continue
+			}
+			filtered = append(filtered, scn)
+		}
+
+		if c.Bool("due") {
+			policy := fedramp.DefaultSCNDeadlinePolicy()
+			if policyFile := c.String("policy"); policyFile != "" {
+				if policy, err = fedramp.LoadSCNDeadlinePolicy(policyFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+			}
+			due := policy.DueSCNObligations(filtered, time.Now())
+			for _, obligation := range due {
+				fmt.Printf("%-10s %-12s %-40s %-36s %s\n", obligation.DueDate.Format("2006-01-02"), obligation.Status,
+					obligation.SCNID, obligation.Name, obligation.Artifact)
+			}
+			fmt.Printf("\nTotal: %d deadline(s) due\n", len(due))
+			return nil
+		}
+
+		count := 0
+		for _, scn := range filtered {
+			fmt.Printf("%-40s %-15s %-15s %-12s %s\n", scn.ID, scn.ServiceOfferingID, scn.SCNType, scn.Status, scn.ShortDescription)
+			count++
+		}
+		fmt.Printf("\nTotal: %d SCN(s)\n", count)
+		return nil
+	},
+}
+
+var scnTransitionCommand = cli.Command{
+	Name:      "transition",
+	Usage:     "Move an SCN to a new lifecycle status",
+	ArgsUsage: "[scn-file.json] [status]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "actor",
+			Usage: "Person performing the transition",
+		},
+		cli.StringFlag{
+			Name:  "comment",
+			Usage: "Comment recorded with the transition",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Exactly 2 arguments are required: SCN file and target status", 1)
+		}
+		if c.String("actor") == "" {
+			return cli.NewExitError("--actor is required", 1)
+		}
+		return nil
+	},
+	Action: func(c *cli.Context) error {
+		scnFile := c.Args()[0]
+
+		data, err := os.ReadFile(scnFile)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading SCN file: %v", err), 1)
+		}
+
+		var scn fedramp.SignificantChangeNotification
+		if err := scn.FromJSON(data); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing SCN JSON: %v", err), 1)
+		}
+
+		to, err := fedramp.ParseSCNStatus(c.Args()[1])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		from := scn.Status
+		if err := scn.Transition(to, c.String("actor"), c.String("comment")); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error transitioning SCN: %v", err), 1)
+		}
+
+		jsonData, err := scn.ToJSON()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error converting SCN to JSON: %v", err), 1)
+		}
+		if err := os.WriteFile(scnFile, jsonData, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing SCN to file: %v", err), 1)
+		}
+
+		fmt.Printf("SCN moved from %s to %s\n", from, scn.Status)
+		return nil
+	},
+}
+
+var scnStoreFlag = cli.StringFlag{
+	Name:  "store",
+	Usage: "Directory of the SCN store",
+	Value: "data/scn",
+}
+
+var scnRulesFlag = cli.StringFlag{
+	Name:  "rules",
+	Usage: "Local JSON file of SCN classification rules",
+}
+
+var scnFRMRFlag = cli.StringFlag{
+	Name:  "frmr",
+	Usage: "FRMR SCN document whose change type definitions are cited by the rules (see 'frmr fetch scn')",
+}
+
+var scnClassifyCommand = cli.Command{
+	Name:      "classify",
+	Usage:     "Classify an SCN from its change attributes, or override the classification",
+	ArgsUsage: "[scn-file.json]",
+	Flags: []cli.Flag{
+		scnRulesFlag,
+		scnFRMRFlag,
+		cli.StringFlag{
+			Name:  "override",
+			Usage: "SCN type to use instead of the classified type",
+		},
+		cli.StringFlag{
+			Name:  "justification",
+			Usage: "Justification recorded with the override",
+		},
+		cli.StringFlag{
+			Name:  "actor",
+			Usage: "Person overriding the classification",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Exactly 1 argument is required: path to SCN JSON file", 1)
+		}
+		if c.String("override") != "" && (c.String("justification") == "" || c.String("actor") == "") {
+			return cli.NewExitError("--justification and --actor are required with --override", 1)
+		}
+		return nil
+	},
+	Action: func(c *cli.Context) error {
+		scnFile := c.Args()[0]
+
+		data, err := os.ReadFile(scnFile)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading SCN file: %v", err), 1)
+		}
+
+		var scn fedramp.SignificantChangeNotification
+		if err := scn.FromJSON(data); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing SCN JSON: %v", err), 1)
+		}
+
+		classifier, err := loadSCNClassifier(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if err := scn.ClassifyWith(classifier); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error classifying SCN type: %v", err), 1)
+		}
+		if override := c.String("override"); override != "" {
+			if err := scn.OverrideClassification(fedramp.SCNType(override), c.String("justification"), c.String("actor")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error overriding SCN classification: %v", err), 1)
+			}
+		}
+
+		jsonData, err := scn.ToJSON()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error converting SCN to JSON: %v", err), 1)
+		}
+		if err := os.WriteFile(scnFile, jsonData, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing SCN to file: %v", err), 1)
+		}
+
+		classification := scn.Classification
+		fmt.Printf("SCN Type: %s\n", scn.SCNType)
+		fmt.Printf("Rule: %s (source: %s)\n", classification.RuleID, classification.Source)
+		if classification.Reference != "" {
+			fmt.Printf("Reference: %s\n", classification.Reference)
+		}
+		fmt.Printf("Explanation: %s\n", classification.Explanation)
+		if o := classification.Override; o != nil {
+			fmt.Printf("Overridden from %s to %s by %s: %s\n", o.OriginalType, o.Type, o.OverriddenBy, o.Justification)
+		}
+		return nil
+	},
+}
+
+var scnDraftCommand = cli.Command{
+	Name:      "draft",
+	Usage:     "Draft an SCN from a git revision range or a terraform plan",
+	ArgsUsage: "[service-id]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "from-git",
+			Usage: "Git revision range to analyze, e.g. v1.2.0..HEAD",
+		},
+		cli.StringFlag{
+			Name:  "from-tfplan",
+			Usage: "Terraform plan JSON produced by 'terraform show -json'",
+		},
+		cli.StringFlag{
+			Name:  "repo",
+			Usage: "Git repository to analyze",
+			Value: ".",
+		},
+		cli.StringFlag{
+			Name:  "mapping",
+			Usage: "JSON file mapping paths and resource types to components and controls",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "Short description of the change",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file for the draft SCN JSON",
+			Value: "scn-draft.json",
+		},
+		scnRulesFlag,
+		scnFRMRFlag,
+	},
+	Before: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Exactly 1 argument is required: service-id", 1)
+		}
+		if (c.String("from-git") == "") == (c.String("from-tfplan") == "") {
+			return cli.NewExitError("Exactly one of --from-git or --from-tfplan is required", 1)
+		}
+		if strings.HasPrefix(c.String("from-git"), "-") {
+			return cli.NewExitError("--from-git must be a revision range, not a git option", 1)
+		}
+		return nil
 	},
 	Action: func(c *cli.Context) error {
-		// This is a placeholder for SCN management functionality
-		// In a real implementation, this would read from a database or file system
-		fmt.Println("SCN List functionality - placeholder for R5.SCN BIR implementation")
-		fmt.Println("This would integrate with FedRAMP's authorization data storage")
+		var changes []fedramp.SCNDetectedChange
+		description := c.String("description")
+
+		if revisions := c.String("from-git"); revisions != "" {
+			cmd := exec.Command("git", "-C", c.String("repo"), "diff", "--name-status", "-z", "-M", revisions, "--")
+			output, err := cmd.Output()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error running git diff: %v", err), 1)
+			}
+			changes, err = fedramp.ParseGitNameStatus(bytes.NewReader(output))
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			if description == "" {
+				description = fmt.Sprintf("Changes in %s", revisions)
+			}
+		} else {
+			f, err := os.Open(c.String("from-tfplan"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error reading terraform plan: %v", err), 1)
+			}
+			changes, err = fedramp.ParseTerraformPlan(f)
+			f.Close()
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			if description == "" {
+				description = fmt.Sprintf("Infrastructure changes in %s", c.String("from-tfplan"))
+			}
+		}
+
+		mapping := fedramp.DefaultSCNDraftMapping()
+		if mappingFile := c.String("mapping"); mappingFile != "" {
+			var err error
+			if mapping, err = fedramp.LoadSCNDraftMapping(mappingFile); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+		}
+		classifier, err := loadSCNClassifier(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		scn, err := fedramp.DraftSCN(c.Args()[0], description, changes, mapping, classifier)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error drafting SCN: %v", err), 1)
+		}
+
+		jsonData, err := scn.ToJSON()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error converting SCN to JSON: %v", err), 1)
+		}
+		outputFile := c.String("output")
+		if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing SCN to file: %v", err), 1)
+		}
+
+		fmt.Printf("Draft SCN saved to %s\n", outputFile)
+		fmt.Printf("Changes Detected: %d\n", len(scn.DetectedChanges))
+		fmt.Printf("Proposed SCN Type: %s (%s)\n", scn.SCNType, scn.Classification.RuleID)
+		fmt.Printf("Change Type: %s\n", scn.ChangeType)
+		fmt.Printf("Affected Components: %v\n", scn.ComponentsAffected)
+		fmt.Printf("Affected Controls: %v\n", scn.ControlsAffected)
+		return nil
+	},
+}
+
+var scnImpactCommand = cli.Command{
+	Name:      "impact",
+	Usage:     "List the SSP statements, parameters and roles affected by an SCN",
+	ArgsUsage: "[scn-file.json]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "ssp",
+			Usage: "OSCAL SSP to analyze",
+		},
+		cli.StringFlag{
+			Name:  "previous-ssp",
+			Usage: "SSP version from before the change, used to detect unchanged narratives",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format (json, markdown)",
+			Value: "json",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file (default stdout)",
+		},
+	},
+	Before: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Exactly 1 argument is required: path to SCN JSON file", 1)
+		}
+		if c.String("ssp") == "" {
+			return cli.NewExitError("--ssp is required", 1)
+		}
 		return nil
 	},
+	Action: func(c *cli.Context) error {
+		data, err := os.ReadFile(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading SCN file: %v", err), 1)
+		}
+		var scn fedramp.SignificantChangeNotification
+		if err := scn.FromJSON(data); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error parsing SCN JSON: %v", err), 1)
+		}
+
+		plan, err := fedramp.OpenSSP(c.String("ssp"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading SSP: %v", err), 1)
+		}
+		var previous *fedramp.SSP
+		if previousFile := c.String("previous-ssp"); previousFile != "" {
+			if previous, err = fedramp.OpenSSP(previousFile); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error reading previous SSP: %v", err), 1)
+			}
+		}
+
+		report := plan.AnalyzeSCNImpact(&scn, previous)
+
+		var output []byte
+		switch c.String("format") {
+		case "json":
+			if output, err = report.ToJSON(); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error converting report to JSON: %v", err), 1)
+			}
+		case "markdown":
+			output = []byte(report.Checklist())
+		default:
+			return cli.NewExitError(fmt.Sprintf("Unsupported format: %s", c.String("format")), 1)
+		}
+
+		if outputFile := c.String("output"); outputFile != "" {
+			if err := os.WriteFile(outputFile, output, 0644); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error writing report: %v", err), 1)
+			}
+			fmt.Printf("Impact analysis saved to %s: %d control(s) affected, %d with unchanged narratives\n",
+				outputFile, len(report.Controls), report.UnchangedCount)
+			return nil
+		}
+		fmt.Println(string(output))
+		return nil
+	},
+}
+
+// loadSCNClassifier returns the classifier selected by the --rules or --frmr
+// flags, falling back to the built-in rules
+func loadSCNClassifier(c *cli.Context) (*fedramp.SCNClassifier, error) {
+	classifier := fedramp.DefaultSCNClassifier()
+	if c.String("rules") != "" {
+		var err error
+		if classifier, err = fedramp.LoadSCNRules(c.String("rules")); err != nil {
+			return nil, err
+		}
+	}
+	if c.String("frmr") != "" {
+		if err := classifier.LoadFRMRDefinitions(c.String("frmr")); err != nil {
+			return nil, err
+		}
+	}
+	return classifier, nil
+}
+
+// parseSCNAttributes converts name or name=value pairs into change attributes
+func parseSCNAttributes(attrs []string) (*fedramp.SCNChangeAttributes, error) {
+	values := make(map[string]interface{})
+	for _, attr := range attrs {
+		name, value := attr, ""
+		if i := strings.Index(attr, "="); i >= 0 {
+			name, value = attr[:i], attr[i+1:]
+		}
+		switch {
+		case name == "new_impact_level":
+			values[name] = value
+		case value == "" || value == "true":
+			values[name] = true
+		case value == "false":
+			values[name] = false
+		default:
+			return nil, fmt.Errorf("invalid change attribute: %s", attr)
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	var changeAttributes fedramp.SCNChangeAttributes
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&changeAttributes); err != nil {
+		return nil, fmt.Errorf("invalid change attributes: %v", err)
+	}
+	return &changeAttributes, nil
 }
 
 func generateSCNSummary(scn *fedramp.SignificantChangeNotification) string {
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gocomply/fedramp/pkg/fedramp"
@@ -18,6 +21,17 @@ var ssadCommand = cli.Command{
 		ssadAddDocCommand,
 		ssadFinalizeCommand,
 		ssadShareCommand,
+		ssadVerifyCommand,
+		ssadBundleCommand,
+		ssadImportCommand,
+		ssadRevokeCommand,
+		ssadEncryptCommand,
+		ssadDecryptCommand,
+		ssadRewrapCommand,
+		ssadSearchCommand,
+		ssadVersionCommand,
+		ssadRemoveDocCommand,
+		ssadDiffCommand,
 	},
 }
 
@@ -135,10 +149,21 @@ var ssadAddDocCommand = cli.Command{
 		if err != nil {
 			return cli.NewExitError(fmt.Sprintf("Error reading document: %v", err), 1)
 		}
+		hash, size, err := fedramp.HashDocumentFile(docPath)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error hashing document: %v", err), 1)
+		}
+		
+		// Locations are relative to the package file so the package can be
+		// verified wherever it is copied
+		location := docPath
+		if rel, err := filepath.Rel(filepath.Dir(packageFile), docPath); err == nil {
+			location = rel
+		}
 		
 		// Create document entry
 		doc := fedramp.SSADDocument{
-			DocumentID:   fmt.Sprintf("DOC-%s-%s", docType, time.Now().Format("20060102")),
+			DocumentID:   fedramp.SSADDocumentID(docType, location),
 			Title:        c.String("title"),
 			Type:         docType,
 			Format:       "JSON", // In practice, detect from file
@@ -146,8 +171,9 @@ var ssadAddDocCommand = cli.Command{
 			CreatedDate:  docInfo.ModTime(),
 			LastModified: docInfo.ModTime(),
 			Author:       pkg.Metadata.CSPName,
-			Size:         docInfo.Size(),
-			Location:     docPath,
+			Size:         size,
+			Hash:         hash,
+			Location:     location,
 			AccessLevel:  "restricted",
 		}
 		
@@ -190,6 +216,18 @@ var ssadFinalizeCommand = cli.Command{
 			Usage: "Person signing the package",
 			Value: "Authorizing Official",
 		},
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "PEM private key (Ed25519, ECDSA or RSA) to sign the package manifest with",
+		},
+		cli.StringFlag{
+			Name:  "cert",
+			Usage: "PEM certificate of the signing key, followed by its intermediates",
+		},
+		cli.StringFlag{
+			Name:  "signature",
+			Usage: "Detached signature output file (defaults to <package-file>.sig)",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		if c.NArg() != 1 {
@@ -212,6 +250,40 @@ var ssadFinalizeCommand = cli.Command{
 			return cli.NewExitError(fmt.Sprintf("Error finalizing package: %v", err), 1)
 		}
 		
+		// Sign the manifest and write it next to the detached signature
+		if c.String("key") != "" {
+			key, err := fedramp.LoadSSADSigningKey(c.String("key"), c.String("cert"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading signing key: %v", err), 1)
+			}
+			sig, err := pkg.Sign(key, c.String("signed-by"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error signing package: %v", err), 1)
+			}
+			sigFile := c.String("signature")
+			if sigFile == "" {
+				sigFile = c.Args()[0] + ".sig"
+			}
+			sigData, err := json.MarshalIndent(sig, "", "  ")
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating signature: %v", err), 1)
+			}
+			if err := os.WriteFile(sigFile, sigData, 0644); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error writing signature: %v", err), 1)
+			}
+			manifest, err := pkg.Manifest().Canonical()
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating manifest: %v", err), 1)
+			}
+			if err := os.WriteFile(c.Args()[0]+".manifest.json", manifest, 0644); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error writing manifest: %v", err), 1)
+			}
+			fmt.Printf("Package signed:\n")
+			fmt.Printf("  Algorithm: %s\n", sig.Algorithm)
+			fmt.Printf("  Key ID: %s\n", sig.KeyID)
+			fmt.Printf("  Signature: %s\n", sigFile)
+		}
+		
 		// Save
 		finalData, err := json.MarshalIndent(&pkg, "", "  ")
 		if err != nil {
@@ -224,6 +296,11 @@ var ssadFinalizeCommand = cli.Command{
 		
 		fmt.Printf("Package finalized:\n")
 		fmt.Printf("  Status: %s\n", pkg.Status)
+		if pkg.Parent != nil {
+			fmt.Printf("  Version: %s (%s update of %s %s)\n", pkg.Version, pkg.VersionBump(), pkg.Parent.PackageID, pkg.Parent.Version)
+		} else {
+			fmt.Printf("  Version: %s\n", pkg.Version)
+		}
 		fmt.Printf("  Package Hash: %s\n", pkg.IntegrityCheck.PackageHash)
 		fmt.Printf("  Signed By: %s\n", pkg.IntegrityCheck.SignedBy)
 		fmt.Printf("  Signature Date: %s\n", pkg.IntegrityCheck.SignatureDate.Format("2006-01-02"))
@@ -257,6 +334,14 @@ var ssadShareCommand = cli.Command{
 			Usage: "Number of days access is valid",
 			Value: 90,
 		},
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "Entity ID (defaults to ENT-<type>-<date>)",
+		},
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "PEM X25519 or RSA public key of the entity, used to encrypt the package for it",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		if c.NArg() != 1 {
@@ -276,14 +361,27 @@ var ssadShareCommand = cli.Command{
 		
 		// Create sharee
 		expiration := time.Now().AddDate(0, 0, c.Int("days"))
+		entityID := c.String("id")
+		if entityID == "" {
+			entityID = fmt.Sprintf("ENT-%s-%s", c.String("type"), time.Now().Format("20060102"))
+		}
 		sharee := fedramp.SSADSharee{
-			EntityID:       fmt.Sprintf("ENT-%s-%s", c.String("type"), time.Now().Format("20060102")),
+			EntityID:       entityID,
 			EntityType:     c.String("type"),
 			Name:           c.String("with"),
 			Email:          fmt.Sprintf("contact@%s.gov", c.String("with")),
 			AccessLevel:    c.String("access"),
 			ExpirationDate: &expiration,
 		}
+		if c.String("key") != "" {
+			key, err := fedramp.LoadSSADRecipientKey(c.String("key"))
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading public key: %v", err), 1)
+			}
+			if sharee.PublicKey, err = fedramp.EncodeSSADRecipientKey(key); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error encoding public key: %v", err), 1)
+			}
+		}
 		
 		// Share
 		pkg.ShareWith(sharee)
@@ -302,11 +400,841 @@ var ssadShareCommand = cli.Command{
 		}
 		
 		fmt.Printf("Package shared:\n")
-		fmt.Printf("  Shared with: %s\n", sharee.Name)
+		fmt.Printf("  Shared with: %s (%s)\n", sharee.Name, sharee.EntityID)
 		fmt.Printf("  Entity type: %s\n", sharee.EntityType)
 		fmt.Printf("  Access level: %s\n", sharee.AccessLevel)
 		fmt.Printf("  Expires: %s\n", sharee.ExpirationDate.Format("2006-01-02"))
 		
 		return nil
 	},
-} 
\ No newline at end of file
+}
+
+var ssadVerifyCommand = cli.Command{
+	Name:  "verify",
+	Usage: "Verify SSAD packages",
+	Subcommands: []cli.Command{
+		ssadVerifyPackageCommand,
+	},
+}
+
+var ssadVerifyPackageCommand = cli.Command{
+	Name:      "package",
+	Usage:     "Re-hash every document of a package and validate its signature",
+	ArgsUsage: "[package-file]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "signature",
+			Usage: "Detached signature file (defaults to <package-file>.sig)",
+		},
+		cli.StringFlag{
+			Name:  "base-dir",
+			Usage: "Directory relative document locations are resolved against (defaults to the package directory)",
+		},
+		cli.StringFlag{
+			Name:  "ca",
+			Usage: "PEM bundle of trusted root certificates (defaults to the system roots)",
+		},
+		cli.StringSliceFlag{
+			Name:  "trusted-key",
+			Usage: "PEM public key trusted without a certificate (can be specified multiple times)",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the verification report as JSON",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Package file is required", 1)
+		}
+		packageFile := c.Args()[0]
+		
+		pkg, err := readSSADPackage(packageFile)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		opts, err := ssadVerifyOptions(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if opts.BaseDir == "" {
+			opts.BaseDir = filepath.Dir(packageFile)
+		}
+		sigFile := c.String("signature")
+		if sigFile == "" {
+			sigFile = packageFile + ".sig"
+		}
+		sig, err := readSSADSignature(sigFile)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		
+		result := fedramp.VerifySSADPackage(pkg, sig, opts)
+		if c.Bool("json") {
+			out, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			fmt.Println(string(out))
+		} else {
+			fmt.Printf("Package %s\n", result.PackageID)
+			for _, doc := range result.Documents {
+				fmt.Printf("  [%s] %s %s (%s)\n", doc.Status, doc.Component, doc.DocumentID, doc.Location)
+			}
+			fmt.Printf("  Manifest digest: %s\n", result.ManifestDigest)
+			fmt.Printf("  Manifest matches package hash: %v\n", result.ManifestMatches)
+			fmt.Printf("  Signature valid: %v\n", result.SignatureValid)
+			fmt.Printf("  Signer trusted: %v", result.Trusted)
+			if result.Signer != "" {
+				fmt.Printf(" (%s)", result.Signer)
+			}
+			fmt.Println()
+			for _, problem := range result.Problems {
+				fmt.Printf("  PROBLEM: %s\n", problem)
+			}
+		}
+		
+		if !result.OK() {
+			return cli.NewExitError("Package verification failed", 1)
+		}
+		fmt.Println("Package verified")
+		return nil
+	},
+}
+
+var ssadBundleCommand = cli.Command{
+	Name:      "bundle",
+	Usage:     "Bundle a finalized package, its documents and signature into a zip or tar.gz archive",
+	ArgsUsage: "[package-file] [archive-file]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "signature",
+			Usage: "Detached signature file (defaults to <package-file>.sig when present)",
+		},
+		cli.StringFlag{
+			Name:  "base-dir",
+			Usage: "Directory relative document locations are resolved against (defaults to the package directory)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Package file and archive file are required", 1)
+		}
+		packageFile, archiveFile := c.Args()[0], c.Args()[1]
+		
+		format, err := fedramp.SSADArchiveFormatFor(archiveFile)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		pkg, err := readSSADPackage(packageFile)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		sigFile := c.String("signature")
+		if sigFile == "" {
+			sigFile = packageFile + ".sig"
+		}
+		sig, err := readSSADSignature(sigFile)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if sig == nil && c.String("signature") != "" {
+			return cli.NewExitError(fmt.Sprintf("Signature file not found: %s", sigFile), 1)
+		}
+		baseDir := c.String("base-dir")
+		if baseDir == "" {
+			baseDir = filepath.Dir(packageFile)
+		}
+		
+		// Write next to the destination so a failed bundle leaves nothing behind
+		tmp := archiveFile + ".tmp"
+		out, err := os.Create(tmp)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error creating archive: %v", err), 1)
+		}
+		index, err := fedramp.BundleSSADPackage(pkg, sig, baseDir, format, out)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return cli.NewExitError(fmt.Sprintf("Error bundling package: %v", err), 1)
+		}
+		if err := os.Rename(tmp, archiveFile); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing archive: %v", err), 1)
+		}
+		
+		fmt.Printf("SSAD archive created:\n")
+		fmt.Printf("  Package ID: %s\n", index.PackageID)
+		fmt.Printf("  Format: %s\n", format)
+		fmt.Printf("  Files: %d\n", len(index.Files))
+		fmt.Printf("  Signed: %v\n", index.Signed)
+		fmt.Printf("  Manifest digest: %s\n", index.ManifestDigest)
+		fmt.Printf("  Saved to: %s\n", archiveFile)
+		return nil
+	},
+}
+
+var ssadImportCommand = cli.Command{
+	Name:      "import",
+	Usage:     "Verify an SSAD archive and restore it into a repository",
+	ArgsUsage: "[archive-file]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "repo",
+			Usage: "Repository directory",
+			Value: "data/ssad",
+		},
+		cli.StringFlag{
+			Name:  "ca",
+			Usage: "PEM bundle of trusted root certificates (defaults to the system roots)",
+		},
+		cli.StringSliceFlag{
+			Name:  "trusted-key",
+			Usage: "PEM public key trusted without a certificate (can be specified multiple times)",
+		},
+		cli.BoolFlag{
+			Name:  "allow-untrusted",
+			Usage: "Import packages that are unsigned or signed by an untrusted key",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Archive file is required", 1)
+		}
+		verifyOpts, err := ssadVerifyOptions(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		opts := fedramp.SSADImportOptions{
+			SSADVerifyOptions: verifyOpts,
+			AllowUntrusted:    c.Bool("allow-untrusted"),
+		}
+		
+		pkg, result, err := fedramp.ImportSSADArchive(c.Args()[0], c.String("repo"), opts)
+		if err != nil {
+			if result != nil {
+				for _, problem := range result.Problems {
+					fmt.Printf("  PROBLEM: %s\n", problem)
+				}
+			}
+			return cli.NewExitError(fmt.Sprintf("Error importing archive: %v", err), 1)
+		}
+		
+		fmt.Printf("SSAD package imported:\n")
+		fmt.Printf("  Package ID: %s\n", pkg.PackageID)
+		fmt.Printf("  Service: %s\n", pkg.ServiceOfferingID)
+		fmt.Printf("  Documents: %d verified\n", len(result.Documents))
+		fmt.Printf("  Signature: valid=%v trusted=%v", result.SignatureValid, result.Trusted)
+		if result.Signer != "" {
+			fmt.Printf(" (%s)", result.Signer)
+		}
+		fmt.Println()
+		for _, problem := range result.Problems {
+			fmt.Printf("  WARNING: %s\n", problem)
+		}
+		fmt.Printf("  Saved to: %s\n", filepath.Join(c.String("repo"), pkg.PackageID))
+		return nil
+	},
+}
+
+var ssadRevokeCommand = cli.Command{
+	Name:      "revoke",
+	Usage:     "Revoke the access of an entity to an SSAD package",
+	ArgsUsage: "[package-file] [entity-id]",
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Package file and entity ID are required", 1)
+		}
+		pkg, err := readSSADPackage(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if !pkg.RevokeSharee(c.Args()[1]) {
+			return cli.NewExitError(fmt.Sprintf("Package is not shared with %s", c.Args()[1]), 1)
+		}
+		if err := writeSSADPackage(c.Args()[0], pkg); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		fmt.Printf("Access of %s revoked\n", c.Args()[1])
+		fmt.Printf("Run 'ssad rewrap' to remove it from encrypted copies of the package\n")
+		return nil
+	},
+}
+
+var ssadEncryptCommand = cli.Command{
+	Name:      "encrypt",
+	Usage:     "Encrypt a package archive for the current sharees of the package",
+	ArgsUsage: "[archive-file]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "package",
+			Usage: "Package file listing the sharees and their public keys",
+		},
+		cli.StringFlag{
+			Name:  "owner-key",
+			Usage: "PEM public key of the package owner, kept as a recipient so sharees can be added later",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Encrypted output file (defaults to <archive-file>.enc)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 || c.String("package") == "" {
+			return cli.NewExitError("Archive file and --package are required", 1)
+		}
+		archiveFile := c.Args()[0]
+		pkg, err := readSSADPackage(c.String("package"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		recipients, skipped, err := ssadRecipients(c, pkg)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		
+		in, err := os.Open(archiveFile)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading archive: %v", err), 1)
+		}
+		defer in.Close()
+		output := c.String("output")
+		if output == "" {
+			output = archiveFile + ".enc"
+		}
+		tmp := output + ".tmp"
+		out, err := os.Create(tmp)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error creating output: %v", err), 1)
+		}
+		env, err := fedramp.EncryptSSADContent(in, out, pkg, recipients)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return cli.NewExitError(fmt.Sprintf("Error encrypting package: %v", err), 1)
+		}
+		if err := writeSSADEnvelope(output+".envelope.json", env); err != nil {
+			os.Remove(tmp)
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if err := os.Rename(tmp, output); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing output: %v", err), 1)
+		}
+		
+		fmt.Printf("Package encrypted:\n")
+		fmt.Printf("  Package ID: %s\n", env.PackageID)
+		for _, wrapped := range env.Recipients {
+			fmt.Printf("  Recipient: %s (%s)\n", wrapped.EntityID, wrapped.Algorithm)
+		}
+		for _, reason := range skipped {
+			fmt.Printf("  Skipped: %s\n", reason)
+		}
+		fmt.Printf("  Encrypted: %s\n", output)
+		fmt.Printf("  Envelope: %s\n", output+".envelope.json")
+		return nil
+	},
+}
+
+var ssadDecryptCommand = cli.Command{
+	Name:      "decrypt",
+	Usage:     "Decrypt an encrypted package archive with a recipient private key",
+	ArgsUsage: "[encrypted-file]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "PEM X25519 or RSA private key of the recipient",
+		},
+		cli.StringFlag{
+			Name:  "envelope",
+			Usage: "Envelope file (defaults to <encrypted-file>.envelope.json)",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Decrypted output file (defaults to the encrypted file without .enc)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 || c.String("key") == "" {
+			return cli.NewExitError("Encrypted file and --key are required", 1)
+		}
+		encrypted := c.Args()[0]
+		env, err := readSSADEnvelope(c, encrypted)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		key, err := fedramp.LoadSSADDecryptionKey(c.String("key"))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error loading private key: %v", err), 1)
+		}
+		
+		in, err := os.Open(encrypted)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error reading encrypted file: %v", err), 1)
+		}
+		defer in.Close()
+		output := c.String("output")
+		if output == "" {
+			output = strings.TrimSuffix(encrypted, ".enc")
+			if output == encrypted {
+				output += ".dec"
+			}
+		}
+		
+		// Plaintext is only kept once every chunk and the digest checked out
+		tmp := output + ".tmp"
+		out, err := os.Create(tmp)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error creating output: %v", err), 1)
+		}
+		err = fedramp.DecryptSSADContent(env, in, out, key, time.Now())
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return cli.NewExitError(fmt.Sprintf("Error decrypting package: %v", err), 1)
+		}
+		if err := os.Rename(tmp, output); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error writing output: %v", err), 1)
+		}
+		
+		fmt.Printf("Package %s decrypted to %s\n", env.PackageID, output)
+		return nil
+	},
+}
+
+var ssadRewrapCommand = cli.Command{
+	Name:      "rewrap",
+	Usage:     "Re-wrap the content key of an encrypted package for its current sharees",
+	ArgsUsage: "[encrypted-file]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "package",
+			Usage: "Package file listing the sharees and their public keys",
+		},
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "PEM private key of an existing recipient, needed to add recipients",
+		},
+		cli.StringFlag{
+			Name:  "owner-key",
+			Usage: "PEM public key of the package owner to keep or add as a recipient",
+		},
+		cli.StringFlag{
+			Name:  "envelope",
+			Usage: "Envelope file (defaults to <encrypted-file>.envelope.json)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 || c.String("package") == "" {
+			return cli.NewExitError("Encrypted file and --package are required", 1)
+		}
+		env, err := readSSADEnvelope(c, c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		pkg, err := readSSADPackage(c.String("package"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if pkg.PackageID != env.PackageID {
+			return cli.NewExitError(fmt.Sprintf("Envelope is for package %s, not %s", env.PackageID, pkg.PackageID), 1)
+		}
+		recipients, skipped, err := ssadRecipients(c, pkg)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		var key crypto.PrivateKey
+		if c.String("key") != "" {
+			if key, err = fedramp.LoadSSADDecryptionKey(c.String("key")); err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error loading private key: %v", err), 1)
+			}
+		}
+		
+		added, removed, err := env.Rewrap(key, recipients, time.Now())
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error rewrapping keys: %v", err), 1)
+		}
+		envelopeFile := c.String("envelope")
+		if envelopeFile == "" {
+			envelopeFile = c.Args()[0] + ".envelope.json"
+		}
+		if err := writeSSADEnvelope(envelopeFile, env); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		
+		fmt.Printf("Envelope updated:\n")
+		fmt.Printf("  Added: %s\n", strings.Join(added, ", "))
+		fmt.Printf("  Removed: %s\n", strings.Join(removed, ", "))
+		for _, reason := range skipped {
+			fmt.Printf("  Skipped: %s\n", reason)
+		}
+		fmt.Printf("  Recipients: %d\n", len(env.Recipients))
+		return nil
+	},
+}
+
+var ssadSearchCommand = cli.Command{
+	Name:      "search",
+	Usage:     "Search the packages of an SSAD repository",
+	ArgsUsage: "[query]",
+	Description: `Terms are AND-ed. Use field:value with the fields id, service, csp,
+   impact, auth, status, format, classification, title, tag, keyword and doc, or
+   free text. Dates (created, updated, authorized, expires) take >, >=, < and <=.
+   Prefix a term with - to negate it, separate alternatives with | and quote
+   values with spaces. Put -- before a query starting with a negated term:
+
+     csp:"CloudNative Inc" impact:High|Moderate doc:sar authorized:>=2026-01
+     -- -status:archived tag:pilot`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "repo",
+			Usage: "Repository directory",
+			Value: "data/ssad",
+		},
+		cli.StringFlag{
+			Name:  "as",
+			Usage: "Only list packages this entity can read",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		query, err := fedramp.ParseSSADQuery(strings.Join(c.Args(), " "))
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Invalid query: %v", err), 1)
+		}
+		store, err := fedramp.NewFileSSADStore(c.String("repo"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		packages, err := store.ListPackages()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error listing packages: %v", err), 1)
+		}
+		
+		now := time.Now()
+		found := 0
+		for _, pkg := range packages {
+			if !query.Match(pkg) {
+				continue
+			}
+			if c.String("as") != "" && !pkg.CanAccess(c.String("as"), fedramp.SSADAccessRead, now) {
+				continue
+			}
+			found++
+			fmt.Printf("%s  %s  %s  %s  %s\n", pkg.PackageID, pkg.Metadata.CSPName, pkg.Metadata.ImpactLevel, pkg.Metadata.AuthorizationType, pkg.Status)
+		}
+		fmt.Printf("%d package(s) found\n", found)
+		return nil
+	},
+}
+
+var ssadVersionCommand = cli.Command{
+	Name:      "version",
+	Usage:     "Start a new draft version of a finalized SSAD package",
+	ArgsUsage: "[package-file]",
+	Description: `The draft keeps the documents, metadata and sharing of the package and
+   links back to it. Replace documents with add-doc, drop them with remove-doc,
+   then finalize: the version number is bumped by major for a new SAP or SAR,
+   minor for a new SSP or removed documents, and patch otherwise.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output file for the new version (defaults to <package-id>.json next to the package)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Package file is required", 1)
+		}
+		packageFile := c.Args()[0]
+		pkg, err := readSSADPackage(packageFile)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		next, err := pkg.NewVersion()
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error creating version: %v", err), 1)
+		}
+		
+		outputFile := c.String("output")
+		if outputFile == "" {
+			outputFile = filepath.Join(filepath.Dir(packageFile), next.PackageID+".json")
+		}
+		
+		// Keep relative document locations pointing at the same files
+		fromDir, toDir := filepath.Dir(packageFile), filepath.Dir(outputFile)
+		next.RelocateDocuments(func(location string) string {
+			if filepath.IsAbs(location) {
+				return location
+			}
+			if rel, err := filepath.Rel(toDir, filepath.Join(fromDir, filepath.FromSlash(location))); err == nil {
+				return rel
+			}
+			return location
+		})
+		
+		if err := os.MkdirAll(toDir, 0755); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error creating output directory: %v", err), 1)
+		}
+		if err := writeSSADPackage(outputFile, next); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		fmt.Printf("New draft version created:\n")
+		fmt.Printf("  Package ID: %s\n", next.PackageID)
+		fmt.Printf("  Supersedes: %s %s\n", pkg.PackageID, pkg.Version)
+		fmt.Printf("  Saved to: %s\n", outputFile)
+		return nil
+	},
+}
+
+var ssadRemoveDocCommand = cli.Command{
+	Name:      "remove-doc",
+	Usage:     "Remove a document from a draft SSAD package",
+	ArgsUsage: "[package-file] [document-id]",
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Package file and document ID are required", 1)
+		}
+		pkg, err := readSSADPackage(c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if err := pkg.RemoveDocument(c.Args()[1]); err != nil {
+			return cli.NewExitError(fmt.Sprintf("Error removing document: %v", err), 1)
+		}
+		if err := writeSSADPackage(c.Args()[0], pkg); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		fmt.Printf("Removed document %s\n", c.Args()[1])
+		return nil
+	},
+}
+
+var ssadDiffCommand = cli.Command{
+	Name:      "diff",
+	Usage:     "Compare two versions of an SSAD package",
+	ArgsUsage: "[from] [to]",
+	Description: `Each version is a package file or the ID of a package in --repo. Documents
+   are compared by hash; changed JSON documents also get a structural diff:
+   control implementations of OSCAL SSPs, POA&M items and SAR findings.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "repo",
+			Usage: "Repository directory for versions given by package ID",
+			Value: "data/ssad",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Output the diff as JSON",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Two package versions are required", 1)
+		}
+		from, fromDir, err := readSSADVersion(c.String("repo"), c.Args()[0])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		to, toDir, err := readSSADVersion(c.String("repo"), c.Args()[1])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		diff := fedramp.DiffSSADPackages(from, to, fedramp.SSADDiffOptions{FromBaseDir: fromDir, ToBaseDir: toDir})
+		
+		if c.Bool("json") {
+			data, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				return cli.NewExitError(fmt.Sprintf("Error generating JSON: %v", err), 1)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		
+		lineage := "not a direct successor"
+		if diff.DirectSuccessor {
+			lineage = "direct successor"
+		}
+		fmt.Printf("%s %s -> %s %s (%s)\n", diff.From.PackageID, diff.From.Version, diff.To.PackageID, diff.To.Version, lineage)
+		if len(diff.Metadata) > 0 {
+			fmt.Printf("Metadata:\n")
+			for _, field := range diff.Metadata {
+				fmt.Printf("  %s: %q -> %q\n", field.Field, field.From, field.To)
+			}
+		}
+		fmt.Printf("Documents: %d added, %d removed, %d modified, %d unchanged\n",
+			diff.Summary[fedramp.SSADDocumentAdded], diff.Summary[fedramp.SSADDocumentRemoved],
+			diff.Summary[fedramp.SSADDocumentModified], diff.Summary["unchanged"])
+		marks := map[string]string{
+			fedramp.SSADItemAdded:    "+",
+			fedramp.SSADItemRemoved:  "-",
+			fedramp.SSADItemModified: "~",
+			fedramp.SSADItemClosed:   "x",
+			fedramp.SSADItemReopened: "!",
+		}
+		for _, doc := range diff.Documents {
+			fmt.Printf("  %s %-10s %s", marks[doc.Change], doc.Component, doc.DocumentID)
+			if doc.FromDocumentID != "" {
+				fmt.Printf(" (replaces %s)", doc.FromDocumentID)
+			}
+			fmt.Printf("  %s\n", doc.Title)
+			if doc.ContentError != "" {
+				fmt.Printf("      no structural diff: %s\n", doc.ContentError)
+			}
+			if doc.Content == nil {
+				continue
+			}
+			for _, item := range doc.Content.Changes {
+				fmt.Printf("      %s %s %s", marks[item.Change], item.Change, item.ID)
+				if item.Title != "" && item.Title != item.ID {
+					fmt.Printf("  %s", item.Title)
+				}
+				switch {
+				case item.FromStatus != item.ToStatus && item.FromStatus != "" && item.ToStatus != "":
+					fmt.Printf("  [%s -> %s]", item.FromStatus, item.ToStatus)
+				case item.ToStatus != "":
+					fmt.Printf("  [%s]", item.ToStatus)
+				case item.FromStatus != "":
+					fmt.Printf("  [was %s]", item.FromStatus)
+				}
+				if len(item.Fields) > 0 {
+					fmt.Printf("  (%s)", strings.Join(item.Fields, ", "))
+				}
+				fmt.Println()
+			}
+		}
+		return nil
+	},
+}
+
+// readSSADVersion reads a package from a file or, failing that, by ID from a
+// repository, with the directory its document locations are relative to
+func readSSADVersion(repo, ref string) (*fedramp.SSADPackage, string, error) {
+	if _, err := os.Stat(ref); err == nil {
+		pkg, err := readSSADPackage(ref)
+		return pkg, filepath.Dir(ref), err
+	}
+	store, err := fedramp.NewFileSSADStore(repo)
+	if err != nil {
+		return nil, "", err
+	}
+	pkg, err := store.GetPackage(ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read package %s: %v", ref, err)
+	}
+	return pkg, filepath.Join(repo, ref), nil
+}
+
+// ssadRecipients returns the sharees of a package able to receive its content
+// key, plus the owner when --owner-key is given
+func ssadRecipients(c *cli.Context, pkg *fedramp.SSADPackage) ([]fedramp.SSADRecipient, []string, error) {
+	recipients, skipped, err := pkg.Recipients(time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.String("owner-key") != "" {
+		key, err := fedramp.LoadSSADRecipientKey(c.String("owner-key"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load owner key: %v", err)
+		}
+		owner := pkg.AccessControl.Owner
+		if owner == "" {
+			owner = "owner"
+		}
+		recipients = append(recipients, fedramp.SSADRecipient{EntityID: owner, Name: owner, PublicKey: key, Owner: true})
+	}
+	return recipients, skipped, nil
+}
+
+func readSSADEnvelope(c *cli.Context, encrypted string) (*fedramp.SSADEnvelope, error) {
+	path := c.String("envelope")
+	if path == "" {
+		path = encrypted + ".envelope.json"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read envelope: %v", err)
+	}
+	var env fedramp.SSADEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse envelope: %v", err)
+	}
+	return &env, nil
+}
+
+func writeSSADEnvelope(path string, env *fedramp.SSADEnvelope) error {
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write envelope: %v", err)
+	}
+	return nil
+}
+
+// writeSSADPackage saves a package file
+func writeSSADPackage(path string, pkg *fedramp.SSADPackage) error {
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write package: %v", err)
+	}
+	return nil
+}
+
+// readSSADPackage reads a package file
+func readSSADPackage(path string) (*fedramp.SSADPackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package: %v", err)
+	}
+	var pkg fedramp.SSADPackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package: %v", err)
+	}
+	return &pkg, nil
+}
+
+// readSSADSignature reads a detached signature, returning nil when the file
+// does not exist
+func readSSADSignature(path string) (*fedramp.SSADSignature, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %v", err)
+	}
+	var sig fedramp.SSADSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %v", err)
+	}
+	return &sig, nil
+}
+
+// ssadVerifyOptions loads the trust anchors given by the --ca and
+// --trusted-key flags
+func ssadVerifyOptions(c *cli.Context) (fedramp.SSADVerifyOptions, error) {
+	opts := fedramp.SSADVerifyOptions{BaseDir: c.String("base-dir")}
+	if c.String("ca") != "" {
+		roots, err := fedramp.LoadCertificatePool(c.String("ca"))
+		if err != nil {
+			return opts, fmt.Errorf("failed to load CA bundle: %v", err)
+		}
+		opts.Roots = roots
+	}
+	for _, path := range c.StringSlice("trusted-key") {
+		key, err := fedramp.LoadPublicKey(path)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load trusted key: %v", err)
+		}
+		opts.TrustedKeys = append(opts.TrustedKeys, key)
+	}
+	return opts, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gocomply/fedramp/pkg/api"
 	"github.com/gocomply/fedramp/pkg/database"
+	"github.com/gocomply/fedramp/pkg/fedramp"
 	"github.com/gocomply/fedramp/pkg/monitor"
 	log "github.com/sirupsen/logrus"
 )
@@ -24,8 +26,11 @@ func main() {
 		dbPassword   = flag.String("db-password", "", "Database password")
 		dbName       = flag.String("db-name", "fedramp", "Database name")
 		enableAuth   = flag.Bool("enable-auth", false, "Enable authentication")
+		authTokens   = flag.String("auth-tokens", "", "JSON file mapping SHA-256 digests of bearer tokens to entity IDs (required with -enable-auth)")
 		enableDash   = flag.Bool("enable-dashboard", true, "Enable web dashboard")
 		logLevel     = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+		dataDir      = flag.String("data-dir", "data", "Directory for file-backed stores when no database is configured")
+		crsPolicy    = flag.String("crs-thresholds", "", "CRS metric threshold policy file (JSON)")
 	)
 	flag.Parse()
 
@@ -67,13 +72,34 @@ func main() {
 		defer db.Close()
 	}
 
+	var crsThresholds *fedramp.CRSThresholdPolicy
+	if *crsPolicy != "" {
+		crsThresholds, err = fedramp.LoadCRSThresholdPolicy(*crsPolicy)
+		if err != nil {
+			log.Fatalf("Invalid CRS threshold policy: %v", err)
+		}
+	}
+
+	var authenticator api.Authenticator
+	if *authTokens != "" {
+		tokens, err := api.LoadTokenAuthenticator(*authTokens)
+		if err != nil {
+			log.Fatalf("Invalid auth tokens: %v", err)
+		}
+		authenticator = tokens
+	}
+
 	// Initialize API server
 	apiConfig := &api.Config{
 		Port:            *port,
 		DatabaseURL:     fmt.Sprintf("postgres://%s:%s@%s:%d/%s", dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Database),
 		EnableAuth:      *enableAuth,
+		Authenticator:   authenticator,
 		EnableMetrics:   true,
 		EnableDashboard: *enableDash,
+		DataDir:         *dataDir,
+		CRSThresholds:   crsThresholds,
+		DB:              db,
 	}
 
 	server := api.NewServer(apiConfig)
@@ -88,6 +114,13 @@ func main() {
 	}
 
 	continuousMonitor := monitor.NewContinuousMonitor(db, monitorConfig)
+	if store := server.SCNStore(); store != nil {
+		continuousMonitor.SetSCNStore(store, fedramp.DefaultSCNDeadlinePolicy())
+	}
+	if store := server.POAMStore(); store != nil {
+		continuousMonitor.SetPOAMStore(store)
+	}
+	continuousMonitor.WatchCRS(server.CRSManager())
 	
 	// Start monitoring
 	if err := continuousMonitor.Start(); err != nil {
@@ -115,6 +148,11 @@ func main() {
 	log.Info("Shutting down server...")
 	
 	// Graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Stop(ctx); err != nil {
+		log.Errorf("Error stopping API server: %v", err)
+	}
 	if err := continuousMonitor.Stop(); err != nil {
 		log.Errorf("Error stopping continuous monitor: %v", err)
 	}
@@ -144,12 +182,24 @@ func setupDevelopmentData(db *database.DB) {
 			"KSI-CMT-05": false, // One failing for demo
 		}
 		
-		for ksiID, status := range evidence {
-			db.SaveKSIEvidence(csoID, ksiID, map[string]interface{}{
-				"status": status,
-				"evidence": "Development test data",
+		byKSI := make(map[string][]fedramp.KSIEvidence)
+		for pointID, status := range evidence {
+			if !status {
+				continue // no evidence for the failing point
+			}
+			ksiID := pointID[:7]
+			byKSI[ksiID] = append(byKSI[ksiID], fedramp.KSIEvidence{
+				Type:        pointID,
+				Description: "Development test data",
+				Timestamp:   time.Now(),
+				Source:      "development",
 			})
 		}
+		for ksiID, items := range byKSI {
+			if err := db.SaveKSIEvidence(csoID, ksiID, items); err != nil {
+				log.Errorf("Failed to save KSI evidence for %s: %v", csoID, err)
+			}
+		}
 	}
 	
 	log.Info("Development data setup complete")
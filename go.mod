@@ -1,6 +1,6 @@
 module github.com/gocomply/fedramp
 
-go 1.19
+go 1.20
 
 require (
 	github.com/Masterminds/vcs v1.13.3
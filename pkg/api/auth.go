@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Authenticator resolves the entity making a request from its credentials
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// ErrUnauthenticated is returned by authenticators for missing or unknown
+// credentials
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// TokenAuthenticator maps the SHA-256 hex digest of each bearer token to the
+// entity it authenticates, so the token file never holds usable tokens
+type TokenAuthenticator map[string]string
+
+// LoadTokenAuthenticator reads a JSON object mapping token digests to entity
+// IDs
+func LoadTokenAuthenticator(path string) (TokenAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	var tokens TokenAuthenticator
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	normalized := make(TokenAuthenticator, len(tokens))
+	for digest, entityID := range tokens {
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 || entityID == "" {
+			return nil, fmt.Errorf("token file entries must map a SHA-256 hex digest to an entity ID")
+		}
+		normalized[strings.ToLower(digest)] = entityID
+	}
+	return normalized, nil
+}
+
+// Authenticate looks up the bearer token of the request
+func (a TokenAuthenticator) Authenticate(r *http.Request) (string, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if token == "" {
+		return "", ErrUnauthenticated
+	}
+	sum := sha256.Sum256([]byte(token))
+	entityID, ok := a[hex.EncodeToString(sum[:])]
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	return entityID, nil
+}
+
+type principalKey struct{}
+
+// principal returns the entity authenticated for the request, or "" for
+// anonymous requests
+func principal(r *http.Request) string {
+	entityID, _ := r.Context().Value(principalKey{}).(string)
+	return entityID
+}
+
+// authMiddleware authenticates every request and records the principal in
+// the request context. A client-supplied EntityHeader is never trusted: it
+// is replaced with the authenticated entity.
+func authMiddleware(auth Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(EntityHeader)
+		entityID, err := auth.Authenticate(r)
+		if err != nil {
+			respondError(w, http.StatusUnauthorized, "Missing or invalid authorization token")
+			return
+		}
+		r.Header.Set(EntityHeader, entityID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, entityID)))
+	})
+}
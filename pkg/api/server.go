@@ -1,12 +1,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/gocomply/fedramp/pkg/database"
 	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/gocomply/fedramp/pkg/metrics"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
@@ -14,8 +23,18 @@ import (
 
 // Server represents the FedRAMP API server
 type Server struct {
-	router *mux.Router
-	config *Config
+	router   *mux.Router
+	config   *Config
+	scnStore  fedramp.SCNStore
+	crsStore  fedramp.CRSRecordStore
+	poamStore fedramp.POAMStore
+	ssadStore fedramp.SSADStore
+	ksiStore  fedramp.KSIEvidenceStore
+	locker    fedramp.EvidenceLocker
+	crs       *fedramp.CRSManager
+	http      *http.Server
+	stop      chan struct{}
+	stopOnce  sync.Once
 }
 
 // Config holds server configuration
@@ -23,24 +42,110 @@ type Config struct {
 	Port            string
 	DatabaseURL     string
 	EnableAuth      bool
+	Authenticator   Authenticator // required when EnableAuth is set
 	EnableMetrics   bool
 	EnableDashboard bool
+	DataDir         string       // file-backed stores are kept here when DB is nil
+	CRSThresholds   *fedramp.CRSThresholdPolicy // defaults when nil
+	EvidenceLocker  fedramp.EvidenceLocker      // a file locker under DataDir when nil
+	DB              *database.DB
 }
 
+// evidenceActor is recorded in the chain of custody when the server verifies
+// cited evidence for an unauthenticated request
+const evidenceActor = "fedramp-api"
+
 // NewServer creates a new API server instance
 func NewServer(config *Config) *Server {
 	s := &Server{
 		router: mux.NewRouter(),
 		config: config,
+		crs:    fedramp.NewCRSManager(),
+		stop:   make(chan struct{}),
+	}
+	s.http = &http.Server{Addr: ":" + config.Port}
+	if config.CRSThresholds != nil {
+		s.crs.SetThresholdPolicy(config.CRSThresholds)
 	}
+	s.crs.OnMetricEvaluated(metrics.RecordCRSMetric)
+	s.setupStores()
 	s.setupRoutes()
+	s.seedMetrics()
 	return s
 }
 
+// setupStores selects Postgres-backed stores when a database is configured
+// and file-backed stores under DataDir otherwise
+func (s *Server) setupStores() {
+	dataDir := s.config.DataDir
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	crsStore, err := fedramp.NewFileCRSRecordStore(filepath.Join(dataDir, "crs"))
+	if err != nil {
+		log.Errorf("CRS record store unavailable: %v", err)
+	} else {
+		s.crsStore = crsStore
+	}
+	ssadStore, err := fedramp.NewFileSSADStore(filepath.Join(dataDir, "ssad"))
+	if err != nil {
+		log.Errorf("SSAD repository unavailable: %v", err)
+	} else {
+		s.ssadStore = ssadStore
+	}
+	s.locker = s.config.EvidenceLocker
+	if s.locker == nil {
+		locker, err := fedramp.NewFileEvidenceLocker(filepath.Join(dataDir, "evidence"))
+		if err != nil {
+			log.Errorf("Evidence locker unavailable: %v", err)
+		} else {
+			s.locker = locker
+		}
+	}
+	if s.locker != nil {
+		s.crs.SetEvidenceLocker(s.locker, evidenceActor)
+	}
+
+	if s.config.DB != nil {
+		s.scnStore = s.config.DB
+		s.poamStore = s.config.DB
+		s.ksiStore = s.config.DB
+		s.crs.SetSeriesStore(s.config.DB)
+		return
+	}
+
+	poamStore, err := fedramp.NewFilePOAMStore(filepath.Join(dataDir, "poam"))
+	if err != nil {
+		log.Errorf("POA&M store unavailable: %v", err)
+	} else {
+		s.poamStore = poamStore
+	}
+	ksiStore, err := fedramp.NewFileKSIEvidenceStore(filepath.Join(dataDir, "ksi"))
+	if err != nil {
+		log.Errorf("KSI evidence store unavailable: %v", err)
+	} else {
+		s.ksiStore = ksiStore
+	}
+
+	store, err := fedramp.NewFileSCNStore(filepath.Join(dataDir, "scn"))
+	if err != nil {
+		log.Errorf("SCN store unavailable: %v", err)
+	} else {
+		s.scnStore = store
+	}
+	series, err := fedramp.NewFileMetricSeriesStore(filepath.Join(dataDir, "metrics"))
+	if err != nil {
+		log.Errorf("Metric series store unavailable: %v", err)
+		return
+	}
+	s.crs.SetSeriesStore(series)
+}
+
 // setupRoutes configures all API endpoints
 func (s *Server) setupRoutes() {
 	// API versioning
 	api := s.router.PathPrefix("/api/v1").Subrouter()
+	s.router.Use(instrumentMiddleware)
 
 	// Health check
 	api.HandleFunc("/health", s.healthCheck).Methods("GET")
@@ -56,9 +161,16 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/scn/{csoId}", s.listSCNs).Methods("GET")
 	api.HandleFunc("/scn/{csoId}/{scnId}", s.getSCN).Methods("GET")
 	api.HandleFunc("/scn/{csoId}/{scnId}/approve", s.approveSCN).Methods("POST")
+	api.HandleFunc("/scn/{csoId}/{scnId}/transition", s.transitionSCN).Methods("POST")
+
+	// POA&M endpoints
+	api.HandleFunc("/poam/{csoId}", s.putPOAM).Methods("PUT")
+	api.HandleFunc("/poam/{csoId}", s.getPOAM).Methods("GET")
+	api.HandleFunc("/poam/{csoId}/forecast", s.forecastPOAM).Methods("GET")
 
 	// CRS endpoints
 	api.HandleFunc("/crs/report", s.createCRSReport).Methods("POST")
+	api.HandleFunc("/crs/records/{csoId}", s.ingestCRSRecords).Methods("POST")
 	api.HandleFunc("/crs/metrics/{csoId}", s.getMetrics).Methods("GET")
 	api.HandleFunc("/crs/dashboard/{csoId}", s.getDashboard).Methods("GET")
 
@@ -70,6 +182,12 @@ func (s *Server) setupRoutes() {
 	// SSAD endpoints
 	api.HandleFunc("/ssad/package", s.createPackage).Methods("POST")
 	api.HandleFunc("/ssad/package/{packageId}", s.getPackage).Methods("GET")
+	api.HandleFunc("/ssad/package/{packageId}/documents/{documentId}", s.getPackageDocument).Methods("GET")
+	api.HandleFunc("/ssad/package/{packageId}/share", s.sharePackage).Methods("POST")
+	api.HandleFunc("/ssad/package/{packageId}/share/{entityId}", s.revokePackageShare).Methods("DELETE")
+	api.HandleFunc("/ssad/package/{packageId}/distributions", s.listDistributions).Methods("GET")
+	api.HandleFunc("/ssad/package/{packageId}/distributions/{distributionId}/ack", s.acknowledgeDistribution).Methods("POST")
+	api.HandleFunc("/ssad/package/{packageId}/access-log", s.getAccessLog).Methods("GET")
 	api.HandleFunc("/ssad/repository", s.listPackages).Methods("GET")
 
 	// FRMR endpoints
@@ -102,11 +220,60 @@ func (s *Server) Start() error {
 	// Add middleware
 	handler = loggingMiddleware(handler)
 	if s.config.EnableAuth {
-		handler = authMiddleware(handler)
+		if s.config.Authenticator == nil {
+			return fmt.Errorf("authentication is enabled but no authenticator is configured")
+		}
+		handler = authMiddleware(s.config.Authenticator, handler)
 	}
 
+	// Overdue POA&M items change with time as well as with the POA&M
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.recordPOAMMetrics()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	s.http.Handler = handler
 	log.Infof("Starting FedRAMP API server on port %s", s.config.Port)
-	return http.ListenAndServe(":"+s.config.Port, handler)
+	err := s.http.ListenAndServe()
+	s.stopOnce.Do(func() { close(s.stop) })
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Stop gracefully shuts down a started server and its background tasks
+func (s *Server) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.http.Shutdown(ctx)
+}
+
+// POAMStore returns the store backing the POA&M endpoints
+func (s *Server) POAMStore() fedramp.POAMStore {
+	return s.poamStore
+}
+
+// SCNStore returns the store backing the SCN endpoints
+func (s *Server) SCNStore() fedramp.SCNStore {
+	return s.scnStore
+}
+
+// CRSManager returns the manager evaluating CRS report metrics
+func (s *Server) CRSManager() *fedramp.CRSManager {
+	return s.crs
+}
+
+// ServeHTTP dispatches a request to the API routes without the server middleware
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
 }
 
 // Health check endpoint
@@ -129,49 +296,139 @@ func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 
 // KSI Endpoints
 
+// validateKSI replaces the evidence of the given KSIs and returns the
+// resulting KSI report of the service offering
 func (s *Server) validateKSI(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		CSOId    string                 `json:"csoId"`
-		Evidence map[string]interface{} `json:"evidence"`
+		CSOId    string                           `json:"csoId"`
+		Evidence map[string][]fedramp.KSIEvidence `json:"evidence"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-
-	// Perform KSI validation
-	validation := fedramp.NewKSIValidation(req.CSOId)
-	// TODO: Apply evidence to validation
-	
-	respondJSON(w, http.StatusOK, validation)
+	if req.CSOId == "" || len(req.Evidence) == 0 {
+		respondError(w, http.StatusBadRequest, "csoId and evidence are required")
+		return
+	}
+	for ksiID := range req.Evidence {
+		if _, ok := fedramp.KSIDefinitions[ksiID]; !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Unknown KSI %s", ksiID))
+			return
+		}
+	}
+	if s.ksiStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "KSI evidence store unavailable")
+		return
+	}
+	var cited []fedramp.KSIEvidence
+	for _, evidence := range req.Evidence {
+		cited = append(cited, evidence...)
+	}
+	if !s.verifyKSIEvidence(w, r, req.CSOId, cited) {
+		return
+	}
+	for ksiID, evidence := range req.Evidence {
+		if err := s.ksiStore.SaveKSIEvidence(req.CSOId, ksiID, evidence); err != nil {
+			log.Errorf("Failed to save KSI evidence for %s: %v", req.CSOId, err)
+			respondError(w, http.StatusInternalServerError, "Failed to save KSI evidence")
+			return
+		}
+	}
+	s.respondKSIReport(w, r, req.CSOId)
 }
 
 func (s *Server) getKSIReport(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	csoId := vars["csoId"]
-
-	report := fedramp.GenerateKSIReport(csoId, time.Now())
-	respondJSON(w, http.StatusOK, report)
+	s.respondKSIReport(w, r, mux.Vars(r)["csoId"])
 }
 
+// submitEvidence adds evidence to one KSI of a service offering
 func (s *Server) submitEvidence(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	csoId := vars["csoId"]
+	csoId := mux.Vars(r)["csoId"]
 
-	var evidence map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&evidence); err != nil {
+	var req struct {
+		KSIId    string                `json:"ksiId"`
+		Evidence []fedramp.KSIEvidence `json:"evidence"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid evidence format")
 		return
 	}
+	if _, ok := fedramp.KSIDefinitions[req.KSIId]; !ok || len(req.Evidence) == 0 {
+		respondError(w, http.StatusBadRequest, "A known ksiId and evidence are required")
+		return
+	}
+	if s.ksiStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "KSI evidence store unavailable")
+		return
+	}
+	if !s.verifyKSIEvidence(w, r, csoId, req.Evidence) {
+		return
+	}
+	stored, err := s.ksiStore.GetKSIEvidence(csoId)
+	if err != nil && err != fedramp.ErrKSIEvidenceNotFound {
+		log.Errorf("Failed to load KSI evidence for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load KSI evidence")
+		return
+	}
+	evidence := append(stored[req.KSIId], req.Evidence...)
+	if err := s.ksiStore.SaveKSIEvidence(csoId, req.KSIId, evidence); err != nil {
+		log.Errorf("Failed to save KSI evidence for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to save KSI evidence")
+		return
+	}
+	log.Infof("Evidence submitted for %s of CSO %s", req.KSIId, csoId)
+	s.respondKSIReport(w, r, csoId)
+}
 
-	// TODO: Store evidence in database
-	log.Infof("Evidence submitted for CSO %s", csoId)
+// verifyKSIEvidence checks the locker evidence cited by submitted KSI
+// evidence, responding with the failure when it cannot be verified
+func (s *Server) verifyKSIEvidence(w http.ResponseWriter, r *http.Request, csoId string, evidence []fedramp.KSIEvidence) bool {
+	var ids []string
+	for _, item := range evidence {
+		ids = append(ids, item.LockerID)
+	}
+	if _, err := fedramp.VerifyCitedEvidence(s.locker, evidenceActorFor(r), "KSI evidence of "+csoId, ids); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	return true
+}
 
-	respondJSON(w, http.StatusOK, map[string]string{
-		"status": "accepted",
-		"csoId":  csoId,
-	})
+// evidenceActorFor returns the custody actor of a request
+func evidenceActorFor(r *http.Request) string {
+	if entityID := principal(r); entityID != "" {
+		return entityID
+	}
+	return evidenceActor
+}
+
+// respondKSIReport validates the stored evidence of a service offering,
+// verifies the locker evidence it cites, updates its KSI gauges and responds
+// with the report. Service offerings without stored evidence are unknown.
+func (s *Server) respondKSIReport(w http.ResponseWriter, r *http.Request, csoId string) {
+	if s.ksiStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "KSI evidence store unavailable")
+		return
+	}
+	evidence, err := s.ksiStore.GetKSIEvidence(csoId)
+	if err == fedramp.ErrKSIEvidenceNotFound {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("No KSI evidence for %s", csoId))
+		return
+	}
+	if err != nil {
+		log.Errorf("Failed to load KSI evidence for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load KSI evidence")
+		return
+	}
+	report := fedramp.BuildKSIReport(csoId, evidence)
+	if err := report.VerifyEvidence(s.locker, evidenceActorFor(r)); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	metrics.RecordKSIReport(report)
+	respondJSON(w, http.StatusOK, report)
 }
 
 func (s *Server) continuousMonitoring(w http.ResponseWriter, r *http.Request) {
@@ -193,17 +450,43 @@ func (s *Server) continuousMonitoring(w http.ResponseWriter, r *http.Request) {
 // SCN Endpoints
 
 func (s *Server) createSCN(w http.ResponseWriter, r *http.Request) {
+	if s.scnStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "SCN store unavailable")
+		return
+	}
+
 	var scn fedramp.SignificantChangeNotification
 	if err := json.NewDecoder(r.Body).Decode(&scn); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid SCN data")
 		return
 	}
+	if scn.ServiceOfferingID == "" {
+		respondError(w, http.StatusBadRequest, "service_offering_id is required")
+		return
+	}
 
-	// Set metadata
-	scn.CreatedAt = time.Now()
-	scn.Status = "pending"
+	// Set metadata; new SCNs always start as drafts with no history
+	now := time.Now()
+	scn.ID = fedramp.NewSCNID(scn.ServiceOfferingID)
+	scn.CreatedAt = now
+	scn.UpdatedAt = now
+	scn.Status = fedramp.SCNStatusDraft
+	scn.History = nil
+	scn.DeadlineAlerts = nil
+	scn.Revision = 0
+	if scn.SCNType == "" {
+		if err := scn.ClassifySCNType(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
-	// TODO: Store in database
+	if err := s.scnStore.SaveSCN(&scn); err != nil {
+		log.Errorf("Failed to save SCN: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to save SCN")
+		return
+	}
+	s.recordSCNMetrics(scn.ServiceOfferingID)
 	respondJSON(w, http.StatusCreated, scn)
 }
 
@@ -211,9 +494,27 @@ func (s *Server) listSCNs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	csoId := vars["csoId"]
 
-	// TODO: Fetch from database
-	scns := []fedramp.SignificantChangeNotification{}
-	
+	if s.scnStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "SCN store unavailable")
+		return
+	}
+	scns, err := s.scnStore.GetSCNsByCSOID(csoId)
+	if err != nil {
+		log.Errorf("Failed to list SCNs: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list SCNs")
+		return
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		filtered := make([]*fedramp.SignificantChangeNotification, 0, len(scns))
+		for _, scn := range scns {
+			if string(scn.Status) == status {
+				filtered = append(filtered, scn)
+			}
+		}
+		scns = filtered
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"csoId": csoId,
 		"scns":  scns,
@@ -222,181 +523,461 @@ func (s *Server) listSCNs(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) getSCN(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	scnId := vars["scnId"]
-
-	// TODO: Fetch from database
-	respondJSON(w, http.StatusNotFound, map[string]string{
-		"error": fmt.Sprintf("SCN %s not found", scnId),
-	})
+	scn, ok := s.loadSCN(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, scn)
 }
 
+// approveSCN approves an SCN. An authenticated caller approves as itself; an
+// approvedBy naming someone else is rejected.
 func (s *Server) approveSCN(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	scnId := vars["scnId"]
-
 	var approval struct {
-		ApprovedBy string `json:"approvedBy"`
-		Comments   string `json:"comments"`
+		ApprovedBy    string `json:"approvedBy"`
+		ApproverTitle string `json:"approverTitle"`
+		Comments      string `json:"comments"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&approval); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid approval data")
 		return
 	}
+	if entityID := principal(r); entityID != "" {
+		if approval.ApprovedBy != "" && approval.ApprovedBy != entityID {
+			respondError(w, http.StatusForbidden, "approvedBy must be the authenticated caller")
+			return
+		}
+		approval.ApprovedBy = entityID
+	}
+
+	scn, ok := s.loadSCN(w, r)
+	if !ok {
+		return
+	}
+	if err := scn.Approve(approval.ApprovedBy, approval.ApproverTitle, approval.Comments); err != nil {
+		respondError(w, scnTransitionStatus(err), err.Error())
+		return
+	}
+	s.saveSCN(w, scn)
+}
 
-	// TODO: Update SCN status in database
-	respondJSON(w, http.StatusOK, map[string]string{
-		"status": "approved",
-		"scnId":  scnId,
+func (s *Server) transitionSCN(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		To      string `json:"to"`
+		Actor   string `json:"actor"`
+		Comment string `json:"comment"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid transition data")
+		return
+	}
+	if entityID := principal(r); entityID != "" {
+		if req.Actor != "" && req.Actor != entityID {
+			respondError(w, http.StatusForbidden, "actor must be the authenticated caller")
+			return
+		}
+		req.Actor = entityID
+	}
+	to, err := fedramp.ParseSCNStatus(req.To)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scn, ok := s.loadSCN(w, r)
+	if !ok {
+		return
+	}
+	if err := scn.Transition(to, req.Actor, req.Comment); err != nil {
+		respondError(w, scnTransitionStatus(err), err.Error())
+		return
+	}
+	s.saveSCN(w, scn)
+}
+
+// scnTransitionStatus maps a transition error to a response status: the
+// lifecycle refusing the move is a conflict with the SCN state, anything else
+// is a problem with the request or the SCN content
+func scnTransitionStatus(err error) int {
+	if errors.Is(err, fedramp.ErrInvalidSCNTransition) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// loadSCN fetches the SCN addressed by the request, writing an error response
+// if it cannot be found or belongs to another CSO
+func (s *Server) loadSCN(w http.ResponseWriter, r *http.Request) (*fedramp.SignificantChangeNotification, bool) {
+	vars := mux.Vars(r)
+	csoId := vars["csoId"]
+	scnId := vars["scnId"]
+
+	if s.scnStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "SCN store unavailable")
+		return nil, false
+	}
+	scn, err := s.scnStore.GetSCN(scnId)
+	if err == fedramp.ErrSCNNotFound || (err == nil && scn.ServiceOfferingID != csoId) {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("SCN %s not found", scnId))
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("Failed to load SCN %s: %v", scnId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load SCN")
+		return nil, false
+	}
+	return scn, true
+}
+
+func (s *Server) saveSCN(w http.ResponseWriter, scn *fedramp.SignificantChangeNotification) {
+	if err := s.scnStore.SaveSCN(scn); err != nil {
+		if errors.Is(err, fedramp.ErrSCNConflict) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		log.Errorf("Failed to save SCN %s: %v", scn.ID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to save SCN")
+		return
+	}
+	s.recordSCNMetrics(scn.ServiceOfferingID)
+	respondJSON(w, http.StatusOK, scn)
+}
+
+// recordSCNMetrics recounts the SCNs of a service offering by state
+func (s *Server) recordSCNMetrics(csoId string) {
+	scns, err := s.scnStore.GetSCNsByCSOID(csoId)
+	if err != nil {
+		log.Errorf("Failed to count SCNs of %s: %v", csoId, err)
+		return
+	}
+	metrics.RecordSCNs(csoId, scns)
+}
+
+// POA&M Endpoints
+
+func (s *Server) putPOAM(w http.ResponseWriter, r *http.Request) {
+	csoId := mux.Vars(r)["csoId"]
+
+	var poam fedramp.PlanOfActionMilestones
+	if err := json.NewDecoder(r.Body).Decode(&poam); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid POA&M data")
+		return
+	}
+	if s.poamStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "POA&M store unavailable")
+		return
+	}
+	poam.ServiceOfferingID = csoId
+	poam.LastUpdated = time.Now()
+	if err := s.poamStore.SavePOAM(&poam); err != nil {
+		log.Errorf("Failed to save POA&M for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to save POA&M")
+		return
+	}
+	metrics.RecordPOAM(csoId, &poam)
+	respondJSON(w, http.StatusOK, poam)
+}
+
+func (s *Server) getPOAM(w http.ResponseWriter, r *http.Request) {
+	csoId := mux.Vars(r)["csoId"]
+
+	if s.poamStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "POA&M store unavailable")
+		return
+	}
+	poam, err := s.poamStore.GetPOAM(csoId)
+	if err == fedramp.ErrPOAMNotFound {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("No POA&M for %s", csoId))
+		return
+	}
+	if err != nil {
+		log.Errorf("Failed to load POA&M for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load POA&M")
+		return
+	}
+	respondJSON(w, http.StatusOK, poam)
+}
+
+// forecastPOAM lists the open items that will go late within the number of
+// days given by the days query parameter (30 by default)
+func (s *Server) forecastPOAM(w http.ResponseWriter, r *http.Request) {
+	csoId := mux.Vars(r)["csoId"]
+
+	days := 30
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			respondError(w, http.StatusBadRequest, "days must be a non-negative integer")
+			return
+		}
+		days = parsed
+	}
+	if s.poamStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "POA&M store unavailable")
+		return
+	}
+	poam, err := s.poamStore.GetPOAM(csoId)
+	if err == fedramp.ErrPOAMNotFound {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("No POA&M for %s", csoId))
+		return
+	}
+	if err != nil {
+		log.Errorf("Failed to load POA&M for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load POA&M")
+		return
+	}
+	items := poam.ForecastOverdueItems(days)
+	if items == nil {
+		items = make([]fedramp.POAMItem, 0)
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"cso_id": csoId,
+		"days":   days,
+		"items":  items,
 	})
 }
 
+// recordPOAMMetrics sets the POA&M gauges of every stored POA&M
+func (s *Server) recordPOAMMetrics() {
+	if s.poamStore == nil {
+		return
+	}
+	poams, err := s.poamStore.ListPOAMs()
+	if err != nil {
+		log.Errorf("Failed to load POA&Ms for metrics: %v", err)
+		return
+	}
+	for _, poam := range poams {
+		metrics.RecordPOAM(poam.ServiceOfferingID, poam)
+	}
+}
+
+// recordKSIMetrics sets the KSI gauges of every service offering with stored
+// evidence
+func (s *Server) recordKSIMetrics() {
+	if s.ksiStore == nil {
+		return
+	}
+	csoIDs, err := s.ksiStore.ListKSIServiceOfferings()
+	if err != nil {
+		log.Errorf("Failed to list KSI evidence for metrics: %v", err)
+		return
+	}
+	for _, csoId := range csoIDs {
+		evidence, err := s.ksiStore.GetKSIEvidence(csoId)
+		if err != nil {
+			log.Errorf("Failed to load KSI evidence of %s for metrics: %v", csoId, err)
+			continue
+		}
+		metrics.RecordKSIReport(fedramp.BuildKSIReport(csoId, evidence))
+	}
+}
+
+// seedMetrics sets the compliance gauges from the data already stored so they
+// are current from the first scrape
+func (s *Server) seedMetrics() {
+	s.recordPOAMMetrics()
+	s.recordKSIMetrics()
+	if s.scnStore == nil {
+		return
+	}
+	scns, err := s.scnStore.GetSCNsByCSOID("")
+	if err != nil {
+		log.Errorf("Failed to load SCNs for metrics: %v", err)
+		return
+	}
+	byCSO := make(map[string][]*fedramp.SignificantChangeNotification)
+	for _, scn := range scns {
+		byCSO[scn.ServiceOfferingID] = append(byCSO[scn.ServiceOfferingID], scn)
+	}
+	for csoId, scns := range byCSO {
+		metrics.RecordSCNs(csoId, scns)
+	}
+}
+
 // CRS Endpoints
 
 func (s *Server) createCRSReport(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
+	var req struct {
+		CSOId           string `json:"cso_id"`
+		ReportingPeriod string `json:"reporting_period"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request data")
 		return
 	}
-
-	// Extract CSO ID from request
-	csoId, _ := req["cso_id"].(string)
-	reportingPeriod, _ := req["reporting_period"].(string)
-
-	// Create metrics with proper structure
-	metrics := fedramp.KeySecurityMetrics{
-		VulnerabilityScanning: fedramp.VulnerabilityMetric{
-			ScansCompleted:  12,
-			CriticalFindings: 0,
-			HighFindings:    2,
-			MediumFindings:  5,
-			LowFindings:     10,
-			LastScanDate:    time.Now(),
-			RemediationRate: 95.5,
-		},
-		SecurityIncidents: fedramp.IncidentMetric{
-			TotalIncidents:        3,
-			OpenIncidents:         0,
-			ClosedIncidents:       3,
-			AverageResolutionTime: 4.2,
-			IncidentsByCategory:   map[string]int{"phishing": 2, "malware": 1},
-		},
-		ConfigurationManagement: fedramp.ConfigurationMetric{
-			BaselineCompliance:  98.5,
-			UnauthorizedChanges: 0,
-			ConfigurationDrift:  2,
-			LastBaselineReview:  time.Now(),
-		},
-		AccessControl: fedramp.AccessControlMetric{
-			ActiveUsers:         150,
-			PrivilegedUsers:     12,
-			FailedLoginAttempts: 8,
-			AccountLockouts:     1,
-			MFAAdoptionRate:     100.0,
-		},
-		SystemAvailability: fedramp.AvailabilityMetric{
-			UptimePercentage:  99.95,
-			PlannedDowntime:   2.0,
-			UnplannedDowntime: 0.5,
-			MTTRHours:         0.25,
-		},
-		PatchManagement: fedramp.PatchMetric{
-			PatchesAvailable:    15,
-			PatchesApplied:      15,
-			CriticalPatches:     2,
-			PatchComplianceRate: 100.0,
-			AveragePatchAge:     3.5,
-		},
+	if req.CSOId == "" {
+		respondError(w, http.StatusBadRequest, "cso_id is required")
+		return
+	}
+	period, err := fedramp.ParseReportingPeriod(req.ReportingPeriod)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	// Create CRS report
-	crs := &fedramp.ContinuousReportingStandard{
-		CSOId:           csoId,
-		ReportingPeriod: reportingPeriod,
-		GeneratedAt:     time.Now(),
-		Metrics:         metrics,
-		ComplianceScore: 98.5,
-		Status:          "compliant",
+	records, ok := s.getCRSRecords(w, req.CSOId)
+	if !ok {
+		return
+	}
+	crs := records.BuildContinuousReportingStandard(req.CSOId, req.ReportingPeriod, period)
+
+	// Evaluate the indicators against the threshold policy
+	report := s.crs.CreateReport(req.CSOId, period)
+	for _, indicator := range crs.Metrics.Indicators() {
+		if err := s.crs.AddMetric(report.ReportID, indicator); err != nil {
+			log.Errorf("Failed to add CRS metric %s: %v", indicator.MetricID, err)
+			respondError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
 	}
+	crs.Indicators = report.Metrics
+	crs.Summary = &report.Summary
 
 	// TODO: Store report
 	respondJSON(w, http.StatusCreated, crs)
 }
 
+func (s *Server) ingestCRSRecords(w http.ResponseWriter, r *http.Request) {
+	csoId := mux.Vars(r)["csoId"]
+
+	var records fedramp.CRSRecords
+	if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid CRS records")
+		return
+	}
+	if s.crsStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "CRS record store unavailable")
+		return
+	}
+	if err := s.crsStore.IngestCRSRecords(csoId, &records); err != nil {
+		log.Errorf("Failed to ingest CRS records for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to ingest CRS records")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"cso_id":           csoId,
+		"scans":            len(records.Scans),
+		"incidents":        len(records.Incidents),
+		"baseline_checks":  len(records.BaselineChecks),
+		"identity_exports": len(records.IdentityExports),
+		"uptime_samples":   len(records.UptimeSamples),
+		"patches":          len(records.Patches),
+	})
+}
+
+// getCRSRecords loads the CRS records of a service offering, responding with
+// an error when they are unavailable
+func (s *Server) getCRSRecords(w http.ResponseWriter, csoId string) (*fedramp.CRSRecords, bool) {
+	if s.crsStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "CRS record store unavailable")
+		return nil, false
+	}
+	records, err := s.crsStore.GetCRSRecords(csoId)
+	if err != nil {
+		log.Errorf("Failed to load CRS records for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load CRS records")
+		return nil, false
+	}
+	return records, true
+}
+
+// getMetrics computes the Key Security Metrics over [from, to) and the trend
+// of every recorded metric sample, downsampled to step. The period defaults to
+// the last 30 days and samples are returned as recorded without a step.
 func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	csoId := vars["csoId"]
+	query := r.URL.Query()
 
-	// TODO: Fetch from metrics database
-	metrics := fedramp.KeySecurityMetrics{
-		VulnerabilityScanning: fedramp.VulnerabilityMetric{
-			ScansCompleted:  52,
-			CriticalFindings: 0,
-			HighFindings:    1,
-			MediumFindings:  8,
-			LowFindings:     23,
-			LastScanDate:    time.Now(),
-			RemediationRate: 98.5,
-		},
-		SecurityIncidents: fedramp.IncidentMetric{
-			TotalIncidents:        12,
-			OpenIncidents:         0,
-			ClosedIncidents:       12,
-			AverageResolutionTime: 3.5,
-			IncidentsByCategory:   map[string]int{"phishing": 8, "malware": 2, "other": 2},
-		},
-		ConfigurationManagement: fedramp.ConfigurationMetric{
-			BaselineCompliance:  99.2,
-			UnauthorizedChanges: 0,
-			ConfigurationDrift:  1,
-			LastBaselineReview:  time.Now().AddDate(0, 0, -7),
-		},
-		AccessControl: fedramp.AccessControlMetric{
-			ActiveUsers:         250,
-			PrivilegedUsers:     15,
-			FailedLoginAttempts: 12,
-			AccountLockouts:     2,
-			MFAAdoptionRate:     95.8,
-		},
-		SystemAvailability: fedramp.AvailabilityMetric{
-			UptimePercentage:  99.99,
-			PlannedDowntime:   4.0,
-			UnplannedDowntime: 0.1,
-			MTTRHours:         0.15,
-		},
-		PatchManagement: fedramp.PatchMetric{
-			PatchesAvailable:    8,
-			PatchesApplied:      8,
-			CriticalPatches:     0,
-			PatchComplianceRate: 100.0,
-			AveragePatchAge:     2.1,
-		},
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := parseTimeParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid to: "+err.Error())
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -30)
+	if value := query.Get("from"); value != "" {
+		parsed, err := parseTimeParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid from: "+err.Error())
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		respondError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	var step time.Duration
+	if value := query.Get("step"); value != "" {
+		parsed, err := fedramp.ParseMetricWindow(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid step: "+err.Error())
+			return
+		}
+		step = parsed
+	}
+	window := 7
+	if value := query.Get("window"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid window")
+			return
+		}
+		window = parsed
+	}
+
+	records, ok := s.getCRSRecords(w, csoId)
+	if !ok {
+		return
+	}
+	metrics := records.KeySecurityMetrics(fedramp.ReportingPeriod{
+		StartDate: from,
+		EndDate:   to,
+		Type:      "custom",
+	})
+
+	trends, err := s.crs.AnalyzeMetricSeries(csoId, query.Get("metric"), from, to, step, window)
+	if err != nil {
+		log.Errorf("Failed to analyze metric series for %s: %v", csoId, err)
+		respondError(w, http.StatusInternalServerError, "Failed to load metric series")
+		return
 	}
 
 	// Wrap in response with CSO ID
 	response := map[string]interface{}{
 		"cso_id":  csoId,
+		"from":    from,
+		"to":      to,
+		"step":    query.Get("step"),
 		"metrics": metrics,
+		"series":  trends,
 		"timestamp": time.Now(),
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
+// parseTimeParam parses an RFC 3339 timestamp or a date
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
 func (s *Server) getDashboard(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	csoId := vars["csoId"]
 
-	// TODO: Aggregate dashboard data
-	dashboard := map[string]interface{}{
-		"csoId":           csoId,
-		"complianceScore": 98.5,
-		"activeAlerts":    0,
-		"lastAssessment":  time.Now().AddDate(0, -1, 0),
-		"nextAssessment":  time.Now().AddDate(0, 11, 0),
-	}
+	dashboard := s.crs.GenerateDashboardData(csoId)
 
 	respondJSON(w, http.StatusOK, dashboard)
 }
@@ -423,15 +1004,32 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func authMiddleware(next http.Handler) http.Handler {
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrumentMiddleware records the latency of every routed request by route
+// template
+func instrumentMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// TODO: Implement proper authentication
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			respondError(w, http.StatusUnauthorized, "Missing authorization token")
-			return
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
 		}
-		next.ServeHTTP(w, r)
+		metrics.ObserveRequest(r.Method, route, recorder.status, time.Since(start))
 	})
 }
 
@@ -483,49 +1081,405 @@ func (s *Server) submitFindings(w http.ResponseWriter, r *http.Request) {
 }
 
 // SSAD Endpoints
+//
+// Callers are the entity authenticated by authMiddleware, so the SSAD
+// endpoints are only available with authentication enabled. Every read is
+// checked against the owner and unexpired shares of the package and logged in
+// its access log.
+
+// EntityHeader carries the authenticated entity to handlers; authMiddleware
+// replaces any value sent by the client
+const EntityHeader = "X-Entity-ID"
+
+// errSSADForbidden is returned from store updates when the caller lacks access
+var errSSADForbidden = errors.New("access denied")
+
+// ssadCaller returns the authenticated entity, responding with an error when
+// the repository is unavailable or the caller is anonymous
+func (s *Server) ssadCaller(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.ssadStore == nil {
+		respondError(w, http.StatusServiceUnavailable, "SSAD repository unavailable")
+		return "", false
+	}
+	entityID := principal(r)
+	if entityID == "" {
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return "", false
+	}
+	return entityID, true
+}
+
+// respondSSADError maps store and access errors to responses
+func respondSSADError(w http.ResponseWriter, packageID string, err error, action string) {
+	switch {
+	case errors.Is(err, fedramp.ErrSSADPackageNotFound):
+		respondError(w, http.StatusNotFound, fmt.Sprintf("Package not found: %s", packageID))
+	case errors.Is(err, errSSADForbidden):
+		respondError(w, http.StatusForbidden, fmt.Sprintf("No access to package %s", packageID))
+	default:
+		log.Errorf("Failed to %s package %s: %v", action, packageID, err)
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to %s package", action))
+	}
+}
+
+// ssadView hides the shares and distributions of other entities from callers
+// without admin access
+func ssadView(pkg *fedramp.SSADPackage, entityID string) *fedramp.SSADPackage {
+	if pkg.CanAccess(entityID, fedramp.SSADAccessAdmin, time.Now()) {
+		return pkg
+	}
+	view := *pkg
+	view.AccessControl.SharedWith = nil
+	for _, sharee := range pkg.AccessControl.SharedWith {
+		if sharee.EntityID == entityID {
+			view.AccessControl.SharedWith = append(view.AccessControl.SharedWith, sharee)
+		}
+	}
+	view.DistributionLog = nil
+	for _, dist := range pkg.DistributionLog {
+		if dist.RecipientID == entityID {
+			view.DistributionLog = append(view.DistributionLog, dist)
+		}
+	}
+	return &view
+}
+
+// recordSSADAccess checks read access, records the distribution and logs the
+// access. Repeated reads for the same purpose share one pending distribution;
+// every read is kept in the access log.
+func (s *Server) recordSSADAccess(packageID, entityID, purpose, documentID string) (*fedramp.SSADPackage, fedramp.SSADDistribution, error) {
+	var dist fedramp.SSADDistribution
+	pkg, err := s.ssadStore.UpdatePackage(packageID, func(pkg *fedramp.SSADPackage) error {
+		if !pkg.CanAccess(entityID, fedramp.SSADAccessRead, time.Now()) {
+			return errSSADForbidden
+		}
+		name := entityID
+		for _, sharee := range pkg.AccessControl.SharedWith {
+			if sharee.EntityID == entityID && sharee.Name != "" {
+				name = sharee.Name
+			}
+		}
+		dist = pkg.RecordAccess(entityID, name, purpose, "api")
+		return nil
+	})
+	if err != nil {
+		return nil, dist, err
+	}
+	err = s.ssadStore.LogAccess(packageID, fedramp.SSADAccessRecord{
+		Time:           time.Now(),
+		EntityID:       entityID,
+		Purpose:        purpose,
+		Method:         "api",
+		DocumentID:     documentID,
+		DistributionID: dist.DistributionID,
+	})
+	return pkg, dist, err
+}
 
 func (s *Server) createPackage(w http.ResponseWriter, r *http.Request) {
-	var pkg map[string]interface{}
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	var pkg fedramp.SSADPackage
 	if err := json.NewDecoder(r.Body).Decode(&pkg); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid package data")
 		return
 	}
+	// A new version may be added by anyone with write access to the version
+	// it supersedes and keeps that version's owner
+	owner := entityID
+	if pkg.Parent != nil {
+		parent, err := s.ssadStore.GetPackage(pkg.Parent.PackageID)
+		if err == nil && !parent.CanAccess(entityID, fedramp.SSADAccessWrite, time.Now()) {
+			err = errSSADForbidden
+		}
+		if err != nil {
+			respondSSADError(w, pkg.Parent.PackageID, err, "version")
+			return
+		}
+		owner = parent.AccessControl.Owner
+	}
+	if pkg.AccessControl.Owner == "" {
+		pkg.AccessControl.Owner = owner
+	}
+	if pkg.AccessControl.Owner != owner {
+		respondError(w, http.StatusForbidden, "Packages can only be added by their owner, and new versions keep the owner of the version they supersede")
+		return
+	}
 
-	pkg["id"] = fmt.Sprintf("PKG-%d", time.Now().Unix())
-	pkg["createdAt"] = time.Now()
-	pkg["status"] = "active"
-
-	respondJSON(w, http.StatusCreated, pkg)
+	err := s.ssadStore.CreatePackage(&pkg)
+	switch {
+	case errors.Is(err, fedramp.ErrSSADPackageExists):
+		respondError(w, http.StatusConflict, fmt.Sprintf("Package already exists: %s", pkg.PackageID))
+		return
+	case err != nil:
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, &pkg)
 }
 
 func (s *Server) getPackage(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	packageId := mux.Vars(r)["packageId"]
+
+	purpose := r.URL.Query().Get("purpose")
+	if purpose == "" {
+		purpose = "Authorization review"
+	}
+	pkg, dist, err := s.recordSSADAccess(packageId, entityID, purpose, "")
+	if err != nil {
+		respondSSADError(w, packageId, err, "read")
+		return
+	}
+	w.Header().Set("X-Distribution-ID", dist.DistributionID)
+	respondJSON(w, http.StatusOK, ssadView(pkg, entityID))
+}
+
+func (s *Server) getPackageDocument(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
 	vars := mux.Vars(r)
-	packageId := vars["packageId"]
+	packageId, documentId := vars["packageId"], vars["documentId"]
 
-	pkg := map[string]interface{}{
-		"id":     packageId,
-		"status": "active",
-		"type":   "ssp",
-		"csoId":  "CSO-001",
+	pkg, err := s.ssadStore.GetPackage(packageId)
+	if err == nil && !pkg.CanAccess(entityID, fedramp.SSADAccessRead, time.Now()) {
+		err = errSSADForbidden
+	}
+	if err != nil {
+		respondSSADError(w, packageId, err, "read")
+		return
+	}
+	doc, _, found := pkg.FindDocument(documentId)
+	if !found {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("Document not found: %s", documentId))
+		return
+	}
+	content, err := s.ssadStore.OpenDocument(packageId, doc)
+	if err != nil {
+		log.Errorf("Failed to open document %s of %s: %v", documentId, packageId, err)
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
+	defer content.Close()
 
-	respondJSON(w, http.StatusOK, pkg)
+	_, dist, err := s.recordSSADAccess(packageId, entityID, "Document download: "+documentId, documentId)
+	if err != nil {
+		respondSSADError(w, packageId, err, "read")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(doc.Location)))
+	w.Header().Set("X-Distribution-ID", dist.DistributionID)
+	w.Header().Set("X-Content-SHA256", doc.Hash)
+	io.Copy(w, content)
+}
+
+func (s *Server) sharePackage(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	packageId := mux.Vars(r)["packageId"]
+
+	var sharee fedramp.SSADSharee
+	if err := json.NewDecoder(r.Body).Decode(&sharee); err != nil || sharee.EntityID == "" {
+		respondError(w, http.StatusBadRequest, "Invalid sharee: entity_id is required")
+		return
+	}
+	if sharee.AccessLevel == "" {
+		sharee.AccessLevel = fedramp.SSADAccessRead
+	}
+	switch sharee.AccessLevel {
+	case fedramp.SSADAccessRead, fedramp.SSADAccessWrite, fedramp.SSADAccessAdmin:
+	default:
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid access level: %s", sharee.AccessLevel))
+		return
+	}
+	if sharee.PublicKey != "" {
+		if _, err := fedramp.ParseSSADRecipientKey([]byte(sharee.PublicKey)); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid public key: %v", err))
+			return
+		}
+	}
+
+	_, err := s.ssadStore.UpdatePackage(packageId, func(pkg *fedramp.SSADPackage) error {
+		if !pkg.CanAccess(entityID, fedramp.SSADAccessAdmin, time.Now()) {
+			return errSSADForbidden
+		}
+		pkg.ShareWith(sharee)
+		return nil
+	})
+	if err != nil {
+		respondSSADError(w, packageId, err, "share")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "shared",
+		"sharee":  sharee.EntityID,
+		"access":  sharee.AccessLevel,
+		"expires": sharee.ExpirationDate,
+	})
+}
+
+func (s *Server) revokePackageShare(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	packageId, shareeId := vars["packageId"], vars["entityId"]
+
+	revoked := false
+	_, err := s.ssadStore.UpdatePackage(packageId, func(pkg *fedramp.SSADPackage) error {
+		if !pkg.CanAccess(entityID, fedramp.SSADAccessAdmin, time.Now()) {
+			return errSSADForbidden
+		}
+		revoked = pkg.RevokeSharee(shareeId)
+		return nil
+	})
+	if err != nil {
+		respondSSADError(w, packageId, err, "revoke access to")
+		return
+	}
+	if !revoked {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("Package is not shared with %s", shareeId))
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked", "sharee": shareeId})
+}
+
+func (s *Server) listDistributions(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	packageId := mux.Vars(r)["packageId"]
+
+	pkg, err := s.ssadStore.GetPackage(packageId)
+	if err == nil && pkg.AccessLevelFor(entityID, time.Now()) == "" {
+		err = errSSADForbidden
+	}
+	if err != nil {
+		respondSSADError(w, packageId, err, "read")
+		return
+	}
+	view := ssadView(pkg, entityID)
+	pending := 0
+	for _, dist := range view.DistributionLog {
+		if !dist.Acknowledgment {
+			pending++
+		}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"package_id":     packageId,
+		"distributions":  view.DistributionLog,
+		"total":          len(view.DistributionLog),
+		"unacknowledged": pending,
+	})
+}
+
+func (s *Server) getAccessLog(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	packageId := mux.Vars(r)["packageId"]
+
+	pkg, err := s.ssadStore.GetPackage(packageId)
+	if err == nil && !pkg.CanAccess(entityID, fedramp.SSADAccessAdmin, time.Now()) {
+		err = errSSADForbidden
+	}
+	if err != nil {
+		respondSSADError(w, packageId, err, "read")
+		return
+	}
+	accesses, err := s.ssadStore.AccessLog(packageId)
+	if err != nil {
+		respondSSADError(w, packageId, err, "read the access log of")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"package_id": packageId,
+		"accesses":   accesses,
+		"total":      len(accesses),
+	})
+}
+
+func (s *Server) acknowledgeDistribution(w http.ResponseWriter, r *http.Request) {
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	packageId, distributionId := vars["packageId"], vars["distributionId"]
+
+	var ack *fedramp.SSADDistribution
+	_, err := s.ssadStore.UpdatePackage(packageId, func(pkg *fedramp.SSADPackage) error {
+		dist, err := pkg.AcknowledgeDistribution(distributionId, entityID)
+		if err != nil {
+			return err
+		}
+		copied := *dist
+		ack = &copied
+		return nil
+	})
+	switch {
+	case errors.Is(err, fedramp.ErrSSADDistributionNotFound):
+		respondError(w, http.StatusNotFound, fmt.Sprintf("Distribution not found: %s", distributionId))
+	case errors.Is(err, fedramp.ErrSSADNotRecipient):
+		respondError(w, http.StatusForbidden, "Only the recipient can acknowledge a distribution")
+	case err != nil:
+		respondSSADError(w, packageId, err, "acknowledge")
+	default:
+		respondJSON(w, http.StatusOK, ack)
+	}
 }
 
 func (s *Server) listPackages(w http.ResponseWriter, r *http.Request) {
-	packages := []map[string]interface{}{
-		{
-			"id":     "PKG-001",
-			"name":   "SSP Package",
-			"status": "active",
-			"csoId":  "CSO-001",
-		},
-		{
-			"id":     "PKG-002",
-			"name":   "SAR Package",
-			"status": "active",
-			"csoId":  "CSO-002",
-		},
+	entityID, ok := s.ssadCaller(w, r)
+	if !ok {
+		return
+	}
+	query, err := fedramp.ParseSSADQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query: %v", err))
+		return
+	}
+	all, err := s.ssadStore.ListPackages()
+	if err != nil {
+		log.Errorf("Failed to list SSAD packages: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list packages")
+		return
+	}
+
+	// Only packages the caller may read are listed
+	now := time.Now()
+	packages := make([]map[string]interface{}, 0)
+	for _, pkg := range all {
+		level := pkg.AccessLevelFor(entityID, now)
+		if level == "" || !query.Match(pkg) {
+			continue
+		}
+		packages = append(packages, map[string]interface{}{
+			"package_id":          pkg.PackageID,
+			"service_offering_id": pkg.ServiceOfferingID,
+			"title":               pkg.Metadata.Title,
+			"csp":                 pkg.Metadata.CSPName,
+			"impact_level":        pkg.Metadata.ImpactLevel,
+			"authorization_type":  pkg.Metadata.AuthorizationType,
+			"status":              pkg.Status,
+			"version":             pkg.Version,
+			"tags":                pkg.Metadata.Tags,
+			"updated_at":          pkg.UpdatedAt,
+			"access_level":        level,
+		})
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -572,24 +1526,5 @@ func (s *Server) transformDocument(w http.ResponseWriter, r *http.Request) {
 // Metrics Endpoint
 
 func (s *Server) getPrometheusMetrics(w http.ResponseWriter, r *http.Request) {
-	// Simple Prometheus metrics format
-	metrics := `# HELP fedramp_compliance_score Current compliance score
-# TYPE fedramp_compliance_score gauge
-fedramp_compliance_score 98.5
-
-# HELP fedramp_active_csos Number of active CSOs
-# TYPE fedramp_active_csos gauge
-fedramp_active_csos 12
-
-# HELP fedramp_open_alerts Number of open alerts
-# TYPE fedramp_open_alerts gauge
-fedramp_open_alerts 2
-
-# HELP fedramp_ksi_compliant Number of compliant KSIs
-# TYPE fedramp_ksi_compliant gauge
-fedramp_ksi_compliant 11
-`
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(metrics))
+	metrics.Default.ServeHTTP(w, r)
 } 
\ No newline at end of file
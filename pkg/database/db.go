@@ -2,10 +2,12 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -75,6 +77,13 @@ func (db *DB) initSchema() error {
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 
+		// POA&M tables
+		`CREATE TABLE IF NOT EXISTS poam_documents (
+			cso_id VARCHAR(255) PRIMARY KEY,
+			document JSONB NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
 		// SCN tables
 		`CREATE TABLE IF NOT EXISTS scn_notifications (
 			id VARCHAR(255) PRIMARY KEY,
@@ -109,6 +118,14 @@ func (db *DB) initSchema() error {
 			metadata JSONB
 		)`,
 
+		`CREATE TABLE IF NOT EXISTS crs_metric_samples (
+			cso_id VARCHAR(255) NOT NULL,
+			metric_id VARCHAR(255) NOT NULL,
+			sampled_at TIMESTAMP NOT NULL,
+			value DOUBLE PRECISION NOT NULL,
+			PRIMARY KEY (cso_id, metric_id, sampled_at)
+		)`,
+
 		// MAS tables
 		`CREATE TABLE IF NOT EXISTS mas_assessments (
 			id VARCHAR(255) PRIMARY KEY,
@@ -152,8 +169,10 @@ func (db *DB) initSchema() error {
 		// Create indexes
 		`CREATE INDEX IF NOT EXISTS idx_ksi_validations_cso_id ON ksi_validations(cso_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_ksi_evidence_cso_ksi ON ksi_evidence(cso_id, ksi_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_ksi_evidence_cso_ksi_key ON ksi_evidence(cso_id, ksi_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_scn_notifications_cso_id ON scn_notifications(cso_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_crs_reports_cso_id ON crs_reports(cso_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_crs_metric_samples_time ON crs_metric_samples(cso_id, sampled_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_mas_assessments_cso_id ON mas_assessments(cso_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_ssad_packages_cso_id ON ssad_packages(cso_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp)`,
@@ -205,39 +224,229 @@ func (db *DB) GetKSIValidation(csoID string) (interface{}, error) {
 	return nil, nil
 }
 
-// SaveKSIEvidence saves KSI evidence
-func (db *DB) SaveKSIEvidence(csoID, ksiID string, evidence interface{}) error {
+// SaveKSIEvidence replaces the evidence of one KSI of a CSO. The status
+// column records whether the evidence validates the KSI.
+func (db *DB) SaveKSIEvidence(csoID, ksiID string, evidence []fedramp.KSIEvidence) error {
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal KSI evidence: %w", err)
+	}
+	status := false
+	if validation := fedramp.ValidateKSI(ksiID, evidence, false); validation != nil {
+		status = validation.Status == fedramp.KSIStatusTrue
+	}
 	query := `
 		INSERT INTO ksi_evidence (cso_id, ksi_id, status, evidence_data, last_validated)
 		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (cso_id, ksi_id) DO UPDATE
 		SET status = $3, evidence_data = $4, last_validated = $5, updated_at = CURRENT_TIMESTAMP
 	`
-	// TODO: Implement proper JSON marshaling
-	_, err := db.conn.Exec(query, csoID, ksiID, true, "{}", time.Now())
+	_, err = db.conn.Exec(query, csoID, ksiID, status, data, time.Now())
+	return err
+}
+
+// GetKSIEvidence retrieves the evidence of every KSI of a CSO
+func (db *DB) GetKSIEvidence(csoID string) (map[string][]fedramp.KSIEvidence, error) {
+	rows, err := db.conn.Query(`SELECT ksi_id, evidence_data FROM ksi_evidence WHERE cso_id = $1`, csoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	evidence := make(map[string][]fedramp.KSIEvidence)
+	for rows.Next() {
+		var ksiID string
+		var data []byte
+		if err := rows.Scan(&ksiID, &data); err != nil {
+			return nil, err
+		}
+		var items []fedramp.KSIEvidence
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse stored evidence of %s: %w", ksiID, err)
+		}
+		evidence[ksiID] = items
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(evidence) == 0 {
+		return nil, fedramp.ErrKSIEvidenceNotFound
+	}
+	return evidence, nil
+}
+
+// ListKSIServiceOfferings retrieves the CSOs with stored KSI evidence
+func (db *DB) ListKSIServiceOfferings() ([]string, error) {
+	rows, err := db.conn.Query(`SELECT DISTINCT cso_id FROM ksi_evidence ORDER BY cso_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	csoIDs := make([]string, 0)
+	for rows.Next() {
+		var csoID string
+		if err := rows.Scan(&csoID); err != nil {
+			return nil, err
+		}
+		csoIDs = append(csoIDs, csoID)
+	}
+	return csoIDs, rows.Err()
+}
+
+// POA&M Operations
+
+// SavePOAM replaces the POA&M of its CSO
+func (db *DB) SavePOAM(poam *fedramp.PlanOfActionMilestones) error {
+	data, err := json.Marshal(poam)
+	if err != nil {
+		return fmt.Errorf("failed to marshal POA&M: %w", err)
+	}
+	query := `
+		INSERT INTO poam_documents (cso_id, document, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (cso_id) DO UPDATE SET document = $2, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err = db.conn.Exec(query, poam.ServiceOfferingID, data)
 	return err
 }
 
+// GetPOAM retrieves the POA&M of a CSO
+func (db *DB) GetPOAM(csoID string) (*fedramp.PlanOfActionMilestones, error) {
+	var data []byte
+	err := db.conn.QueryRow(`SELECT document FROM poam_documents WHERE cso_id = $1`, csoID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fedramp.ErrPOAMNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPOAM(data)
+}
+
+// ListPOAMs retrieves the POA&M of every CSO
+func (db *DB) ListPOAMs() ([]*fedramp.PlanOfActionMilestones, error) {
+	rows, err := db.conn.Query(`SELECT document FROM poam_documents ORDER BY cso_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	poams := make([]*fedramp.PlanOfActionMilestones, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		poam, err := unmarshalPOAM(data)
+		if err != nil {
+			return nil, err
+		}
+		poams = append(poams, poam)
+	}
+	return poams, rows.Err()
+}
+
+func unmarshalPOAM(data []byte) (*fedramp.PlanOfActionMilestones, error) {
+	var poam fedramp.PlanOfActionMilestones
+	if err := json.Unmarshal(data, &poam); err != nil {
+		return nil, fmt.Errorf("failed to parse stored POA&M: %w", err)
+	}
+	return &poam, nil
+}
+
 // SCN Operations
 
-// SaveSCN saves a significant change notification
-func (db *DB) SaveSCN(scn interface{}) error {
-	// TODO: Implement
+// SaveSCN saves a significant change notification. The full SCN, including
+// its lifecycle history, is kept in the metadata column. The update only
+// applies when the stored revision is the one the SCN was read at.
+func (db *DB) SaveSCN(scn *fedramp.SignificantChangeNotification) error {
+	if scn.ID == "" {
+		scn.ID = fedramp.NewSCNID(scn.ServiceOfferingID)
+	}
+	revision := scn.Revision
+	scn.Revision++
+	data, err := json.Marshal(scn)
+	if err != nil {
+		scn.Revision = revision
+		return fmt.Errorf("failed to marshal SCN: %w", err)
+	}
+
+	var approvedAt *time.Time
+	for _, transition := range scn.History {
+		if transition.To == fedramp.SCNStatusApproved {
+			at := transition.Timestamp
+			approvedAt = &at
+		}
+	}
+
+	query := `
+		INSERT INTO scn_notifications (id, cso_id, change_type, title, description, justification,
+			affected_controls, classification, status, approver_name, approver_title, created_at, approved_at, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO UPDATE
+		SET change_type = $3, title = $4, description = $5, justification = $6, affected_controls = $7,
+			classification = $8, status = $9, approver_name = $10, approver_title = $11,
+			approved_at = $13, metadata = $14
+		WHERE COALESCE((scn_notifications.metadata->>'revision')::int, 0) = $15
+	`
+	result, err := db.conn.Exec(query, scn.ID, scn.ServiceOfferingID, scn.ChangeType, scn.ShortDescription,
+		scn.ImpactAnalysis, scn.ReasonForChange, pq.Array(scn.ControlsAffected), string(scn.SCNType),
+		string(scn.Status), scn.ApproverName, scn.ApproverTitle, scn.CreatedAt, approvedAt, data, revision)
+	if err != nil {
+		scn.Revision = revision
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		scn.Revision = revision
+		return fmt.Errorf("%w: %s is not at revision %d", fedramp.ErrSCNConflict, scn.ID, revision)
+	}
 	return nil
 }
 
-// GetSCNsByCSOID retrieves all SCNs for a CSO
-func (db *DB) GetSCNsByCSOID(csoID string) ([]interface{}, error) {
-	query := `SELECT * FROM scn_notifications WHERE cso_id = $1 ORDER BY created_at DESC`
+// GetSCN retrieves a significant change notification by ID
+func (db *DB) GetSCN(id string) (*fedramp.SignificantChangeNotification, error) {
+	var data []byte
+	err := db.conn.QueryRow(`SELECT metadata FROM scn_notifications WHERE id = $1`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fedramp.ErrSCNNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSCN(data)
+}
+
+// GetSCNsByCSOID retrieves all SCNs for a CSO. An empty csoID returns all SCNs.
+func (db *DB) GetSCNsByCSOID(csoID string) ([]*fedramp.SignificantChangeNotification, error) {
+	query := `SELECT metadata FROM scn_notifications WHERE cso_id = $1 OR $1 = '' ORDER BY created_at DESC`
 	rows, err := db.conn.Query(query, csoID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var scns []interface{}
-	// TODO: Implement row scanning
-	return scns, nil
+	scns := make([]*fedramp.SignificantChangeNotification, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		scn, err := unmarshalSCN(data)
+		if err != nil {
+			return nil, err
+		}
+		scns = append(scns, scn)
+	}
+	return scns, rows.Err()
+}
+
+func unmarshalSCN(data []byte) (*fedramp.SignificantChangeNotification, error) {
+	var scn fedramp.SignificantChangeNotification
+	if err := json.Unmarshal(data, &scn); err != nil {
+		return nil, fmt.Errorf("failed to parse stored SCN: %w", err)
+	}
+	return &scn, nil
 }
 
 // CRS Operations
@@ -260,6 +469,49 @@ func (db *DB) GetLatestMetrics(csoID string) (interface{}, error) {
 	return nil, nil
 }
 
+// AppendMetricSamples stores metric samples. A sample for the same metric and
+// time replaces the earlier value.
+func (db *DB) AppendMetricSamples(samples []fedramp.MetricSample) error {
+	return db.Transaction(func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO crs_metric_samples (cso_id, metric_id, sampled_at, value)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (cso_id, metric_id, sampled_at) DO UPDATE SET value = $4
+		`
+		for _, sample := range samples {
+			if _, err := tx.Exec(query, sample.CSOID, sample.MetricID, sample.Timestamp, sample.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetMetricSamples retrieves the samples of a CSO in [from, to) ordered by
+// time. An empty metricID returns every metric.
+func (db *DB) GetMetricSamples(csoID, metricID string, from, to time.Time) ([]fedramp.MetricSample, error) {
+	query := `
+		SELECT cso_id, metric_id, sampled_at, value FROM crs_metric_samples
+		WHERE cso_id = $1 AND (metric_id = $2 OR $2 = '') AND sampled_at >= $3 AND sampled_at < $4
+		ORDER BY sampled_at
+	`
+	rows, err := db.conn.Query(query, csoID, metricID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([]fedramp.MetricSample, 0)
+	for rows.Next() {
+		var sample fedramp.MetricSample
+		if err := rows.Scan(&sample.CSOID, &sample.MetricID, &sample.Timestamp, &sample.Value); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
+
 // Audit Operations
 
 // LogAuditEvent logs an audit event
@@ -40,3 +40,43 @@ func ParagraphReplaceWithText(originalParagraph xml.Node, newText string) error
 	return nil
 
 }
+
+// StyledParagraph is the text of a paragraph and the Word style applied to
+// it. An empty style keeps the style of the original paragraph.
+type StyledParagraph struct {
+	Style string
+	Text  string
+}
+
+func paragraphSetStyle(pNode xml.Node, style string) error {
+	existingPPr, err := pNode.Search("./w:pPr")
+	if err != nil {
+		return err
+	}
+	for _, pPrNode := range existingPPr {
+		pPrNode.Remove()
+	}
+	return pNode.FirstChild().InsertBefore(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+}
+
+// ParagraphReplaceWithParagraphs replaces the paragraph with one copy per
+// styled paragraph, keeping them in place of the original
+func ParagraphReplaceWithParagraphs(originalParagraph xml.Node, paragraphs []StyledParagraph) error {
+	for _, paragraph := range paragraphs {
+		clone := originalParagraph.Duplicate(libxml2_copy_constant)
+		if err := paragraphSetText(clone, paragraph.Text); err != nil {
+			return err
+		}
+		if paragraph.Style != "" {
+			if err := paragraphSetStyle(clone, paragraph.Style); err != nil {
+				return err
+			}
+		}
+		if err := originalParagraph.AddPreviousSibling(clone); err != nil {
+			return err
+		}
+	}
+
+	originalParagraph.Remove()
+	return nil
+}
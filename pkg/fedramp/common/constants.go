@@ -1,5 +1,7 @@
 package common
 
+import "strings"
+
 type BaselineLevel int
 
 const (
@@ -27,3 +29,14 @@ func (l BaselineLevel) Name() string {
 	}
 	return "unknown"
 }
+
+// BaselineLevelFromName parses a baseline name such as "Moderate"
+func BaselineLevelFromName(name string) BaselineLevel {
+	var level BaselineLevel
+	for level = LevelLow; level <= LevelHigh; level++ {
+		if strings.EqualFold(strings.TrimSpace(name), level.Name()) {
+			return level
+		}
+	}
+	return LevelUnknown
+}
@@ -0,0 +1,323 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConMonInputs are the sources of a monthly Continuous Monitoring package
+type ConMonInputs struct {
+	ServiceID     string
+	Label         string // e.g. "2026-09", used in file names and titles
+	Period        ReportingPeriod
+	Scans         []VulnerabilityScan // normalized scan results
+	POAM          *PlanOfActionMilestones
+	InventoryPath string // optional inventory workbook or export
+	SCNs          []*SignificantChangeNotification
+	Author        string
+}
+
+// ConMonSCN summarizes an SCN open during the period
+type ConMonSCN struct {
+	ID          string    `json:"id"`
+	Type        SCNType   `json:"scn_type"`
+	Status      SCNStatus `json:"status"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ConMonSummary is the summary report of a monthly Continuous Monitoring package
+type ConMonSummary struct {
+	ServiceOfferingID  string              `json:"service_offering_id"`
+	Label              string              `json:"label"`
+	Period             ReportingPeriod     `json:"period"`
+	GeneratedAt        time.Time           `json:"generated_at"`
+	ScansCompleted     int                 `json:"scans_completed"`
+	OpenFindings       map[string]int      `json:"open_findings_by_severity"`
+	RemediatedFindings int                 `json:"remediated_findings"`
+	Reconciliation     *POAMReconciliation `json:"poam_reconciliation"`
+	POAM               POAMSummary         `json:"poam_summary"`
+	DeviationRequests  map[string]int      `json:"deviation_requests_by_status"`
+	InventoryIncluded  bool                `json:"inventory_included"`
+	OpenSCNs           []ConMonSCN         `json:"open_scns"`
+	Documents          []SSADDocument      `json:"documents"`
+}
+
+// BuildConMonPackage assembles the monthly Continuous Monitoring package of a
+// service offering in outputDir. The POA&M is reconciled with the scans of the
+// period and written as JSON and as a workbook CSV next to the scan results,
+// the deviation requests, the inventory and the SCNs open at the end of the
+// period. Every file is hashed into a ConMon document of the returned package,
+// whose integrity check covers all documents, and the summary report is
+// written in JSON and Markdown.
+func BuildConMonPackage(in ConMonInputs, outputDir string) (*SSADPackage, *ConMonSummary, error) {
+	if in.POAM == nil {
+		in.POAM = NewPOAM(in.ServiceID)
+	}
+	if in.Author == "" {
+		in.Author = in.ServiceID
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	reconciliation, err := in.POAM.ReconcileScanFindings(in.Scans, in.Period, in.Author)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary := &ConMonSummary{
+		ServiceOfferingID: in.ServiceID,
+		Label:             in.Label,
+		Period:            in.Period,
+		GeneratedAt:       time.Now(),
+		OpenFindings:      make(map[string]int),
+		Reconciliation:    reconciliation,
+		POAM:              in.POAM.Summary,
+		DeviationRequests: make(map[string]int),
+		OpenSCNs:          make([]ConMonSCN, 0),
+		Documents:         make([]SSADDocument, 0),
+	}
+
+	var scans []VulnerabilityScan
+	for _, scan := range in.Scans {
+		if in.Period.Contains(scan.CompletedAt) {
+			scans = append(scans, scan)
+		}
+	}
+	summary.ScansCompleted = len(scans)
+	for _, observed := range LatestScanFindings(in.Scans, in.Period) {
+		if in.Period.endsBy(observed.RemediatedAt) {
+			summary.RemediatedFindings++
+		} else {
+			summary.OpenFindings[NormalizeSeverity(observed.Severity)]++
+		}
+	}
+	for _, request := range in.POAM.DeviationRequests {
+		summary.DeviationRequests[string(request.Status)]++
+	}
+
+	pkg := NewSSADPackage(in.ServiceID, SSADMetadata{
+		Title:         fmt.Sprintf("Continuous Monitoring Package %s - %s", in.Label, in.ServiceID),
+		Description:   "Monthly continuous monitoring deliverables",
+		PackageFormat: "JSON",
+		Tags:          []string{"fedramp", "conmon", in.Label},
+		Keywords:      []string{in.ServiceID, "continuous monitoring"},
+	})
+	pkg.AccessControl.Owner = in.Author
+
+	prefix := fmt.Sprintf("conmon-%s-%s", in.ServiceID, in.Label)
+	add := func(docType, name, title string, data []byte) error {
+		path := filepath.Join(outputDir, name)
+		if data != nil {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+		}
+		doc, err := NewSSADFileDocument(path, docType, title, in.Author)
+		if err != nil {
+			return err
+		}
+		doc.Location = name
+		summary.Documents = append(summary.Documents, doc)
+		switch docType {
+		case "scn":
+			return pkg.AddDocument("scn", doc)
+		case "poam":
+			if err := pkg.AddDocument("poam", doc); err != nil {
+				return err
+			}
+		}
+		return pkg.AddDocument("conmon", doc)
+	}
+
+	scanData, err := json.MarshalIndent(scans, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := add("scan-results", prefix+"-scans.json", fmt.Sprintf("Vulnerability Scan Results %s", in.Label), scanData); err != nil {
+		return nil, nil, err
+	}
+
+	poamData, err := in.POAM.ToJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := add("poam", prefix+"-poam.json", fmt.Sprintf("Plan of Action and Milestones %s", in.Label), poamData); err != nil {
+		return nil, nil, err
+	}
+	workbook, err := in.POAM.ToWorkbookCSV()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := add("poam-workbook", prefix+"-poam.csv", fmt.Sprintf("POA&M Workbook %s", in.Label), workbook); err != nil {
+		return nil, nil, err
+	}
+
+	if len(in.POAM.DeviationRequests) > 0 {
+		deviations, err := json.MarshalIndent(in.POAM.DeviationRequests, "", "  ")
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add("deviation-requests", prefix+"-deviations.json", fmt.Sprintf("Deviation Requests %s", in.Label), deviations); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if in.InventoryPath != "" {
+		name := prefix + "-inventory" + filepath.Ext(in.InventoryPath)
+		if err := copyFile(in.InventoryPath, filepath.Join(outputDir, name)); err != nil {
+			return nil, nil, fmt.Errorf("failed to copy inventory: %w", err)
+		}
+		if err := add("inventory", name, fmt.Sprintf("Integrated Inventory %s", in.Label), nil); err != nil {
+			return nil, nil, err
+		}
+		summary.InventoryIncluded = true
+	}
+
+	for _, scn := range openSCNs(in.SCNs, in.Period) {
+		data, err := scn.ToJSON()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := add("scn", fmt.Sprintf("scn-%s.json", scn.ID), fmt.Sprintf("Significant Change Notification %s", scn.ID), data); err != nil {
+			return nil, nil, err
+		}
+		summary.OpenSCNs = append(summary.OpenSCNs, ConMonSCN{
+			ID:          scn.ID,
+			Type:        scn.SCNType,
+			Status:      scn.Status,
+			Description: scn.ShortDescription,
+			CreatedAt:   scn.CreatedAt,
+		})
+	}
+
+	// The summary reports are the last documents, so they list all the others
+	summaryData, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := add("conmon-summary", prefix+"-summary.json", fmt.Sprintf("Continuous Monitoring Summary %s", in.Label), summaryData); err != nil {
+		return nil, nil, err
+	}
+	if err := add("conmon-summary", prefix+"-summary.md", fmt.Sprintf("Continuous Monitoring Summary %s", in.Label), []byte(summary.Markdown())); err != nil {
+		return nil, nil, err
+	}
+
+	digest, err := pkg.Manifest().Digest()
+	if err != nil {
+		return nil, nil, err
+	}
+	pkg.IntegrityCheck = SSADIntegrity{
+		PackageHash:   digest,
+		HashAlgorithm: "SHA-256",
+	}
+	return pkg, summary, nil
+}
+
+// openSCNs returns the SCNs created before the end of the period that were
+// not closed or withdrawn, oldest first
+func openSCNs(scns []*SignificantChangeNotification, period ReportingPeriod) []*SignificantChangeNotification {
+	open := make([]*SignificantChangeNotification, 0)
+	for _, scn := range scns {
+		if scn.Status == SCNStatusClosed || scn.Status == SCNStatusWithdrawn || !scn.CreatedAt.Before(period.EndDate) {
+			continue
+		}
+		open = append(open, scn)
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].CreatedAt.Before(open[j].CreatedAt)
+	})
+	return open
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Markdown renders the summary report for the agency
+func (s *ConMonSummary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Continuous Monitoring Summary %s\n\n", s.Label)
+	fmt.Fprintf(&b, "Service Offering: %s  \nPeriod: %s to %s  \nGenerated: %s\n\n",
+		s.ServiceOfferingID, s.Period.StartDate.Format("2006-01-02"),
+		s.Period.EndDate.Add(-time.Second).Format("2006-01-02"), s.GeneratedAt.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "## Vulnerability Scanning\n\n")
+	fmt.Fprintf(&b, "Scans completed: %d  \nFindings remediated: %d\n\n", s.ScansCompleted, s.RemediatedFindings)
+	b.WriteString("| Severity | Open Findings |\n|---|---|\n")
+	for _, severity := range []string{"Critical", "High", "Moderate", "Low"} {
+		fmt.Fprintf(&b, "| %s | %d |\n", severity, s.OpenFindings[severity])
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "## POA&M\n\n")
+	fmt.Fprintf(&b, "Total items: %d  \nOpen items: %d  \nOverdue items: %d\n\n",
+		s.POAM.TotalItems, s.POAM.OpenItems, s.POAM.OverdueItems)
+	if r := s.Reconciliation; r != nil {
+		fmt.Fprintf(&b, "- Opened from scan findings: %s\n", itemList(r.Added))
+		fmt.Fprintf(&b, "- Closed as remediated: %s\n", itemList(r.Closed))
+		fmt.Fprintf(&b, "- Reopened: %s\n", itemList(r.Reopened))
+		fmt.Fprintf(&b, "- Open but not reported by this month's scans: %s\n\n", itemList(r.Unobserved))
+	}
+
+	if len(s.DeviationRequests) > 0 {
+		fmt.Fprintf(&b, "## Deviation Requests\n\n")
+		statuses := make([]string, 0, len(s.DeviationRequests))
+		for status := range s.DeviationRequests {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&b, "- %s: %d\n", status, s.DeviationRequests[status])
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## Open Significant Change Notifications\n\n")
+	if len(s.OpenSCNs) == 0 {
+		b.WriteString("None.\n\n")
+	}
+	for _, scn := range s.OpenSCNs {
+		fmt.Fprintf(&b, "- %s (%s, %s): %s\n", scn.ID, scn.Type, scn.Status, scn.Description)
+	}
+	if len(s.OpenSCNs) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "## Documents\n\n")
+	if !s.InventoryIncluded {
+		b.WriteString("No inventory was provided for this period.\n\n")
+	}
+	b.WriteString("| Document | File | SHA-256 |\n|---|---|---|\n")
+	for _, doc := range s.Documents {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", doc.Title, doc.Location, doc.Hash)
+	}
+	return b.String()
+}
+
+func itemList(ids []string) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	return strings.Join(ids, ", ")
+}
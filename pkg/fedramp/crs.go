@@ -3,6 +3,9 @@ package fedramp
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -41,6 +44,7 @@ type MetricEvidence struct {
 	Source       string    `json:"source"`
 	Timestamp    time.Time `json:"timestamp"`
 	Reference    string    `json:"reference,omitempty"`
+	LockerID     string    `json:"locker_id,omitempty"`
 }
 
 // ContinuousReport represents a continuous monitoring report
@@ -77,6 +81,7 @@ type SecurityIncident struct {
 	IncidentID      string    `json:"incident_id"`
 	Title           string    `json:"title"`
 	Severity        string    `json:"severity"`
+	Category        string    `json:"category,omitempty"`
 	Status          string    `json:"status"`
 	DetectedAt      time.Time `json:"detected_at"`
 	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
@@ -96,20 +101,86 @@ type ReportAttestation struct {
 
 // CRSManager handles Continuous Reporting Standard operations
 type CRSManager struct {
-	reports map[string]*ContinuousReport
-	metrics map[string]*KeySecurityMetric
+	reports    map[string]*ContinuousReport
+	metrics    map[string]*KeySecurityMetric
+	thresholds *CRSThresholdPolicy
+	history    map[string][]metricSample // keyed by service and metric ID
+	lastStatus map[string]MetricStatus   // keyed by service and metric ID
+	onRed      MetricTransitionFunc
+	onEval     MetricEvaluatedFunc
+	series     MetricSeriesStore
+	locker     EvidenceLocker
+	lockerUser string
+	mu         sync.Mutex
 }
 
-// NewCRSManager creates a new CRS manager
+// MetricTransitionFunc is called when a metric of a service offering turns red
+type MetricTransitionFunc func(serviceID string, metric KeySecurityMetric, previous MetricStatus)
+
+// MetricEvaluatedFunc is called with every metric added to a report once its
+// status is set
+type MetricEvaluatedFunc func(serviceID string, metric KeySecurityMetric)
+
+// metricSample is a metric value reported at a point in time
+type metricSample struct {
+	at    time.Time
+	value float64
+}
+
+// NewCRSManager creates a new CRS manager evaluating metrics against the
+// default threshold policy
 func NewCRSManager() *CRSManager {
 	return &CRSManager{
-		reports: make(map[string]*ContinuousReport),
-		metrics: make(map[string]*KeySecurityMetric),
+		reports:    make(map[string]*ContinuousReport),
+		metrics:    make(map[string]*KeySecurityMetric),
+		thresholds: DefaultCRSThresholdPolicy(),
+		history:    make(map[string][]metricSample),
+		lastStatus: make(map[string]MetricStatus),
 	}
 }
 
+// SetThresholdPolicy replaces the threshold policy used to evaluate metrics
+func (mgr *CRSManager) SetThresholdPolicy(policy *CRSThresholdPolicy) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.thresholds = policy
+}
+
+// SetSeriesStore records the value of every numeric metric added to a report
+// in the given store
+func (mgr *CRSManager) SetSeriesStore(store MetricSeriesStore) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.series = store
+}
+
+// SetEvidenceLocker verifies the locker evidence of every metric added to a
+// report against the given locker, as actor
+func (mgr *CRSManager) SetEvidenceLocker(locker EvidenceLocker, actor string) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.locker = locker
+	mgr.lockerUser = actor
+}
+
+// OnRedTransition registers a function called whenever a metric turns red
+func (mgr *CRSManager) OnRedTransition(fn MetricTransitionFunc) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.onRed = fn
+}
+
+// OnMetricEvaluated registers a function called with every evaluated metric
+func (mgr *CRSManager) OnMetricEvaluated(fn MetricEvaluatedFunc) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.onEval = fn
+}
+
 // CreateReport creates a new continuous monitoring report
 func (mgr *CRSManager) CreateReport(serviceID string, period ReportingPeriod) *ContinuousReport {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
 	reportID := fmt.Sprintf("%s-%s-%s", serviceID, period.Type, period.EndDate.Format("2006-01-02"))
 	
 	report := &ContinuousReport{
@@ -131,18 +202,121 @@ func (mgr *CRSManager) CreateReport(serviceID string, period ReportingPeriod) *C
 
 // AddMetric adds a metric to a report
 func (mgr *CRSManager) AddMetric(reportID string, metric KeySecurityMetric) error {
-	report, exists := mgr.reports[reportID]
+	mgr.mu.Lock()
+	_, exists := mgr.reports[reportID]
+	locker, actor := mgr.locker, mgr.lockerUser
+	mgr.mu.Unlock()
 	if !exists {
 		return fmt.Errorf("report %s not found", reportID)
 	}
-	
+	var lockerIDs []string
+	for _, evidence := range metric.Evidence {
+		lockerIDs = append(lockerIDs, evidence.LockerID)
+	}
+	if _, err := VerifyCitedEvidence(locker, actor, "metric "+metric.MetricID+" of report "+reportID, lockerIDs); err != nil {
+		return err
+	}
+
+	mgr.mu.Lock()
+	report := mgr.reports[reportID]
+	serviceID := report.ServiceOfferingID
 	metric.LastUpdated = time.Now()
+	// Metrics of past periods are dated at the last second of the period
+	sampledAt := metric.LastUpdated
+	if end := report.ReportingPeriod.EndDate; !end.IsZero() && end.Before(sampledAt) {
+		sampledAt = end.Add(-time.Second)
+	}
+	series := mgr.series
+	threshold, hasThreshold := mgr.thresholds.Metrics[metric.MetricID]
+	mgr.mu.Unlock()
+
+	// With a series store, the window is evaluated over the stored samples so
+	// it survives restarts, and the sample is persisted before the metric is
+	// added so a failed write leaves the report unchanged
+	var history []metricSample
+	if value, ok := metricNumber(metric.Value); ok && series != nil {
+		if window, _ := threshold.WindowDuration(); hasThreshold && window > 0 {
+			stored, err := series.GetMetricSamples(serviceID, metric.MetricID, sampledAt.Add(-window), sampledAt)
+			if err != nil {
+				return fmt.Errorf("failed to read the history of metric %s: %w", metric.MetricID, err)
+			}
+			history = make([]metricSample, 0, len(stored))
+			for _, sample := range stored {
+				history = append(history, metricSample{at: sample.Timestamp, value: sample.Value})
+			}
+		}
+		sample := MetricSample{
+			CSOID:     serviceID,
+			MetricID:  metric.MetricID,
+			Timestamp: sampledAt,
+			Value:     value,
+		}
+		if err := series.AppendMetricSamples([]MetricSample{sample}); err != nil {
+			return fmt.Errorf("failed to record metric %s: %w", metric.MetricID, err)
+		}
+	}
+
+	mgr.mu.Lock()
+	previous := mgr.evaluateMetric(serviceID, &metric, sampledAt, history)
 	report.Metrics = append(report.Metrics, metric)
 	mgr.updateReportSummary(report)
-	
+	onRed, onEval := mgr.onRed, mgr.onEval
+	mgr.mu.Unlock()
+
+	if onEval != nil {
+		onEval(serviceID, metric)
+	}
+	if onRed != nil && metric.Status == MetricStatusRed && previous != MetricStatusRed {
+		onRed(serviceID, metric, previous)
+	}
 	return nil
 }
 
+// evaluateMetric sets the status of a metric from its threshold and returns
+// the previous status of the metric for the service. Metrics without a
+// threshold or a numeric value are gray. The window is evaluated over the
+// given history, or the samples kept in memory when history is nil.
+func (mgr *CRSManager) evaluateMetric(serviceID string, metric *KeySecurityMetric, at time.Time, history []metricSample) MetricStatus {
+	key := serviceID + "/" + metric.MetricID
+	previous, seen := mgr.lastStatus[key]
+	if !seen {
+		previous = MetricStatusGray
+	}
+
+	metric.Status = MetricStatusGray
+	threshold, hasThreshold := mgr.thresholds.Metrics[metric.MetricID]
+	value, numeric := metricNumber(metric.Value)
+	if hasThreshold && numeric {
+		metric.Threshold = threshold
+		window, _ := threshold.WindowDuration()
+		if history == nil {
+			history = mgr.history[key]
+		}
+		samples := append(history, metricSample{at: at, value: value})
+		if window > 0 {
+			// Drop the samples that have left the window and evaluate the mean
+			cutoff := at.Add(-window)
+			kept := samples[:0]
+			var sum float64
+			for _, sample := range samples {
+				if !sample.at.Before(cutoff) {
+					kept = append(kept, sample)
+					sum += sample.value
+				}
+			}
+			samples = kept
+			value = sum / float64(len(samples))
+		} else {
+			samples = samples[len(samples)-1:]
+		}
+		mgr.history[key] = samples
+		metric.Status = threshold.Evaluate(value)
+	}
+
+	mgr.lastStatus[key] = metric.Status
+	return previous
+}
+
 // updateReportSummary updates the summary statistics for a report
 func (mgr *CRSManager) updateReportSummary(report *ContinuousReport) {
 	summary := &report.Summary
@@ -150,14 +324,25 @@ func (mgr *CRSManager) updateReportSummary(report *ContinuousReport) {
 	summary.MetricsByStatus = make(map[MetricStatus]int)
 	summary.CriticalFindings = 0
 	
+	summary.KeyInsights = nil
+	
 	for _, metric := range report.Metrics {
 		summary.MetricsByStatus[metric.Status]++
-		if metric.Status == MetricStatusRed {
+		switch metric.Status {
+		case MetricStatusRed:
 			summary.CriticalFindings++
+			summary.KeyInsights = append(summary.KeyInsights, fmt.Sprintf("%s is red at %s", metric.MetricName, metricValueText(metric)))
+		case MetricStatusYellow:
+			summary.KeyInsights = append(summary.KeyInsights, fmt.Sprintf("%s is approaching its threshold at %s", metric.MetricName, metricValueText(metric)))
 		}
 	}
 }
 
+// metricValueText formats a metric value with its unit
+func metricValueText(metric KeySecurityMetric) string {
+	return strings.TrimSpace(fmt.Sprintf("%v %s", metric.Value, metric.Unit))
+}
+
 // GenerateStandardMetrics creates standard FedRAMP continuous monitoring metrics
 func (mgr *CRSManager) GenerateStandardMetrics() []KeySecurityMetric {
 	now := time.Now()
@@ -252,6 +437,8 @@ func (mgr *CRSManager) GenerateStandardMetrics() []KeySecurityMetric {
 
 // ExportReport exports a continuous monitoring report as JSON
 func (mgr *CRSManager) ExportReport(reportID string) ([]byte, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
 	report, exists := mgr.reports[reportID]
 	if !exists {
 		return nil, fmt.Errorf("report %s not found", reportID)
@@ -262,6 +449,8 @@ func (mgr *CRSManager) ExportReport(reportID string) ([]byte, error) {
 
 // ValidateReport validates a continuous monitoring report
 func (mgr *CRSManager) ValidateReport(reportID string) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
 	report, exists := mgr.reports[reportID]
 	if !exists {
 		return fmt.Errorf("report %s not found", reportID)
@@ -296,56 +485,189 @@ func (mgr *CRSManager) ValidateReport(reportID string) error {
 	return nil
 }
 
-// GetMetricTrends analyzes trends for a specific metric across multiple reports
-func (mgr *CRSManager) GetMetricTrends(serviceID, metricID string, days int) map[string]interface{} {
-	trends := map[string]interface{}{
-		"metric_id": metricID,
-		"service_id": serviceID,
-		"period_days": days,
-		"data_points": make([]map[string]interface{}, 0),
-		"trend_direction": "stable",
-		"average_value": 0.0,
+// GetMetricTrends analyzes the samples of a metric over the last days,
+// downsampled to daily values with a 7-day moving average
+func (mgr *CRSManager) GetMetricTrends(serviceID, metricID string, days int) (*MetricTrend, error) {
+	to := time.Now()
+	trends, err := mgr.AnalyzeMetricSeries(serviceID, metricID, to.AddDate(0, 0, -days), to, 24*time.Hour, 7)
+	if err != nil {
+		return nil, err
 	}
-	
-	// This would be implemented to analyze historical data
-	// For now, return placeholder structure
-	
-	return trends
+	if len(trends) == 0 {
+		trend := AnalyzeMetricTrend(metricID, nil, nil, to.AddDate(0, 0, -days), to, 24*time.Hour, 7, nil)
+		return &trend, nil
+	}
+	return &trends[0], nil
+}
+
+// AnalyzeMetricSeries returns the trend of each metric of the service with
+// samples in [from, to), or of the given metric only. Each trend is compared
+// with the preceding period of the same length and forecast against the
+// metric's threshold.
+func (mgr *CRSManager) AnalyzeMetricSeries(serviceID, metricID string, from, to time.Time, step time.Duration, window int) ([]MetricTrend, error) {
+	mgr.mu.Lock()
+	series := mgr.series
+	thresholds := mgr.thresholds
+	mgr.mu.Unlock()
+	if series == nil {
+		return nil, fmt.Errorf("no metric series store configured")
+	}
+
+	samples, err := series.GetMetricSamples(serviceID, metricID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := series.GetMetricSamples(serviceID, metricID, from.Add(-to.Sub(from)), from)
+	if err != nil {
+		return nil, err
+	}
+
+	byMetric := make(map[string][]MetricSample)
+	var metricIDs []string
+	for _, sample := range samples {
+		if _, ok := byMetric[sample.MetricID]; !ok {
+			metricIDs = append(metricIDs, sample.MetricID)
+		}
+		byMetric[sample.MetricID] = append(byMetric[sample.MetricID], sample)
+	}
+	previousByMetric := make(map[string][]MetricSample)
+	for _, sample := range previous {
+		previousByMetric[sample.MetricID] = append(previousByMetric[sample.MetricID], sample)
+	}
+	sort.Strings(metricIDs)
+
+	trends := make([]MetricTrend, 0, len(metricIDs))
+	for _, id := range metricIDs {
+		var threshold *MetricThreshold
+		if t, ok := thresholds.Metrics[id]; ok {
+			threshold = &t
+		}
+		trends = append(trends, AnalyzeMetricTrend(id, byMetric[id], previousByMetric[id], from, to, step, window, threshold))
+	}
+	return trends, nil
+}
+
+// metricStatusRank orders statuses from best to worst
+var metricStatusRank = map[MetricStatus]int{
+	MetricStatusGray:   0,
+	MetricStatusGreen:  1,
+	MetricStatusYellow: 2,
+	MetricStatusRed:    3,
 }
 
 // GenerateDashboardData creates data for a continuous monitoring dashboard
+// from the latest value of each metric. With a metric series store, metrics
+// sampled in the last 30 days are listed from the store, so the dashboard
+// survives restarts, and the 30-day trend of each metric is included.
 func (mgr *CRSManager) GenerateDashboardData(serviceID string) map[string]interface{} {
-	dashboard := map[string]interface{}{
-		"service_id": serviceID,
-		"last_updated": time.Now(),
-		"overall_status": "green",
-		"metrics_summary": map[string]int{
-			"total": 0,
-			"green": 0,
-			"yellow": 0,
-			"red": 0,
-		},
-		"recent_incidents": make([]SecurityIncident, 0),
-		"key_metrics": make([]KeySecurityMetric, 0),
-	}
-	
-	// Aggregate data from recent reports
+	now := time.Now()
+	mgr.mu.Lock()
+	series, thresholds := mgr.series, mgr.thresholds
+	var reports []*ContinuousReport
 	for _, report := range mgr.reports {
 		if report.ServiceOfferingID == serviceID {
-			// Add metrics to dashboard
-			for _, metric := range report.Metrics {
-				dashboard["key_metrics"] = append(dashboard["key_metrics"].([]KeySecurityMetric), metric)
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].GeneratedAt.Before(reports[j].GeneratedAt)
+	})
+
+	// Later reports replace the metrics of earlier ones
+	latest := make(map[string]KeySecurityMetric)
+	var metricIDs []string
+	incidents := make([]SecurityIncident, 0)
+	for _, report := range reports {
+		for _, metric := range report.Metrics {
+			if _, ok := latest[metric.MetricID]; !ok {
+				metricIDs = append(metricIDs, metric.MetricID)
+			}
+			latest[metric.MetricID] = metric
+		}
+		incidents = append(incidents, report.Incidents...)
+	}
+	mgr.mu.Unlock()
+
+	if series != nil {
+		samples, err := series.GetMetricSamples(serviceID, "", now.AddDate(0, 0, -30), now.Add(time.Second))
+		if err == nil {
+			byMetric := make(map[string][]MetricSample)
+			for _, sample := range samples {
+				byMetric[sample.MetricID] = append(byMetric[sample.MetricID], sample)
 			}
-			
-			// Add incidents
-			for _, incident := range report.Incidents {
-				dashboard["recent_incidents"] = append(dashboard["recent_incidents"].([]SecurityIncident), incident)
+			for metricID, samples := range byMetric {
+				if _, ok := latest[metricID]; !ok {
+					metricIDs = append(metricIDs, metricID)
+					latest[metricID] = storedMetric(metricID, samples, thresholds)
+				}
 			}
 		}
 	}
-	
-	return dashboard
-} 
+	sort.Strings(metricIDs)
+
+	summary := map[string]int{
+		"total":  len(metricIDs),
+		"green":  0,
+		"yellow": 0,
+		"red":    0,
+	}
+	overall := MetricStatusGreen
+	keyMetrics := make([]KeySecurityMetric, 0, len(metricIDs))
+	trends := make(map[string]*MetricTrend)
+	for _, metricID := range metricIDs {
+		metric := latest[metricID]
+		keyMetrics = append(keyMetrics, metric)
+		summary[string(metric.Status)]++
+		if metricStatusRank[metric.Status] > metricStatusRank[overall] {
+			overall = metric.Status
+		}
+		if trend, err := mgr.GetMetricTrends(serviceID, metricID, 30); err == nil {
+			trends[metricID] = trend
+		}
+	}
+
+	return map[string]interface{}{
+		"service_id":       serviceID,
+		"last_updated":     time.Now(),
+		"overall_status":   overall,
+		"metrics_summary":  summary,
+		"recent_incidents": incidents,
+		"key_metrics":      keyMetrics,
+		"trends":           trends,
+	}
+}
+
+// storedMetric rebuilds the latest state of a metric from its stored samples,
+// ordered by time, evaluating the mean over the threshold window as
+// evaluateMetric does
+func storedMetric(metricID string, samples []MetricSample, thresholds *CRSThresholdPolicy) KeySecurityMetric {
+	last := samples[len(samples)-1]
+	metric := KeySecurityMetric{
+		MetricID:    metricID,
+		Value:       last.Value,
+		Status:      MetricStatusGray,
+		LastUpdated: last.Timestamp,
+	}
+	threshold, ok := thresholds.Metrics[metricID]
+	if !ok {
+		return metric
+	}
+	metric.Threshold = threshold
+	value := last.Value
+	if window, _ := threshold.WindowDuration(); window > 0 {
+		var sum float64
+		var n int
+		for _, sample := range samples {
+			if !sample.Timestamp.Before(last.Timestamp.Add(-window)) {
+				sum += sample.Value
+				n++
+			}
+		}
+		value = sum / float64(n)
+	}
+	metric.Status = threshold.Evaluate(value)
+	return metric
+}
 
 // KeySecurityMetrics represents the key security metrics for FedRAMP
 type KeySecurityMetrics struct {
@@ -417,6 +739,9 @@ type ContinuousReportingStandard struct {
 	ReportingPeriod string             `json:"reporting_period"`
 	GeneratedAt     time.Time          `json:"generated_at"`
 	Metrics         KeySecurityMetrics `json:"metrics"`
+	Period          *ReportingPeriod   `json:"period,omitempty"`
+	Indicators      []KeySecurityMetric `json:"indicators,omitempty"`
+	Summary         *ReportSummary     `json:"summary,omitempty"`
 	ComplianceScore float64            `json:"compliance_score"`
 	Status          string             `json:"status"`
 } 
\ No newline at end of file
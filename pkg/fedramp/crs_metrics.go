@@ -0,0 +1,726 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CRSRecords are the raw records the Key Security Metrics are computed from
+type CRSRecords struct {
+	Scans           []VulnerabilityScan `json:"scans,omitempty"`
+	Incidents       []SecurityIncident  `json:"incidents,omitempty"`
+	BaselineChecks  []BaselineCheck     `json:"baseline_checks,omitempty"`
+	IdentityExports []IdentityExport    `json:"identity_exports,omitempty"`
+	UptimeSamples   []UptimeSample      `json:"uptime_samples,omitempty"`
+	Patches         []PatchRecord       `json:"patches,omitempty"`
+}
+
+// VulnerabilityScan is a completed vulnerability scan and its findings.
+// Targets lists the assets the scan covered, including those it found
+// nothing on.
+type VulnerabilityScan struct {
+	ScanID      string        `json:"scan_id"`
+	Scanner     string        `json:"scanner,omitempty"`
+	CompletedAt time.Time     `json:"completed_at"`
+	Targets     []string      `json:"targets,omitempty"`
+	Findings    []ScanFinding `json:"findings"`
+}
+
+// covers reports whether the scan covered an asset: it was one of the scan
+// targets or the scan reported a finding on it
+func (scan VulnerabilityScan) covers(asset string) bool {
+	if containsString(scan.Targets, asset) {
+		return true
+	}
+	for _, finding := range scan.Findings {
+		if finding.Asset == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanFinding is a vulnerability reported by a scan. FindingID identifies the
+// same vulnerability on the same asset across scans.
+type ScanFinding struct {
+	FindingID    string     `json:"finding_id"`
+	Asset        string     `json:"asset"`
+	Severity     string     `json:"severity"`
+	Title        string     `json:"title,omitempty"`
+	PluginID     string     `json:"plugin_id,omitempty"` // scanner plugin or check that reported the finding
+	RemediatedAt *time.Time `json:"remediated_at,omitempty"`
+}
+
+// BaselineCheck is the result of checking a resource against a configuration
+// baseline rule
+type BaselineCheck struct {
+	ResourceID     string    `json:"resource_id"`
+	RuleID         string    `json:"rule_id"`
+	CheckedAt      time.Time `json:"checked_at"`
+	Compliant      bool      `json:"compliant"`
+	ChangeDetected bool      `json:"change_detected,omitempty"`
+	Authorized     bool      `json:"authorized,omitempty"` // the detected change has an approved change request
+}
+
+// IdentityExport is a snapshot of the accounts in an identity provider
+type IdentityExport struct {
+	Source     string            `json:"source"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Accounts   []IdentityAccount `json:"accounts"`
+}
+
+// IdentityAccount is an account in an identity export. Failed logins and
+// lockouts are counted since the previous export of the same source.
+type IdentityAccount struct {
+	UserID       string `json:"user_id"`
+	Enabled      bool   `json:"enabled"`
+	Privileged   bool   `json:"privileged"`
+	MFAEnabled   bool   `json:"mfa_enabled"`
+	FailedLogins int    `json:"failed_logins"`
+	Lockouts     int    `json:"lockouts"`
+}
+
+// UptimeSample is an availability probe result covering IntervalSeconds
+// starting at Timestamp
+type UptimeSample struct {
+	Timestamp       time.Time `json:"timestamp"`
+	IntervalSeconds float64   `json:"interval_seconds"`
+	Up              bool      `json:"up"`
+	Planned         bool      `json:"planned,omitempty"` // downtime within an announced maintenance window
+}
+
+// PatchRecord tracks a vendor patch applicable to an asset
+type PatchRecord struct {
+	PatchID    string     `json:"patch_id"`
+	Asset      string     `json:"asset"`
+	Severity   string     `json:"severity"`
+	ReleasedAt time.Time  `json:"released_at"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+}
+
+// defaultUptimeInterval is used for uptime samples without an interval
+const defaultUptimeInterval = 60.0
+
+// Merge adds other records to r. Records already in r are replaced rather
+// than counted twice: scans by ScanID, incidents by IncidentID, baseline
+// checks by resource, rule and time, identity exports by source and time,
+// uptime samples by time and patches by patch and asset.
+func (r *CRSRecords) Merge(other *CRSRecords) {
+	scans := make(map[string]int)
+	for i, scan := range r.Scans {
+		scans[scan.ScanID] = i
+	}
+	for _, scan := range other.Scans {
+		if i, ok := scans[scan.ScanID]; ok && scan.ScanID != "" {
+			r.Scans[i] = scan
+			continue
+		}
+		scans[scan.ScanID] = len(r.Scans)
+		r.Scans = append(r.Scans, scan)
+	}
+
+	incidents := make(map[string]int)
+	for i, incident := range r.Incidents {
+		incidents[incident.IncidentID] = i
+	}
+	for _, incident := range other.Incidents {
+		if i, ok := incidents[incident.IncidentID]; ok && incident.IncidentID != "" {
+			r.Incidents[i] = incident
+			continue
+		}
+		incidents[incident.IncidentID] = len(r.Incidents)
+		r.Incidents = append(r.Incidents, incident)
+	}
+
+	checks := make(map[string]int)
+	checkKey := func(check BaselineCheck) string {
+		return check.ResourceID + "\x00" + check.RuleID + "\x00" + check.CheckedAt.UTC().Format(time.RFC3339Nano)
+	}
+	for i, check := range r.BaselineChecks {
+		checks[checkKey(check)] = i
+	}
+	for _, check := range other.BaselineChecks {
+		if i, ok := checks[checkKey(check)]; ok {
+			r.BaselineChecks[i] = check
+			continue
+		}
+		checks[checkKey(check)] = len(r.BaselineChecks)
+		r.BaselineChecks = append(r.BaselineChecks, check)
+	}
+
+	exports := make(map[string]int)
+	exportKey := func(export IdentityExport) string {
+		return export.Source + "\x00" + export.ExportedAt.UTC().Format(time.RFC3339Nano)
+	}
+	for i, export := range r.IdentityExports {
+		exports[exportKey(export)] = i
+	}
+	for _, export := range other.IdentityExports {
+		if i, ok := exports[exportKey(export)]; ok {
+			r.IdentityExports[i] = export
+			continue
+		}
+		exports[exportKey(export)] = len(r.IdentityExports)
+		r.IdentityExports = append(r.IdentityExports, export)
+	}
+
+	samples := make(map[int64]int)
+	for i, sample := range r.UptimeSamples {
+		samples[sample.Timestamp.UnixNano()] = i
+	}
+	for _, sample := range other.UptimeSamples {
+		if i, ok := samples[sample.Timestamp.UnixNano()]; ok {
+			r.UptimeSamples[i] = sample
+			continue
+		}
+		samples[sample.Timestamp.UnixNano()] = len(r.UptimeSamples)
+		r.UptimeSamples = append(r.UptimeSamples, sample)
+	}
+
+	patches := make(map[string]int)
+	for i, patch := range r.Patches {
+		patches[patch.PatchID+"\x00"+patch.Asset] = i
+	}
+	for _, patch := range other.Patches {
+		key := patch.PatchID + "\x00" + patch.Asset
+		if i, ok := patches[key]; ok {
+			r.Patches[i] = patch
+			continue
+		}
+		patches[key] = len(r.Patches)
+		r.Patches = append(r.Patches, patch)
+	}
+}
+
+// LoadCRSRecords reads CRS records from a JSON file
+func LoadCRSRecords(path string) (*CRSRecords, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRS records: %w", err)
+	}
+	var records CRSRecords
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse CRS records: %w", err)
+	}
+	return &records, nil
+}
+
+// ParseReportingPeriod parses a reporting period given as a month
+// ("2026-09"), a quarter ("2026-Q3"), a year ("2026") or a date range
+// ("2026-09-01/2026-09-15", end date inclusive)
+func ParseReportingPeriod(value string) (ReportingPeriod, error) {
+	value = strings.TrimSpace(value)
+	if start, end, ok := strings.Cut(value, "/"); ok {
+		from, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return ReportingPeriod{}, fmt.Errorf("invalid period start %q", start)
+		}
+		to, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return ReportingPeriod{}, fmt.Errorf("invalid period end %q", end)
+		}
+		if to.Before(from) {
+			return ReportingPeriod{}, fmt.Errorf("period end %s is before its start %s", end, start)
+		}
+		return ReportingPeriod{StartDate: from, EndDate: to.AddDate(0, 0, 1), Type: "custom"}, nil
+	}
+
+	if month, err := time.Parse("2006-01", value); err == nil {
+		return ReportingPeriod{StartDate: month, EndDate: month.AddDate(0, 1, 0), Type: "monthly"}, nil
+	}
+	var year, quarter int
+	if n, _ := fmt.Sscanf(value, "%d-Q%d", &year, &quarter); n == 2 && quarter >= 1 && quarter <= 4 {
+		start := time.Date(year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, time.UTC)
+		return ReportingPeriod{StartDate: start, EndDate: start.AddDate(0, 3, 0), Type: "quarterly"}, nil
+	}
+	if y, err := time.Parse("2006", value); err == nil {
+		return ReportingPeriod{StartDate: y, EndDate: y.AddDate(1, 0, 0), Type: "annual"}, nil
+	}
+	return ReportingPeriod{}, fmt.Errorf("invalid reporting period %q", value)
+}
+
+// Contains reports whether t falls in the period. The start is inclusive and
+// the end exclusive.
+func (p ReportingPeriod) Contains(t time.Time) bool {
+	return !t.Before(p.StartDate) && t.Before(p.EndDate)
+}
+
+// endsBy reports whether t is set and falls before the end of the period
+func (p ReportingPeriod) endsBy(t *time.Time) bool {
+	return t != nil && t.Before(p.EndDate)
+}
+
+// percentage returns part/total as a percentage rounded to two decimals, or
+// the given default when total is zero
+func percentage(part, total int, empty float64) float64 {
+	if total == 0 {
+		return empty
+	}
+	return round2(float64(part) / float64(total) * 100)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// ComputeVulnerabilityMetric computes the vulnerability scanning metric from
+// the scans completed in the period. Findings are deduplicated by FindingID,
+// keeping the most recent scan's view. A finding is open unless it was
+// remediated before the end of the period; open findings are counted by
+// severity and RemediationRate is the share of findings remediated (100 when
+// the scans found nothing).
+func ComputeVulnerabilityMetric(scans []VulnerabilityScan, period ReportingPeriod) VulnerabilityMetric {
+	var metric VulnerabilityMetric
+	for _, scan := range scans {
+		if period.Contains(scan.CompletedAt) {
+			metric.ScansCompleted++
+			if scan.CompletedAt.After(metric.LastScanDate) {
+				metric.LastScanDate = scan.CompletedAt
+			}
+		}
+	}
+
+	findings := LatestScanFindings(scans, period)
+	remediated := 0
+	for _, observed := range findings {
+		finding := observed.ScanFinding
+		if period.endsBy(finding.RemediatedAt) {
+			remediated++
+			continue
+		}
+		switch NormalizeSeverity(finding.Severity) {
+		case "Critical":
+			metric.CriticalFindings++
+		case "High":
+			metric.HighFindings++
+		case "Moderate":
+			metric.MediumFindings++
+		case "Low":
+			metric.LowFindings++
+		}
+	}
+	metric.RemediationRate = percentage(remediated, len(findings), 100)
+	return metric
+}
+
+// ObservedFinding is a scan finding with the dates it was first and last
+// reported in a period
+type ObservedFinding struct {
+	ScanFinding
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Scanner   string    `json:"scanner,omitempty"`
+}
+
+// LatestScanFindings deduplicates the findings of the scans completed in the
+// period by FindingID, keeping the most recent scan's view of each finding.
+// Findings without an ID are keyed by control, asset and weakness, taken from the
+// plugin ID or else the title, so rescans match them; findings with neither
+// are keyed by scan and position. A finding that a later scan by its scanner
+// covering its asset no longer reports is remediated as of that scan; scans
+// of other assets leave it open.
+func LatestScanFindings(scans []VulnerabilityScan, period ReportingPeriod) map[string]ObservedFinding {
+	var inPeriod []VulnerabilityScan
+	for _, scan := range scans {
+		if period.Contains(scan.CompletedAt) {
+			inPeriod = append(inPeriod, scan)
+		}
+	}
+	sort.SliceStable(inPeriod, func(i, j int) bool {
+		return inPeriod[i].CompletedAt.Before(inPeriod[j].CompletedAt)
+	})
+
+	findings := make(map[string]ObservedFinding)
+	scannerRuns := make(map[string][]VulnerabilityScan)
+	for _, scan := range inPeriod {
+		scannerRuns[scan.Scanner] = append(scannerRuns[scan.Scanner], scan)
+		for i, finding := range scan.Findings {
+			key := finding.FindingID
+			if key == "" {
+				key = scanWeaknessKey(finding)
+				if key == "" {
+					key = fmt.Sprintf("%s#%d", scan.ScanID, i)
+				}
+				finding.FindingID = key
+			}
+			observed, seen := findings[key]
+			if !seen {
+				observed.FirstSeen = scan.CompletedAt
+			}
+			observed.ScanFinding = finding
+			observed.LastSeen = scan.CompletedAt
+			observed.Scanner = scan.Scanner
+			findings[key] = observed
+		}
+	}
+
+	for key, observed := range findings {
+		if observed.RemediatedAt != nil {
+			continue
+		}
+		for _, run := range scannerRuns[observed.Scanner] {
+			if run.CompletedAt.After(observed.LastSeen) && run.covers(observed.Asset) {
+				closed := run.CompletedAt
+				observed.RemediatedAt = &closed
+				findings[key] = observed
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// scanWeaknessKey identifies a finding without an ID by the control scan
+// findings are tracked under, its asset and its plugin ID or title, so the
+// same weakness on two assets is tracked twice. Findings without an asset
+// keep the control:weakness key.
+func scanWeaknessKey(finding ScanFinding) string {
+	weakness := finding.PluginID
+	if weakness == "" {
+		weakness = strings.ToLower(strings.TrimSpace(finding.Title))
+	}
+	if weakness == "" {
+		return ""
+	}
+	if asset := strings.TrimSpace(finding.Asset); asset != "" {
+		return scanFindingControl + ":" + asset + ":" + weakness
+	}
+	return scanFindingControl + ":" + weakness
+}
+
+// ComputeIncidentMetric computes the incident metric from the incidents
+// detected in the period. An incident is closed when it was resolved before
+// the end of the period; AverageResolutionTime is the mean time from
+// detection to resolution of the closed incidents, in hours.
+func ComputeIncidentMetric(incidents []SecurityIncident, period ReportingPeriod) IncidentMetric {
+	metric := IncidentMetric{IncidentsByCategory: make(map[string]int)}
+	var resolutionHours float64
+	for _, incident := range incidents {
+		if !period.Contains(incident.DetectedAt) {
+			continue
+		}
+		metric.TotalIncidents++
+		category := incident.Category
+		if category == "" {
+			category = "uncategorized"
+		}
+		metric.IncidentsByCategory[category]++
+
+		if period.endsBy(incident.ResolvedAt) {
+			metric.ClosedIncidents++
+			resolutionHours += incident.ResolvedAt.Sub(incident.DetectedAt).Hours()
+		} else {
+			metric.OpenIncidents++
+		}
+	}
+	if metric.ClosedIncidents > 0 {
+		metric.AverageResolutionTime = round2(resolutionHours / float64(metric.ClosedIncidents))
+	}
+	return metric
+}
+
+// ComputeConfigurationMetric computes the configuration management metric
+// from the baseline checks run in the period. BaselineCompliance is the share
+// of resource/rule pairs whose latest check passed and ConfigurationDrift the
+// number that failed. Every detected change without an approved change request
+// counts as unauthorized.
+func ComputeConfigurationMetric(checks []BaselineCheck, period ReportingPeriod) ConfigurationMetric {
+	var metric ConfigurationMetric
+	latest := make(map[string]BaselineCheck)
+	for _, check := range checks {
+		if !period.Contains(check.CheckedAt) {
+			continue
+		}
+		if check.ChangeDetected && !check.Authorized {
+			metric.UnauthorizedChanges++
+		}
+		if check.CheckedAt.After(metric.LastBaselineReview) {
+			metric.LastBaselineReview = check.CheckedAt
+		}
+		key := check.ResourceID + "\x00" + check.RuleID
+		if previous, ok := latest[key]; !ok || !check.CheckedAt.Before(previous.CheckedAt) {
+			latest[key] = check
+		}
+	}
+
+	compliant := 0
+	for _, check := range latest {
+		if check.Compliant {
+			compliant++
+		} else {
+			metric.ConfigurationDrift++
+		}
+	}
+	metric.BaselineCompliance = percentage(compliant, len(latest), 0)
+	return metric
+}
+
+// ComputeAccessControlMetric computes the access control metric from the
+// identity exports taken in the period. User counts and MFA adoption come from
+// the latest export of each source (accounts are matched by user ID across
+// sources); failed logins and lockouts are summed over all exports.
+func ComputeAccessControlMetric(exports []IdentityExport, period ReportingPeriod) AccessControlMetric {
+	var metric AccessControlMetric
+	latest := make(map[string]IdentityExport)
+	for _, export := range exports {
+		if !period.Contains(export.ExportedAt) {
+			continue
+		}
+		for _, account := range export.Accounts {
+			metric.FailedLoginAttempts += account.FailedLogins
+			metric.AccountLockouts += account.Lockouts
+		}
+		if previous, ok := latest[export.Source]; !ok || !export.ExportedAt.Before(previous.ExportedAt) {
+			latest[export.Source] = export
+		}
+	}
+
+	type account struct{ privileged, mfa bool }
+	accounts := make(map[string]*account)
+	for _, export := range latest {
+		for _, a := range export.Accounts {
+			if !a.Enabled {
+				continue
+			}
+			merged, ok := accounts[a.UserID]
+			if !ok {
+				// MFA counts only when every source enforces it for the user
+				merged = &account{mfa: true}
+				accounts[a.UserID] = merged
+			}
+			merged.privileged = merged.privileged || a.Privileged
+			merged.mfa = merged.mfa && a.MFAEnabled
+		}
+	}
+
+	withMFA := 0
+	for _, a := range accounts {
+		metric.ActiveUsers++
+		if a.privileged {
+			metric.PrivilegedUsers++
+		}
+		if a.mfa {
+			withMFA++
+		}
+	}
+	metric.MFAAdoptionRate = percentage(withMFA, metric.ActiveUsers, 0)
+	return metric
+}
+
+// ComputeAvailabilityMetric computes the availability metric from the uptime
+// samples taken in the period. Planned downtime is excluded from the uptime
+// percentage. Consecutive unplanned down samples form one outage and MTTRHours
+// is the mean outage length.
+func ComputeAvailabilityMetric(samples []UptimeSample, period ReportingPeriod) AvailabilityMetric {
+	var metric AvailabilityMetric
+	var inPeriod []UptimeSample
+	for _, sample := range samples {
+		if period.Contains(sample.Timestamp) {
+			inPeriod = append(inPeriod, sample)
+		}
+	}
+	sort.SliceStable(inPeriod, func(i, j int) bool {
+		return inPeriod[i].Timestamp.Before(inPeriod[j].Timestamp)
+	})
+
+	var upSeconds, plannedSeconds, unplannedSeconds float64
+	outages := 0
+	inOutage := false
+	for _, sample := range inPeriod {
+		interval := sample.IntervalSeconds
+		if interval <= 0 {
+			interval = defaultUptimeInterval
+		}
+		switch {
+		case sample.Up:
+			upSeconds += interval
+			inOutage = false
+		case sample.Planned:
+			plannedSeconds += interval
+			inOutage = false
+		default:
+			unplannedSeconds += interval
+			if !inOutage {
+				outages++
+			}
+			inOutage = true
+		}
+	}
+
+	if measured := upSeconds + unplannedSeconds; measured > 0 {
+		metric.UptimePercentage = round2(upSeconds / measured * 100)
+	}
+	metric.PlannedDowntime = round2(plannedSeconds / 3600)
+	metric.UnplannedDowntime = round2(unplannedSeconds / 3600)
+	if outages > 0 {
+		metric.MTTRHours = round2(unplannedSeconds / 3600 / float64(outages))
+	}
+	return metric
+}
+
+// ComputePatchMetric computes the patch management metric. A patch is
+// available in the period when it was released before the period ended and
+// not applied before it started, and applied when it was applied before the
+// period ended. AveragePatchAge is the mean number of days from release to
+// application, or to the end of the period for patches still outstanding.
+func ComputePatchMetric(patches []PatchRecord, period ReportingPeriod) PatchMetric {
+	var metric PatchMetric
+	var ageDays float64
+	for _, patch := range patches {
+		if !patch.ReleasedAt.Before(period.EndDate) {
+			continue
+		}
+		if patch.AppliedAt != nil && patch.AppliedAt.Before(period.StartDate) {
+			continue
+		}
+		metric.PatchesAvailable++
+		if NormalizeSeverity(patch.Severity) == "Critical" {
+			metric.CriticalPatches++
+		}
+		until := period.EndDate
+		if period.endsBy(patch.AppliedAt) {
+			metric.PatchesApplied++
+			until = *patch.AppliedAt
+		}
+		ageDays += until.Sub(patch.ReleasedAt).Hours() / 24
+	}
+	metric.PatchComplianceRate = percentage(metric.PatchesApplied, metric.PatchesAvailable, 100)
+	if metric.PatchesAvailable > 0 {
+		metric.AveragePatchAge = round2(ageDays / float64(metric.PatchesAvailable))
+	}
+	return metric
+}
+
+// KeySecurityMetrics computes all Key Security Metrics for the period
+func (r *CRSRecords) KeySecurityMetrics(period ReportingPeriod) KeySecurityMetrics {
+	return KeySecurityMetrics{
+		VulnerabilityScanning:   ComputeVulnerabilityMetric(r.Scans, period),
+		SecurityIncidents:       ComputeIncidentMetric(r.Incidents, period),
+		ConfigurationManagement: ComputeConfigurationMetric(r.BaselineChecks, period),
+		AccessControl:           ComputeAccessControlMetric(r.IdentityExports, period),
+		SystemAvailability:      ComputeAvailabilityMetric(r.UptimeSamples, period),
+		PatchManagement:         ComputePatchMetric(r.Patches, period),
+	}
+}
+
+// CRS report statuses
+const (
+	CRSStatusCompliant    = "compliant"
+	CRSStatusAtRisk       = "at-risk"
+	CRSStatusNonCompliant = "non-compliant"
+	CRSStatusNoData       = "no-data"
+)
+
+// ComplianceScore is the mean of the percentage metrics that have data in
+// the period: vulnerability remediation rate, share of incidents closed,
+// baseline compliance, MFA adoption, uptime and patch compliance. The second
+// result is false when no metric had data.
+func (m KeySecurityMetrics) ComplianceScore() (float64, bool) {
+	var scores []float64
+	if m.VulnerabilityScanning.ScansCompleted > 0 {
+		scores = append(scores, m.VulnerabilityScanning.RemediationRate)
+	}
+	if m.SecurityIncidents.TotalIncidents > 0 {
+		scores = append(scores, percentage(m.SecurityIncidents.ClosedIncidents, m.SecurityIncidents.TotalIncidents, 100))
+	}
+	if !m.ConfigurationManagement.LastBaselineReview.IsZero() {
+		scores = append(scores, m.ConfigurationManagement.BaselineCompliance)
+	}
+	if m.AccessControl.ActiveUsers > 0 {
+		scores = append(scores, m.AccessControl.MFAAdoptionRate)
+	}
+	if m.SystemAvailability.UptimePercentage > 0 || m.SystemAvailability.UnplannedDowntime > 0 {
+		scores = append(scores, m.SystemAvailability.UptimePercentage)
+	}
+	if m.PatchManagement.PatchesAvailable > 0 {
+		scores = append(scores, m.PatchManagement.PatchComplianceRate)
+	}
+	if len(scores) == 0 {
+		return 0, false
+	}
+
+	var total float64
+	for _, score := range scores {
+		total += score
+	}
+	return round2(total / float64(len(scores))), true
+}
+
+// BuildContinuousReportingStandard computes the Key Security Metrics of a
+// service offering over the period. The report is compliant when the
+// compliance score is at least 95 with no open critical findings, at risk
+// when the score is at least 80 and non-compliant otherwise.
+func (r *CRSRecords) BuildContinuousReportingStandard(csoID, label string, period ReportingPeriod) *ContinuousReportingStandard {
+	metrics := r.KeySecurityMetrics(period)
+	score, ok := metrics.ComplianceScore()
+
+	status := CRSStatusNonCompliant
+	switch {
+	case !ok:
+		status = CRSStatusNoData
+	case score >= 95 && metrics.VulnerabilityScanning.CriticalFindings == 0:
+		status = CRSStatusCompliant
+	case score >= 80:
+		status = CRSStatusAtRisk
+	}
+
+	return &ContinuousReportingStandard{
+		CSOId:           csoID,
+		ReportingPeriod: label,
+		GeneratedAt:     time.Now(),
+		Metrics:         metrics,
+		Period:          &period,
+		ComplianceScore: score,
+		Status:          status,
+	}
+}
+
+// Indicators flattens the Key Security Metrics into metrics that can be
+// evaluated against a threshold policy. Indicators without data in the period
+// are omitted.
+func (m KeySecurityMetrics) Indicators() []KeySecurityMetric {
+	var indicators []KeySecurityMetric
+	add := func(id, name, category, unit string, value interface{}, controls ...string) {
+		indicators = append(indicators, KeySecurityMetric{
+			MetricID:         id,
+			MetricName:       name,
+			Category:         category,
+			Value:            value,
+			Unit:             unit,
+			CollectionMethod: "automated",
+			RelatedControls:  controls,
+		})
+	}
+
+	if vuln := m.VulnerabilityScanning; vuln.ScansCompleted > 0 {
+		add("vuln-remediation-rate", "Vulnerability Remediation Rate", "Vulnerability Management", "percentage", vuln.RemediationRate, "RA-5", "SI-2")
+		add("open-critical-findings", "Open Critical Findings", "Vulnerability Management", "count", vuln.CriticalFindings, "RA-5")
+		add("open-high-findings", "Open High Findings", "Vulnerability Management", "count", vuln.HighFindings, "RA-5")
+	}
+	if incidents := m.SecurityIncidents; incidents.TotalIncidents > 0 {
+		add("open-incidents", "Open Security Incidents", "Incident Response", "count", incidents.OpenIncidents, "IR-4", "IR-6")
+		if incidents.ClosedIncidents > 0 {
+			add("incident-resolution-time", "Mean Incident Resolution Time", "Incident Response", "hours", incidents.AverageResolutionTime, "IR-4")
+		}
+	}
+	if config := m.ConfigurationManagement; !config.LastBaselineReview.IsZero() {
+		add("baseline-compliance", "Baseline Configuration Compliance", "Configuration Management", "percentage", config.BaselineCompliance, "CM-2", "CM-6")
+		add("unauthorized-changes", "Unauthorized Configuration Changes", "Configuration Management", "count", config.UnauthorizedChanges, "CM-3")
+	}
+	if access := m.AccessControl; access.ActiveUsers > 0 {
+		add("mfa-coverage", "Multi-Factor Authentication Coverage", "Identification and Authentication", "percentage", access.MFAAdoptionRate, "IA-2(1)", "IA-2(2)")
+		add("failed-logins", "Failed Login Attempts", "Access Control", "count", access.FailedLoginAttempts, "AC-7", "AU-2")
+	}
+	if availability := m.SystemAvailability; availability.UptimePercentage > 0 || availability.UnplannedDowntime > 0 {
+		add("system-uptime", "System Uptime", "Contingency Planning", "percentage", availability.UptimePercentage, "CP-2", "SC-5")
+	}
+	if patches := m.PatchManagement; patches.PatchesAvailable > 0 {
+		add("patch-compliance", "Security Patch Compliance", "Configuration Management", "percentage", patches.PatchComplianceRate, "SI-2", "CM-6")
+	}
+	return indicators
+}
@@ -0,0 +1,406 @@
+package fedramp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var september = ReportingPeriod{
+	StartDate: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+	EndDate:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	Type:      "monthly",
+}
+
+func day(d int) time.Time {
+	return time.Date(2026, 9, d, 12, 0, 0, 0, time.UTC)
+}
+
+func at(t time.Time) *time.Time {
+	return &t
+}
+
+func TestParseReportingPeriod(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		value   string
+		want    ReportingPeriod
+		wantErr bool
+	}{
+		{value: "2026-09", want: ReportingPeriod{StartDate: date(2026, 9, 1), EndDate: date(2026, 10, 1), Type: "monthly"}},
+		{value: " 2026-12 ", want: ReportingPeriod{StartDate: date(2026, 12, 1), EndDate: date(2027, 1, 1), Type: "monthly"}},
+		{value: "2026-Q3", want: ReportingPeriod{StartDate: date(2026, 7, 1), EndDate: date(2026, 10, 1), Type: "quarterly"}},
+		{value: "2026-Q4", want: ReportingPeriod{StartDate: date(2026, 10, 1), EndDate: date(2027, 1, 1), Type: "quarterly"}},
+		{value: "2026", want: ReportingPeriod{StartDate: date(2026, 1, 1), EndDate: date(2027, 1, 1), Type: "annual"}},
+		{value: "2026-09-01/2026-09-15", want: ReportingPeriod{StartDate: date(2026, 9, 1), EndDate: date(2026, 9, 16), Type: "custom"}},
+		{value: "2026-09-15/2026-09-01", wantErr: true},
+		{value: "2026-09-01/soon", wantErr: true},
+		{value: "2026-Q5", wantErr: true},
+		{value: "2026-13", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReportingPeriod(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReportingPeriod(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseReportingPeriod(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestComputeVulnerabilityMetric(t *testing.T) {
+	tests := []struct {
+		name  string
+		scans []VulnerabilityScan
+		want  VulnerabilityMetric
+	}{
+		{
+			name: "no scans",
+			want: VulnerabilityMetric{RemediationRate: 100},
+		},
+		{
+			name: "scans outside the period are ignored",
+			scans: []VulnerabilityScan{
+				{ScanID: "S0", CompletedAt: day(1).AddDate(0, -1, 0), Findings: []ScanFinding{{FindingID: "F1", Severity: "critical"}}},
+			},
+			want: VulnerabilityMetric{RemediationRate: 100},
+		},
+		{
+			name: "open findings by severity",
+			scans: []VulnerabilityScan{
+				{ScanID: "S1", CompletedAt: day(5), Findings: []ScanFinding{
+					{FindingID: "F1", Severity: "Critical"},
+					{FindingID: "F2", Severity: "high"},
+					{FindingID: "F3", Severity: "Medium"},
+					{FindingID: "F4", Severity: "low"},
+					{FindingID: "F5", Severity: "low", RemediatedAt: at(day(6))},
+				}},
+			},
+			want: VulnerabilityMetric{ScansCompleted: 1, CriticalFindings: 1, HighFindings: 1, MediumFindings: 1, LowFindings: 1, LastScanDate: day(5), RemediationRate: 20},
+		},
+		{
+			name: "latest scan view of a finding wins",
+			scans: []VulnerabilityScan{
+				{ScanID: "S2", Scanner: "nessus", CompletedAt: day(20), Findings: []ScanFinding{{FindingID: "F1", Severity: "High", RemediatedAt: at(day(19))}}},
+				{ScanID: "S1", Scanner: "nessus", CompletedAt: day(5), Findings: []ScanFinding{{FindingID: "F1", Severity: "High"}}},
+			},
+			want: VulnerabilityMetric{ScansCompleted: 2, LastScanDate: day(20), RemediationRate: 100},
+		},
+		{
+			name: "remediation after the period end leaves the finding open",
+			scans: []VulnerabilityScan{
+				{ScanID: "S1", CompletedAt: day(5), Findings: []ScanFinding{{FindingID: "F1", Severity: "High", RemediatedAt: at(day(5).AddDate(0, 1, 0))}}},
+			},
+			want: VulnerabilityMetric{ScansCompleted: 1, HighFindings: 1, LastScanDate: day(5), RemediationRate: 0},
+		},
+		{
+			name: "finding missing from a later scan by the same scanner is closed",
+			scans: []VulnerabilityScan{
+				{ScanID: "S1", Scanner: "nessus", CompletedAt: day(5), Findings: []ScanFinding{{FindingID: "F1", Severity: "High"}, {FindingID: "F2", Severity: "Low"}}},
+				{ScanID: "S2", Scanner: "nessus", CompletedAt: day(12), Findings: []ScanFinding{{FindingID: "F2", Severity: "Low"}}},
+			},
+			want: VulnerabilityMetric{ScansCompleted: 2, LowFindings: 1, LastScanDate: day(12), RemediationRate: 50},
+		},
+		{
+			name: "finding missing from a scan by another scanner stays open",
+			scans: []VulnerabilityScan{
+				{ScanID: "S1", Scanner: "nessus", CompletedAt: day(5), Findings: []ScanFinding{{FindingID: "F1", Severity: "High"}}},
+				{ScanID: "S2", Scanner: "zap", CompletedAt: day(12), Findings: []ScanFinding{}},
+			},
+			want: VulnerabilityMetric{ScansCompleted: 2, HighFindings: 1, LastScanDate: day(12), RemediationRate: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeVulnerabilityMetric(tt.scans, september); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestScanFindingsClosesUnreportedFindings(t *testing.T) {
+	scans := []VulnerabilityScan{
+		{ScanID: "S1", Scanner: "nessus", CompletedAt: day(5), Findings: []ScanFinding{{FindingID: "F1", Asset: "vm-1", Severity: "High"}}},
+		{ScanID: "S2", Scanner: "nessus", CompletedAt: day(8), Targets: []string{"vm-2"}},
+		{ScanID: "S3", Scanner: "nessus", CompletedAt: day(12), Targets: []string{"vm-1", "vm-2"}},
+		{ScanID: "S4", Scanner: "nessus", CompletedAt: day(19), Targets: []string{"vm-1"}},
+	}
+	findings := LatestScanFindings(scans, september)
+	got := findings["F1"].RemediatedAt
+	if got == nil || !got.Equal(day(12)) {
+		t.Fatalf("RemediatedAt = %v, want %v", got, day(12))
+	}
+}
+
+func TestLatestScanFindingsKeepsFindingsOfUnscannedAssets(t *testing.T) {
+	scans := []VulnerabilityScan{
+		{ScanID: "S1", Scanner: "nessus", CompletedAt: day(5), Findings: []ScanFinding{
+			{FindingID: "F1", Asset: "vm-1", Severity: "High"},
+			{FindingID: "F2", Asset: "vm-2", Severity: "Low"},
+		}},
+		{ScanID: "S2", Scanner: "nessus", CompletedAt: day(12), Findings: []ScanFinding{{FindingID: "F3", Asset: "vm-2", Severity: "Low"}}},
+		{ScanID: "S3", Scanner: "nessus", CompletedAt: day(19)},
+	}
+	findings := LatestScanFindings(scans, september)
+	if got := findings["F1"].RemediatedAt; got != nil {
+		t.Errorf("F1 on an asset no later scan covered was closed at %v", got)
+	}
+	if got := findings["F2"].RemediatedAt; got == nil || !got.Equal(day(12)) {
+		t.Errorf("F2 RemediatedAt = %v, want %v", got, day(12))
+	}
+}
+
+func TestComputeIncidentMetric(t *testing.T) {
+	tests := []struct {
+		name      string
+		incidents []SecurityIncident
+		want      IncidentMetric
+	}{
+		{
+			name: "no incidents",
+			want: IncidentMetric{IncidentsByCategory: map[string]int{}},
+		},
+		{
+			name: "open, closed and uncategorized incidents",
+			incidents: []SecurityIncident{
+				{IncidentID: "I1", Category: "malware", DetectedAt: day(2), ResolvedAt: at(day(2).Add(4 * time.Hour))},
+				{IncidentID: "I2", Category: "malware", DetectedAt: day(3), ResolvedAt: at(day(3).Add(8 * time.Hour))},
+				{IncidentID: "I3", DetectedAt: day(10)},
+				{IncidentID: "I4", Category: "phishing", DetectedAt: day(28), ResolvedAt: at(day(28).AddDate(0, 1, 0))},
+				{IncidentID: "I5", Category: "phishing", DetectedAt: day(1).AddDate(0, -1, 0)},
+			},
+			want: IncidentMetric{
+				TotalIncidents:        4,
+				OpenIncidents:         2,
+				ClosedIncidents:       2,
+				AverageResolutionTime: 6,
+				IncidentsByCategory:   map[string]int{"malware": 2, "uncategorized": 1, "phishing": 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeIncidentMetric(tt.incidents, september); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeConfigurationMetric(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []BaselineCheck
+		want   ConfigurationMetric
+	}{
+		{
+			name: "no checks",
+			want: ConfigurationMetric{},
+		},
+		{
+			name: "latest check of each resource and rule counts",
+			checks: []BaselineCheck{
+				{ResourceID: "vm-1", RuleID: "R1", CheckedAt: day(2), Compliant: false},
+				{ResourceID: "vm-1", RuleID: "R1", CheckedAt: day(9), Compliant: true},
+				{ResourceID: "vm-1", RuleID: "R2", CheckedAt: day(9), Compliant: true},
+				{ResourceID: "vm-2", RuleID: "R1", CheckedAt: day(9), Compliant: true, ChangeDetected: true, Authorized: true},
+				{ResourceID: "vm-3", RuleID: "R1", CheckedAt: day(16), Compliant: false, ChangeDetected: true},
+				{ResourceID: "vm-4", RuleID: "R1", CheckedAt: day(1).AddDate(0, -1, 0), Compliant: false, ChangeDetected: true},
+			},
+			want: ConfigurationMetric{BaselineCompliance: 75, UnauthorizedChanges: 1, ConfigurationDrift: 1, LastBaselineReview: day(16)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeConfigurationMetric(tt.checks, september); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeAccessControlMetric(t *testing.T) {
+	tests := []struct {
+		name    string
+		exports []IdentityExport
+		want    AccessControlMetric
+	}{
+		{
+			name: "no exports",
+			want: AccessControlMetric{},
+		},
+		{
+			name: "latest export per source, accounts merged across sources",
+			exports: []IdentityExport{
+				{Source: "okta", ExportedAt: day(1), Accounts: []IdentityAccount{
+					{UserID: "alice", Enabled: true, MFAEnabled: false, FailedLogins: 5, Lockouts: 1},
+					{UserID: "old", Enabled: true, MFAEnabled: true},
+				}},
+				{Source: "okta", ExportedAt: day(15), Accounts: []IdentityAccount{
+					{UserID: "alice", Enabled: true, MFAEnabled: true, FailedLogins: 2},
+					{UserID: "bob", Enabled: true, MFAEnabled: true, Privileged: true},
+					{UserID: "carol", Enabled: false},
+				}},
+				{Source: "aws", ExportedAt: day(15), Accounts: []IdentityAccount{
+					{UserID: "alice", Enabled: true, MFAEnabled: false, Privileged: true},
+					{UserID: "dave", Enabled: true, MFAEnabled: true},
+				}},
+			},
+			want: AccessControlMetric{ActiveUsers: 3, PrivilegedUsers: 2, FailedLoginAttempts: 7, AccountLockouts: 1, MFAAdoptionRate: 66.67},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeAccessControlMetric(tt.exports, september); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeAvailabilityMetric(t *testing.T) {
+	hour := func(h int, up, planned bool) UptimeSample {
+		return UptimeSample{Timestamp: day(2).Add(time.Duration(h) * time.Hour), IntervalSeconds: 3600, Up: up, Planned: planned}
+	}
+	tests := []struct {
+		name    string
+		samples []UptimeSample
+		want    AvailabilityMetric
+	}{
+		{
+			name: "no samples",
+			want: AvailabilityMetric{},
+		},
+		{
+			name: "planned downtime excluded and outages merged",
+			samples: []UptimeSample{
+				hour(0, true, false), hour(1, true, false), hour(2, true, false), hour(3, true, false),
+				hour(4, false, false), hour(5, false, false),
+				hour(6, true, false), hour(7, false, true), hour(8, true, false),
+				hour(9, false, false),
+			},
+			want: AvailabilityMetric{UptimePercentage: 66.67, PlannedDowntime: 1, UnplannedDowntime: 3, MTTRHours: 1.5},
+		},
+		{
+			name:    "samples without an interval count a minute",
+			samples: []UptimeSample{{Timestamp: day(3), Up: true}, {Timestamp: day(3).Add(time.Minute)}},
+			want:    AvailabilityMetric{UptimePercentage: 50, UnplannedDowntime: 0.02, MTTRHours: 0.02},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeAvailabilityMetric(tt.samples, september); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePatchMetric(t *testing.T) {
+	tests := []struct {
+		name    string
+		patches []PatchRecord
+		want    PatchMetric
+	}{
+		{
+			name: "no patches",
+			want: PatchMetric{PatchComplianceRate: 100},
+		},
+		{
+			name: "applied, outstanding and out of period patches",
+			patches: []PatchRecord{
+				{PatchID: "P1", Asset: "vm-1", Severity: "critical", ReleasedAt: day(1), AppliedAt: at(day(3))},
+				{PatchID: "P2", Asset: "vm-1", Severity: "high", ReleasedAt: day(1).AddDate(0, 0, -10)},
+				{PatchID: "P3", Asset: "vm-2", Severity: "low", ReleasedAt: day(1).AddDate(0, -2, 0), AppliedAt: at(day(1).AddDate(0, -1, 0))},
+				{PatchID: "P4", Asset: "vm-2", Severity: "low", ReleasedAt: day(1).AddDate(0, 1, 0)},
+			},
+			want: PatchMetric{PatchesAvailable: 2, PatchesApplied: 1, CriticalPatches: 1, PatchComplianceRate: 50, AveragePatchAge: 20.75},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputePatchMetric(tt.patches, september); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComplianceScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics KeySecurityMetrics
+		want    float64
+		wantOK  bool
+	}{
+		{
+			name:    "no data",
+			metrics: KeySecurityMetrics{},
+			want:    0,
+			wantOK:  false,
+		},
+		{
+			name: "only metrics with data count",
+			metrics: KeySecurityMetrics{
+				VulnerabilityScanning: VulnerabilityMetric{ScansCompleted: 1, RemediationRate: 90},
+				PatchManagement:       PatchMetric{PatchesAvailable: 4, PatchComplianceRate: 80},
+			},
+			want:   85,
+			wantOK: true,
+		},
+		{
+			name: "all metrics",
+			metrics: KeySecurityMetrics{
+				VulnerabilityScanning:   VulnerabilityMetric{ScansCompleted: 1, RemediationRate: 100},
+				SecurityIncidents:       IncidentMetric{TotalIncidents: 4, ClosedIncidents: 3},
+				ConfigurationManagement: ConfigurationMetric{LastBaselineReview: day(1), BaselineCompliance: 90},
+				AccessControl:           AccessControlMetric{ActiveUsers: 10, MFAAdoptionRate: 100},
+				SystemAvailability:      AvailabilityMetric{UptimePercentage: 99.9},
+				PatchManagement:         PatchMetric{PatchesAvailable: 1, PatchComplianceRate: 100},
+			},
+			want:   94.15,
+			wantOK: true,
+		},
+		{
+			name: "total outage counts as zero uptime",
+			metrics: KeySecurityMetrics{
+				SystemAvailability: AvailabilityMetric{UnplannedDowntime: 2},
+			},
+			want:   0,
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.metrics.ComplianceScore()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCRSRecordsMergeReplacesReposts(t *testing.T) {
+	records := &CRSRecords{
+		Scans:     []VulnerabilityScan{{ScanID: "S1", CompletedAt: day(5)}},
+		Incidents: []SecurityIncident{{IncidentID: "I1", DetectedAt: day(2)}},
+		Patches:   []PatchRecord{{PatchID: "P1", Asset: "vm-1", ReleasedAt: day(1)}},
+	}
+	records.Merge(&CRSRecords{
+		Scans:     []VulnerabilityScan{{ScanID: "S1", CompletedAt: day(5)}, {ScanID: "S2", CompletedAt: day(12)}},
+		Incidents: []SecurityIncident{{IncidentID: "I1", DetectedAt: day(2), ResolvedAt: at(day(3))}},
+		Patches:   []PatchRecord{{PatchID: "P1", Asset: "vm-1", ReleasedAt: day(1), AppliedAt: at(day(4))}, {PatchID: "P1", Asset: "vm-2", ReleasedAt: day(1)}},
+	})
+	if len(records.Scans) != 2 || len(records.Incidents) != 1 || len(records.Patches) != 2 {
+		t.Fatalf("got %d scans, %d incidents, %d patches; want 2, 1, 2", len(records.Scans), len(records.Incidents), len(records.Patches))
+	}
+	if records.Incidents[0].ResolvedAt == nil || records.Patches[0].AppliedAt == nil {
+		t.Errorf("reposted records did not replace the stored copies")
+	}
+}
@@ -0,0 +1,83 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CRSRecordStore persists the records Key Security Metrics are computed from
+type CRSRecordStore interface {
+	IngestCRSRecords(csoID string, records *CRSRecords) error
+	GetCRSRecords(csoID string) (*CRSRecords, error)
+}
+
+// FileCRSRecordStore keeps the records of each service offering in a JSON file
+type FileCRSRecordStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileCRSRecordStore creates a file store rooted at dir, creating it if needed
+func NewFileCRSRecordStore(dir string) (*FileCRSRecordStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create CRS record store: %w", err)
+	}
+	return &FileCRSRecordStore{dir: dir}, nil
+}
+
+func (s *FileCRSRecordStore) path(csoID string) (string, error) {
+	if csoID == "" || strings.ContainsAny(csoID, `/\`) || csoID == "." || csoID == ".." {
+		return "", fmt.Errorf("invalid CSO ID: %q", csoID)
+	}
+	return filepath.Join(s.dir, csoID+".json"), nil
+}
+
+// IngestCRSRecords merges records into those already stored for the service
+// offering; records posted again replace the stored copy
+func (s *FileCRSRecordStore) IngestCRSRecords(csoID string, records *CRSRecords) error {
+	path, err := s.path(csoID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.read(path)
+	if err != nil {
+		return err
+	}
+	stored.Merge(records)
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write CRS records for %s: %w", csoID, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// GetCRSRecords returns the records of a service offering. A service offering
+// without records has empty records.
+func (s *FileCRSRecordStore) GetCRSRecords(csoID string) (*CRSRecords, error) {
+	path, err := s.path(csoID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(path)
+}
+
+func (s *FileCRSRecordStore) read(path string) (*CRSRecords, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &CRSRecords{}, nil
+	}
+	return LoadCRSRecords(path)
+}
@@ -0,0 +1,60 @@
+package fedramp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func currentPeriod() ReportingPeriod {
+	now := time.Now()
+	return ReportingPeriod{StartDate: now.AddDate(0, 0, -1), EndDate: now.AddDate(0, 0, 1), Type: "custom"}
+}
+
+func TestCRSWindowSurvivesRestart(t *testing.T) {
+	store, err := NewFileMetricSeriesStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := NewCRSManager()
+	first.SetSeriesStore(store)
+	report := first.CreateReport("CSO-1", currentPeriod())
+	if err := first.AddMetric(report.ReportID, KeySecurityMetric{MetricID: "failed-logins", Value: 150}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new manager over the same store evaluates the 24h window including
+	// the sample recorded before the restart: mean (150 + 10) / 2 = 80
+	second := NewCRSManager()
+	second.SetSeriesStore(store)
+	report = second.CreateReport("CSO-1", currentPeriod())
+	if err := second.AddMetric(report.ReportID, KeySecurityMetric{MetricID: "failed-logins", Value: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if got := report.Metrics[0].Status; got != MetricStatusYellow {
+		t.Errorf("status after restart = %s, want %s", got, MetricStatusYellow)
+	}
+}
+
+type failingSeriesStore struct{}
+
+func (failingSeriesStore) AppendMetricSamples([]MetricSample) error {
+	return errors.New("disk full")
+}
+
+func (failingSeriesStore) GetMetricSamples(string, string, time.Time, time.Time) ([]MetricSample, error) {
+	return nil, nil
+}
+
+func TestCRSAddMetricPersistsFirst(t *testing.T) {
+	mgr := NewCRSManager()
+	mgr.SetSeriesStore(failingSeriesStore{})
+	report := mgr.CreateReport("CSO-1", currentPeriod())
+	if err := mgr.AddMetric(report.ReportID, KeySecurityMetric{MetricID: "failed-logins", Value: 150}); err == nil {
+		t.Fatal("AddMetric succeeded although the sample was not recorded")
+	}
+	if len(report.Metrics) != 0 || report.Summary.TotalMetrics != 0 {
+		t.Errorf("metric added to the report although the sample was not recorded")
+	}
+}
@@ -0,0 +1,177 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ThresholdDirection tells whether higher or lower metric values are better
+type ThresholdDirection string
+
+const (
+	// ThresholdMin metrics must stay at or above their levels (e.g. coverage)
+	ThresholdMin ThresholdDirection = "min"
+	// ThresholdMax metrics must stay at or below their levels (e.g. failed logins)
+	ThresholdMax ThresholdDirection = "max"
+)
+
+// MetricThreshold defines the warning and critical levels of a metric. With
+// an evaluation window, the mean of the values reported within the window is
+// evaluated instead of the latest value.
+type MetricThreshold struct {
+	Direction ThresholdDirection `json:"direction"`
+	Warning   float64            `json:"warning"`
+	Critical  float64            `json:"critical"`
+	Window    string             `json:"window,omitempty"` // e.g. "24h", "7d"
+}
+
+// CRSThresholdPolicy maps metric IDs to their thresholds
+type CRSThresholdPolicy struct {
+	Metrics map[string]MetricThreshold `json:"metrics"`
+}
+
+// DefaultCRSThresholdPolicy returns the thresholds of the standard metrics and
+// of the indicators derived from the Key Security Metrics
+func DefaultCRSThresholdPolicy() *CRSThresholdPolicy {
+	return &CRSThresholdPolicy{
+		Metrics: map[string]MetricThreshold{
+			"vuln-scan-coverage":       {Direction: ThresholdMin, Warning: 95, Critical: 90},
+			"vuln-remediation-rate":    {Direction: ThresholdMin, Warning: 95, Critical: 90},
+			"open-critical-findings":   {Direction: ThresholdMax, Warning: 0, Critical: 0},
+			"open-high-findings":       {Direction: ThresholdMax, Warning: 0, Critical: 5},
+			"open-incidents":           {Direction: ThresholdMax, Warning: 1, Critical: 5},
+			"incident-resolution-time": {Direction: ThresholdMax, Warning: 24, Critical: 72},
+			"baseline-compliance":      {Direction: ThresholdMin, Warning: 95, Critical: 90},
+			"unauthorized-changes":     {Direction: ThresholdMax, Warning: 0, Critical: 2},
+			"failed-logins":            {Direction: ThresholdMax, Warning: 50, Critical: 100, Window: "24h"},
+			"mfa-coverage":             {Direction: ThresholdMin, Warning: 100, Critical: 95},
+			"system-uptime":            {Direction: ThresholdMin, Warning: 99.9, Critical: 99.5},
+			"patch-compliance":         {Direction: ThresholdMin, Warning: 95, Critical: 90},
+			"backup-success-rate":      {Direction: ThresholdMin, Warning: 99.5, Critical: 99},
+			"encryption-coverage":      {Direction: ThresholdMin, Warning: 100, Critical: 99},
+		},
+	}
+}
+
+// LoadCRSThresholdPolicy reads a threshold policy from a JSON file. Metrics
+// missing from the file keep their default thresholds.
+func LoadCRSThresholdPolicy(path string) (*CRSThresholdPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRS threshold policy: %w", err)
+	}
+
+	var loaded CRSThresholdPolicy
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse CRS threshold policy: %w", err)
+	}
+
+	policy := DefaultCRSThresholdPolicy()
+	for metricID, threshold := range loaded.Metrics {
+		policy.Metrics[metricID] = threshold
+	}
+	return policy, policy.Validate()
+}
+
+// Validate checks that every threshold has a direction, consistent levels and
+// a parseable window
+func (p *CRSThresholdPolicy) Validate() error {
+	for metricID, threshold := range p.Metrics {
+		switch threshold.Direction {
+		case ThresholdMin:
+			if threshold.Critical > threshold.Warning {
+				return fmt.Errorf("metric %s: critical level %v is above the warning level %v", metricID, threshold.Critical, threshold.Warning)
+			}
+		case ThresholdMax:
+			if threshold.Critical < threshold.Warning {
+				return fmt.Errorf("metric %s: critical level %v is below the warning level %v", metricID, threshold.Critical, threshold.Warning)
+			}
+		default:
+			return fmt.Errorf("metric %s: direction must be %q or %q", metricID, ThresholdMin, ThresholdMax)
+		}
+		if _, err := threshold.WindowDuration(); err != nil {
+			return fmt.Errorf("metric %s: %w", metricID, err)
+		}
+	}
+	return nil
+}
+
+// WindowDuration parses the evaluation window. An empty window is zero.
+func (t MetricThreshold) WindowDuration() (time.Duration, error) {
+	if t.Window == "" {
+		return 0, nil
+	}
+	return ParseMetricWindow(t.Window)
+}
+
+// ParseMetricWindow parses a window or step given as a Go duration ("24h") or
+// as whole days ("7d")
+func ParseMetricWindow(value string) (time.Duration, error) {
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid window %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window < 0 {
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+	return window, nil
+}
+
+// Evaluate returns the traffic-light status of a value
+func (t MetricThreshold) Evaluate(value float64) MetricStatus {
+	switch t.Direction {
+	case ThresholdMin:
+		if value < t.Critical {
+			return MetricStatusRed
+		}
+		if value < t.Warning {
+			return MetricStatusYellow
+		}
+	case ThresholdMax:
+		if value > t.Critical {
+			return MetricStatusRed
+		}
+		if value > t.Warning {
+			return MetricStatusYellow
+		}
+	default:
+		return MetricStatusGray
+	}
+	return MetricStatusGreen
+}
+
+// NumericValue returns the value of the metric as a number, if it is one
+func (m KeySecurityMetric) NumericValue() (float64, bool) {
+	return metricNumber(m.Value)
+}
+
+// metricNumber converts a metric value to a number
+func metricNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,372 @@
+package fedramp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricSample is the value of a metric of a service offering at a point in time
+type MetricSample struct {
+	CSOID     string    `json:"cso_id"`
+	MetricID  string    `json:"metric_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricSeriesStore persists metric samples
+type MetricSeriesStore interface {
+	AppendMetricSamples(samples []MetricSample) error
+	// GetMetricSamples returns the samples in [from, to) ordered by time. An
+	// empty metricID returns the samples of every metric.
+	GetMetricSamples(csoID, metricID string, from, to time.Time) ([]MetricSample, error)
+}
+
+// FileMetricSeriesStore keeps the samples of each metric in a JSON Lines file
+// under a directory per service offering
+type FileMetricSeriesStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileMetricSeriesStore creates a file store rooted at dir, creating it if needed
+func NewFileMetricSeriesStore(dir string) (*FileMetricSeriesStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create metric series store: %w", err)
+	}
+	return &FileMetricSeriesStore{dir: dir}, nil
+}
+
+func validSeriesName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\`) && name != "." && name != ".."
+}
+
+// AppendMetricSamples adds the samples to their series. A sample for the same
+// metric and time replaces the earlier value.
+func (s *FileMetricSeriesStore) AppendMetricSamples(samples []MetricSample) error {
+	bySeries := make(map[string][]MetricSample)
+	var paths []string
+	for _, sample := range samples {
+		if !validSeriesName(sample.CSOID) || !validSeriesName(sample.MetricID) {
+			return fmt.Errorf("invalid metric series %q/%q", sample.CSOID, sample.MetricID)
+		}
+		path := filepath.Join(s.dir, sample.CSOID, sample.MetricID+".jsonl")
+		if _, ok := bySeries[path]; !ok {
+			paths = append(paths, path)
+		}
+		bySeries[path] = append(bySeries[path], sample)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create metric series: %w", err)
+		}
+		stored, err := readMetricSeries(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, sample := range bySeries[path] {
+			replaced := false
+			for i := range stored {
+				if stored[i].Timestamp.Equal(sample.Timestamp) {
+					stored[i] = sample
+					replaced = true
+				}
+			}
+			if !replaced {
+				stored = append(stored, sample)
+			}
+		}
+		if err := writeMetricSeries(path, stored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMetricSamples reads the samples of a service offering in [from, to)
+func (s *FileMetricSeriesStore) GetMetricSamples(csoID, metricID string, from, to time.Time) ([]MetricSample, error) {
+	if !validSeriesName(csoID) || (metricID != "" && !validSeriesName(metricID)) {
+		return nil, fmt.Errorf("invalid metric series %q/%q", csoID, metricID)
+	}
+	pattern := "*.jsonl"
+	if metricID != "" {
+		pattern = metricID + ".jsonl"
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, csoID, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]MetricSample, 0)
+	for _, path := range paths {
+		stored, err := readMetricSeries(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range stored {
+			if !sample.Timestamp.Before(from) && sample.Timestamp.Before(to) {
+				samples = append(samples, sample)
+			}
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+	return samples, nil
+}
+
+// readMetricSeries reads the samples of a series file. A missing file is
+// returned as the os error.
+func readMetricSeries(path string) ([]MetricSample, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metric series: %w", err)
+	}
+	defer file.Close()
+
+	var samples []MetricSample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample MetricSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, fmt.Errorf("failed to parse metric sample in %s: %w", path, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metric series: %w", err)
+	}
+	return samples, nil
+}
+
+// writeMetricSeries replaces a series file with the samples
+func writeMetricSeries(path string, samples []MetricSample) error {
+	var buf bytes.Buffer
+	for _, sample := range samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write metric series: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// DownsampleMetricSamples averages the samples of one metric into buckets of
+// the given step aligned to from. Each bucket is timestamped with its start;
+// empty buckets are skipped. A zero step returns the samples unchanged.
+func DownsampleMetricSamples(samples []MetricSample, from time.Time, step time.Duration) []MetricSample {
+	if step <= 0 || len(samples) == 0 {
+		return samples
+	}
+	buckets := make(map[int64][]MetricSample)
+	var keys []int64
+	for _, sample := range samples {
+		bucket := int64(sample.Timestamp.Sub(from) / step)
+		if sample.Timestamp.Before(from) {
+			bucket--
+		}
+		if _, ok := buckets[bucket]; !ok {
+			keys = append(keys, bucket)
+		}
+		buckets[bucket] = append(buckets[bucket], sample)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	downsampled := make([]MetricSample, 0, len(keys))
+	for _, key := range keys {
+		bucket := buckets[key]
+		downsampled = append(downsampled, MetricSample{
+			CSOID:     bucket[0].CSOID,
+			MetricID:  bucket[0].MetricID,
+			Timestamp: from.Add(time.Duration(key) * step),
+			Value:     meanSampleValue(bucket),
+		})
+	}
+	return downsampled
+}
+
+func meanSampleValue(samples []MetricSample) float64 {
+	var sum float64
+	for _, sample := range samples {
+		sum += sample.Value
+	}
+	return sum / float64(len(samples))
+}
+
+// MovingAverage returns the trailing mean over the given number of samples.
+// The first points average the samples available so far.
+func MovingAverage(samples []MetricSample, window int) []MetricSample {
+	if window < 1 {
+		window = 1
+	}
+	averages := make([]MetricSample, len(samples))
+	for i := range samples {
+		start := i - window + 1
+		if start < 0 {
+			start = 0
+		}
+		averages[i] = samples[i]
+		averages[i].Value = round2(meanSampleValue(samples[start : i+1]))
+	}
+	return averages
+}
+
+// linearFit returns the least-squares slope (per day) and intercept of the
+// samples, with time measured in days since origin
+func linearFit(samples []MetricSample, origin time.Time) (slope, intercept float64, ok bool) {
+	n := float64(len(samples))
+	if n < 2 {
+		return 0, 0, false
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.Timestamp.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += sample.Value
+		sumXY += x * sample.Value
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept, true
+}
+
+// Slopes smaller than stableSlopePerDay are treated as flat, and crossings
+// further than forecastHorizon past the last sample are not forecast
+const (
+	stableSlopePerDay = 1e-9
+	forecastHorizon   = 5 * 365 * 24 * time.Hour
+)
+
+// ThresholdForecast is the projected crossing of a threshold level. A level
+// that is neither crossed nor forecast within five years has no CrossesAt.
+type ThresholdForecast struct {
+	Level     string     `json:"level"` // warning or critical
+	Threshold float64    `json:"threshold"`
+	CrossesAt *time.Time `json:"crosses_at,omitempty"`
+	Crossed   bool       `json:"crossed"` // the latest value is already past the level
+}
+
+// MetricTrend describes how a metric moved over a period
+type MetricTrend struct {
+	MetricID         string              `json:"metric_id"`
+	From             time.Time           `json:"from"`
+	To               time.Time           `json:"to"`
+	Step             string              `json:"step,omitempty"`
+	Samples          []MetricSample      `json:"samples"`
+	MovingAverage    []MetricSample      `json:"moving_average"`
+	Latest           *float64            `json:"latest,omitempty"`
+	Mean             *float64            `json:"mean,omitempty"`
+	SlopePerDay      float64             `json:"slope_per_day"`
+	Direction        string              `json:"direction"` // rising, falling, stable or unknown
+	PeriodOverPeriod *float64            `json:"period_over_period_percent,omitempty"`
+	Forecasts        []ThresholdForecast `json:"forecasts,omitempty"`
+}
+
+// AnalyzeMetricTrend downsamples the samples of a metric in [from, to) and
+// computes their moving average, least-squares slope and the percent change of
+// the mean against the samples of the previous period. With a threshold, the
+// fitted line is extended to forecast when the warning and critical levels
+// will be crossed.
+func AnalyzeMetricTrend(metricID string, samples, previous []MetricSample, from, to time.Time, step time.Duration, window int, threshold *MetricThreshold) MetricTrend {
+	trend := MetricTrend{
+		MetricID:  metricID,
+		From:      from,
+		To:        to,
+		Samples:   DownsampleMetricSamples(samples, from, step),
+		Direction: "unknown",
+	}
+	if step > 0 {
+		trend.Step = step.String()
+	}
+	trend.MovingAverage = MovingAverage(trend.Samples, window)
+	if len(trend.Samples) == 0 {
+		return trend
+	}
+
+	latest := trend.Samples[len(trend.Samples)-1].Value
+	mean := round2(meanSampleValue(samples))
+	trend.Latest, trend.Mean = &latest, &mean
+	if len(previous) > 0 {
+		if previousMean := meanSampleValue(previous); previousMean != 0 {
+			change := round2((mean - previousMean) / math.Abs(previousMean) * 100)
+			trend.PeriodOverPeriod = &change
+		}
+	}
+
+	slope, intercept, ok := linearFit(trend.Samples, from)
+	if !ok {
+		return trend
+	}
+	trend.SlopePerDay = math.Round(slope*10000) / 10000
+	switch {
+	case math.Abs(slope) < stableSlopePerDay:
+		trend.Direction = "stable"
+	case slope > 0:
+		trend.Direction = "rising"
+	default:
+		trend.Direction = "falling"
+	}
+
+	if threshold != nil {
+		lastAt := trend.Samples[len(trend.Samples)-1].Timestamp
+		for _, level := range []struct {
+			name  string
+			value float64
+		}{{"warning", threshold.Warning}, {"critical", threshold.Critical}} {
+			forecast := ThresholdForecast{Level: level.name, Threshold: level.value}
+			breached := (threshold.Direction == ThresholdMin && latest < level.value) ||
+				(threshold.Direction == ThresholdMax && latest > level.value)
+			worsening := trend.Direction != "stable" &&
+				((threshold.Direction == ThresholdMin && slope < 0) ||
+					(threshold.Direction == ThresholdMax && slope > 0))
+			switch {
+			case breached:
+				forecast.Crossed = true
+			case worsening:
+				// Compare in days so that distant crossings are dropped
+				// before the conversion to a duration can overflow
+				days := (level.value - intercept) / slope
+				horizon := lastAt.Add(forecastHorizon).Sub(from).Hours() / 24
+				if days > horizon {
+					break
+				}
+				at := lastAt
+				if days > 0 {
+					if crossing := from.Add(time.Duration(days * 24 * float64(time.Hour))); crossing.After(lastAt) {
+						at = crossing
+					}
+				}
+				forecast.CrossesAt = &at
+			}
+			trend.Forecasts = append(trend.Forecasts, forecast)
+		}
+	}
+	return trend
+}
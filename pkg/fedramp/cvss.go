@@ -0,0 +1,549 @@
+package fedramp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CVSSVector is a parsed CVSS v3.0, v3.1 or v4.0 vector string
+type CVSSVector struct {
+	Version string            `json:"version"` // 3.0, 3.1, 4.0
+	Metrics map[string]string `json:"metrics"`
+}
+
+// cvssMetricValues lists the metrics and allowed values of each CVSS version.
+// Base metrics are mandatory, all others may be omitted or set to X.
+var cvssMetricValues = map[string]map[string][]string{
+	"3": {
+		"AV": {"N", "A", "L", "P"}, "AC": {"L", "H"}, "PR": {"N", "L", "H"}, "UI": {"N", "R"},
+		"S": {"U", "C"}, "C": {"H", "L", "N"}, "I": {"H", "L", "N"}, "A": {"H", "L", "N"},
+		"E": {"X", "H", "F", "P", "U"}, "RL": {"X", "U", "W", "T", "O"}, "RC": {"X", "C", "R", "U"},
+		"CR": {"X", "H", "M", "L"}, "IR": {"X", "H", "M", "L"}, "AR": {"X", "H", "M", "L"},
+		"MAV": {"X", "N", "A", "L", "P"}, "MAC": {"X", "L", "H"}, "MPR": {"X", "N", "L", "H"},
+		"MUI": {"X", "N", "R"}, "MS": {"X", "U", "C"}, "MC": {"X", "H", "L", "N"},
+		"MI": {"X", "H", "L", "N"}, "MA": {"X", "H", "L", "N"},
+	},
+	"4": {
+		"AV": {"N", "A", "L", "P"}, "AC": {"L", "H"}, "AT": {"N", "P"}, "PR": {"N", "L", "H"},
+		"UI": {"N", "P", "A"}, "VC": {"H", "L", "N"}, "VI": {"H", "L", "N"}, "VA": {"H", "L", "N"},
+		"SC": {"H", "L", "N"}, "SI": {"H", "L", "N"}, "SA": {"H", "L", "N"},
+		"E": {"X", "A", "P", "U"}, "CR": {"X", "H", "M", "L"}, "IR": {"X", "H", "M", "L"},
+		"AR": {"X", "H", "M", "L"}, "MAV": {"X", "N", "A", "L", "P"}, "MAC": {"X", "L", "H"},
+		"MAT": {"X", "N", "P"}, "MPR": {"X", "N", "L", "H"}, "MUI": {"X", "N", "P", "A"},
+		"MVC": {"X", "H", "L", "N"}, "MVI": {"X", "H", "L", "N"}, "MVA": {"X", "H", "L", "N"},
+		"MSC": {"X", "H", "L", "N"}, "MSI": {"X", "S", "H", "L", "N"}, "MSA": {"X", "S", "H", "L", "N"},
+		"S": {"X", "N", "P"}, "AU": {"X", "N", "Y"}, "R": {"X", "A", "U", "I"},
+		"V": {"X", "D", "C"}, "RE": {"X", "L", "M", "H"}, "U": {"X", "Clear", "Green", "Amber", "Red"},
+	},
+}
+
+var cvssBaseMetrics = map[string][]string{
+	"3": {"AV", "AC", "PR", "UI", "S", "C", "I", "A"},
+	"4": {"AV", "AC", "AT", "PR", "UI", "VC", "VI", "VA", "SC", "SI", "SA"},
+}
+
+var cvssMetricOrder = map[string][]string{
+	"3": {"AV", "AC", "PR", "UI", "S", "C", "I", "A", "E", "RL", "RC", "CR", "IR", "AR",
+		"MAV", "MAC", "MPR", "MUI", "MS", "MC", "MI", "MA"},
+	"4": {"AV", "AC", "AT", "PR", "UI", "VC", "VI", "VA", "SC", "SI", "SA", "E", "CR", "IR", "AR",
+		"MAV", "MAC", "MAT", "MPR", "MUI", "MVC", "MVI", "MVA", "MSC", "MSI", "MSA",
+		"S", "AU", "R", "V", "RE", "U"},
+}
+
+// ParseCVSSVector parses a CVSS vector string such as
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+func ParseCVSSVector(vector string) (*CVSSVector, error) {
+	parts := strings.Split(strings.TrimSpace(vector), "/")
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "CVSS:") {
+		return nil, fmt.Errorf("invalid CVSS vector: %s", vector)
+	}
+
+	v := &CVSSVector{Version: strings.TrimPrefix(parts[0], "CVSS:"), Metrics: make(map[string]string)}
+	major := v.major()
+	allowed, ok := cvssMetricValues[major]
+	if !ok || (v.Version != "3.0" && v.Version != "3.1" && v.Version != "4.0") {
+		return nil, fmt.Errorf("unsupported CVSS version: %s", v.Version)
+	}
+
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid CVSS metric: %s", part)
+		}
+		values, known := allowed[kv[0]]
+		if !known {
+			return nil, fmt.Errorf("unknown CVSS %s metric: %s", v.Version, kv[0])
+		}
+		if !containsString(values, kv[1]) {
+			return nil, fmt.Errorf("invalid value %s for CVSS metric %s", kv[1], kv[0])
+		}
+		if _, dup := v.Metrics[kv[0]]; dup {
+			return nil, fmt.Errorf("duplicate CVSS metric: %s", kv[0])
+		}
+		v.Metrics[kv[0]] = kv[1]
+	}
+
+	for _, metric := range cvssBaseMetrics[major] {
+		if _, ok := v.Metrics[metric]; !ok {
+			return nil, fmt.Errorf("CVSS vector is missing base metric %s", metric)
+		}
+	}
+	return v, nil
+}
+
+// WithMetrics returns a copy of the vector with the given metrics set. It is
+// used to apply environmental (modified) metrics for mitigating factors.
+func (v *CVSSVector) WithMetrics(metrics map[string]string) (*CVSSVector, error) {
+	allowed := cvssMetricValues[v.major()]
+	out := &CVSSVector{Version: v.Version, Metrics: make(map[string]string, len(v.Metrics)+len(metrics))}
+	for k, val := range v.Metrics {
+		out.Metrics[k] = val
+	}
+	for k, val := range metrics {
+		values, known := allowed[k]
+		if !known || !containsString(values, val) {
+			return nil, fmt.Errorf("invalid CVSS %s metric %s:%s", v.Version, k, val)
+		}
+		out.Metrics[k] = val
+	}
+	return out, nil
+}
+
+// String renders the vector in the metric order of the specification,
+// omitting metrics that are not defined
+func (v *CVSSVector) String() string {
+	parts := []string{"CVSS:" + v.Version}
+	for _, metric := range cvssMetricOrder[v.major()] {
+		if value, ok := v.Metrics[metric]; ok && value != "X" {
+			parts = append(parts, metric+":"+value)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// BaseScore returns the CVSS base score. For v4.0 this is the CVSS-B score.
+func (v *CVSSVector) BaseScore() float64 {
+	if v.major() == "4" {
+		base := &CVSSVector{Version: v.Version, Metrics: make(map[string]string)}
+		for _, metric := range cvssBaseMetrics["4"] {
+			base.Metrics[metric] = v.Metrics[metric]
+		}
+		return base.score40()
+	}
+	return v.score3(false)
+}
+
+// EnvironmentalScore returns the score after applying the threat, temporal
+// and environmental metrics of the vector. For v4.0 this is the CVSS-BTE score.
+func (v *CVSSVector) EnvironmentalScore() float64 {
+	if v.major() == "4" {
+		return v.score40()
+	}
+	return v.score3(true)
+}
+
+func (v *CVSSVector) major() string {
+	return strings.SplitN(v.Version, ".", 2)[0]
+}
+
+// metric returns the effective value of a metric, preferring the modified
+// environmental metric when it is set
+func (v *CVSSVector) metric(name string) string {
+	if modified, ok := v.Metrics["M"+name]; ok && modified != "X" {
+		return modified
+	}
+	return v.Metrics[name]
+}
+
+// CVSS v3.x metric weights
+var cvss3Weights = map[string]map[string]float64{
+	"AV":  {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC":  {"L": 0.77, "H": 0.44},
+	"UI":  {"N": 0.85, "R": 0.62},
+	"CIA": {"H": 0.56, "L": 0.22, "N": 0},
+	"E":   {"X": 1, "H": 1, "F": 0.97, "P": 0.94, "U": 0.91},
+	"RL":  {"X": 1, "U": 1, "W": 0.97, "T": 0.96, "O": 0.95},
+	"RC":  {"X": 1, "C": 1, "R": 0.96, "U": 0.92},
+	"REQ": {"X": 1, "H": 1.5, "M": 1, "L": 0.5},
+}
+
+func cvss3PrivilegeWeight(pr string, changed bool) float64 {
+	switch pr {
+	case "N":
+		return 0.85
+	case "L":
+		if changed {
+			return 0.68
+		}
+		return 0.62
+	case "H":
+		if changed {
+			return 0.5
+		}
+		return 0.27
+	}
+	return 0
+}
+
+// score3 implements the CVSS v3.0/v3.1 base and environmental equations
+func (v *CVSSVector) score3(environmental bool) float64 {
+	w := cvss3Weights
+	roundup := cvss31Roundup
+	if v.Version == "3.0" {
+		roundup = cvss30Roundup
+	}
+
+	if !environmental {
+		changed := v.Metrics["S"] == "C"
+		iss := 1 - (1-w["CIA"][v.Metrics["C"]])*(1-w["CIA"][v.Metrics["I"]])*(1-w["CIA"][v.Metrics["A"]])
+		impact := 6.42 * iss
+		if changed {
+			impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+		}
+		exploitability := 8.22 * w["AV"][v.Metrics["AV"]] * w["AC"][v.Metrics["AC"]] *
+			cvss3PrivilegeWeight(v.Metrics["PR"], changed) * w["UI"][v.Metrics["UI"]]
+		if impact <= 0 {
+			return 0
+		}
+		if changed {
+			return roundup(math.Min(1.08*(impact+exploitability), 10))
+		}
+		return roundup(math.Min(impact+exploitability, 10))
+	}
+
+	temporal := w["E"][v.metricOrX("E")] * w["RL"][v.metricOrX("RL")] * w["RC"][v.metricOrX("RC")]
+	changed := v.metric("S") == "C"
+	miss := math.Min(1-
+		(1-w["REQ"][v.metricOrX("CR")]*w["CIA"][v.metric("C")])*
+			(1-w["REQ"][v.metricOrX("IR")]*w["CIA"][v.metric("I")])*
+			(1-w["REQ"][v.metricOrX("AR")]*w["CIA"][v.metric("A")]), 0.915)
+	impact := 6.42 * miss
+	if changed {
+		if v.Version == "3.0" {
+			impact = 7.52*(miss-0.029) - 3.25*math.Pow(miss-0.02, 15)
+		} else {
+			impact = 7.52*(miss-0.029) - 3.25*math.Pow(miss*0.9731-0.02, 13)
+		}
+	}
+	exploitability := 8.22 * w["AV"][v.metric("AV")] * w["AC"][v.metric("AC")] *
+		cvss3PrivilegeWeight(v.metric("PR"), changed) * w["UI"][v.metric("UI")]
+	if impact <= 0 {
+		return 0
+	}
+	if changed {
+		return roundup(roundup(math.Min(1.08*(impact+exploitability), 10)) * temporal)
+	}
+	return roundup(roundup(math.Min(impact+exploitability, 10)) * temporal)
+}
+
+func (v *CVSSVector) metricOrX(name string) string {
+	if value, ok := v.Metrics[name]; ok {
+		return value
+	}
+	return "X"
+}
+
+// cvss31Roundup is the Roundup function defined in CVSS v3.1 Appendix A
+func cvss31Roundup(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
+
+func cvss30Roundup(x float64) float64 {
+	return math.Ceil(x*10) / 10
+}
+
+// effective40 returns the value of a v4.0 metric used for scoring, with
+// modified metrics applied and the worst-case defaults for unset threat and
+// security requirement metrics
+func (v *CVSSVector) effective40(name string) string {
+	value := v.metric(name)
+	if value == "" || value == "X" {
+		switch name {
+		case "E":
+			return "A"
+		case "CR", "IR", "AR":
+			return "H"
+		}
+	}
+	return value
+}
+
+// cvss40Levels are the severity distances between metric values, in steps of
+// 0.1, used to interpolate within a MacroVector
+var cvss40Levels = map[string]map[string]float64{
+	"AV": {"N": 0, "A": 1, "L": 2, "P": 3},
+	"PR": {"N": 0, "L": 1, "H": 2},
+	"UI": {"N": 0, "P": 1, "A": 2},
+	"AC": {"L": 0, "H": 1},
+	"AT": {"N": 0, "P": 1},
+	"VC": {"H": 0, "L": 1, "N": 2},
+	"VI": {"H": 0, "L": 1, "N": 2},
+	"VA": {"H": 0, "L": 1, "N": 2},
+	"SC": {"H": 1, "L": 2, "N": 3},
+	"SI": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"SA": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"CR": {"H": 0, "M": 1, "L": 2},
+	"IR": {"H": 0, "M": 1, "L": 2},
+	"AR": {"H": 0, "M": 1, "L": 2},
+}
+
+// cvss40MaxVectors lists the highest severity vectors of each MacroVector
+// level (CVSS v4.0 specification, section 8.2)
+var cvss40MaxVectors = struct {
+	eq1, eq2, eq4 map[int][]string
+	eq3eq6        map[int]map[int][]string
+}{
+	eq1: map[int][]string{
+		0: {"AV:N/PR:N/UI:N"},
+		1: {"AV:A/PR:N/UI:N", "AV:N/PR:L/UI:N", "AV:N/PR:N/UI:P"},
+		2: {"AV:P/PR:N/UI:N", "AV:A/PR:L/UI:P"},
+	},
+	eq2: map[int][]string{
+		0: {"AC:L/AT:N"},
+		1: {"AC:H/AT:N", "AC:L/AT:P"},
+	},
+	eq4: map[int][]string{
+		0: {"SC:H/SI:S/SA:S"},
+		1: {"SC:H/SI:H/SA:H"},
+		2: {"SC:L/SI:L/SA:L"},
+	},
+	eq3eq6: map[int]map[int][]string{
+		0: {
+			0: {"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H"},
+			1: {"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M"},
+		},
+		1: {
+			0: {"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H"},
+			1: {"VC:H/VI:L/VA:H/CR:M/IR:H/AR:M", "VC:H/VI:L/VA:L/CR:M/IR:H/AR:H",
+				"VC:L/VI:H/VA:H/CR:H/IR:M/AR:M", "VC:L/VI:H/VA:L/CR:H/IR:M/AR:H",
+				"VC:L/VI:L/VA:H/CR:H/IR:H/AR:M"},
+		},
+		2: {
+			1: {"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H"},
+		},
+	},
+}
+
+// cvss40Depth is the number of severity steps within each MacroVector level
+var cvss40Depth = struct {
+	eq1, eq2, eq4 map[int]float64
+	eq3eq6        map[int]map[int]float64
+}{
+	eq1:    map[int]float64{0: 1, 1: 4, 2: 5},
+	eq2:    map[int]float64{0: 1, 1: 2},
+	eq4:    map[int]float64{0: 6, 1: 5, 2: 4},
+	eq3eq6: map[int]map[int]float64{0: {0: 7, 1: 6}, 1: {0: 8, 1: 8}, 2: {1: 10}},
+}
+
+// macroVector40 computes the EQ1-EQ6 equivalence classes of a v4.0 vector
+func (v *CVSSVector) macroVector40() [6]int {
+	m := v.effective40
+	var eq [6]int
+
+	switch {
+	case m("AV") == "N" && m("PR") == "N" && m("UI") == "N":
+		eq[0] = 0
+	case (m("AV") == "N" || m("PR") == "N" || m("UI") == "N") && m("AV") != "P":
+		eq[0] = 1
+	default:
+		eq[0] = 2
+	}
+
+	if !(m("AC") == "L" && m("AT") == "N") {
+		eq[1] = 1
+	}
+
+	switch {
+	case m("VC") == "H" && m("VI") == "H":
+		eq[2] = 0
+	case m("VC") == "H" || m("VI") == "H" || m("VA") == "H":
+		eq[2] = 1
+	default:
+		eq[2] = 2
+	}
+
+	switch {
+	case m("SI") == "S" || m("SA") == "S":
+		eq[3] = 0
+	case m("SC") == "H" || m("SI") == "H" || m("SA") == "H":
+		eq[3] = 1
+	default:
+		eq[3] = 2
+	}
+
+	switch m("E") {
+	case "P":
+		eq[4] = 1
+	case "U":
+		eq[4] = 2
+	}
+
+	if !((m("CR") == "H" && m("VC") == "H") || (m("IR") == "H" && m("VI") == "H") || (m("AR") == "H" && m("VA") == "H")) {
+		eq[5] = 1
+	}
+	return eq
+}
+
+func cvss40Lookup(eq [6]int) float64 {
+	key := fmt.Sprintf("%d%d%d%d%d%d", eq[0], eq[1], eq[2], eq[3], eq[4], eq[5])
+	if score, ok := cvss40MacroVectorScores[key]; ok {
+		return score
+	}
+	return math.NaN()
+}
+
+// score40 implements the CVSS v4.0 scoring algorithm: the MacroVector score
+// is looked up and then reduced by the mean proportional distance of the
+// vector from the highest severity vector of its MacroVector
+func (v *CVSSVector) score40() float64 {
+	m := v.effective40
+	noImpact := true
+	for _, metric := range []string{"VC", "VI", "VA", "SC", "SI", "SA"} {
+		if m(metric) != "N" {
+			noImpact = false
+		}
+	}
+	if noImpact {
+		return 0
+	}
+
+	eq := v.macroVector40()
+	value := cvss40Lookup(eq)
+
+	next := func(i int) float64 {
+		lower := eq
+		lower[i]++
+		return cvss40Lookup(lower)
+	}
+	lowerEQ1, lowerEQ2, lowerEQ4, lowerEQ5 := next(0), next(1), next(3), next(4)
+
+	var lowerEQ3EQ6 float64
+	switch {
+	case eq[2] == 0 && eq[5] == 0:
+		lowerEQ3EQ6 = math.Max(next(2), next(5))
+	case eq[2] == 1 && eq[5] == 0:
+		lowerEQ3EQ6 = next(5)
+	default:
+		lowerEQ3EQ6 = next(2)
+	}
+
+	// Find the first highest severity vector the scored vector does not exceed
+	distance := func(metric, maxVector string) float64 {
+		for _, part := range strings.Split(maxVector, "/") {
+			if strings.HasPrefix(part, metric+":") {
+				return cvss40Levels[metric][m(metric)] - cvss40Levels[metric][strings.TrimPrefix(part, metric+":")]
+			}
+		}
+		return 0
+	}
+	var distEQ1, distEQ2, distEQ3EQ6, distEQ4 float64
+	found := false
+	for _, mx1 := range cvss40MaxVectors.eq1[eq[0]] {
+		for _, mx2 := range cvss40MaxVectors.eq2[eq[1]] {
+			for _, mx36 := range cvss40MaxVectors.eq3eq6[eq[2]][eq[5]] {
+				for _, mx4 := range cvss40MaxVectors.eq4[eq[3]] {
+					if found {
+						continue
+					}
+					d := map[string]float64{}
+					negative := false
+					for _, pair := range []struct{ metrics, max string }{
+						{"AV PR UI", mx1}, {"AC AT", mx2}, {"VC VI VA CR IR AR", mx36}, {"SC SI SA", mx4},
+					} {
+						for _, metric := range strings.Fields(pair.metrics) {
+							d[metric] = distance(metric, pair.max)
+							if d[metric] < 0 {
+								negative = true
+							}
+						}
+					}
+					if negative {
+						continue
+					}
+					distEQ1 = d["AV"] + d["PR"] + d["UI"]
+					distEQ2 = d["AC"] + d["AT"]
+					distEQ3EQ6 = d["VC"] + d["VI"] + d["VA"] + d["CR"] + d["IR"] + d["AR"]
+					distEQ4 = d["SC"] + d["SI"] + d["SA"]
+					found = true
+				}
+			}
+		}
+	}
+
+	total, lower := 0.0, 0
+	for _, step := range []struct{ next, dist, depth float64 }{
+		{lowerEQ1, distEQ1, cvss40Depth.eq1[eq[0]]},
+		{lowerEQ2, distEQ2, cvss40Depth.eq2[eq[1]]},
+		{lowerEQ3EQ6, distEQ3EQ6, cvss40Depth.eq3eq6[eq[2]][eq[5]]},
+		{lowerEQ4, distEQ4, cvss40Depth.eq4[eq[3]]},
+		// EQ5 has a single metric, so the distance within a level is always 0
+		{lowerEQ5, 0, 1},
+	} {
+		if math.IsNaN(step.next) {
+			continue
+		}
+		lower++
+		total += (value - step.next) * step.dist / step.depth
+	}
+
+	if lower > 0 {
+		value -= total / float64(lower)
+	}
+	value = math.Max(0, math.Min(10, value))
+	return math.Round(value*10) / 10
+}
+
+// cvss40MacroVectorScores maps each CVSS v4.0 MacroVector (EQ1-EQ6) to its
+// score, as published in the CVSS v4.0 specification
+var cvss40MacroVectorScores = map[string]float64{
+	"000000": 10.0, "000001": 9.9, "000010": 9.8, "000011": 9.5, "000020": 9.5, "000021": 9.2,
+	"000100": 10.0, "000101": 9.6, "000110": 9.3, "000111": 8.7, "000120": 9.1, "000121": 8.1,
+	"000200": 9.3, "000201": 9.0, "000210": 8.9, "000211": 8.0, "000220": 8.1, "000221": 6.8,
+	"001000": 9.8, "001001": 9.5, "001010": 9.5, "001011": 9.2, "001020": 9.0, "001021": 8.4,
+	"001100": 9.3, "001101": 9.2, "001110": 8.9, "001111": 8.1, "001120": 8.1, "001121": 6.5,
+	"001200": 8.8, "001201": 8.0, "001210": 7.8, "001211": 7.0, "001220": 6.9, "001221": 4.8,
+	"002001": 9.2, "002011": 8.2, "002021": 7.2, "002101": 7.9, "002111": 6.9, "002121": 5.0,
+	"002201": 6.9, "002211": 5.5, "002221": 2.7, "010000": 9.9, "010001": 9.7, "010010": 9.5,
+	"010011": 9.2, "010020": 9.2, "010021": 8.5, "010100": 9.5, "010101": 9.1, "010110": 9.0,
+	"010111": 8.3, "010120": 8.4, "010121": 7.1, "010200": 9.2, "010201": 8.1, "010210": 8.2,
+	"010211": 7.1, "010220": 7.2, "010221": 5.3, "011000": 9.5, "011001": 9.3, "011010": 9.2,
+	"011011": 8.5, "011020": 8.5, "011021": 7.3, "011100": 9.2, "011101": 8.2, "011110": 8.0,
+	"011111": 7.2, "011120": 7.0, "011121": 5.9, "011200": 8.4, "011201": 7.0, "011210": 7.1,
+	"011211": 5.2, "011220": 5.0, "011221": 3.0, "012001": 8.6, "012011": 7.5, "012021": 5.2,
+	"012101": 7.1, "012111": 5.2, "012121": 2.9, "012201": 6.3, "012211": 2.9, "012221": 1.7,
+	"100000": 9.8, "100001": 9.5, "100010": 9.4, "100011": 8.7, "100020": 9.1, "100021": 8.1,
+	"100100": 9.4, "100101": 8.9, "100110": 8.6, "100111": 7.4, "100120": 7.7, "100121": 6.4,
+	"100200": 8.7, "100201": 7.5, "100210": 7.4, "100211": 6.3, "100220": 6.3, "100221": 4.9,
+	"101000": 9.4, "101001": 8.9, "101010": 8.8, "101011": 7.7, "101020": 7.6, "101021": 6.7,
+	"101100": 8.6, "101101": 7.6, "101110": 7.4, "101111": 5.8, "101120": 5.9, "101121": 5.0,
+	"101200": 7.2, "101201": 5.7, "101210": 5.7, "101211": 5.2, "101220": 5.2, "101221": 2.5,
+	"102001": 8.3, "102011": 7.0, "102021": 5.4, "102101": 6.5, "102111": 5.8, "102121": 2.6,
+	"102201": 5.3, "102211": 2.1, "102221": 1.3, "110000": 9.5, "110001": 9.0, "110010": 8.8,
+	"110011": 7.6, "110020": 7.6, "110021": 7.0, "110100": 9.0, "110101": 7.7, "110110": 7.5,
+	"110111": 6.2, "110120": 6.1, "110121": 5.3, "110200": 7.7, "110201": 6.6, "110210": 6.8,
+	"110211": 5.9, "110220": 5.2, "110221": 3.0, "111000": 8.9, "111001": 7.8, "111010": 7.6,
+	"111011": 6.7, "111020": 6.2, "111021": 5.8, "111100": 7.4, "111101": 5.9, "111110": 5.7,
+	"111111": 5.7, "111120": 4.7, "111121": 2.3, "111200": 6.1, "111201": 5.2, "111210": 5.7,
+	"111211": 2.9, "111220": 2.4, "111221": 1.6, "112001": 7.1, "112011": 5.9, "112021": 3.0,
+	"112101": 5.8, "112111": 2.6, "112121": 1.5, "112201": 2.3, "112211": 1.3, "112221": 0.6,
+	"200000": 9.3, "200001": 8.7, "200010": 8.6, "200011": 7.2, "200020": 7.5, "200021": 5.8,
+	"200100": 8.6, "200101": 7.4, "200110": 7.4, "200111": 6.1, "200120": 5.6, "200121": 3.4,
+	"200200": 7.0, "200201": 5.4, "200210": 5.2, "200211": 4.0, "200220": 4.0, "200221": 2.2,
+	"201000": 8.5, "201001": 7.5, "201010": 7.4, "201011": 5.5, "201020": 6.2, "201021": 5.1,
+	"201100": 7.2, "201101": 5.7, "201110": 5.5, "201111": 4.1, "201120": 4.6, "201121": 1.9,
+	"201200": 5.3, "201201": 3.6, "201210": 3.4, "201211": 1.9, "201220": 1.9, "201221": 0.8,
+	"202001": 6.4, "202011": 5.1, "202021": 2.0, "202101": 4.7, "202111": 2.1, "202121": 1.1,
+	"202201": 2.4, "202211": 0.9, "202221": 0.4, "210000": 8.8, "210001": 7.5, "210010": 7.3,
+	"210011": 5.3, "210020": 6.0, "210021": 5.0, "210100": 7.3, "210101": 5.5, "210110": 5.9,
+	"210111": 4.0, "210120": 4.1, "210121": 2.0, "210200": 5.4, "210201": 4.3, "210210": 4.5,
+	"210211": 2.2, "210220": 2.0, "210221": 1.1, "211000": 7.5, "211001": 5.5, "211010": 5.8,
+	"211011": 4.5, "211020": 4.0, "211021": 2.1, "211100": 6.1, "211101": 5.1, "211110": 4.8,
+	"211111": 1.8, "211120": 2.0, "211121": 0.9, "211200": 4.6, "211201": 1.8, "211210": 1.7,
+	"211211": 0.7, "211220": 0.8, "211221": 0.2, "212001": 5.3, "212011": 2.4, "212021": 1.4,
+	"212101": 2.4, "212111": 1.2, "212121": 0.5, "212201": 1.0, "212211": 0.3, "212221": 0.1,
+}
@@ -0,0 +1,360 @@
+package fedramp
+
+import (
+	"fmt"
+	"time"
+)
+
+// DeviationType represents the kind of deviation requested for a POA&M item
+type DeviationType string
+
+const (
+	DeviationFalsePositive          DeviationType = "false-positive"
+	DeviationOperationalRequirement DeviationType = "operational-requirement"
+	DeviationRiskAdjustment         DeviationType = "risk-adjustment"
+)
+
+// DeviationStatus represents where a deviation request is in its lifecycle
+type DeviationStatus string
+
+const (
+	DeviationDraft     DeviationStatus = "draft"
+	DeviationSubmitted DeviationStatus = "submitted"
+	DeviationApproved  DeviationStatus = "approved"
+	DeviationRejected  DeviationStatus = "rejected"
+	DeviationExpired   DeviationStatus = "expired"
+)
+
+// DeviationRequest documents a request to treat a POA&M item as a false
+// positive, an operational requirement or a risk adjustment
+type DeviationRequest struct {
+	RequestID          string          `json:"request_id"`
+	ItemID             string          `json:"item_id"`
+	Type               DeviationType   `json:"type"`
+	Status             DeviationStatus `json:"status"`
+	Justification      string          `json:"justification"`
+	SupportingEvidence []string        `json:"supporting_evidence"`
+	MitigatingFactors  string          `json:"mitigating_factors,omitempty"`
+	RequestedSeverity  string          `json:"requested_severity,omitempty"` // risk adjustments only
+	OriginalSeverity   string          `json:"original_severity,omitempty"`
+	OriginalStatus     string          `json:"original_status,omitempty"`        // restored when the deviation expires
+	OriginalResidual   string          `json:"original_residual_risk,omitempty"` // restored when the deviation expires
+	OriginalMitigating string          `json:"original_mitigating_factors,omitempty"` // restored when the deviation expires
+	RequestedBy        string          `json:"requested_by"`
+	CreatedAt          time.Time       `json:"created_at"`
+	SubmittedAt        *time.Time      `json:"submitted_at,omitempty"`
+	ReviewedBy         string          `json:"reviewed_by,omitempty"` // Authorizing Official
+	ReviewedAt         *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewComments     string          `json:"review_comments,omitempty"`
+	ExpirationDate     *time.Time      `json:"expiration_date,omitempty"`
+}
+
+// CreateDeviationRequest starts a draft deviation request for a POA&M item.
+// It returns a copy of the stored request; later changes go through the
+// request ID.
+func (poam *PlanOfActionMilestones) CreateDeviationRequest(itemID string, devType DeviationType, justification, requestedBy string) (*DeviationRequest, error) {
+	if poam.findItem(itemID) == nil {
+		return nil, fmt.Errorf("POA&M item %s not found", itemID)
+	}
+	switch devType {
+	case DeviationFalsePositive, DeviationOperationalRequirement, DeviationRiskAdjustment:
+	default:
+		return nil, fmt.Errorf("unknown deviation type: %s", devType)
+	}
+
+	request := DeviationRequest{
+		RequestID:          fmt.Sprintf("DR-%s-%d", itemID, len(poam.DeviationRequests)+1),
+		ItemID:             itemID,
+		Type:               devType,
+		Status:             DeviationDraft,
+		Justification:      justification,
+		SupportingEvidence: make([]string, 0),
+		RequestedBy:        requestedBy,
+		CreatedAt:          time.Now(),
+	}
+	poam.DeviationRequests = append(poam.DeviationRequests, request)
+	poam.LastUpdated = time.Now()
+
+	return &request, nil
+}
+
+// SetDeviationAdjustment records the requested severity and mitigating
+// factors of a draft risk adjustment
+func (poam *PlanOfActionMilestones) SetDeviationAdjustment(requestID, requestedSeverity, mitigatingFactors string) error {
+	request, err := poam.GetDeviationRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.Status != DeviationDraft {
+		return fmt.Errorf("only draft deviation requests can be changed, %s is %s", requestID, request.Status)
+	}
+	if request.Type != DeviationRiskAdjustment {
+		return fmt.Errorf("%s is not a risk adjustment", requestID)
+	}
+	request.RequestedSeverity = requestedSeverity
+	request.MitigatingFactors = mitigatingFactors
+	poam.LastUpdated = time.Now()
+	return nil
+}
+
+// GetDeviationRequest retrieves a deviation request by ID
+func (poam *PlanOfActionMilestones) GetDeviationRequest(requestID string) (*DeviationRequest, error) {
+	for i := range poam.DeviationRequests {
+		if poam.DeviationRequests[i].RequestID == requestID {
+			return &poam.DeviationRequests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("deviation request %s not found", requestID)
+}
+
+// AddDeviationEvidence attaches a supporting evidence reference to a draft request
+func (poam *PlanOfActionMilestones) AddDeviationEvidence(requestID, reference string) error {
+	request, err := poam.GetDeviationRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.Status != DeviationDraft {
+		return fmt.Errorf("evidence can only be added to draft deviation requests")
+	}
+	request.SupportingEvidence = append(request.SupportingEvidence, reference)
+	return nil
+}
+
+// SubmitDeviationRequest sends a draft request to the Authorizing Official
+func (poam *PlanOfActionMilestones) SubmitDeviationRequest(requestID string) error {
+	request, err := poam.GetDeviationRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.Status != DeviationDraft {
+		return fmt.Errorf("only draft deviation requests can be submitted, %s is %s", requestID, request.Status)
+	}
+	if request.Justification == "" {
+		return fmt.Errorf("justification is required")
+	}
+	if len(request.SupportingEvidence) == 0 {
+		return fmt.Errorf("at least one piece of supporting evidence is required")
+	}
+	if request.Type == DeviationRiskAdjustment && request.RequestedSeverity == "" {
+		return fmt.Errorf("requested severity is required for risk adjustments")
+	}
+
+	now := time.Now()
+	request.Status = DeviationSubmitted
+	request.SubmittedAt = &now
+	poam.LastUpdated = now
+	return nil
+}
+
+// ApproveDeviationRequest records the AO approval and applies the requested
+// adjustment to the POA&M item
+func (poam *PlanOfActionMilestones) ApproveDeviationRequest(requestID, approvedBy, comments string, expiration *time.Time) error {
+	request, err := poam.GetDeviationRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.Status != DeviationSubmitted {
+		return fmt.Errorf("only submitted deviation requests can be approved, %s is %s", requestID, request.Status)
+	}
+	item := poam.findItem(request.ItemID)
+	if item == nil {
+		return fmt.Errorf("POA&M item %s not found", request.ItemID)
+	}
+
+	now := time.Now()
+	request.Status = DeviationApproved
+	request.ReviewedBy = approvedBy
+	request.ReviewedAt = &now
+	request.ReviewComments = comments
+	request.ExpirationDate = expiration
+	request.OriginalSeverity = item.Severity
+	request.OriginalStatus = item.Status
+	request.OriginalResidual = item.ResidualRisk
+	request.OriginalMitigating = item.MitigatingFactors
+
+	switch request.Type {
+	case DeviationFalsePositive:
+		recordChange(item, approvedBy, "false_positive", item.FalsePositive, true)
+		recordChange(item, approvedBy, "status", item.Status, "Completed")
+		recordChange(item, approvedBy, "actual_completion", item.ActualCompletion, now)
+		item.FalsePositive = true
+		item.Status = "Completed"
+		item.ActualCompletion = &now
+	case DeviationOperationalRequirement:
+		recordChange(item, approvedBy, "operational_requirement", item.OperationalRequirement, true)
+		item.OperationalRequirement = true
+		acceptance := RiskAcceptance{
+			ItemID:         item.ItemID,
+			AcceptanceDate: now,
+			AcceptedBy:     approvedBy,
+			Justification:  request.Justification,
+			ReviewDate:     now.AddDate(0, 0, 90),
+		}
+		if expiration != nil {
+			acceptance.ExpirationDate = *expiration
+		}
+		poam.RiskAdjustment.AcceptedRisks = append(poam.RiskAdjustment.AcceptedRisks, acceptance)
+	case DeviationRiskAdjustment:
+		severity := NormalizeSeverity(request.RequestedSeverity)
+		recordChange(item, approvedBy, "severity", item.Severity, severity)
+		recordChange(item, approvedBy, "residual_risk", item.ResidualRisk, severity)
+		item.Severity = severity
+		item.ResidualRisk = item.Severity
+		planned := poam.policy().DueDate(*item)
+		recordChange(item, approvedBy, "planned_completion", item.PlannedCompletion, planned)
+		item.PlannedCompletion = planned
+		if request.MitigatingFactors != "" {
+			recordChange(item, approvedBy, "mitigating_factors", item.MitigatingFactors, request.MitigatingFactors)
+			item.MitigatingFactors = request.MitigatingFactors
+		}
+		poam.RiskAdjustment.MitigatedRisks = append(poam.RiskAdjustment.MitigatedRisks, RiskMitigation{
+			ItemID:             item.ItemID,
+			MitigationStrategy: request.MitigatingFactors,
+			ImplementationDate: now,
+			ResidualRisk:       item.Severity,
+		})
+	}
+
+	poam.LastUpdated = now
+	poam.updateSummary()
+	return nil
+}
+
+// RejectDeviationRequest records the AO rejection of a submitted request
+func (poam *PlanOfActionMilestones) RejectDeviationRequest(requestID, rejectedBy, comments string) error {
+	request, err := poam.GetDeviationRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.Status != DeviationSubmitted {
+		return fmt.Errorf("only submitted deviation requests can be rejected, %s is %s", requestID, request.Status)
+	}
+
+	now := time.Now()
+	request.Status = DeviationRejected
+	request.ReviewedBy = rejectedBy
+	request.ReviewedAt = &now
+	request.ReviewComments = comments
+	poam.LastUpdated = now
+	return nil
+}
+
+// ExpireDeviationRequests marks approved requests past their expiration date
+// as expired and reverts the adjustments they applied, including the risk
+// acceptance or mitigation recorded on approval. Risk scores are recomputed
+// without the adjustment. It returns the IDs of the requests that expired.
+func (poam *PlanOfActionMilestones) ExpireDeviationRequests(now time.Time) []string {
+	expired := make([]string, 0)
+
+	for i := range poam.DeviationRequests {
+		request := &poam.DeviationRequests[i]
+		if request.Status != DeviationApproved || request.ExpirationDate == nil || !now.After(*request.ExpirationDate) {
+			continue
+		}
+		request.Status = DeviationExpired
+		expired = append(expired, request.RequestID)
+
+		item := poam.findItem(request.ItemID)
+		if item == nil {
+			continue
+		}
+		actor := "deviation-expiry:" + request.RequestID
+		switch request.Type {
+		case DeviationFalsePositive:
+			status := request.OriginalStatus
+			if status == "" {
+				status = "Open"
+			}
+			recordChange(item, actor, "false_positive", item.FalsePositive, false)
+			recordChange(item, actor, "status", item.Status, status)
+			recordChange(item, actor, "actual_completion", item.ActualCompletion, (*time.Time)(nil))
+			item.FalsePositive = false
+			item.Status = status
+			item.ActualCompletion = nil
+		case DeviationOperationalRequirement:
+			recordChange(item, actor, "operational_requirement", item.OperationalRequirement, false)
+			item.OperationalRequirement = false
+			poam.removeAcceptedRisk(request)
+		case DeviationRiskAdjustment:
+			recordChange(item, actor, "severity", item.Severity, request.OriginalSeverity)
+			recordChange(item, actor, "residual_risk", item.ResidualRisk, request.OriginalResidual)
+			item.Severity = request.OriginalSeverity
+			item.ResidualRisk = request.OriginalResidual
+			planned := poam.policy().DueDate(*item)
+			recordChange(item, actor, "planned_completion", item.PlannedCompletion, planned)
+			item.PlannedCompletion = planned
+			if item.MitigatingFactors != request.OriginalMitigating {
+				recordChange(item, actor, "mitigating_factors", item.MitigatingFactors, request.OriginalMitigating)
+				item.MitigatingFactors = request.OriginalMitigating
+			}
+			poam.removeMitigatedRisk(request)
+		}
+	}
+
+	if len(expired) > 0 {
+		poam.LastUpdated = now
+		poam.updateSummary()
+	}
+	return expired
+}
+
+// removeAcceptedRisk drops the risk acceptance recorded when the request was
+// approved
+func (poam *PlanOfActionMilestones) removeAcceptedRisk(request *DeviationRequest) {
+	kept := poam.RiskAdjustment.AcceptedRisks[:0]
+	for _, acceptance := range poam.RiskAdjustment.AcceptedRisks {
+		if acceptance.ItemID == request.ItemID && request.ReviewedAt != nil && acceptance.AcceptanceDate.Equal(*request.ReviewedAt) {
+			continue
+		}
+		kept = append(kept, acceptance)
+	}
+	poam.RiskAdjustment.AcceptedRisks = kept
+}
+
+// removeMitigatedRisk drops the risk mitigation recorded when the request was
+// approved
+func (poam *PlanOfActionMilestones) removeMitigatedRisk(request *DeviationRequest) {
+	kept := poam.RiskAdjustment.MitigatedRisks[:0]
+	for _, mitigation := range poam.RiskAdjustment.MitigatedRisks {
+		if mitigation.ItemID == request.ItemID && request.ReviewedAt != nil && mitigation.ImplementationDate.Equal(*request.ReviewedAt) {
+			continue
+		}
+		kept = append(kept, mitigation)
+	}
+	poam.RiskAdjustment.MitigatedRisks = kept
+}
+
+// ActiveDeviation returns the most recent submitted or approved deviation
+// request for an item, or nil if there is none
+func (poam *PlanOfActionMilestones) ActiveDeviation(itemID string) *DeviationRequest {
+	var active *DeviationRequest
+	for i := range poam.DeviationRequests {
+		request := &poam.DeviationRequests[i]
+		if request.ItemID != itemID {
+			continue
+		}
+		if request.Status == DeviationSubmitted || request.Status == DeviationApproved {
+			active = request
+		}
+	}
+	return active
+}
+
+func (poam *PlanOfActionMilestones) approvedDeviation(itemID string, devType DeviationType) *DeviationRequest {
+	for i := range poam.DeviationRequests {
+		request := &poam.DeviationRequests[i]
+		if request.ItemID == itemID && request.Type == devType && request.Status == DeviationApproved {
+			return request
+		}
+	}
+	return nil
+}
+
+func (poam *PlanOfActionMilestones) findItem(itemID string) *POAMItem {
+	for i := range poam.POAMItems {
+		if poam.POAMItems[i].ItemID == itemID {
+			return &poam.POAMItems[i]
+		}
+	}
+	return nil
+}
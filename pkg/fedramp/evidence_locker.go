@@ -0,0 +1,608 @@
+package fedramp
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EvidenceLockerIDPrefix starts every locker ID; the rest is the SHA-256
+// digest of the evidence content
+const EvidenceLockerIDPrefix = "sha256:"
+
+// Chain of custody actions
+const (
+	CustodyIngest  = "ingest"
+	CustodyAccess  = "access"
+	CustodyExport  = "export"
+	CustodyVerify  = "verify"
+	CustodyDispose = "dispose"
+)
+
+// EvidenceRecord describes a file held in the evidence locker
+type EvidenceRecord struct {
+	LockerID     string     `json:"locker_id"`
+	Digest       string     `json:"digest"`
+	Size         int64      `json:"size"`
+	Filename     string     `json:"filename,omitempty"`
+	Type         string     `json:"type,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	Collector    string     `json:"collector"`
+	CollectedAt  time.Time  `json:"collected_at"`
+	SourceSystem string     `json:"source_system,omitempty"`
+	RetainUntil  time.Time  `json:"retain_until"`
+	IngestedAt   time.Time  `json:"ingested_at"`
+	DisposedAt   *time.Time `json:"disposed_at,omitempty"`
+}
+
+// CustodyEvent is an entry of the chain of custody log. Each entry carries
+// the hash of the one before it, so removed or edited entries break the chain.
+type CustodyEvent struct {
+	Sequence     int       `json:"sequence"`
+	Time         time.Time `json:"time"`
+	LockerID     string    `json:"locker_id"`
+	Action       string    `json:"action"`
+	Actor        string    `json:"actor"`
+	Purpose      string    `json:"purpose,omitempty"`
+	Outcome      string    `json:"outcome,omitempty"`
+	PreviousHash string    `json:"previous_hash,omitempty"`
+}
+
+// EvidenceLocker stores evidence by content digest and records every access
+// to it
+type EvidenceLocker interface {
+	// Ingest stores content once; ingesting the same content again returns
+	// the existing record with the later retention date
+	Ingest(content io.Reader, record EvidenceRecord, actor string) (*EvidenceRecord, error)
+	Get(lockerID string) (*EvidenceRecord, error)
+	List() ([]*EvidenceRecord, error)
+	// Open reads evidence content; reading fails at the end if the content
+	// does not match its digest
+	Open(lockerID, actor, purpose string) (io.ReadCloser, error)
+	Export(lockerID string, w io.Writer, actor, purpose string) error
+	// Verify rehashes the stored content and returns ErrEvidenceIntegrity
+	// when it no longer matches the locker ID
+	Verify(lockerID, actor, purpose string) (*EvidenceRecord, error)
+	// Custody returns the chain of custody of one item, or of the whole
+	// locker when lockerID is empty
+	Custody(lockerID string) ([]CustodyEvent, error)
+	// Dispose deletes evidence content whose retention date has passed,
+	// keeping its record, and returns ErrEvidenceRetained before then
+	Dispose(lockerID, actor, purpose string) (*EvidenceRecord, error)
+}
+
+// ErrEvidenceNotFound is returned by lockers for unknown locker IDs
+var ErrEvidenceNotFound = errors.New("evidence not found")
+
+// ErrEvidenceIntegrity is returned when stored evidence does not match its
+// digest
+var ErrEvidenceIntegrity = errors.New("evidence failed its integrity check")
+
+// ErrEvidenceRetained is returned when evidence is disposed of before its
+// retention date
+var ErrEvidenceRetained = errors.New("evidence is under retention")
+
+// ErrEvidenceDisposed is returned when disposed evidence is read or verified
+var ErrEvidenceDisposed = errors.New("evidence was disposed of")
+
+// ParseEvidenceLockerID returns the hex digest of a locker ID
+func ParseEvidenceLockerID(lockerID string) (string, error) {
+	digest := strings.TrimPrefix(lockerID, EvidenceLockerIDPrefix)
+	if digest == lockerID || len(digest) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid evidence locker ID: %q", lockerID)
+	}
+	if _, err := hex.DecodeString(digest); err != nil || strings.ToLower(digest) != digest {
+		return "", fmt.Errorf("invalid evidence locker ID: %q", lockerID)
+	}
+	return digest, nil
+}
+
+// FileEvidenceLocker keeps evidence content under objects/sha256, a JSON
+// record per item under records and the chain of custody in custody.jsonl
+type FileEvidenceLocker struct {
+	dir      string
+	mu       sync.RWMutex
+	custody  sync.Mutex
+	lastHash string
+	lastSeq  int
+	logSize  int64 // size of the custody log after the last event written
+	loaded   bool
+}
+
+// NewFileEvidenceLocker creates a file locker rooted at dir, creating it if
+// needed
+func NewFileEvidenceLocker(dir string) (*FileEvidenceLocker, error) {
+	for _, sub := range []string{"objects/sha256", "records", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(sub)), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create evidence locker: %w", err)
+		}
+	}
+	return &FileEvidenceLocker{dir: dir}, nil
+}
+
+func (l *FileEvidenceLocker) objectPath(digest string) string {
+	return filepath.Join(l.dir, "objects", "sha256", digest[:2], digest)
+}
+
+func (l *FileEvidenceLocker) recordPath(digest string) string {
+	return filepath.Join(l.dir, "records", digest+".json")
+}
+
+// Ingest copies content into the locker and records who collected it
+func (l *FileEvidenceLocker) Ingest(content io.Reader, record EvidenceRecord, actor string) (*EvidenceRecord, error) {
+	if record.Collector == "" {
+		return nil, fmt.Errorf("evidence collector is required")
+	}
+	if record.RetainUntil.IsZero() {
+		return nil, fmt.Errorf("evidence retention date is required")
+	}
+	tmp, err := os.CreateTemp(filepath.Join(l.dir, "tmp"), "ingest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store evidence: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store evidence: %w", err)
+	}
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	now := time.Now()
+	record.LockerID = EvidenceLockerIDPrefix + digest
+	record.Digest = digest
+	record.Size = size
+	record.IngestedAt = now
+	if record.CollectedAt.IsZero() {
+		record.CollectedAt = now
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	outcome := "stored"
+	existing, err := readEvidenceRecord(l.recordPath(digest))
+	switch {
+	case err == nil && existing.DisposedAt == nil:
+		// The content is already held; keep the original provenance and
+		// only extend its retention
+		outcome = "already stored"
+		if record.RetainUntil.After(existing.RetainUntil) {
+			existing.RetainUntil = record.RetainUntil
+			if err := writeEvidenceRecord(l.recordPath(digest), existing); err != nil {
+				return nil, err
+			}
+		}
+		record = *existing
+	case err == nil || errors.Is(err, ErrEvidenceNotFound):
+		// New content, or content disposed of and collected again
+		path := l.objectPath(digest)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to store evidence: %w", err)
+		}
+		if err := os.Chmod(tmp.Name(), 0444); err != nil {
+			return nil, fmt.Errorf("failed to store evidence: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, fmt.Errorf("failed to store evidence: %w", err)
+		}
+		if err := writeEvidenceRecord(l.recordPath(digest), &record); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	if err := l.logCustody(record.LockerID, CustodyIngest, actor, record.Description, outcome); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Get returns the record of an evidence item
+func (l *FileEvidenceLocker) Get(lockerID string) (*EvidenceRecord, error) {
+	digest, err := ParseEvidenceLockerID(lockerID)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return readEvidenceRecord(l.recordPath(digest))
+}
+
+// List returns every evidence record, oldest ingest first
+func (l *FileEvidenceLocker) List() ([]*EvidenceRecord, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	paths, err := filepath.Glob(filepath.Join(l.dir, "records", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*EvidenceRecord, 0, len(paths))
+	for _, path := range paths {
+		record, err := readEvidenceRecord(path)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].IngestedAt.Before(records[j].IngestedAt)
+	})
+	return records, nil
+}
+
+// Open logs an access and opens the evidence content
+func (l *FileEvidenceLocker) Open(lockerID, actor, purpose string) (io.ReadCloser, error) {
+	return l.open(lockerID, CustodyAccess, actor, purpose)
+}
+
+// Export logs an export and copies the evidence content to w
+func (l *FileEvidenceLocker) Export(lockerID string, w io.Writer, actor, purpose string) error {
+	content, err := l.open(lockerID, CustodyExport, actor, purpose)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if _, err := io.Copy(w, content); err != nil {
+		return fmt.Errorf("failed to export evidence %s: %w", lockerID, err)
+	}
+	return nil
+}
+
+func (l *FileEvidenceLocker) open(lockerID, action, actor, purpose string) (io.ReadCloser, error) {
+	digest, err := ParseEvidenceLockerID(lockerID)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	record, err := readEvidenceRecord(l.recordPath(digest))
+	if err != nil {
+		return nil, err
+	}
+	if record.DisposedAt != nil {
+		return nil, fmt.Errorf("evidence %s: %w", lockerID, ErrEvidenceDisposed)
+	}
+	file, err := os.Open(l.objectPath(digest))
+	if err != nil {
+		return nil, fmt.Errorf("evidence %s is missing: %w", lockerID, err)
+	}
+	if err := l.logCustody(lockerID, action, actor, purpose, ""); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &verifyingReader{file: file, hash: sha256.New(), digest: digest}, nil
+}
+
+// Verify rehashes evidence content and logs the outcome
+func (l *FileEvidenceLocker) Verify(lockerID, actor, purpose string) (*EvidenceRecord, error) {
+	digest, err := ParseEvidenceLockerID(lockerID)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	record, err := readEvidenceRecord(l.recordPath(digest))
+	if err != nil {
+		return nil, err
+	}
+	hash, size, err := HashDocumentFile(l.objectPath(digest))
+	outcome := "verified"
+	switch {
+	case record.DisposedAt != nil:
+		outcome = "disposed"
+		err = fmt.Errorf("evidence %s: %w", lockerID, ErrEvidenceDisposed)
+	case err != nil:
+		outcome = "missing"
+		err = fmt.Errorf("evidence %s is missing: %w", lockerID, err)
+	case hash != digest || size != record.Size:
+		outcome = "failed"
+		err = fmt.Errorf("evidence %s: %w", lockerID, ErrEvidenceIntegrity)
+	}
+	if logErr := l.logCustody(lockerID, CustodyVerify, actor, purpose, outcome); logErr != nil {
+		return nil, logErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Dispose deletes the content of evidence past its retention date. The record
+// is kept, so citations of disposed evidence fail with ErrEvidenceDisposed,
+// and refused disposals are logged too.
+func (l *FileEvidenceLocker) Dispose(lockerID, actor, purpose string) (*EvidenceRecord, error) {
+	digest, err := ParseEvidenceLockerID(lockerID)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	record, err := readEvidenceRecord(l.recordPath(digest))
+	if err != nil {
+		return nil, err
+	}
+	if record.DisposedAt != nil {
+		return nil, fmt.Errorf("evidence %s: %w", lockerID, ErrEvidenceDisposed)
+	}
+	now := time.Now()
+	if now.Before(record.RetainUntil) {
+		if err := l.logCustody(lockerID, CustodyDispose, actor, purpose, "refused"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("evidence %s is retained until %s: %w", lockerID, record.RetainUntil.Format("2006-01-02"), ErrEvidenceRetained)
+	}
+	if err := l.logCustody(lockerID, CustodyDispose, actor, purpose, "disposed"); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(l.objectPath(digest)); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to dispose of evidence %s: %w", lockerID, err)
+	}
+	record.DisposedAt = &now
+	if err := writeEvidenceRecord(l.recordPath(digest), record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Custody reads the chain of custody and checks that it is unbroken
+func (l *FileEvidenceLocker) Custody(lockerID string) ([]CustodyEvent, error) {
+	if lockerID != "" {
+		if _, err := ParseEvidenceLockerID(lockerID); err != nil {
+			return nil, err
+		}
+	}
+	l.custody.Lock()
+	defer l.custody.Unlock()
+	all, _, err := l.readCustody()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]CustodyEvent, 0)
+	for _, event := range all {
+		if lockerID == "" || event.LockerID == lockerID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (l *FileEvidenceLocker) custodyPath() string {
+	return filepath.Join(l.dir, "custody.jsonl")
+}
+
+// readCustody returns the custody events and the hash of the last line,
+// failing when a line does not link to the one before it
+func (l *FileEvidenceLocker) readCustody() ([]CustodyEvent, string, error) {
+	file, err := os.Open(l.custodyPath())
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read custody log: %w", err)
+	}
+	defer file.Close()
+	var events []CustodyEvent
+	previous := ""
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event CustodyEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, "", fmt.Errorf("failed to parse custody log entry %d: %w", len(events)+1, err)
+		}
+		if event.Sequence != len(events)+1 || event.PreviousHash != previous {
+			return nil, "", fmt.Errorf("custody log is broken at entry %d", len(events)+1)
+		}
+		events = append(events, event)
+		previous = sha256Hex(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to read custody log: %w", err)
+	}
+	return events, previous, nil
+}
+
+func (l *FileEvidenceLocker) logCustody(lockerID, action, actor, purpose, outcome string) error {
+	if actor == "" {
+		return fmt.Errorf("an actor is required to %s evidence", action)
+	}
+	l.custody.Lock()
+	defer l.custody.Unlock()
+	unlock, err := l.lockCustodyFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// Another process sharing the locker, such as the CLI next to the API
+	// server, may have appended events since the chain head was cached
+	var size int64
+	if info, err := os.Stat(l.custodyPath()); err == nil {
+		size = info.Size()
+	}
+	if !l.loaded || size != l.logSize {
+		events, last, err := l.readCustody()
+		if err != nil {
+			return err
+		}
+		l.lastSeq, l.lastHash, l.loaded = len(events), last, true
+	}
+	event := CustodyEvent{
+		Sequence:     l.lastSeq + 1,
+		Time:         time.Now(),
+		LockerID:     lockerID,
+		Action:       action,
+		Actor:        actor,
+		Purpose:      purpose,
+		Outcome:      outcome,
+		PreviousHash: l.lastHash,
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.custodyPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write custody log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write custody log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write custody log: %w", err)
+	}
+	l.lastSeq, l.lastHash = event.Sequence, sha256Hex(line)
+	l.logSize = size + int64(len(line)+1)
+	return nil
+}
+
+// Custody lock file timings: how long to wait for another process, and how
+// old a lock must be to have been left behind by a process that died
+const (
+	custodyLockTimeout = 10 * time.Second
+	custodyLockStale   = time.Minute
+)
+
+// lockCustodyFile serializes custody log appends across processes with a lock
+// file created exclusively next to the log. The returned function releases
+// the lock.
+func (l *FileEvidenceLocker) lockCustodyFile() (func(), error) {
+	path := filepath.Join(l.dir, "custody.lock")
+	deadline := time.Now().Add(custodyLockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock custody log: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > custodyLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("custody log is locked by another process (%s)", path)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// verifyingReader hashes evidence as it is read and reports an integrity
+// failure instead of the final EOF when the content does not match
+type verifyingReader struct {
+	file   *os.File
+	hash   hash.Hash
+	digest string
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.file.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.hash.Sum(nil)) != r.digest {
+		return n, ErrEvidenceIntegrity
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.file.Close()
+}
+
+func readEvidenceRecord(path string) (*EvidenceRecord, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrEvidenceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read evidence record: %w", err)
+	}
+	var record EvidenceRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse evidence record %s: %w", filepath.Base(path), err)
+	}
+	return &record, nil
+}
+
+func writeEvidenceRecord(path string, record *EvidenceRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write evidence record %s: %w", record.LockerID, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// VerifyCitedEvidence verifies every locker ID cited in a report, logging
+// the citation in the chain of custody, and returns the records by ID
+func VerifyCitedEvidence(locker EvidenceLocker, actor, citedIn string, lockerIDs []string) (map[string]*EvidenceRecord, error) {
+	records := make(map[string]*EvidenceRecord)
+	for _, id := range lockerIDs {
+		if id == "" || records[id] != nil {
+			continue
+		}
+		if locker == nil {
+			return nil, fmt.Errorf("%s cites locker evidence %s but no evidence locker is available", citedIn, id)
+		}
+		record, err := locker.Verify(id, actor, "cited in "+citedIn)
+		if errors.Is(err, ErrEvidenceNotFound) {
+			return nil, fmt.Errorf("%s cites unknown evidence %s: %w", citedIn, id, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s cites %w", citedIn, err)
+		}
+		records[id] = record
+	}
+	return records, nil
+}
+
+// VerifyEvidence verifies the locker evidence of a SAR and records its
+// digest as the evidence hash
+func (sar *SecurityAssessmentReport) VerifyEvidence(locker EvidenceLocker, actor string) error {
+	var ids []string
+	for _, evidence := range sar.Evidence {
+		ids = append(ids, evidence.LockerID)
+	}
+	records, err := VerifyCitedEvidence(locker, actor, "SAR "+sar.ReportID, ids)
+	if err != nil {
+		return err
+	}
+	for i, evidence := range sar.Evidence {
+		if record := records[evidence.LockerID]; record != nil {
+			sar.Evidence[i].Hash = record.Digest
+		}
+	}
+	return nil
+}
+
+// VerifyEvidence verifies the locker evidence cited by the validations of a
+// KSI report
+func (r *KSIReport) VerifyEvidence(locker EvidenceLocker, actor string) error {
+	var ids []string
+	for _, validation := range r.Validations {
+		for _, evidence := range validation.Evidence {
+			ids = append(ids, evidence.LockerID)
+		}
+	}
+	_, err := VerifyCitedEvidence(locker, actor, "KSI report "+r.ReportID, ids)
+	return err
+}
@@ -0,0 +1,119 @@
+package fedramp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvidenceCustodyAcrossLockers(t *testing.T) {
+	dir := t.TempDir()
+	first, err := NewFileEvidenceLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := first.Ingest(strings.NewReader("scan results"), EvidenceRecord{
+		Collector:   "3pao",
+		RetainUntil: time.Now().AddDate(3, 0, 0),
+	}, "assessor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each locker stands in for a separate process sharing the directory,
+	// such as the CLI next to the API server
+	lockers := []*FileEvidenceLocker{first}
+	for i := 0; i < 7; i++ {
+		locker, err := NewFileEvidenceLocker(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lockers = append(lockers, locker)
+	}
+	const rounds = 50
+	var wg sync.WaitGroup
+	for _, locker := range lockers {
+		wg.Add(1)
+		go func(locker *FileEvidenceLocker) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if _, err := locker.Verify(record.LockerID, "auditor", "spot check"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(locker)
+	}
+	wg.Wait()
+
+	events, err := first.Custody(record.LockerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1 + len(lockers)*rounds; len(events) != want {
+		t.Errorf("custody events = %d, want %d", len(events), want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "custody.lock")); !os.IsNotExist(err) {
+		t.Errorf("custody lock left behind: %v", err)
+	}
+}
+
+func TestEvidenceCustodyStaleLock(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := NewFileEvidenceLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := filepath.Join(dir, "custody.lock")
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * custodyLockStale)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.Ingest(strings.NewReader("policy"), EvidenceRecord{
+		Collector:   "isso",
+		RetainUntil: time.Now().AddDate(3, 0, 0),
+	}, "isso"); err != nil {
+		t.Errorf("ingest with a stale lock: %v", err)
+	}
+}
+
+func TestEvidenceCustodyWaitsForLock(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := NewFileEvidenceLocker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := locker.Ingest(strings.NewReader("scan results"), EvidenceRecord{
+		Collector:   "3pao",
+		RetainUntil: time.Now().AddDate(3, 0, 0),
+	}, "assessor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process holds the custody lock
+	lock := filepath.Join(dir, "custody.lock")
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := locker.Verify(record.LockerID, "auditor", "spot check")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("custody event logged while another process held the lock: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	os.Remove(lock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
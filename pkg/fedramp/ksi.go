@@ -22,6 +22,7 @@ type KSIEvidence struct {
 	Reference   string    `json:"reference"`
 	Timestamp   time.Time `json:"timestamp"`
 	Source      string    `json:"source"`
+	LockerID    string    `json:"locker_id,omitempty"`
 }
 
 // KSIValidation represents a single KSI validation result
@@ -348,7 +349,8 @@ func NewKSIValidation(csoID string) *KSIValidation {
 	return validation
 }
 
-// GenerateKSIReport generates a complete KSI report for a CSO
+// GenerateKSIReport generates a KSI report for a CSO from demonstration
+// evidence. Use BuildKSIReport for reports on submitted evidence.
 func GenerateKSIReport(csoID string, reportDate time.Time) *KSIReport {
 	report := NewKSIReport(csoID)
 	
@@ -372,4 +374,16 @@ func GenerateKSIReport(csoID string, reportDate time.Time) *KSIReport {
 	}
 	
 	return report
-}
\ No newline at end of file
+}
+// BuildKSIReport validates every KSI of a CSO against the evidence stored for
+// it. KSIs without evidence are reported as False.
+func BuildKSIReport(csoID string, evidence map[string][]KSIEvidence) *KSIReport {
+	report := NewKSIReport(csoID)
+	for ksiID := range KSIDefinitions {
+		validation := ValidateKSI(ksiID, evidence[ksiID], false)
+		if validation != nil {
+			report.AddValidation(validation)
+		}
+	}
+	return report
+}
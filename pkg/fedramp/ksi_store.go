@@ -0,0 +1,117 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// KSIEvidenceStore persists the evidence submitted for the KSIs of each
+// service offering. A service offering is known once evidence has been
+// stored for it.
+type KSIEvidenceStore interface {
+	SaveKSIEvidence(csoID, ksiID string, evidence []KSIEvidence) error
+	GetKSIEvidence(csoID string) (map[string][]KSIEvidence, error)
+	ListKSIServiceOfferings() ([]string, error)
+}
+
+// ErrKSIEvidenceNotFound is returned by stores when no evidence has been
+// stored for a service offering
+var ErrKSIEvidenceNotFound = errors.New("KSI evidence not found")
+
+// FileKSIEvidenceStore keeps the KSI evidence of each service offering in a
+// JSON file
+type FileKSIEvidenceStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileKSIEvidenceStore creates a file store rooted at dir, creating it if
+// needed
+func NewFileKSIEvidenceStore(dir string) (*FileKSIEvidenceStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create KSI evidence store: %w", err)
+	}
+	return &FileKSIEvidenceStore{dir: dir}, nil
+}
+
+func (s *FileKSIEvidenceStore) path(csoID string) (string, error) {
+	if csoID == "" || strings.ContainsAny(csoID, `/\`) || csoID == "." || csoID == ".." {
+		return "", fmt.Errorf("invalid CSO ID: %q", csoID)
+	}
+	return filepath.Join(s.dir, csoID+".json"), nil
+}
+
+// SaveKSIEvidence replaces the evidence of one KSI of a service offering
+func (s *FileKSIEvidenceStore) SaveKSIEvidence(csoID, ksiID string, evidence []KSIEvidence) error {
+	path, err := s.path(csoID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := readKSIEvidence(path)
+	if err == ErrKSIEvidenceNotFound {
+		stored = make(map[string][]KSIEvidence)
+	} else if err != nil {
+		return err
+	}
+	stored[ksiID] = evidence
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal KSI evidence: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write KSI evidence for %s: %w", csoID, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// GetKSIEvidence reads the evidence of every KSI of a service offering
+func (s *FileKSIEvidenceStore) GetKSIEvidence(csoID string) (map[string][]KSIEvidence, error) {
+	path, err := s.path(csoID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return readKSIEvidence(path)
+}
+
+// ListKSIServiceOfferings returns the service offerings with stored evidence
+func (s *FileKSIEvidenceStore) ListKSIServiceOfferings() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	csoIDs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		csoIDs = append(csoIDs, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	sort.Strings(csoIDs)
+	return csoIDs, nil
+}
+
+func readKSIEvidence(path string) (map[string][]KSIEvidence, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrKSIEvidenceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KSI evidence: %w", err)
+	}
+	var evidence map[string][]KSIEvidence
+	if err := json.Unmarshal(data, &evidence); err != nil {
+		return nil, fmt.Errorf("failed to parse KSI evidence %s: %w", filepath.Base(path), err)
+	}
+	return evidence, nil
+}
@@ -3,6 +3,7 @@ package fedramp
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -27,6 +28,12 @@ type MASRequirement struct {
 	MinimumEvidence  []string          `json:"minimum_evidence"`
 	RelatedControls  []string          `json:"related_controls"`
 	AutomationLevel  string            `json:"automation_level"` // full, partial, manual
+	KeyWord          string            `json:"key_word,omitempty"` // MUST, SHOULD, MAY from the FRMR statement
+	ImpactLevels     []string          `json:"impact_levels,omitempty"` // empty applies to every impact level
+	AppliesWhen      string            `json:"applies_when,omitempty"` // FRMR applicability condition
+	Objectives       []string          `json:"assessment_objectives,omitempty"`
+	Source           string            `json:"source,omitempty"` // FRMR document and release
+	Inferred         bool              `json:"inferred,omitempty"` // methods and evidence inferred from the statement wording
 }
 
 // MASAssessment represents an assessment following MAS
@@ -34,6 +41,7 @@ type MASAssessment struct {
 	AssessmentID      string                     `json:"assessment_id"`
 	ServiceOfferingID string                     `json:"service_offering_id"`
 	AssessmentType    MASAssessmentType          `json:"assessment_type"`
+	ImpactLevel       string                     `json:"impact_level,omitempty"` // Low, Moderate, High
 	StartDate         time.Time                  `json:"start_date"`
 	EndDate           *time.Time                 `json:"end_date,omitempty"`
 	Status            string                     `json:"status"` // planned, in_progress, complete
@@ -62,6 +70,12 @@ type AssessmentScope struct {
 	Locations           []string `json:"locations"`
 	DataTypes           []string `json:"data_types"`
 	UserPopulation      int      `json:"user_population"`
+	// InapplicableRequirements justifies conditional MAS requirements whose
+	// condition does not hold, by requirement ID
+	InapplicableRequirements map[string]string `json:"inapplicable_requirements,omitempty"`
+	// Conditions answers FRMR applicability conditions that cannot be
+	// evaluated from the assessment, by condition text
+	Conditions map[string]bool `json:"conditions,omitempty"`
 }
 
 // AssessmentMethod describes how assessment is performed
@@ -96,6 +110,7 @@ type AssessmentEvidence struct {
 	CollectionDate  time.Time `json:"collection_date"`
 	CollectedBy     string    `json:"collected_by"`
 	Location        string    `json:"location"`
+	LockerID        string    `json:"locker_id,omitempty"`
 	ControlsCovered []string  `json:"controls_covered"`
 	Automated       bool      `json:"automated"`
 }
@@ -209,38 +224,24 @@ func (a *MASAssessment) Complete() {
 	a.Status = "complete"
 }
 
-// GetRequirements returns the MAS requirements for this assessment type
+// GetRequirements returns the built-in MAS requirements for this assessment
+// type, used when no FRMR MAS document is given
 func (a *MASAssessment) GetRequirements() []MASRequirement {
 	return MASRequirements[a.AssessmentType]
 }
 
 // ValidateCompleteness checks if assessment meets MAS requirements
 func (a *MASAssessment) ValidateCompleteness() error {
-	requirements := a.GetRequirements()
-	
-	for _, req := range requirements {
-		// Check if required methods were used
-		methodsUsed := make(map[string]bool)
-		for _, method := range a.Methods {
-			methodsUsed[method.MethodType] = true
-		}
-		
-		for _, reqMethod := range req.RequiredMethods {
-			if !methodsUsed[reqMethod] {
-				return fmt.Errorf("missing required assessment method: %s for %s", reqMethod, req.Name)
-			}
-		}
-		
-		// Check if minimum evidence types are present
-		evidenceTypes := make(map[string]bool)
-		for _, evidence := range a.Evidence {
-			evidenceTypes[evidence.Type] = true
-		}
-		
-		for _, reqEvidence := range req.MinimumEvidence {
-			if !evidenceTypes[reqEvidence] {
-				return fmt.Errorf("missing required evidence type: %s for %s", reqEvidence, req.Name)
-			}
+	return a.ValidateAgainst(a.GetRequirements())
+}
+
+// ValidateAgainst checks the assessment against MAS requirements and reports
+// the first mandatory requirement it does not satisfy
+func (a *MASAssessment) ValidateAgainst(requirements []MASRequirement) error {
+	report := a.Validate(requirements)
+	for _, result := range report.Results {
+		if result.Mandatory && result.Status != MASRequirementSatisfied && result.Status != MASRequirementNotApplicable {
+			return fmt.Errorf("requirement %s is %s: %s", result.RequirementID, result.Status, strings.Join(result.Gaps, "; "))
 		}
 	}
 	
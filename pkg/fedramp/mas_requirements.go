@@ -0,0 +1,412 @@
+package fedramp
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocomply/fedramp/pkg/fedramp/frmr"
+)
+
+// masMethodTerms map words of FRMR statements and assessment objectives onto
+// the assessment methods that verify them. Only whole words match, so the
+// lists spell out each word form.
+var masMethodTerms = map[string]*regexp.Regexp{
+	"examine": masWords("document", "documents", "documented", "documentation", "describe", "describes",
+		"described", "record", "records", "recorded", "justify", "justifies", "justified", "justification",
+		"metadata", "inventory", "inventories"),
+	"interview": masWords("interview", "interviews", "interviewed", "personnel", "staff", "training",
+		"trained", "awareness"),
+	"test": masWords("test", "tests", "tested", "testing", "scan", "scans", "scanned", "scanning",
+		"penetration", "exercise", "exercises", "exercised", "demonstrate", "demonstrates", "demonstrated"),
+}
+
+// masEvidenceTerms map words of FRMR statements onto the evidence types that
+// show a requirement was assessed
+var masEvidenceTerms = []struct {
+	terms    *regexp.Regexp
+	evidence string
+}{
+	{masWords("information flow", "information flows"), "data_flow_diagrams"},
+	{masWords("third-party", "third party", "third-parties", "third parties"), "third_party_inventory"},
+	{masWords("information resource", "information resources", "metadata"), "inventory"},
+	{masWords("impact level", "impact levels"), "categorization"},
+	{masWords("boundary", "boundaries", "minimum assessment scope"), "boundary_documentation"},
+	{masWords("interface", "interfaces", "interconnection", "interconnections", "interconnected"), "interconnection_documentation"},
+	{masWords("compensating", "mitigation", "mitigations"), "risk_documentation"},
+	{masWords("configuration", "configurations"), "configurations"},
+	{masWords("policy", "policies"), "policies"},
+	{masWords("penetration"), "pentest_report"},
+	{masWords("scan", "scans", "scanned", "scanning"), "scan_reports"},
+	{masWords("test", "tests", "tested", "testing"), "test_results"},
+}
+
+// masWords matches any of the words or phrases as whole words in lower case
+// text
+func masWords(words ...string) *regexp.Regexp {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// MASRequirementsFromFRMR builds MAS requirements from the FRR statements of
+// an FRMR MAS document. Assessment methods and minimum evidence are derived
+// from the wording of each statement and its assessment objectives.
+func MASRequirementsFromFRMR(doc *frmr.FRMRDocument) ([]MASRequirement, error) {
+	source := fmt.Sprintf("%s %s", doc.Info.ShortName, doc.Info.CurrentRelease)
+
+	keys := make([]string, 0, len(doc.FRR))
+	for key := range doc.FRR {
+		if strings.HasPrefix(strings.ToUpper(key), "MAS") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var requirements []MASRequirement
+	for _, key := range keys {
+		for _, item := range doc.FRR[key].Base.Requirements {
+			statement := item.Statement
+			req := MASRequirement{
+				ID:              item.ID,
+				Description:     statement,
+				Frequency:       item.Frequency,
+				KeyWord:         strings.ToUpper(item.PrimaryKeyWord),
+				ImpactLevels:    item.AppliedImpactLevels,
+				Objectives:      item.AssessmentObjectives,
+				RelatedControls: make([]string, 0),
+				AutomationLevel: "manual",
+				Source:          source,
+				Inferred:        true,
+			}
+			if item.Condition != nil {
+				req.AppliesWhen = item.Condition.AppliesWhen
+				if item.Condition.Statement != "" {
+					req.Description = item.Condition.Statement
+				}
+			}
+			req.Name = masRequirementName(req.Description)
+			if req.KeyWord == "" {
+				req.KeyWord = masKeyWord(req.Description)
+			}
+
+			text := strings.ToLower(strings.Join(append([]string{req.Description}, req.Objectives...), " "))
+			for _, method := range []string{"examine", "interview", "test"} {
+				if masMethodTerms[method].MatchString(text) {
+					req.RequiredMethods = append(req.RequiredMethods, method)
+				}
+			}
+			if len(req.RequiredMethods) == 0 {
+				req.RequiredMethods = []string{"examine"}
+			}
+			req.MinimumEvidence = make([]string, 0)
+			for _, t := range masEvidenceTerms {
+				if t.terms.MatchString(text) && !containsString(req.MinimumEvidence, t.evidence) {
+					req.MinimumEvidence = append(req.MinimumEvidence, t.evidence)
+				}
+			}
+			requirements = append(requirements, req)
+		}
+	}
+	if len(requirements) == 0 {
+		return nil, fmt.Errorf("FRMR document %s does not define MAS requirements", doc.Info.ShortName)
+	}
+	return requirements, nil
+}
+
+// LoadMASRequirementsFromFRMR reads an FRMR MAS document and builds its
+// requirements
+func LoadMASRequirementsFromFRMR(path string) ([]MASRequirement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FRMR document: %w", err)
+	}
+	defer f.Close()
+
+	doc, err := frmr.ParseFRMR(f)
+	if err != nil {
+		return nil, err
+	}
+	return MASRequirementsFromFRMR(doc)
+}
+
+// masRequirementName shortens a statement to its first words
+func masRequirementName(statement string) string {
+	const max = 60
+	name := strings.TrimSpace(statement)
+	if i := strings.IndexAny(name, ".;:"); i > 0 {
+		name = name[:i]
+	}
+	if len(name) <= max {
+		return name
+	}
+	cut := strings.LastIndex(name[:max], " ")
+	if cut < 0 {
+		cut = max
+	}
+	return name[:cut] + "..."
+}
+
+// masKeyWord finds the RFC 2119 key word of a statement
+func masKeyWord(statement string) string {
+	for _, word := range []string{"MUST NOT", "MUST", "SHOULD NOT", "SHOULD", "MAY"} {
+		if strings.Contains(statement, word) {
+			return word
+		}
+	}
+	return ""
+}
+
+// Mandatory reports whether the requirement must be satisfied. Built-in
+// requirements have no key word and are always mandatory.
+func (r MASRequirement) Mandatory() bool {
+	return r.KeyWord == "" || strings.HasPrefix(r.KeyWord, "MUST")
+}
+
+// MAS requirement statuses
+const (
+	MASRequirementSatisfied     = "satisfied"
+	MASRequirementPartial       = "partially_satisfied"
+	MASRequirementUnaddressed   = "unaddressed"
+	MASRequirementNotApplicable = "not_applicable"
+)
+
+// MASRequirementResult is the assessment status of one requirement
+type MASRequirementResult struct {
+	RequirementID   string   `json:"requirement_id"`
+	Name            string   `json:"name"`
+	KeyWord         string   `json:"key_word,omitempty"`
+	Mandatory       bool     `json:"mandatory"`
+	Status          string   `json:"status"`
+	Methods         []string `json:"methods,omitempty"` // method types used for the requirement
+	MissingMethods  []string `json:"missing_methods,omitempty"`
+	Evidence        []string `json:"evidence,omitempty"`         // evidence IDs
+	MissingEvidence []string `json:"missing_evidence,omitempty"` // evidence types
+	Findings        []string `json:"findings,omitempty"`         // finding IDs
+	OpenFindings    int      `json:"open_findings"`
+	Gaps            []string `json:"gaps,omitempty"`
+	Reason          string   `json:"reason,omitempty"`        // why the requirement does not apply
+	Condition       string   `json:"condition,omitempty"`     // applicability condition
+	ConditionMet    *bool    `json:"condition_met,omitempty"` // nil when the condition could not be evaluated
+	Inferred        bool     `json:"inferred,omitempty"`      // required methods and evidence were inferred
+}
+
+// MASValidationReport evaluates an assessment against every MAS requirement
+type MASValidationReport struct {
+	AssessmentID   string                 `json:"assessment_id"`
+	AssessmentType MASAssessmentType      `json:"assessment_type"`
+	ImpactLevel    string                 `json:"impact_level,omitempty"`
+	Source         string                 `json:"source"`
+	Results        []MASRequirementResult `json:"results"`
+	Summary        map[string]int         `json:"summary"`
+	Complete       bool                   `json:"complete"` // every applicable mandatory requirement is satisfied
+}
+
+// Validate evaluates the assessment's methods, evidence and findings against
+// each requirement. Methods and evidence count for a requirement when they
+// cover its ID or one of its related controls, and findings count by control
+// ID. Applicability conditions are answered by the scope's conditions, or
+// evaluated from the impact level and assessment type they name; a condition
+// that cannot be evaluated leaves the requirement assessed unless the scope
+// declares it inapplicable.
+func (a *MASAssessment) Validate(requirements []MASRequirement) *MASValidationReport {
+	report := &MASValidationReport{
+		AssessmentID:   a.AssessmentID,
+		AssessmentType: a.AssessmentType,
+		ImpactLevel:    a.ImpactLevel,
+		Source:         "built-in",
+		Results:        make([]MASRequirementResult, 0, len(requirements)),
+		Summary: map[string]int{
+			MASRequirementSatisfied:     0,
+			MASRequirementPartial:       0,
+			MASRequirementUnaddressed:   0,
+			MASRequirementNotApplicable: 0,
+		},
+		Complete: true,
+	}
+	evidenceIDs := make(map[string]bool)
+	for _, evidence := range a.Evidence {
+		evidenceIDs[evidence.EvidenceID] = true
+	}
+
+	for _, req := range requirements {
+		if req.Source != "" {
+			report.Source = req.Source
+		}
+		result := a.validateRequirement(req, evidenceIDs)
+		report.Summary[result.Status]++
+		if result.Mandatory && result.Status != MASRequirementSatisfied && result.Status != MASRequirementNotApplicable {
+			report.Complete = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func (a *MASAssessment) validateRequirement(req MASRequirement, evidenceIDs map[string]bool) MASRequirementResult {
+	result := MASRequirementResult{
+		RequirementID: req.ID,
+		Name:          req.Name,
+		KeyWord:       req.KeyWord,
+		Mandatory:     req.Mandatory(),
+		Condition:     req.AppliesWhen,
+		Inferred:      req.Inferred,
+	}
+
+	if req.AssessmentType != "" && req.AssessmentType != a.AssessmentType {
+		result.Status = MASRequirementNotApplicable
+		result.Reason = fmt.Sprintf("applies to %s assessments", req.AssessmentType)
+		return result
+	}
+	if len(req.ImpactLevels) > 0 && a.ImpactLevel != "" && !masContainsFold(req.ImpactLevels, a.ImpactLevel) {
+		result.Status = MASRequirementNotApplicable
+		result.Reason = fmt.Sprintf("applies to %s impact", strings.Join(req.ImpactLevels, ", "))
+		return result
+	}
+	if justification, ok := a.Scope.InapplicableRequirements[req.ID]; ok {
+		if req.AppliesWhen != "" {
+			result.Status = MASRequirementNotApplicable
+			result.Reason = justification
+			return result
+		}
+		result.Gaps = append(result.Gaps, "requirement is declared inapplicable but has no applicability condition")
+	}
+	if req.AppliesWhen != "" {
+		if holds, known := a.masConditionHolds(req.AppliesWhen); known {
+			result.ConditionMet = &holds
+			if !holds {
+				result.Status = MASRequirementNotApplicable
+				result.Reason = fmt.Sprintf("condition does not hold: %s", req.AppliesWhen)
+				return result
+			}
+		}
+	}
+
+	related := func(covered ...string) bool {
+		for _, control := range req.RelatedControls {
+			if control == "all" || control == "subset" {
+				return true
+			}
+		}
+		for _, id := range covered {
+			if strings.EqualFold(id, req.ID) || masContainsFold(req.RelatedControls, id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, method := range a.Methods {
+		if related(method.ControlsCovered...) && !containsString(result.Methods, method.MethodType) {
+			result.Methods = append(result.Methods, method.MethodType)
+		}
+	}
+	evidenceTypes := make(map[string]bool)
+	for _, evidence := range a.Evidence {
+		if related(evidence.ControlsCovered...) {
+			result.Evidence = append(result.Evidence, evidence.EvidenceID)
+			evidenceTypes[evidence.Type] = true
+		}
+	}
+	for _, finding := range a.Findings {
+		if !related(finding.ControlID) {
+			continue
+		}
+		result.Findings = append(result.Findings, finding.FindingID)
+		if finding.Status == "" || finding.Status == "open" {
+			result.OpenFindings++
+		}
+		if len(finding.Evidence) == 0 {
+			result.Gaps = append(result.Gaps, fmt.Sprintf("finding %s cites no evidence", finding.FindingID))
+		}
+		for _, ref := range finding.Evidence {
+			if !evidenceIDs[ref] {
+				result.Gaps = append(result.Gaps, fmt.Sprintf("finding %s cites unknown evidence %s", finding.FindingID, ref))
+			}
+		}
+	}
+
+	inferred := ""
+	if req.Inferred {
+		inferred = " (inferred from the requirement wording)"
+	}
+	for _, method := range req.RequiredMethods {
+		if !containsString(result.Methods, method) {
+			result.MissingMethods = append(result.MissingMethods, method)
+			result.Gaps = append(result.Gaps, fmt.Sprintf("no %s method covers the requirement%s", method, inferred))
+		}
+	}
+	for _, evidenceType := range req.MinimumEvidence {
+		if !evidenceTypes[evidenceType] {
+			result.MissingEvidence = append(result.MissingEvidence, evidenceType)
+			result.Gaps = append(result.Gaps, fmt.Sprintf("no %s evidence%s", evidenceType, inferred))
+		}
+	}
+	if len(result.Evidence) == 0 && len(req.MinimumEvidence) == 0 {
+		result.Gaps = append(result.Gaps, "no evidence covers the requirement")
+	}
+
+	switch {
+	case len(result.Methods) == 0 && len(result.Evidence) == 0 && len(result.Findings) == 0:
+		result.Status = MASRequirementUnaddressed
+	case len(result.Gaps) == 0:
+		result.Status = MASRequirementSatisfied
+	default:
+		result.Status = MASRequirementPartial
+	}
+	return result
+}
+
+// masAssessmentTypeTerms map assessment types onto the phrases conditions use
+// for them
+var masAssessmentTypeTerms = map[MASAssessmentType]*regexp.Regexp{
+	MASInitial:     masWords("initial assessment", "initial assessments", "initial authorization"),
+	MASAnnual:      masWords("annual assessment", "annual assessments"),
+	MASSignificant: masWords("significant change", "significant changes"),
+}
+
+// masImpactTerms match conditions that name impact levels
+var masImpactTerms = masWords("low impact", "moderate impact", "high impact", "impact level", "impact levels")
+
+// masConditionHolds evaluates an applicability condition. Conditions answered
+// in the scope take precedence; otherwise a condition that names impact
+// levels or assessment types holds when it names the assessment's. known is
+// false when the condition could not be evaluated.
+func (a *MASAssessment) masConditionHolds(condition string) (holds, known bool) {
+	for text, value := range a.Scope.Conditions {
+		if strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(condition)) {
+			return value, true
+		}
+	}
+
+	text := strings.ToLower(condition)
+	if a.ImpactLevel != "" && masImpactTerms.MatchString(text) {
+		return masWords(strings.ToLower(a.ImpactLevel)).MatchString(text), true
+	}
+	named := false
+	for assessmentType, terms := range masAssessmentTypeTerms {
+		if terms.MatchString(text) {
+			named = true
+			if assessmentType == a.AssessmentType {
+				return true, true
+			}
+		}
+	}
+	if named && a.AssessmentType != "" {
+		return false, true
+	}
+	return false, false
+}
+
+func masContainsFold(values []string, item string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package fedramp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gocomply/fedramp/pkg/fedramp/frmr"
+)
+
+const masTestFRMR = `{
+  "info": {"short_name": "MAS", "current_release": "25.09A"},
+  "FRR": {
+    "MAS": {"base": {"id": "FRR-MAS", "requirements": [
+      {"id": "FRR-MAS-01", "statement": "Providers MUST document the information flows of the offering.", "primary_key_word": "MUST"},
+      {"id": "FRR-MAS-02", "statement": "Providers MUST document third-party information resources.", "primary_key_word": "MUST",
+       "condition": {"applies_when": "The offering uses third-party information resources"}},
+      {"id": "FRR-MAS-03", "statement": "Providers MUST document the boundary.", "primary_key_word": "MUST",
+       "condition": {"applies_when": "Applies to High impact level offerings"}},
+      {"id": "FRR-MAS-04", "statement": "Providers MUST document the boundary.", "primary_key_word": "MUST",
+       "condition": {"applies_when": "During a significant change assessment"}}
+    ]}}
+  }
+}`
+
+func masTestRequirements(t *testing.T) []MASRequirement {
+	t.Helper()
+	doc, err := frmr.ParseFRMR(strings.NewReader(masTestFRMR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements, err := MASRequirementsFromFRMR(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return requirements
+}
+
+func masResult(t *testing.T, report *MASValidationReport, id string) MASRequirementResult {
+	t.Helper()
+	for _, result := range report.Results {
+		if result.RequirementID == id {
+			return result
+		}
+	}
+	t.Fatalf("no result for %s", id)
+	return MASRequirementResult{}
+}
+
+func TestMASValidateRequiresRelatedEvidence(t *testing.T) {
+	requirements := masTestRequirements(t)
+	assessment := NewMASAssessment("CSO-1", MASInitial)
+	assessment.Methods = append(assessment.Methods, AssessmentMethod{MethodType: "examine", ControlsCovered: []string{"FRR-MAS-01"}})
+
+	// Evidence of the right type for unrelated controls does not count
+	assessment.Evidence = append(assessment.Evidence, AssessmentEvidence{EvidenceID: "E1", Type: "data_flow_diagrams", ControlsCovered: []string{"AC-2"}})
+	result := masResult(t, assessment.Validate(requirements), "FRR-MAS-01")
+	if result.Status != MASRequirementPartial || len(result.Evidence) != 0 {
+		t.Errorf("unrelated evidence: status %s, evidence %v", result.Status, result.Evidence)
+	}
+	if !result.Inferred || len(result.Gaps) == 0 || !strings.Contains(result.Gaps[0], "inferred") {
+		t.Errorf("inferred requirement gaps not marked: %v", result.Gaps)
+	}
+
+	assessment.Evidence = append(assessment.Evidence, AssessmentEvidence{EvidenceID: "E2", Type: "data_flow_diagrams", ControlsCovered: []string{"FRR-MAS-01"}})
+	if result := masResult(t, assessment.Validate(requirements), "FRR-MAS-01"); result.Status != MASRequirementSatisfied {
+		t.Errorf("related evidence: status %s, gaps %v", result.Status, result.Gaps)
+	}
+}
+
+func TestMASValidateEvaluatesConditions(t *testing.T) {
+	requirements := masTestRequirements(t)
+	assessment := NewMASAssessment("CSO-1", MASInitial)
+	assessment.ImpactLevel = "Moderate"
+
+	report := assessment.Validate(requirements)
+	if result := masResult(t, report, "FRR-MAS-02"); result.ConditionMet != nil || result.Status == MASRequirementNotApplicable {
+		t.Errorf("unanswered condition: met %v, status %s", result.ConditionMet, result.Status)
+	}
+	for _, id := range []string{"FRR-MAS-03", "FRR-MAS-04"} {
+		result := masResult(t, report, id)
+		if result.ConditionMet == nil || *result.ConditionMet || result.Status != MASRequirementNotApplicable {
+			t.Errorf("%s: met %v, status %s", id, result.ConditionMet, result.Status)
+		}
+	}
+
+	assessment.ImpactLevel = "High"
+	assessment.AssessmentType = MASSignificant
+	assessment.Scope.Conditions = map[string]bool{"the offering uses third-party information resources": false}
+	report = assessment.Validate(requirements)
+	if result := masResult(t, report, "FRR-MAS-02"); result.Status != MASRequirementNotApplicable {
+		t.Errorf("condition answered false: status %s", result.Status)
+	}
+	for _, id := range []string{"FRR-MAS-03", "FRR-MAS-04"} {
+		result := masResult(t, report, id)
+		if result.ConditionMet == nil || !*result.ConditionMet || result.Status == MASRequirementNotApplicable {
+			t.Errorf("%s: met %v, status %s", id, result.ConditionMet, result.Status)
+		}
+	}
+}
@@ -4,12 +4,10 @@
 // Status: Basic structure implemented, integration pending
 // TODO:
 //   - Integration with ConMon findings
-//   - Automated risk scoring
-//   - FedRAMP POA&M template generation
-//   - Deviation request handling
 package fedramp
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -24,6 +22,8 @@ type PlanOfActionMilestones struct {
 	POAMItems         []POAMItem   `json:"poam_items"`
 	Summary           POAMSummary  `json:"summary"`
 	RiskAdjustment    RiskAdjustment `json:"risk_adjustment"`
+	RemediationPolicy *RemediationPolicy `json:"remediation_policy,omitempty"`
+	DeviationRequests []DeviationRequest `json:"deviation_requests,omitempty"`
 }
 
 // POAMItem represents an individual POA&M entry
@@ -49,6 +49,10 @@ type POAMItem struct {
 	VendorDependency    bool      `json:"vendor_dependency"`
 	FalsePositive       bool      `json:"false_positive"`
 	OperationalRequirement bool   `json:"operational_requirement"`
+	CVSSVector          string    `json:"cvss_vector,omitempty"`
+	MitigatingAdjustments []MitigatingFactor `json:"mitigating_adjustments,omitempty"`
+	RiskScore           *RiskScore `json:"risk_score,omitempty"`
+	History             []POAMChange `json:"history,omitempty"` // append-only field-level change log
 }
 
 // POAMMilestone represents a milestone in the POA&M
@@ -71,6 +75,8 @@ type POAMSummary struct {
 	OldestItem        time.Time      `json:"oldest_item_date"`
 	CompletionRate    float64        `json:"completion_rate"`
 	ProjectedClosure  time.Time      `json:"projected_closure"`
+	RiskByControl     map[string]RiskRollup `json:"risk_by_control"`
+	SystemRisk        RiskRollup     `json:"system_risk"`
 }
 
 // RiskAdjustment tracks risk acceptance and mitigation
@@ -109,34 +115,85 @@ func NewPOAM(serviceID string) *PlanOfActionMilestones {
 		LastUpdated:       time.Now(),
 		POAMItems:         make([]POAMItem, 0),
 		Summary:           POAMSummary{ItemsBySeverity: make(map[string]int), ItemsByStatus: make(map[string]int)},
+		RemediationPolicy: DefaultRemediationPolicy(),
 	}
 }
 
-// AddItem adds a new POA&M item
+// policy returns the remediation policy in effect, falling back to the
+// FedRAMP defaults for documents created without one
+func (poam *PlanOfActionMilestones) policy() *RemediationPolicy {
+	if poam.RemediationPolicy == nil {
+		return DefaultRemediationPolicy()
+	}
+	return poam.RemediationPolicy
+}
+
+// SetRemediationPolicy replaces the remediation policy and recomputes the
+// planned completion date of open items whose date came from the previous
+// policy. Dates set or extended by hand are kept.
+func (poam *PlanOfActionMilestones) SetRemediationPolicy(policy *RemediationPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	previous := poam.policy()
+	poam.RemediationPolicy = policy
+	for i, item := range poam.POAMItems {
+		if isOpenPOAMStatus(item.Status) && (item.PlannedCompletion.IsZero() || item.PlannedCompletion.Equal(previous.DueDate(item))) {
+			poam.POAMItems[i].PlannedCompletion = policy.DueDate(item)
+		}
+	}
+	poam.LastUpdated = time.Now()
+	poam.updateSummary()
+	return nil
+}
+
+// AddItem adds a new POA&M item. An invalid CVSS vector leaves the item
+// scored by its severity label; use AddItemChecked to reject it instead.
 func (poam *PlanOfActionMilestones) AddItem(item POAMItem) {
+	poam.addItem(item, false)
+}
+
+// AddItemChecked adds a new POA&M item and rejects items with an invalid
+// CVSS vector
+func (poam *PlanOfActionMilestones) AddItemChecked(item POAMItem) error {
+	return poam.addItem(item, true)
+}
+
+func (poam *PlanOfActionMilestones) addItem(item POAMItem, strict bool) error {
 	item.ItemID = fmt.Sprintf("POAM-%d", len(poam.POAMItems)+1)
+	if item.IdentifiedDate.IsZero() {
+		item.IdentifiedDate = time.Now()
+	}
+	if err := item.UpdateRiskScore(); err != nil {
+		if strict {
+			return fmt.Errorf("POA&M item %s: %w", item.ItemID, err)
+		}
+		item.RiskScore = nil
+	}
+	if item.PlannedCompletion.IsZero() {
+		item.PlannedCompletion = poam.policy().DueDate(item)
+	}
 	poam.POAMItems = append(poam.POAMItems, item)
 	poam.LastUpdated = time.Now()
 	poam.updateSummary()
+	return nil
 }
 
-// UpdateItem updates an existing POA&M item
+// UpdateItem updates an existing POA&M item from a map keyed by the item
+// JSON field names. Unknown fields are rejected; see PatchItem.
 func (poam *PlanOfActionMilestones) UpdateItem(itemID string, updates map[string]interface{}) error {
-	for i, item := range poam.POAMItems {
-		if item.ItemID == itemID {
-			// Apply updates (simplified for brevity)
-			if status, ok := updates["status"].(string); ok {
-				poam.POAMItems[i].Status = status
-			}
-			if completion, ok := updates["actual_completion"].(time.Time); ok {
-				poam.POAMItems[i].ActualCompletion = &completion
-			}
-			poam.LastUpdated = time.Now()
-			poam.updateSummary()
-			return nil
-		}
+	// Round-trip the map through JSON so callers get the same validation as PatchItem
+	data, err := json.Marshal(updates)
+	if err != nil {
+		return fmt.Errorf("failed to encode updates: %w", err)
+	}
+	var patch POAMItemPatch
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		return fmt.Errorf("invalid POA&M item update: %w", err)
 	}
-	return fmt.Errorf("POA&M item %s not found", itemID)
+	return poam.PatchItem(itemID, patch, "system")
 }
 
 // updateSummary recalculates summary statistics
@@ -149,6 +206,7 @@ func (poam *PlanOfActionMilestones) updateSummary() {
 	summary.ItemsByStatus = make(map[string]int)
 	
 	now := time.Now()
+	policy := poam.policy()
 	totalAge := 0.0
 	completed := 0
 	
@@ -160,11 +218,11 @@ func (poam *PlanOfActionMilestones) updateSummary() {
 		summary.ItemsBySeverity[item.Severity]++
 		
 		// Count open items
-		if item.Status == "Open" || item.Status == "Ongoing" {
+		if isOpenPOAMStatus(item.Status) {
 			summary.OpenItems++
 			
 			// Check if overdue
-			if item.PlannedCompletion.Before(now) {
+			if policy.IsOverdue(item, now) {
 				summary.OverdueItems++
 			}
 		}
@@ -189,15 +247,29 @@ func (poam *PlanOfActionMilestones) updateSummary() {
 		summary.AverageAge = totalAge / float64(summary.TotalItems)
 		summary.CompletionRate = float64(completed) / float64(summary.TotalItems) * 100
 	}
+	
+	poam.updateRiskScores()
+}
+
+// GetOpenItems returns the POA&M items still open or ongoing
+func (poam *PlanOfActionMilestones) GetOpenItems() []POAMItem {
+	var open []POAMItem
+	for _, item := range poam.POAMItems {
+		if isOpenPOAMStatus(item.Status) {
+			open = append(open, item)
+		}
+	}
+	return open
 }
 
 // GetOverdueItems returns all overdue POA&M items
 func (poam *PlanOfActionMilestones) GetOverdueItems() []POAMItem {
 	var overdue []POAMItem
 	now := time.Now()
+	policy := poam.policy()
 	
 	for _, item := range poam.POAMItems {
-		if (item.Status == "Open" || item.Status == "Ongoing") && item.PlannedCompletion.Before(now) {
+		if policy.IsOverdue(item, now) {
 			overdue = append(overdue, item)
 		}
 	}
@@ -205,13 +277,40 @@ func (poam *PlanOfActionMilestones) GetOverdueItems() []POAMItem {
 	return overdue
 }
 
+// ForecastOverdueItems returns open items that are not yet late but will pass
+// their remediation deadline within the next number of days
+func (poam *PlanOfActionMilestones) ForecastOverdueItems(days int) []POAMItem {
+	var upcoming []POAMItem
+	now := time.Now()
+	horizon := now.AddDate(0, 0, days)
+	policy := poam.policy()
+	
+	for _, item := range poam.POAMItems {
+		if !isOpenPOAMStatus(item.Status) || policy.IsOverdue(item, now) {
+			continue
+		}
+		if !policy.Deadline(item).After(horizon) {
+			upcoming = append(upcoming, item)
+		}
+	}
+	
+	return upcoming
+}
+
 // ToJSON exports the POA&M as JSON
 func (poam *PlanOfActionMilestones) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(poam, "", "  ")
 }
 
-// GeneratePOAMFromFindings creates POA&M items from SAR findings
+// GeneratePOAMFromFindings creates POA&M items from SAR findings using the
+// default FedRAMP remediation timelines
 func GeneratePOAMFromFindings(findings []ControlFinding) []POAMItem {
+	return GeneratePOAMFromFindingsWithPolicy(findings, DefaultRemediationPolicy())
+}
+
+// GeneratePOAMFromFindingsWithPolicy creates POA&M items from SAR findings,
+// computing planned completion dates from the given remediation policy
+func GeneratePOAMFromFindingsWithPolicy(findings []ControlFinding, policy *RemediationPolicy) []POAMItem {
 	items := make([]POAMItem, 0)
 	
 	for _, finding := range findings {
@@ -224,10 +323,16 @@ func GeneratePOAMFromFindings(findings []ControlFinding) []POAMItem {
 				RawRisk:           finding.RiskRating,
 				Status:            "Open",
 				IdentifiedDate:    finding.TestDate,
-				PlannedCompletion: finding.TestDate.AddDate(0, 3, 0), // Default 3 months
 				RemediationPlan:   finding.RemediationPlan,
 				Source:            "SAR",
+				CVSSVector:        finding.CVSSVector,
+				MitigatingAdjustments: finding.MitigatingAdjustments,
+				RiskScore:         finding.RiskScore,
+			}
+			if item.IdentifiedDate.IsZero() {
+				item.IdentifiedDate = time.Now()
 			}
+			item.PlannedCompletion = policy.DueDate(item)
 			items = append(items, item)
 		}
 	}
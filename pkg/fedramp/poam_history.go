@@ -0,0 +1,404 @@
+package fedramp
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// POAMChange records a single field-level change to a POA&M item
+type POAMChange struct {
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+}
+
+// POAMItemPatch describes a partial update to a POA&M item. Only non-nil
+// fields are applied. FalsePositive, OperationalRequirement and the "Risk
+// Accepted" status are rejected: they are applied by approving a deviation
+// request.
+type POAMItemPatch struct {
+	FindingID              *string            `json:"finding_id,omitempty"`
+	ControlID              *string            `json:"control_id,omitempty"`
+	Weakness               *string            `json:"weakness,omitempty"`
+	Severity               *string            `json:"severity,omitempty"`
+	RawRisk                *string            `json:"raw_risk,omitempty"`
+	Status                 *string            `json:"status,omitempty"`
+	ResponsibleParty       *string            `json:"responsible_party,omitempty"`
+	Resources              *string            `json:"resources,omitempty"`
+	IdentifiedDate         *time.Time         `json:"identified_date,omitempty"`
+	PlannedCompletion      *time.Time         `json:"planned_completion,omitempty"`
+	ActualCompletion       *time.Time         `json:"actual_completion,omitempty"`
+	Comments               *string            `json:"comments,omitempty"`
+	RemediationPlan        *string            `json:"remediation_plan,omitempty"`
+	MitigatingFactors      *string            `json:"mitigating_factors,omitempty"`
+	ResidualRisk           *string            `json:"residual_risk,omitempty"`
+	Source                 *string            `json:"source,omitempty"`
+	VendorDependency       *bool              `json:"vendor_dependency,omitempty"`
+	FalsePositive          *bool              `json:"false_positive,omitempty"`
+	OperationalRequirement *bool              `json:"operational_requirement,omitempty"`
+	CVSSVector             *string            `json:"cvss_vector,omitempty"`
+	MitigatingAdjustments  []MitigatingFactor `json:"mitigating_adjustments,omitempty"`
+	Milestones             []MilestonePatch   `json:"milestones,omitempty"`
+}
+
+// MilestonePatch describes a change to a POA&M milestone. An empty ID adds a
+// new milestone; Remove deletes the milestone with the given ID.
+type MilestonePatch struct {
+	ID          string     `json:"id,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+	Remove      bool       `json:"remove,omitempty"`
+}
+
+// poamStatusTransitions lists the statuses each POA&M status may move to
+var poamStatusTransitions = map[string][]string{
+	"Open":          {"Ongoing", "Risk Accepted", "Completed", "Cancelled"},
+	"Ongoing":       {"Open", "Risk Accepted", "Completed", "Cancelled"},
+	"Risk Accepted": {"Open", "Ongoing", "Completed"},
+	"Completed":     {"Open"},
+	"Cancelled":     {"Open"},
+}
+
+// ValidatePOAMStatusTransition checks whether an item may move between statuses
+func ValidatePOAMStatusTransition(from, to string) error {
+	if from == to {
+		return nil
+	}
+	allowed, known := poamStatusTransitions[from]
+	if _, valid := poamStatusTransitions[to]; !valid {
+		return fmt.Errorf("unknown POA&M status: %s", to)
+	}
+	if !known {
+		// Items imported with a non-standard status may move to any valid status
+		return nil
+	}
+	if !containsString(allowed, to) {
+		return fmt.Errorf("invalid POA&M status transition from %s to %s", from, to)
+	}
+	return nil
+}
+
+// PatchItem applies a typed patch to a POA&M item, validating the result and
+// recording every changed field in the item history
+func (poam *PlanOfActionMilestones) PatchItem(itemID string, patch POAMItemPatch, actor string) error {
+	item := poam.findItem(itemID)
+	if item == nil {
+		return fmt.Errorf("POA&M item %s not found", itemID)
+	}
+	if patch.FalsePositive != nil || patch.OperationalRequirement != nil {
+		return fmt.Errorf("false_positive and operational_requirement require an approved deviation request")
+	}
+	if patch.Status != nil && *patch.Status == "Risk Accepted" && item.Status != "Risk Accepted" {
+		return fmt.Errorf("accepting the risk of %s requires an approved deviation request", itemID)
+	}
+
+	updated := *item
+	updated.MilestoneDates = append([]POAMMilestone(nil), item.MilestoneDates...)
+	changes := make([]POAMChange, 0)
+	now := time.Now()
+	record := func(field string, oldValue, newValue interface{}) {
+		oldStr, newStr := historyValue(oldValue), historyValue(newValue)
+		if oldStr != newStr {
+			changes = append(changes, POAMChange{Timestamp: now, Actor: actor, Field: field, OldValue: oldStr, NewValue: newStr})
+		}
+	}
+
+	patchString(&updated.FindingID, patch.FindingID, "finding_id", record)
+	patchString(&updated.ControlID, patch.ControlID, "control_id", record)
+	patchString(&updated.Weakness, patch.Weakness, "weakness", record)
+	patchString(&updated.RawRisk, patch.RawRisk, "raw_risk", record)
+	patchString(&updated.ResponsibleParty, patch.ResponsibleParty, "responsible_party", record)
+	patchString(&updated.Resources, patch.Resources, "resources", record)
+	patchString(&updated.Comments, patch.Comments, "comments", record)
+	patchString(&updated.RemediationPlan, patch.RemediationPlan, "remediation_plan", record)
+	patchString(&updated.MitigatingFactors, patch.MitigatingFactors, "mitigating_factors", record)
+	patchString(&updated.ResidualRisk, patch.ResidualRisk, "residual_risk", record)
+	patchString(&updated.Source, patch.Source, "source", record)
+	patchBool(&updated.VendorDependency, patch.VendorDependency, "vendor_dependency", record)
+
+	if patch.Severity != nil {
+		severity := NormalizeSeverity(*patch.Severity)
+		if _, ok := severityNominalScore[severity]; !ok {
+			return fmt.Errorf("unknown severity: %s", *patch.Severity)
+		}
+		record("severity", updated.Severity, severity)
+		updated.Severity = severity
+	}
+	if patch.IdentifiedDate != nil {
+		record("identified_date", updated.IdentifiedDate, *patch.IdentifiedDate)
+		updated.IdentifiedDate = *patch.IdentifiedDate
+	}
+	if patch.PlannedCompletion != nil {
+		record("planned_completion", updated.PlannedCompletion, *patch.PlannedCompletion)
+		updated.PlannedCompletion = *patch.PlannedCompletion
+	}
+	if patch.ActualCompletion != nil {
+		record("actual_completion", updated.ActualCompletion, *patch.ActualCompletion)
+		completion := *patch.ActualCompletion
+		updated.ActualCompletion = &completion
+	}
+	if patch.Status != nil {
+		if err := ValidatePOAMStatusTransition(updated.Status, *patch.Status); err != nil {
+			return err
+		}
+		record("status", updated.Status, *patch.Status)
+		if updated.Status == "Completed" && *patch.Status != "Completed" && patch.ActualCompletion == nil {
+			// Reopening an item clears its completion date
+			record("actual_completion", updated.ActualCompletion, "")
+			updated.ActualCompletion = nil
+		}
+		updated.Status = *patch.Status
+	}
+
+	if patch.CVSSVector != nil || patch.MitigatingAdjustments != nil {
+		patchString(&updated.CVSSVector, patch.CVSSVector, "cvss_vector", record)
+		if patch.MitigatingAdjustments != nil {
+			record("mitigating_adjustments", mitigatingFactorList(updated.MitigatingAdjustments), mitigatingFactorList(patch.MitigatingAdjustments))
+			updated.MitigatingAdjustments = patch.MitigatingAdjustments
+		}
+		if err := updated.UpdateRiskScore(); err != nil {
+			return err
+		}
+	}
+
+	for _, mp := range patch.Milestones {
+		if err := applyMilestonePatch(&updated, mp, record); err != nil {
+			return err
+		}
+	}
+
+	if err := validatePOAMItem(updated); err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	updated.History = append(item.History, changes...)
+	*item = updated
+	poam.LastUpdated = now
+	poam.updateSummary()
+	return nil
+}
+
+// validatePOAMItem checks cross-field consistency of an item
+func validatePOAMItem(item POAMItem) error {
+	if item.Status == "Completed" && item.ActualCompletion == nil {
+		return fmt.Errorf("actual completion date is required to complete item %s", item.ItemID)
+	}
+	if item.ActualCompletion != nil && item.ActualCompletion.Before(item.IdentifiedDate) {
+		return fmt.Errorf("actual completion cannot precede the identified date")
+	}
+	if !item.PlannedCompletion.IsZero() && item.PlannedCompletion.Before(item.IdentifiedDate) {
+		return fmt.Errorf("planned completion cannot precede the identified date")
+	}
+	return nil
+}
+
+func applyMilestonePatch(item *POAMItem, mp MilestonePatch, record func(string, interface{}, interface{})) error {
+	if mp.ID == "" {
+		if mp.Remove {
+			return fmt.Errorf("milestone ID is required to remove a milestone")
+		}
+		milestone := POAMMilestone{ID: nextMilestoneID(*item)}
+		if mp.Title == nil || mp.DueDate == nil {
+			return fmt.Errorf("new milestones require a title and due date")
+		}
+		milestone.Title = *mp.Title
+		milestone.DueDate = *mp.DueDate
+		if mp.Description != nil {
+			milestone.Description = *mp.Description
+		}
+		milestone.Status = "Open"
+		if mp.Status != nil {
+			milestone.Status = *mp.Status
+		}
+		item.MilestoneDates = append(item.MilestoneDates, milestone)
+		record("milestones."+milestone.ID, "", milestone.Title)
+		return nil
+	}
+
+	for i := range item.MilestoneDates {
+		milestone := &item.MilestoneDates[i]
+		if milestone.ID != mp.ID {
+			continue
+		}
+		prefix := "milestones." + milestone.ID + "."
+		if mp.Remove {
+			record("milestones."+milestone.ID, milestone.Title, "")
+			item.MilestoneDates = append(item.MilestoneDates[:i], item.MilestoneDates[i+1:]...)
+			return nil
+		}
+		patchString(&milestone.Title, mp.Title, prefix+"title", record)
+		patchString(&milestone.Description, mp.Description, prefix+"description", record)
+		patchString(&milestone.Status, mp.Status, prefix+"status", record)
+		if mp.DueDate != nil {
+			record(prefix+"due_date", milestone.DueDate, *mp.DueDate)
+			milestone.DueDate = *mp.DueDate
+		}
+		return nil
+	}
+	return fmt.Errorf("milestone %s not found on item %s", mp.ID, item.ItemID)
+}
+
+// nextMilestoneID returns a milestone ID not yet used on the item
+func nextMilestoneID(item POAMItem) string {
+	for n := len(item.MilestoneDates) + 1; ; n++ {
+		id := fmt.Sprintf("%s-M%d", item.ItemID, n)
+		used := false
+		for _, m := range item.MilestoneDates {
+			if m.ID == id {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return id
+		}
+	}
+}
+
+func patchString(target *string, value *string, field string, record func(string, interface{}, interface{})) {
+	if value == nil {
+		return
+	}
+	record(field, *target, *value)
+	*target = *value
+}
+
+func patchBool(target *bool, value *bool, field string, record func(string, interface{}, interface{})) {
+	if value == nil {
+		return
+	}
+	record(field, *target, *value)
+	*target = *value
+}
+
+// historyValue renders a field value for the change history
+func historyValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return historyValue(*v)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// mitigatingFactorList renders mitigating factors for the change history
+func mitigatingFactorList(factors []MitigatingFactor) string {
+	descriptions := make([]string, 0, len(factors))
+	for _, factor := range factors {
+		descriptions = append(descriptions, factor.Description)
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// recordChange appends a change made outside PatchItem to the item history
+func recordChange(item *POAMItem, actor, field string, oldValue, newValue interface{}) {
+	oldStr, newStr := historyValue(oldValue), historyValue(newValue)
+	if oldStr == newStr {
+		return
+	}
+	item.History = append(item.History, POAMChange{
+		Timestamp: time.Now(),
+		Actor:     actor,
+		Field:     field,
+		OldValue:  oldStr,
+		NewValue:  newStr,
+	})
+}
+
+// OSCALRiskLog represents the risk-log assembly of an OSCAL POA&M risk
+type OSCALRiskLog struct {
+	Entries []OSCALRiskLogEntry `json:"entries"`
+}
+
+// OSCALRiskLogEntry represents a single OSCAL risk-log entry
+type OSCALRiskLogEntry struct {
+	Uuid         string      `json:"uuid"`
+	Title        string      `json:"title"`
+	Description  string      `json:"description"`
+	Start        time.Time   `json:"start"`
+	StatusChange string      `json:"status-change,omitempty"`
+	Props        []OSCALProp `json:"props,omitempty"`
+}
+
+// OSCALProp represents an OSCAL property
+type OSCALProp struct {
+	Name  string `json:"name"`
+	Ns    string `json:"ns,omitempty"`
+	Value string `json:"value"`
+}
+
+// SetUuid sets the entry UUID
+func (e *OSCALRiskLogEntry) SetUuid(id string) {
+	e.Uuid = id
+}
+
+// oscalRiskStatus maps POA&M statuses onto OSCAL risk-status values
+var oscalRiskStatus = map[string]string{
+	"Open":          "open",
+	"Ongoing":       "investigating",
+	"Risk Accepted": "deviation-approved",
+	"Completed":     "closed",
+	"Cancelled":     "closed",
+}
+
+// riskLogNamespace is the RFC 4122 URL namespace, used to derive name-based
+// risk-log entry UUIDs
+var riskLogNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// riskLogEntryUUID returns a version 5 UUID for the n-th history entry of an
+// item, so an entry keeps its UUID across exports
+func riskLogEntryUUID(itemID string, n int) string {
+	name := fmt.Sprintf("https://fedramp.gov/ns/oscal/poam-item/%s/risk-log/%d", itemID, n)
+	sum := sha1.Sum(append(riskLogNamespace[:], name...))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// RiskLog converts the change history of an item into OSCAL risk-log entries.
+// Entry UUIDs are derived from the item ID and the position in the history.
+func (item *POAMItem) RiskLog() (*OSCALRiskLog, error) {
+	log := &OSCALRiskLog{Entries: make([]OSCALRiskLogEntry, 0, len(item.History))}
+
+	for i, change := range item.History {
+		entry := OSCALRiskLogEntry{
+			Uuid:        riskLogEntryUUID(item.ItemID, i+1),
+			Title:       fmt.Sprintf("%s changed", change.Field),
+			Description: fmt.Sprintf("%s changed %s from %q to %q", change.Actor, change.Field, change.OldValue, change.NewValue),
+			Start:       change.Timestamp,
+			Props: []OSCALProp{
+				{Name: "actor", Ns: "https://fedramp.gov/ns/oscal", Value: change.Actor},
+				{Name: "field", Ns: "https://fedramp.gov/ns/oscal", Value: change.Field},
+				{Name: "old-value", Ns: "https://fedramp.gov/ns/oscal", Value: change.OldValue},
+				{Name: "new-value", Ns: "https://fedramp.gov/ns/oscal", Value: change.NewValue},
+			},
+		}
+		if change.Field == "status" {
+			entry.StatusChange = oscalRiskStatus[change.NewValue]
+		}
+		log.Entries = append(log.Entries, entry)
+	}
+
+	return log, nil
+}
@@ -0,0 +1,122 @@
+package fedramp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// scanFindingControl is the control POA&M items opened for scan findings are
+// tracked under
+const scanFindingControl = "RA-5"
+
+// POAMReconciliation lists the POA&M items changed by reconciling the POA&M
+// with scan results
+type POAMReconciliation struct {
+	Added      []string `json:"added"`      // items opened for new findings
+	Closed     []string `json:"closed"`     // items completed because the finding was remediated
+	Reopened   []string `json:"reopened"`   // completed items whose finding was reported again
+	Suppressed []string `json:"suppressed"` // completed items reported again but kept closed by a deviation
+	Unobserved []string `json:"unobserved"` // open scan items whose finding no scan reported
+}
+
+// ReconcileScanFindings brings the POA&M in line with the scans completed in
+// the period. Open findings without an item get a new scan-sourced item,
+// items of findings remediated before the end of the period are completed on
+// the remediation date and completed items of findings still open are
+// reopened, unless they are false positives or have an approved deviation.
+// Informational findings are ignored. Changes are recorded in the item
+// history under the given actor.
+func (poam *PlanOfActionMilestones) ReconcileScanFindings(scans []VulnerabilityScan, period ReportingPeriod, actor string) (*POAMReconciliation, error) {
+	result := &POAMReconciliation{
+		Added:      make([]string, 0),
+		Closed:     make([]string, 0),
+		Reopened:   make([]string, 0),
+		Suppressed: make([]string, 0),
+		Unobserved: make([]string, 0),
+	}
+
+	findings := LatestScanFindings(scans, period)
+	ids := make([]string, 0, len(findings))
+	for id := range findings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		finding := findings[id]
+		severity := NormalizeSeverity(finding.Severity)
+		if severity == "Informational" {
+			continue
+		}
+		remediated := period.endsBy(finding.RemediatedAt)
+		item := poam.itemForFinding(id)
+
+		switch {
+		case item == nil && !remediated:
+			weakness := finding.Title
+			if weakness == "" {
+				weakness = id
+			}
+			if err := poam.AddItemChecked(POAMItem{
+				FindingID:      id,
+				ControlID:      scanFindingControl,
+				Weakness:       weakness,
+				Severity:       severity,
+				RawRisk:        severity,
+				Status:         "Open",
+				IdentifiedDate: finding.FirstSeen,
+				Comments:       fmt.Sprintf("Reported on asset %s", finding.Asset),
+				Source:         "Scan",
+			}); err != nil {
+				return nil, err
+			}
+			result.Added = append(result.Added, poam.POAMItems[len(poam.POAMItems)-1].ItemID)
+
+		case item != nil && remediated && item.Status != "Completed" && item.Status != "Cancelled":
+			status := "Completed"
+			if err := poam.PatchItem(item.ItemID, POAMItemPatch{Status: &status, ActualCompletion: finding.RemediatedAt}, actor); err != nil {
+				return nil, fmt.Errorf("failed to close POA&M item %s: %w", item.ItemID, err)
+			}
+			result.Closed = append(result.Closed, item.ItemID)
+
+		case item != nil && !remediated && item.Status == "Completed" && (item.ActualCompletion == nil || finding.LastSeen.After(*item.ActualCompletion)):
+			if item.FalsePositive || poam.hasApprovedDeviation(item.ItemID) {
+				result.Suppressed = append(result.Suppressed, item.ItemID)
+				continue
+			}
+			status := "Open"
+			if err := poam.PatchItem(item.ItemID, POAMItemPatch{Status: &status}, actor); err != nil {
+				return nil, fmt.Errorf("failed to reopen POA&M item %s: %w", item.ItemID, err)
+			}
+			result.Reopened = append(result.Reopened, item.ItemID)
+		}
+	}
+
+	for _, item := range poam.POAMItems {
+		if _, seen := findings[item.FindingID]; !seen && item.Source == "Scan" && isOpenPOAMStatus(item.Status) {
+			result.Unobserved = append(result.Unobserved, item.ItemID)
+		}
+	}
+	return result, nil
+}
+
+// hasApprovedDeviation reports whether an item has an approved deviation
+// request of any type
+func (poam *PlanOfActionMilestones) hasApprovedDeviation(itemID string) bool {
+	for _, request := range poam.DeviationRequests {
+		if request.ItemID == itemID && request.Status == DeviationApproved {
+			return true
+		}
+	}
+	return false
+}
+
+// itemForFinding returns the most recent item tracking a finding
+func (poam *PlanOfActionMilestones) itemForFinding(findingID string) *POAMItem {
+	for i := len(poam.POAMItems) - 1; i >= 0; i-- {
+		if poam.POAMItems[i].FindingID == findingID {
+			return &poam.POAMItems[i]
+		}
+	}
+	return nil
+}
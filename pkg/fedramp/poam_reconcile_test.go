@@ -0,0 +1,80 @@
+package fedramp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReconcileScanFindingsSuppressedByDeviation(t *testing.T) {
+	scan := func(id string, d int, findingIDs ...string) VulnerabilityScan {
+		s := VulnerabilityScan{ScanID: id, Scanner: "nessus", CompletedAt: day(d), Targets: []string{"vm-1"}}
+		for _, findingID := range findingIDs {
+			s.Findings = append(s.Findings, ScanFinding{FindingID: findingID, Asset: "vm-1", Severity: "High"})
+		}
+		return s
+	}
+	scans := []VulnerabilityScan{scan("S1", 5, "F1", "F2")}
+
+	poam := NewPOAM("CSO-1")
+	result, err := poam.ReconcileScanFindings(scans, september, "conmon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POAM-1", "POAM-2"}; !reflect.DeepEqual(result.Added, want) {
+		t.Fatalf("Added = %v, want %v", result.Added, want)
+	}
+
+	// POAM-1 is closed as a false positive; POAM-2 is remediated by the next scan
+	request, err := poam.CreateDeviationRequest("POAM-1", DeviationFalsePositive, "Version check misreads the backported fix", "isso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := poam.AddDeviationEvidence(request.RequestID, "vendor advisory"); err != nil {
+		t.Fatal(err)
+	}
+	if err := poam.SubmitDeviationRequest(request.RequestID); err != nil {
+		t.Fatal(err)
+	}
+	if err := poam.ApproveDeviationRequest(request.RequestID, "ao", "approved", nil); err != nil {
+		t.Fatal(err)
+	}
+	poam.findItem("POAM-1").ActualCompletion = at(day(6))
+
+	scans = append(scans, scan("S2", 8, "F1"))
+	if _, err := poam.ReconcileScanFindings(scans, september, "conmon"); err != nil {
+		t.Fatal(err)
+	}
+	if got := poam.findItem("POAM-2").Status; got != "Completed" {
+		t.Fatalf("POAM-2 status = %s, want Completed", got)
+	}
+
+	// Both findings are reported again
+	scans = append(scans, scan("S3", 12, "F1", "F2"))
+	result, err = poam.ReconcileScanFindings(scans, september, "conmon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POAM-2"}; !reflect.DeepEqual(result.Reopened, want) {
+		t.Errorf("Reopened = %v, want %v", result.Reopened, want)
+	}
+	if want := []string{"POAM-1"}; !reflect.DeepEqual(result.Suppressed, want) {
+		t.Errorf("Suppressed = %v, want %v", result.Suppressed, want)
+	}
+	if got := poam.findItem("POAM-1").Status; got != "Completed" {
+		t.Errorf("false positive POAM-1 status = %s, want Completed", got)
+	}
+}
+
+func TestScanWeaknessKeyIncludesAsset(t *testing.T) {
+	scans := []VulnerabilityScan{{ScanID: "S1", Scanner: "nessus", CompletedAt: day(5), Findings: []ScanFinding{
+		{Asset: "vm-1", PluginID: "12345", Severity: "High"},
+		{Asset: "vm-2", PluginID: "12345", Severity: "High"},
+		{PluginID: "12345", Severity: "High"},
+	}}}
+	findings := LatestScanFindings(scans, september)
+	for _, key := range []string{"RA-5:vm-1:12345", "RA-5:vm-2:12345", "RA-5:12345"} {
+		if _, ok := findings[key]; !ok {
+			t.Errorf("no finding keyed %s in %v", key, findings)
+		}
+	}
+}
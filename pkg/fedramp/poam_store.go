@@ -0,0 +1,107 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// POAMStore persists the POA&M of each service offering
+type POAMStore interface {
+	SavePOAM(poam *PlanOfActionMilestones) error
+	GetPOAM(csoID string) (*PlanOfActionMilestones, error)
+	ListPOAMs() ([]*PlanOfActionMilestones, error)
+}
+
+// ErrPOAMNotFound is returned by stores when a service offering has no POA&M
+var ErrPOAMNotFound = errors.New("POA&M not found")
+
+// FilePOAMStore keeps the POA&M of each service offering in a JSON file
+type FilePOAMStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFilePOAMStore creates a file store rooted at dir, creating it if needed
+func NewFilePOAMStore(dir string) (*FilePOAMStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create POA&M store: %w", err)
+	}
+	return &FilePOAMStore{dir: dir}, nil
+}
+
+func (s *FilePOAMStore) path(csoID string) (string, error) {
+	if csoID == "" || strings.ContainsAny(csoID, `/\`) || csoID == "." || csoID == ".." {
+		return "", fmt.Errorf("invalid CSO ID: %q", csoID)
+	}
+	return filepath.Join(s.dir, csoID+".json"), nil
+}
+
+// SavePOAM replaces the POA&M of its service offering
+func (s *FilePOAMStore) SavePOAM(poam *PlanOfActionMilestones) error {
+	path, err := s.path(poam.ServiceOfferingID)
+	if err != nil {
+		return err
+	}
+	data, err := poam.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write POA&M for %s: %w", poam.ServiceOfferingID, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// GetPOAM reads the POA&M of a service offering
+func (s *FilePOAMStore) GetPOAM(csoID string) (*PlanOfActionMilestones, error) {
+	path, err := s.path(csoID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return readPOAM(path)
+}
+
+// ListPOAMs returns the POA&M of every service offering
+func (s *FilePOAMStore) ListPOAMs() ([]*PlanOfActionMilestones, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	poams := make([]*PlanOfActionMilestones, 0, len(paths))
+	for _, path := range paths {
+		poam, err := readPOAM(path)
+		if err != nil {
+			return nil, err
+		}
+		poams = append(poams, poam)
+	}
+	return poams, nil
+}
+
+func readPOAM(path string) (*PlanOfActionMilestones, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrPOAMNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read POA&M: %w", err)
+	}
+	var poam PlanOfActionMilestones
+	if err := json.Unmarshal(data, &poam); err != nil {
+		return nil, fmt.Errorf("failed to parse POA&M %s: %w", filepath.Base(path), err)
+	}
+	return &poam, nil
+}
@@ -0,0 +1,149 @@
+package fedramp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"time"
+)
+
+// POAMWorkbookColumns lists the columns of the FedRAMP POA&M template
+// (Open POA&M Items worksheet) in template order
+var POAMWorkbookColumns = []string{
+	"POAM ID",
+	"Controls",
+	"Weakness Name",
+	"Weakness Description",
+	"Weakness Detector Source",
+	"Weakness Source Identifier",
+	"Asset Identifier",
+	"Point of Contact",
+	"Resources Required",
+	"Overall Remediation Plan",
+	"Original Detection Date",
+	"Scheduled Completion Date",
+	"Planned Milestones",
+	"Milestone Changes",
+	"Status Date",
+	"Vendor Dependency",
+	"Last Vendor Check-in Date",
+	"Vendor Dependent Product Name",
+	"Original Risk Rating",
+	"Adjusted Risk Rating",
+	"Risk Adjustment",
+	"False Positive",
+	"Operational Requirement",
+	"Deviation Rationale",
+	"Supporting Documents",
+	"Comments",
+	"Auto-Approve",
+}
+
+// ToWorkbookCSV exports the POA&M items as CSV using the FedRAMP POA&M
+// template columns, including the deviation columns
+func (poam *PlanOfActionMilestones) ToWorkbookCSV() ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(POAMWorkbookColumns); err != nil {
+		return nil, err
+	}
+
+	for _, item := range poam.POAMItems {
+		if err := writer.Write(poam.workbookRow(item)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func (poam *PlanOfActionMilestones) workbookRow(item POAMItem) []string {
+	milestones := make([]string, 0, len(item.MilestoneDates))
+	for _, m := range item.MilestoneDates {
+		milestones = append(milestones, m.Title+" ("+formatWorkbookDate(m.DueDate)+")")
+	}
+
+	originalRisk := item.Severity
+	adjustedRisk := ""
+	rationale := ""
+	documents := ""
+	riskAdjustment := deviationColumn(poam, item.ItemID, DeviationRiskAdjustment)
+	falsePositive := deviationColumn(poam, item.ItemID, DeviationFalsePositive)
+	operational := deviationColumn(poam, item.ItemID, DeviationOperationalRequirement)
+
+	if request := poam.ActiveDeviation(item.ItemID); request != nil {
+		rationale = request.Justification
+		documents = strings.Join(request.SupportingEvidence, "; ")
+		if request.Type == DeviationRiskAdjustment && request.Status == DeviationApproved {
+			originalRisk = request.OriginalSeverity
+			adjustedRisk = item.Severity
+		}
+	}
+
+	statusDate := poam.LastUpdated
+	if item.ActualCompletion != nil {
+		statusDate = *item.ActualCompletion
+	}
+
+	vendorDependency := "No"
+	if item.VendorDependency {
+		vendorDependency = "Yes"
+	}
+
+	return []string{
+		item.ItemID,
+		item.ControlID,
+		item.Weakness,
+		item.Weakness,
+		item.Source,
+		item.FindingID,
+		"",
+		item.ResponsibleParty,
+		item.Resources,
+		item.RemediationPlan,
+		formatWorkbookDate(item.IdentifiedDate),
+		formatWorkbookDate(item.PlannedCompletion),
+		strings.Join(milestones, "; "),
+		"",
+		formatWorkbookDate(statusDate),
+		vendorDependency,
+		"",
+		"",
+		originalRisk,
+		adjustedRisk,
+		riskAdjustment,
+		falsePositive,
+		operational,
+		rationale,
+		documents,
+		item.Comments,
+		"No",
+	}
+}
+
+// deviationColumn renders the Yes/No/Pending value used by the deviation
+// columns of the POA&M template
+func deviationColumn(poam *PlanOfActionMilestones, itemID string, devType DeviationType) string {
+	value := "No"
+	for _, request := range poam.DeviationRequests {
+		if request.ItemID != itemID || request.Type != devType {
+			continue
+		}
+		switch request.Status {
+		case DeviationApproved:
+			return "Yes"
+		case DeviationSubmitted:
+			value = "Pending"
+		}
+	}
+	return value
+}
+
+func formatWorkbookDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("01/02/2006")
+}
@@ -0,0 +1,140 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// RemediationPolicy defines the remediation timelines applied to POA&M items.
+// The defaults follow the FedRAMP Continuous Monitoring Strategy Guide:
+// High (and Critical) findings within 30 days, Moderate within 90 days and
+// Low within 180 days of identification.
+type RemediationPolicy struct {
+	SeverityDays                  map[string]int `json:"severity_days"`
+	DefaultDays                   int            `json:"default_days"`
+	GracePeriodDays               int            `json:"grace_period_days"`
+	VendorDependencyExtensionDays int            `json:"vendor_dependency_extension_days"`
+}
+
+// DefaultRemediationPolicy returns the FedRAMP ConMon remediation timelines
+func DefaultRemediationPolicy() *RemediationPolicy {
+	return &RemediationPolicy{
+		SeverityDays: map[string]int{
+			"Critical": 30,
+			"High":     30,
+			"Moderate": 90,
+			"Low":      180,
+		},
+		DefaultDays:                   90,
+		GracePeriodDays:               0,
+		VendorDependencyExtensionDays: 0,
+	}
+}
+
+// LoadRemediationPolicy reads a remediation policy from a JSON file. Severities
+// missing from the file keep their default timelines.
+func LoadRemediationPolicy(path string) (*RemediationPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remediation policy: %w", err)
+	}
+
+	var loaded RemediationPolicy
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse remediation policy: %w", err)
+	}
+
+	policy := DefaultRemediationPolicy()
+	for severity, days := range loaded.SeverityDays {
+		policy.SeverityDays[NormalizeSeverity(severity)] = days
+	}
+	if loaded.DefaultDays > 0 {
+		policy.DefaultDays = loaded.DefaultDays
+	}
+	policy.GracePeriodDays = loaded.GracePeriodDays
+	policy.VendorDependencyExtensionDays = loaded.VendorDependencyExtensionDays
+
+	return policy, policy.Validate()
+}
+
+// Validate checks that the policy timelines are usable
+func (p *RemediationPolicy) Validate() error {
+	for severity, days := range p.SeverityDays {
+		if days <= 0 {
+			return fmt.Errorf("remediation days for %s must be positive", severity)
+		}
+	}
+	if p.DefaultDays <= 0 {
+		return fmt.Errorf("default remediation days must be positive")
+	}
+	if p.GracePeriodDays < 0 {
+		return fmt.Errorf("grace period cannot be negative")
+	}
+	if p.VendorDependencyExtensionDays < 0 {
+		return fmt.Errorf("vendor dependency extension cannot be negative")
+	}
+	return nil
+}
+
+// RemediationDays returns the number of days allowed to remediate a finding
+// of the given severity
+func (p *RemediationPolicy) RemediationDays(severity string) int {
+	if days, ok := p.SeverityDays[NormalizeSeverity(severity)]; ok {
+		return days
+	}
+	return p.DefaultDays
+}
+
+// DueDate computes the planned completion date for an item, including the
+// extension granted for vendor dependencies
+func (p *RemediationPolicy) DueDate(item POAMItem) time.Time {
+	days := p.RemediationDays(item.Severity)
+	if item.VendorDependency {
+		days += p.VendorDependencyExtensionDays
+	}
+	return item.IdentifiedDate.AddDate(0, 0, days)
+}
+
+// Deadline returns the date after which an item is considered late. This is
+// the planned completion date plus the grace period.
+func (p *RemediationPolicy) Deadline(item POAMItem) time.Time {
+	due := item.PlannedCompletion
+	if due.IsZero() {
+		due = p.DueDate(item)
+	}
+	return due.AddDate(0, 0, p.GracePeriodDays)
+}
+
+// IsOverdue reports whether an open item has passed its deadline. Items with
+// an approved operational requirement are tracked but never considered late.
+func (p *RemediationPolicy) IsOverdue(item POAMItem, now time.Time) bool {
+	if item.OperationalRequirement {
+		return false
+	}
+	return isOpenPOAMStatus(item.Status) && now.After(p.Deadline(item))
+}
+
+// NormalizeSeverity maps the severity spellings used by scanners, MAS findings
+// and SAR findings onto the POA&M severity labels
+func NormalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "very high":
+		return "Critical"
+	case "high":
+		return "High"
+	case "moderate", "medium":
+		return "Moderate"
+	case "low":
+		return "Low"
+	case "informational", "info", "none":
+		return "Informational"
+	}
+	return severity
+}
+
+func isOpenPOAMStatus(status string) bool {
+	return status == "Open" || status == "Ongoing"
+}
@@ -0,0 +1,239 @@
+package fedramp
+
+import (
+	"fmt"
+	"math"
+)
+
+// MitigatingFactor documents a compensating control or environmental
+// condition that lowers the risk of a finding. The effect is expressed as
+// CVSS environmental metrics, e.g. MAV:L for a service that is not reachable
+// from the Internet or MPR:H when access is restricted to administrators.
+type MitigatingFactor struct {
+	Description string            `json:"description"`
+	Metrics     map[string]string `json:"metrics"`
+}
+
+// RiskScore holds the CVSS scores of a finding or POA&M item
+type RiskScore struct {
+	Vector             string  `json:"vector"`
+	Version            string  `json:"version"`
+	BaseScore          float64 `json:"base_score"`
+	BaseSeverity       string  `json:"base_severity"`
+	EnvironmentalScore float64 `json:"environmental_score"` // includes mitigating factors
+	ResidualSeverity   string  `json:"residual_severity"`
+	AdjustedVector     string  `json:"adjusted_vector,omitempty"`
+}
+
+// RiskRollup aggregates risk scores across items, controls or the system
+type RiskRollup struct {
+	Items         int     `json:"items"`
+	BaseScore     float64 `json:"base_score"`
+	ResidualScore float64 `json:"residual_score"`
+	MaxScore      float64 `json:"max_score"`
+	Severity      string  `json:"severity"` // severity of the highest residual score
+}
+
+// severityNominalScore is the score used for items and findings that have a
+// severity label but no CVSS vector
+var severityNominalScore = map[string]float64{
+	"Critical":      9.5,
+	"High":          8.0,
+	"Moderate":      5.5,
+	"Low":           2.0,
+	"Informational": 0.0,
+}
+
+// severityMaxScore is the highest CVSS score within each severity band
+var severityMaxScore = map[string]float64{
+	"Critical":      10.0,
+	"High":          8.9,
+	"Moderate":      6.9,
+	"Low":           3.9,
+	"Informational": 0.0,
+}
+
+// CVSSSeverity maps a CVSS score onto the FedRAMP severity labels using the
+// CVSS qualitative rating scale (Medium is reported as Moderate)
+func CVSSSeverity(score float64) string {
+	switch {
+	case score >= 9.0:
+		return "Critical"
+	case score >= 7.0:
+		return "High"
+	case score >= 4.0:
+		return "Moderate"
+	case score > 0:
+		return "Low"
+	}
+	return "Informational"
+}
+
+// ScoreRisk parses a CVSS vector and computes its base score and the
+// environmental score after applying the mitigating factors
+func ScoreRisk(vector string, factors []MitigatingFactor) (*RiskScore, error) {
+	parsed, err := ParseCVSSVector(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	adjusted := parsed
+	for _, factor := range factors {
+		adjusted, err = adjusted.WithMetrics(factor.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("mitigating factor %q: %w", factor.Description, err)
+		}
+	}
+
+	score := &RiskScore{
+		Vector:             parsed.String(),
+		Version:            parsed.Version,
+		BaseScore:          parsed.BaseScore(),
+		EnvironmentalScore: adjusted.EnvironmentalScore(),
+	}
+	score.BaseSeverity = CVSSSeverity(score.BaseScore)
+	score.ResidualSeverity = CVSSSeverity(score.EnvironmentalScore)
+	if len(factors) > 0 {
+		score.AdjustedVector = adjusted.String()
+	}
+	return score, nil
+}
+
+// UpdateRiskScore recomputes the item risk score from its CVSS vector and
+// mitigating adjustments. Items without a severity take the base severity.
+func (item *POAMItem) UpdateRiskScore() error {
+	if item.CVSSVector == "" {
+		item.RiskScore = nil
+		return nil
+	}
+	score, err := ScoreRisk(item.CVSSVector, item.MitigatingAdjustments)
+	if err != nil {
+		return err
+	}
+	item.RiskScore = score
+	if item.Severity == "" {
+		item.Severity = score.BaseSeverity
+	}
+	if item.RawRisk == "" {
+		item.RawRisk = score.BaseSeverity
+	}
+	return nil
+}
+
+// UpdateRiskScore recomputes the finding risk score from its CVSS vector and
+// sets the risk rating to the residual severity
+func (finding *ControlFinding) UpdateRiskScore() error {
+	if finding.CVSSVector == "" {
+		finding.RiskScore = nil
+		return nil
+	}
+	score, err := ScoreRisk(finding.CVSSVector, finding.MitigatingAdjustments)
+	if err != nil {
+		return err
+	}
+	finding.RiskScore = score
+	finding.RiskRating = score.ResidualSeverity
+	if finding.Severity == "" {
+		finding.Severity = score.BaseSeverity
+	}
+	return nil
+}
+
+// Scores returns the base and residual score of a finding, falling back to
+// the nominal score of its severity when it has no CVSS vector
+func (finding ControlFinding) Scores() (base, residual float64) {
+	if finding.RiskScore != nil {
+		return finding.RiskScore.BaseScore, finding.RiskScore.EnvironmentalScore
+	}
+	nominal := severityNominalScore[NormalizeSeverity(finding.Severity)]
+	return nominal, nominal
+}
+
+// itemScores returns the base and residual score of an item. An approved
+// risk adjustment caps the residual score at the adjusted severity band.
+func (poam *PlanOfActionMilestones) itemScores(item POAMItem) (base, residual float64) {
+	original := item.Severity
+	adjustment := poam.approvedDeviation(item.ItemID, DeviationRiskAdjustment)
+	if adjustment != nil {
+		original = adjustment.OriginalSeverity
+	}
+
+	if item.RiskScore == nil {
+		return severityNominalScore[NormalizeSeverity(original)], severityNominalScore[NormalizeSeverity(item.Severity)]
+	}
+
+	base, residual = item.RiskScore.BaseScore, item.RiskScore.EnvironmentalScore
+	if adjustment != nil {
+		if max, ok := severityMaxScore[NormalizeSeverity(item.Severity)]; ok {
+			residual = math.Min(residual, max)
+		}
+	}
+	return base, residual
+}
+
+func (r *RiskRollup) add(base, residual float64) {
+	r.Items++
+	r.BaseScore = roundScore(r.BaseScore + base)
+	r.ResidualScore = roundScore(r.ResidualScore + residual)
+	if residual > r.MaxScore {
+		r.MaxScore = residual
+	}
+	r.Severity = CVSSSeverity(r.MaxScore)
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*10) / 10
+}
+
+// updateRiskScores recomputes the item, control and system risk totals. The
+// total score uses each open item's score before mitigation and any approved
+// risk adjustment; the adjusted score uses the residual score. Both exclude
+// false positives.
+func (poam *PlanOfActionMilestones) updateRiskScores() {
+	total, adjusted := 0.0, 0.0
+	byControl := make(map[string]RiskRollup)
+	system := RiskRollup{Severity: CVSSSeverity(0)}
+
+	for _, item := range poam.POAMItems {
+		base, residual := poam.itemScores(item)
+
+		if item.FalsePositive {
+			continue
+		}
+		if !isOpenPOAMStatus(item.Status) {
+			continue
+		}
+
+		total += base
+		adjusted += residual
+
+		control := byControl[item.ControlID]
+		control.add(base, residual)
+		byControl[item.ControlID] = control
+		system.add(base, residual)
+	}
+
+	poam.RiskAdjustment.TotalRiskScore = roundScore(total)
+	poam.RiskAdjustment.AdjustedRiskScore = roundScore(adjusted)
+	poam.Summary.RiskByControl = byControl
+	poam.Summary.SystemRisk = system
+}
+
+// FindingRiskByControl rolls up the residual scores of findings that were not
+// satisfied by control
+func FindingRiskByControl(findings []ControlFinding) (map[string]RiskRollup, RiskRollup) {
+	byControl := make(map[string]RiskRollup)
+	system := RiskRollup{Severity: CVSSSeverity(0)}
+
+	for _, finding := range findings {
+		if finding.Status != "Other Than Satisfied" {
+			continue
+		}
+		base, residual := finding.Scores()
+		control := byControl[finding.ControlID]
+		control.add(base, residual)
+		byControl[finding.ControlID] = control
+		system.add(base, residual)
+	}
+	return byControl, system
+}
@@ -0,0 +1,133 @@
+package fedramp
+
+import "testing"
+
+// Expected scores are those of the FIRST CVSS calculators
+func TestCVSSScores(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", 6.4},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N", 5.3},
+		{"CVSS:3.1/AV:A/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N", 6.5},
+		{"CVSS:3.1/AV:P/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0.0},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.3},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", 10.0},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.7},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.5},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:H/SC:N/SI:N/SA:N", 8.7},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N", 6.9},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:P/VC:N/VI:N/VA:N/SC:L/SI:L/SA:N", 5.1},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", 0.0},
+	}
+	for _, tt := range tests {
+		vector, err := ParseCVSSVector(tt.vector)
+		if err != nil {
+			t.Errorf("ParseCVSSVector(%q): %v", tt.vector, err)
+			continue
+		}
+		if got := vector.BaseScore(); got != tt.want {
+			t.Errorf("BaseScore(%q) = %.1f, want %.1f", tt.vector, got, tt.want)
+		}
+	}
+}
+
+func TestScoreRiskMitigatingFactors(t *testing.T) {
+	factors := []MitigatingFactor{{Description: "not reachable from the Internet", Metrics: map[string]string{"MAV": "L"}}}
+	score, err := ScoreRisk("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", factors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score.BaseScore != 9.8 || score.BaseSeverity != "Critical" {
+		t.Errorf("base = %.1f %s, want 9.8 Critical", score.BaseScore, score.BaseSeverity)
+	}
+	if score.EnvironmentalScore != 8.4 || score.ResidualSeverity != "High" {
+		t.Errorf("environmental = %.1f %s, want 8.4 High", score.EnvironmentalScore, score.ResidualSeverity)
+	}
+
+	if _, err := ScoreRisk("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", []MitigatingFactor{{Metrics: map[string]string{"MAV": "Q"}}}); err == nil {
+		t.Error("invalid mitigating metric accepted")
+	}
+	for _, vector := range []string{"", "CVSS:2.0/AV:N", "CVSS:3.1/AV:N/AC:L", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:X/SC:N/SI:N/SA:N"} {
+		if _, err := ScoreRisk(vector, nil); err == nil {
+			t.Errorf("ScoreRisk(%q) accepted an invalid vector", vector)
+		}
+	}
+}
+
+func TestPOAMRiskScoresExcludeFalsePositives(t *testing.T) {
+	poam := NewPOAM("CSO-1")
+	poam.AddItem(POAMItem{ControlID: "AC-2", Severity: "High", Status: "Open", CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"})
+	poam.AddItem(POAMItem{ControlID: "AC-2", Severity: "Moderate", Status: "Open", FalsePositive: true})
+	poam.AddItem(POAMItem{ControlID: "SI-2", Severity: "Low", Status: "Completed"})
+
+	if got := poam.RiskAdjustment.TotalRiskScore; got != 9.8 {
+		t.Errorf("TotalRiskScore = %.1f, want 9.8", got)
+	}
+	if got := poam.RiskAdjustment.AdjustedRiskScore; got != 9.8 {
+		t.Errorf("AdjustedRiskScore = %.1f, want 9.8", got)
+	}
+	if got := poam.Summary.SystemRisk.Items; got != 1 {
+		t.Errorf("SystemRisk.Items = %d, want 1", got)
+	}
+}
+
+func TestAddItemInvalidVector(t *testing.T) {
+	poam := NewPOAM("CSO-1")
+	poam.AddItem(POAMItem{ControlID: "AC-2", Severity: "High", Status: "Open", CVSSVector: "CVSS:3.1/AV:N"})
+	if len(poam.POAMItems) != 1 || poam.POAMItems[0].RiskScore != nil {
+		t.Fatalf("AddItem should keep an item with an invalid vector unscored, got %+v", poam.POAMItems)
+	}
+	if got := poam.RiskAdjustment.TotalRiskScore; got != severityNominalScore["High"] {
+		t.Errorf("TotalRiskScore = %.1f, want the nominal High score", got)
+	}
+
+	if err := poam.AddItemChecked(POAMItem{ControlID: "AC-2", Severity: "High", Status: "Open", CVSSVector: "CVSS:3.1/AV:N"}); err == nil {
+		t.Error("AddItemChecked accepted an invalid vector")
+	}
+	if len(poam.POAMItems) != 1 {
+		t.Errorf("rejected item was added")
+	}
+
+	sar := NewSecurityAssessmentReport("CSO-1", "Annual")
+	sar.AddControlFinding(ControlFinding{ControlID: "AC-2", Severity: "High", Status: "Other Than Satisfied", CVSSVector: "bogus"})
+	if err := sar.AddControlFindingChecked(ControlFinding{ControlID: "AC-2", Severity: "High", Status: "Other Than Satisfied", CVSSVector: "bogus"}); err == nil {
+		t.Error("AddControlFindingChecked accepted an invalid vector")
+	}
+	if len(sar.ControlFindings) != 1 {
+		t.Errorf("ControlFindings = %d, want 1", len(sar.ControlFindings))
+	}
+}
+
+func TestSAROverallRisk(t *testing.T) {
+	tests := []struct {
+		name     string
+		high     int
+		moderate int
+		want     string
+	}{
+		{name: "ten Moderate", moderate: 10, want: "Low"},
+		{name: "eleven Moderate", moderate: 11, want: "Moderate"},
+		{name: "three High", high: 3, want: "Moderate"},
+		{name: "four High", high: 4, want: "High"},
+	}
+	for _, tt := range tests {
+		sar := NewSecurityAssessmentReport("CSO-1", "Annual")
+		for i := 0; i < tt.high; i++ {
+			sar.AddControlFinding(ControlFinding{ControlID: "AC-2", Severity: "High", Status: "Other Than Satisfied"})
+		}
+		for i := 0; i < tt.moderate; i++ {
+			sar.AddControlFinding(ControlFinding{ControlID: "CM-6", Severity: "Moderate", Status: "Other Than Satisfied"})
+		}
+		if got := sar.ExecutiveSummary.OverallRisk; got != tt.want {
+			t.Errorf("%s: OverallRisk = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
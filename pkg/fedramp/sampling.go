@@ -0,0 +1,414 @@
+package fedramp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SamplingPopulation is a list of items a sample is drawn from, such as an
+// asset inventory, change tickets or user accounts
+type SamplingPopulation struct {
+	Name  string         `json:"name"`
+	Type  string         `json:"type,omitempty"` // assets, changes, accounts, controls
+	Items []SamplingItem `json:"items"`
+}
+
+// SamplingItem is one member of a population
+type SamplingItem struct {
+	ID         string            `json:"id"`
+	Stratum    string            `json:"stratum,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// SamplingParameters determine the sample size
+type SamplingParameters struct {
+	Preset             string  `json:"preset,omitempty"`
+	Confidence         float64 `json:"confidence"`          // e.g. 0.95
+	TolerableDeviation float64 `json:"tolerable_deviation"` // e.g. 0.05
+	ExpectedDeviation  float64 `json:"expected_deviation"`  // e.g. 0.01
+}
+
+// SamplingPresets are the default parameters for each impact level. They
+// follow the attribute sampling tables 3PAOs commonly apply to FedRAMP
+// assessments; organizations may use stricter parameters.
+var SamplingPresets = map[string]SamplingParameters{
+	"low":      {Preset: "low", Confidence: 0.90, TolerableDeviation: 0.10},
+	"moderate": {Preset: "moderate", Confidence: 0.95, TolerableDeviation: 0.05},
+	"high":     {Preset: "high", Confidence: 0.95, TolerableDeviation: 0.03},
+}
+
+// Sampling methods
+const (
+	SamplingRandom     = "random"
+	SamplingStratified = "stratified"
+)
+
+// SampleRecord documents a drawn sample so it can be reproduced and audited
+type SampleRecord struct {
+	Population     string             `json:"population"`
+	PopulationType string             `json:"population_type,omitempty"`
+	PopulationSize int                `json:"population_size"`
+	PopulationHash string             `json:"population_hash"` // SHA-256 of the sorted item IDs
+	Method         string             `json:"method"`
+	Algorithm      string             `json:"algorithm"`
+	Seed           string             `json:"seed"`
+	Parameters     SamplingParameters `json:"parameters"`
+	SampleSize     int                `json:"sample_size"`
+	Strata         []StratumSample    `json:"strata,omitempty"`
+	Items          []string           `json:"items"`
+	DrawnAt        time.Time          `json:"drawn_at"`
+}
+
+// StratumSample is the part of a stratified sample drawn from one stratum
+type StratumSample struct {
+	Stratum        string   `json:"stratum"`
+	PopulationSize int      `json:"population_size"`
+	SampleSize     int      `json:"sample_size"`
+	Items          []string `json:"items"`
+}
+
+// SampleAlgorithm describes how items are selected: each item is ranked by
+// the hex SHA-256 of "<seed>:<item id>" and the lowest ranks are selected.
+// The result depends only on the seed and the set of IDs, not on the order of
+// the population.
+const SampleAlgorithm = "sha256-rank"
+
+// Validate checks that the parameters are usable
+func (p SamplingParameters) Validate() error {
+	if p.Confidence <= 0 || p.Confidence >= 1 {
+		return fmt.Errorf("confidence level must be between 0 and 1, got %v", p.Confidence)
+	}
+	if p.TolerableDeviation <= 0 || p.TolerableDeviation >= 1 {
+		return fmt.Errorf("tolerable deviation must be between 0 and 1, got %v", p.TolerableDeviation)
+	}
+	if p.ExpectedDeviation < 0 || p.ExpectedDeviation >= p.TolerableDeviation {
+		return fmt.Errorf("expected deviation must be at least 0 and below the tolerable deviation")
+	}
+	return nil
+}
+
+// SampleSize returns the attribute sample size for a population: the
+// smallest n for which finding no more than the expected number of
+// deviations gives the required confidence that the deviation rate is below
+// the tolerable rate (binomial model), reduced with the finite population
+// correction n / (1 + (n-1)/N)
+func SampleSize(populationSize int, params SamplingParameters) (int, error) {
+	if err := params.Validate(); err != nil {
+		return 0, err
+	}
+	if populationSize <= 0 {
+		return 0, nil
+	}
+
+	risk := 1 - params.Confidence
+	n := 1
+	for ; n < 100000; n++ {
+		allowed := int(math.Ceil(float64(n)*params.ExpectedDeviation - 1e-9))
+		if binomialCDF(allowed, n, params.TolerableDeviation) <= risk {
+			break
+		}
+	}
+
+	adjusted := int(math.Ceil(float64(n) / (1 + float64(n-1)/float64(populationSize))))
+	if adjusted > populationSize {
+		adjusted = populationSize
+	}
+	return adjusted, nil
+}
+
+// binomialCDF returns P(X <= k) for X ~ Binomial(n, p)
+func binomialCDF(k, n int, p float64) float64 {
+	sum := 0.0
+	for i := 0; i <= k && i <= n; i++ {
+		lg := lgamma(float64(n+1)) - lgamma(float64(i+1)) - lgamma(float64(n-i+1))
+		sum += math.Exp(lg + float64(i)*math.Log(p) + float64(n-i)*math.Log(1-p))
+	}
+	return sum
+}
+
+func lgamma(x float64) float64 {
+	v, _ := math.Lgamma(x)
+	return v
+}
+
+// NewSampleSeed returns a random seed for a new sample
+func NewSampleSeed() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// DrawSample selects a sample from the population. A stratified sample
+// allocates the sample size to strata in proportion to their size, with at
+// least one item from each stratum, so it can exceed the computed size.
+func DrawSample(population *SamplingPopulation, params SamplingParameters, method, seed string) (*SampleRecord, error) {
+	if seed == "" {
+		return nil, fmt.Errorf("a seed is required")
+	}
+	ids := make(map[string]bool, len(population.Items))
+	for _, item := range population.Items {
+		if item.ID == "" {
+			return nil, fmt.Errorf("population %s has an item without ID", population.Name)
+		}
+		if ids[item.ID] {
+			return nil, fmt.Errorf("population %s lists %s more than once", population.Name, item.ID)
+		}
+		ids[item.ID] = true
+	}
+	size, err := SampleSize(len(population.Items), params)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &SampleRecord{
+		Population:     population.Name,
+		PopulationType: population.Type,
+		PopulationSize: len(population.Items),
+		PopulationHash: population.Hash(),
+		Method:         method,
+		Algorithm:      SampleAlgorithm,
+		Seed:           seed,
+		Parameters:     params,
+		DrawnAt:        time.Now(),
+	}
+
+	switch method {
+	case SamplingRandom:
+		record.Items = rankSampleItems(population.Items, seed)[:size]
+	case SamplingStratified:
+		strata := make(map[string][]SamplingItem)
+		var names []string
+		for _, item := range population.Items {
+			if _, ok := strata[item.Stratum]; !ok {
+				names = append(names, item.Stratum)
+			}
+			strata[item.Stratum] = append(strata[item.Stratum], item)
+		}
+		sort.Strings(names)
+		if len(names) == 1 && names[0] == "" {
+			return nil, fmt.Errorf("population %s has no strata", population.Name)
+		}
+		for i, allocated := range allocateSample(names, strata, size) {
+			ranked := rankSampleItems(strata[names[i]], seed)[:allocated]
+			record.Strata = append(record.Strata, StratumSample{
+				Stratum:        names[i],
+				PopulationSize: len(strata[names[i]]),
+				SampleSize:     allocated,
+				Items:          ranked,
+			})
+			record.Items = append(record.Items, ranked...)
+		}
+	default:
+		return nil, fmt.Errorf("unknown sampling method %q", method)
+	}
+	record.SampleSize = len(record.Items)
+	return record, nil
+}
+
+// allocateSample splits size across strata proportionally using the largest
+// remainder method, giving every stratum at least one item
+func allocateSample(names []string, strata map[string][]SamplingItem, size int) []int {
+	total := 0
+	for _, name := range names {
+		total += len(strata[name])
+	}
+	allocation := make([]int, len(names))
+	if total == 0 {
+		return allocation
+	}
+	type remainder struct {
+		index int
+		value float64
+	}
+	remainders := make([]remainder, len(names))
+	assigned := 0
+	for i, name := range names {
+		share := float64(size) * float64(len(strata[name])) / float64(total)
+		allocation[i] = int(share)
+		remainders[i] = remainder{i, share - float64(allocation[i])}
+		assigned += allocation[i]
+	}
+	sort.SliceStable(remainders, func(a, b int) bool {
+		return remainders[a].value > remainders[b].value
+	})
+	for i := 0; assigned < size && i < len(remainders); i++ {
+		allocation[remainders[i].index]++
+		assigned++
+	}
+	for i, name := range names {
+		if allocation[i] == 0 {
+			allocation[i] = 1
+		}
+		if allocation[i] > len(strata[name]) {
+			allocation[i] = len(strata[name])
+		}
+	}
+	return allocation
+}
+
+// rankSampleItems orders item IDs by their SHA-256 rank under the seed
+func rankSampleItems(items []SamplingItem, seed string) []string {
+	type ranked struct {
+		id   string
+		rank string
+	}
+	ranks := make([]ranked, len(items))
+	for i, item := range items {
+		sum := sha256.Sum256([]byte(seed + ":" + item.ID))
+		ranks[i] = ranked{item.ID, hex.EncodeToString(sum[:])}
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].rank != ranks[j].rank {
+			return ranks[i].rank < ranks[j].rank
+		}
+		return ranks[i].id < ranks[j].id
+	})
+	ids := make([]string, len(ranks))
+	for i, r := range ranks {
+		ids[i] = r.id
+	}
+	return ids
+}
+
+// Hash returns the SHA-256 of the sorted item IDs, one per line, so a
+// recorded sample can be checked against the population it was drawn from
+func (p *SamplingPopulation) Hash() string {
+	ids := make([]string, len(p.Items))
+	for i, item := range p.Items {
+		ids[i] = item.ID
+	}
+	sort.Strings(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadSamplingPopulation reads a population from a JSON file in the
+// SamplingPopulation format, or from a CSV file with a header row. For CSV
+// files, idColumn names the item ID column (default: the first column) and
+// stratumColumn, if set, the column to stratify by; the other columns become
+// attributes.
+func LoadSamplingPopulation(path, idColumn, stratumColumn string) (*SamplingPopulation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open population: %w", err)
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var population SamplingPopulation
+		if err := json.NewDecoder(f).Decode(&population); err != nil {
+			return nil, fmt.Errorf("failed to parse population: %w", err)
+		}
+		if population.Name == "" {
+			population.Name = name
+		}
+		if stratumColumn != "" {
+			for i := range population.Items {
+				population.Items[i].Stratum = population.Items[i].Attributes[stratumColumn]
+			}
+		}
+		return &population, nil
+	}
+	return readCSVPopulation(f, name, idColumn, stratumColumn)
+}
+
+func readCSVPopulation(r io.Reader, name, idColumn, stratumColumn string) (*SamplingPopulation, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read population header: %w", err)
+	}
+	column := func(want string) (int, error) {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), want) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("population has no %q column", want)
+	}
+	idIndex, stratumIndex := 0, -1
+	if idColumn != "" {
+		if idIndex, err = column(idColumn); err != nil {
+			return nil, err
+		}
+	}
+	if stratumColumn != "" {
+		if stratumIndex, err = column(stratumColumn); err != nil {
+			return nil, err
+		}
+	}
+
+	population := &SamplingPopulation{Name: name, Items: make([]SamplingItem, 0)}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read population: %w", err)
+		}
+		item := SamplingItem{ID: strings.TrimSpace(row[idIndex]), Attributes: make(map[string]string)}
+		if item.ID == "" {
+			continue
+		}
+		for i, value := range row {
+			if i < len(header) && i != idIndex {
+				item.Attributes[header[i]] = value
+			}
+		}
+		if stratumIndex >= 0 {
+			item.Stratum = row[stratumIndex]
+		}
+		population.Items = append(population.Items, item)
+	}
+	return population, nil
+}
+
+// RecordSample adds a drawn sample to the SAP sampling strategy, replacing an
+// earlier sample of the same population. The confidence level lists the level
+// of each population when they differ.
+func (sap *SecurityAssessmentPlan) RecordSample(record *SampleRecord) {
+	strategy := &sap.Methodology.SamplingStrategy
+	strategy.Approach = "Statistical"
+	if strategy.SampleSizes == nil {
+		strategy.SampleSizes = make(map[string]int)
+	}
+	strategy.SampleSizes[record.Population] = record.SampleSize
+
+	kept := strategy.Samples[:0]
+	for _, sample := range strategy.Samples {
+		if sample.Population != record.Population {
+			kept = append(kept, sample)
+		}
+	}
+	strategy.Samples = append(kept, *record)
+
+	// One level for the plan, or the level of each population when they differ
+	var levels []string
+	for _, sample := range strategy.Samples {
+		levels = append(levels, fmt.Sprintf("%s %g%%", sample.Population, sample.Parameters.Confidence*100))
+	}
+	strategy.Confidence = fmt.Sprintf("%g%%", record.Parameters.Confidence*100)
+	for _, sample := range strategy.Samples {
+		if sample.Parameters.Confidence != record.Parameters.Confidence {
+			strategy.Confidence = strings.Join(levels, ", ")
+			break
+		}
+	}
+	strategy.Rationale = fmt.Sprintf("Attribute sampling (binomial, finite population correction); items ranked by %s with the recorded seeds", SampleAlgorithm)
+}
@@ -4,7 +4,6 @@
 // Status: Basic structure implemented, integration pending
 // TODO:
 //   - Test case library for all controls
-//   - Sampling methodology calculator
 //   - Assessment schedule optimization
 //   - Integration with MAS requirements
 package fedramp
@@ -105,6 +104,7 @@ type SamplingStrategy struct {
 	SampleSizes  map[string]int    `json:"sample_sizes"`
 	Confidence   string            `json:"confidence_level"`
 	Rationale    string            `json:"rationale"`
+	Samples      []SampleRecord    `json:"samples,omitempty"` // drawn samples with their seeds
 }
 
 // TestingTool represents tools to be used
@@ -147,12 +147,30 @@ type SelectedControl struct {
 type TestProcedure struct {
 	ProcedureID    string   `json:"procedure_id"`
 	ControlID      string   `json:"control_id"`
+	ObjectiveID    string   `json:"objective_id,omitempty"` // SP 800-53A determination, e.g. AC-2(a)[1]
 	Objective      string   `json:"objective"`
+	Methods        []string `json:"methods,omitempty"` // examine, interview, test
+	AssessmentObjects map[string][]string `json:"assessment_objects,omitempty"` // by method
 	TestSteps      []string `json:"test_steps"`
 	ExpectedResult string   `json:"expected_result"`
 	TestData       string   `json:"test_data"`
 	Prerequisites  []string `json:"prerequisites"`
 	Duration       string   `json:"estimated_duration"`
+	Parameters     map[string]string `json:"parameters,omitempty"` // parameter values substituted into the procedure
+	Source         string   `json:"source,omitempty"` // catalog, organization, generic
+	Result         *TestProcedureResult `json:"result,omitempty"` // set once the procedure is executed
+}
+
+// TestProcedureResult records the execution of a test procedure
+type TestProcedureResult struct {
+	Status       string    `json:"status"` // passed, failed, not_applicable
+	ActualResult string    `json:"actual_result"`
+	Evidence     []string  `json:"evidence_refs,omitempty"` // MAS evidence IDs
+	FindingID    string    `json:"finding_id,omitempty"` // MAS finding raised for a failure
+	Severity     string    `json:"severity,omitempty"` // of a failure without a MAS finding
+	CVSSVector   string    `json:"cvss_vector,omitempty"`
+	Tester       string    `json:"tester"`
+	TestedAt     time.Time `json:"tested_at"`
 }
 
 // RulesOfEngagement defines assessment rules
@@ -233,7 +251,9 @@ func (sap *SecurityAssessmentPlan) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(sap, "", "  ")
 }
 
-// GenerateTestProcedures creates standard test procedures for controls
+// GenerateTestProcedures creates standard test procedures for controls. Use
+// TestProcedureLibrary for procedures derived from the assessment objectives
+// of the catalog.
 func GenerateTestProcedures(controlIDs []string) []TestProcedure {
 	procedures := make([]TestProcedure, 0)
 	
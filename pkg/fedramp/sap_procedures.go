@@ -0,0 +1,701 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocomply/fedramp/bundled"
+	"github.com/gocomply/fedramp/pkg/fedramp/common"
+)
+
+// ControlAssessment holds the NIST SP 800-53A assessment objectives, methods
+// and objects of one control, as carried in the FedRAMP resolved catalogs
+type ControlAssessment struct {
+	ControlID  string                `json:"control_id"` // AC-2(1)
+	OSCALID    string                `json:"oscal_id"`   // ac-2.1
+	Title      string                `json:"title"`
+	Parameters []ControlParameter    `json:"parameters,omitempty"`
+	Objectives []AssessmentObjective `json:"objectives"`
+	Objects    map[string][]string   `json:"assessment_objects,omitempty"` // by method
+}
+
+// ControlParameter is an assignment in a control. Value holds the FedRAMP
+// constraint, if the baseline defines one.
+type ControlParameter struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Value string `json:"value,omitempty"`
+}
+
+// AssessmentObjective is a determination statement an assessor makes a
+// finding against. Assignments in Text are written as {{ param-id }}.
+type AssessmentObjective struct {
+	ID      string   `json:"id"`    // ac-2.a_obj.1
+	Label   string   `json:"label"` // AC-2(a)[1]
+	Text    string   `json:"text"`  // Determine if the organization ...
+	Methods []string `json:"methods"`
+}
+
+// Assessment methods
+const (
+	MethodExamine   = "examine"
+	MethodInterview = "interview"
+	MethodTest      = "test"
+)
+
+// Test procedure sources
+const (
+	ProcedureSourceCatalog      = "catalog"
+	ProcedureSourceOrganization = "organization"
+	ProcedureSourceGeneric      = "generic"
+)
+
+// assessmentMethodOrder sorts methods the way SP 800-53A lists them
+var assessmentMethodOrder = []string{MethodExamine, MethodInterview, MethodTest}
+
+// assessmentMethodSteps describe what the assessor does for each method
+var assessmentMethodSteps = map[string]string{
+	MethodExamine:   "Examine the documents and records listed under the examine objects to determine if %s",
+	MethodInterview: "Interview the personnel listed under the interview objects to determine if %s",
+	MethodTest:      "Test the processes and mechanisms listed under the test objects to determine if %s",
+}
+
+// assessmentMethodMinutes estimate the effort of each method per objective
+var assessmentMethodMinutes = map[string]int{
+	MethodExamine:   30,
+	MethodInterview: 30,
+	MethodTest:      60,
+}
+
+// XML view of the resolved catalogs. The oscalkit catalog model keeps a
+// single paragraph per part, which drops all but the last assessment object.
+type assessmentCatalogXML struct {
+	Groups   []assessmentCatalogXML `xml:"group"`
+	Controls []assessmentControlXML `xml:"control"`
+}
+
+type assessmentControlXML struct {
+	ID     string `xml:"id,attr"`
+	Title  string `xml:"title"`
+	Params []struct {
+		ID          string   `xml:"id,attr"`
+		Label       string   `xml:"label"`
+		Constraints []string `xml:"constraint"`
+	} `xml:"param"`
+	Props    []assessmentPropXML    `xml:"prop"`
+	Parts    []assessmentPartXML    `xml:"part"`
+	Controls []assessmentControlXML `xml:"control"`
+}
+
+type assessmentPartXML struct {
+	ID    string              `xml:"id,attr"`
+	Name  string              `xml:"name,attr"`
+	Props []assessmentPropXML `xml:"prop"`
+	Paras []struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"p"`
+	Parts []assessmentPartXML `xml:"part"`
+}
+
+type assessmentPropXML struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+var (
+	insertPattern      = regexp.MustCompile(`<insert param-id="([^"]*)"\s*/>`)
+	markupPattern      = regexp.MustCompile(`<[^>]*>`)
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9._-]+)\s*\}\}`)
+	controlIDPattern   = regexp.MustCompile(`^([a-z]{2})-0*([0-9]+)(?:\(0*([0-9]+)\)|\.0*([0-9]+))?$`)
+)
+
+// ReadControlAssessments reads the assessment objectives of every control in
+// an OSCAL XML catalog
+func ReadControlAssessments(r io.Reader) ([]ControlAssessment, error) {
+	var doc assessmentCatalogXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %v", err)
+	}
+	var controls []ControlAssessment
+	var walk func(group assessmentCatalogXML)
+	var addControl func(control assessmentControlXML)
+	addControl = func(control assessmentControlXML) {
+		controls = append(controls, newControlAssessment(control))
+		for _, enhancement := range control.Controls {
+			addControl(enhancement)
+		}
+	}
+	walk = func(group assessmentCatalogXML) {
+		for _, control := range group.Controls {
+			addControl(control)
+		}
+		for _, child := range group.Groups {
+			walk(child)
+		}
+	}
+	walk(doc)
+	if len(controls) == 0 {
+		return nil, fmt.Errorf("catalog contains no controls")
+	}
+	return controls, nil
+}
+
+// OpenControlAssessments reads the assessment objectives from a catalog file
+func OpenControlAssessments(path string) ([]ControlAssessment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog: %v", err)
+	}
+	defer f.Close()
+	return ReadControlAssessments(f)
+}
+
+// BundledControlAssessments reads the assessment objectives from the bundled
+// resolved catalog of a baseline
+func BundledControlAssessments(level common.BaselineLevel) ([]ControlAssessment, error) {
+	f, err := bundled.CatalogOSCAL(level)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadControlAssessments(f)
+}
+
+func newControlAssessment(control assessmentControlXML) ControlAssessment {
+	result := ControlAssessment{
+		ControlID: strings.ToUpper(control.ID),
+		OSCALID:   control.ID,
+		Title:     strings.TrimSpace(control.Title),
+		Objects:   make(map[string][]string),
+	}
+	for _, prop := range control.Props {
+		if prop.Name == "label" {
+			result.ControlID = strings.TrimSpace(prop.Value)
+		}
+	}
+	for _, param := range control.Params {
+		p := ControlParameter{ID: param.ID, Label: normalizeSpace(param.Label)}
+		if len(param.Constraints) > 0 {
+			p.Value = normalizeSpace(strings.Join(param.Constraints, "; "))
+		}
+		result.Parameters = append(result.Parameters, p)
+	}
+
+	var controlMethods []string
+	for _, part := range control.Parts {
+		if part.Name != "assessment" {
+			continue
+		}
+		methods := partMethods(part)
+		for _, method := range methods {
+			if !containsString(controlMethods, method) {
+				controlMethods = append(controlMethods, method)
+			}
+			for _, objects := range part.Parts {
+				if objects.Name != "objects" {
+					continue
+				}
+				for _, p := range objects.Paras {
+					result.Objects[method] = append(result.Objects[method], partText(p.Inner))
+				}
+			}
+		}
+	}
+	sortAssessmentMethods(controlMethods)
+
+	for _, part := range control.Parts {
+		if part.Name == "objective" {
+			result.Objectives = append(result.Objectives, collectObjectives(part, result.ControlID, controlMethods)...)
+		}
+	}
+	return result
+}
+
+// collectObjectives turns the objective tree of a control into determination
+// statements. A part with assessment methods is one objective; its
+// sub-parts are folded into its text. Parts without methods contribute
+// their text as the lead-in of the objectives below them.
+func collectObjectives(top assessmentPartXML, controlID string, controlMethods []string) []AssessmentObjective {
+	lead := strings.TrimSuffix(paragraphs(top), ":")
+	if !hasObjectiveParts(top) {
+		return []AssessmentObjective{{
+			ID:      top.ID,
+			Label:   controlID,
+			Text:    sentence(lead),
+			Methods: methodsOrDefault(partMethods(top), controlMethods),
+		}}
+	}
+
+	var objectives []AssessmentObjective
+	var walk func(part assessmentPartXML, prefix string)
+	walk = func(part assessmentPartXML, prefix string) {
+		text := paragraphs(part)
+		methods := partMethods(part)
+		if len(methods) > 0 || partProp(part, "response-point") || !hasObjectiveParts(part) {
+			objectives = append(objectives, AssessmentObjective{
+				ID:      part.ID,
+				Label:   partLabel(part, controlID),
+				Text:    sentence(joinObjectiveText(lead, prefix, objectiveText(part))),
+				Methods: methodsOrDefault(methods, controlMethods),
+			})
+			return
+		}
+		if text != "" {
+			prefix = joinObjectiveText("", prefix, text)
+		}
+		for _, child := range part.Parts {
+			if child.Name == "objective" {
+				walk(child, prefix)
+			}
+		}
+	}
+	for _, child := range top.Parts {
+		if child.Name == "objective" {
+			walk(child, "")
+		}
+	}
+	return objectives
+}
+
+// objectiveText is the text of a part followed by the text of its
+// sub-parts
+func objectiveText(part assessmentPartXML) string {
+	text := paragraphs(part)
+	var children []string
+	for _, child := range part.Parts {
+		if child.Name == "objective" {
+			if childText := objectiveText(child); childText != "" {
+				children = append(children, trimObjectiveText(childText))
+			}
+		}
+	}
+	if len(children) == 0 {
+		return text
+	}
+	text = strings.TrimSuffix(trimObjectiveText(text), ":")
+	return strings.TrimSpace(text + ": " + strings.Join(children, "; "))
+}
+
+func joinObjectiveText(parts ...string) string {
+	var kept []string
+	for i, part := range parts {
+		part = trimObjectiveText(part)
+		if part == "" {
+			continue
+		}
+		if i < len(parts)-1 {
+			part = strings.TrimSuffix(part, ":")
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, " ")
+}
+
+// trimObjectiveText drops the list punctuation SP 800-53A leaves at the end
+// of each item
+func trimObjectiveText(text string) string {
+	text = strings.TrimSpace(text)
+	for {
+		trimmed := strings.TrimSpace(strings.TrimRight(text, ";,."))
+		for _, conjunction := range []string{" and", " or", "; and", "; or"} {
+			trimmed = strings.TrimSuffix(trimmed, conjunction)
+		}
+		if trimmed == text {
+			return text
+		}
+		text = trimmed
+	}
+}
+
+func sentence(text string) string {
+	text = trimObjectiveText(text)
+	if text == "" {
+		return ""
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+func paragraphs(part assessmentPartXML) string {
+	var texts []string
+	for _, p := range part.Paras {
+		if text := partText(p.Inner); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// partText converts catalog markup to text, writing assignments as
+// {{ param-id }}
+func partText(markup string) string {
+	text := insertPattern.ReplaceAllString(markup, "{{ $1 }}")
+	text = markupPattern.ReplaceAllString(text, "")
+	for _, entity := range [][2]string{{"&lt;", "<"}, {"&gt;", ">"}, {"&quot;", `"`}, {"&apos;", "'"}, {"&amp;", "&"}} {
+		text = strings.ReplaceAll(text, entity[0], entity[1])
+	}
+	return normalizeSpace(text)
+}
+
+func normalizeSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func hasObjectiveParts(part assessmentPartXML) bool {
+	for _, child := range part.Parts {
+		if child.Name == "objective" {
+			return true
+		}
+	}
+	return false
+}
+
+func partProp(part assessmentPartXML, name string) bool {
+	for _, prop := range part.Props {
+		if prop.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func partLabel(part assessmentPartXML, controlID string) string {
+	for _, prop := range part.Props {
+		if prop.Name == "label" {
+			return strings.TrimSpace(prop.Value)
+		}
+	}
+	return controlID
+}
+
+func partMethods(part assessmentPartXML) []string {
+	var methods []string
+	for _, prop := range part.Props {
+		method := strings.ToLower(strings.TrimSpace(prop.Value))
+		if prop.Name == "method" && !containsString(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	sortAssessmentMethods(methods)
+	return methods
+}
+
+func methodsOrDefault(methods, defaults []string) []string {
+	if len(methods) > 0 {
+		return methods
+	}
+	if len(defaults) > 0 {
+		return defaults
+	}
+	return []string{MethodExamine}
+}
+
+func sortAssessmentMethods(methods []string) {
+	rank := func(method string) int {
+		for i, m := range assessmentMethodOrder {
+			if m == method {
+				return i
+			}
+		}
+		return len(assessmentMethodOrder)
+	}
+	sort.SliceStable(methods, func(i, j int) bool {
+		return rank(methods[i]) < rank(methods[j])
+	})
+}
+
+// OSCALControlID converts a control ID such as "AC-2 (1)" or "AC-02(01)" to
+// its catalog form "ac-2.1"
+func OSCALControlID(controlID string) string {
+	id := strings.ToLower(strings.Join(strings.Fields(controlID), ""))
+	match := controlIDPattern.FindStringSubmatch(id)
+	if match == nil {
+		return id
+	}
+	result := match[1] + "-" + match[2]
+	if match[3] != "" {
+		result += "." + match[3]
+	} else if match[4] != "" {
+		result += "." + match[4]
+	}
+	return result
+}
+
+// TestProcedureLibrary generates test procedures from catalog assessment
+// objectives. Parameter values from the SSP are substituted into the
+// objectives, and organization-specific procedures override the catalog.
+type TestProcedureLibrary struct {
+	Controls   map[string]*ControlAssessment // by OSCAL ID
+	Parameters map[string]string             // SSP parameter values by parameter ID
+	Overrides  []TestProcedure               // organization-specific procedures
+}
+
+// testProcedureLibraryFile is the file format of organization procedures
+type testProcedureLibraryFile struct {
+	Procedures []TestProcedure `json:"procedures"`
+}
+
+// NewTestProcedureLibrary creates a library from catalog controls
+func NewTestProcedureLibrary(controls []ControlAssessment) *TestProcedureLibrary {
+	library := &TestProcedureLibrary{
+		Controls:   make(map[string]*ControlAssessment),
+		Parameters: make(map[string]string),
+	}
+	for i := range controls {
+		library.Controls[controls[i].OSCALID] = &controls[i]
+	}
+	return library
+}
+
+// LoadOverrides reads organization-specific procedures from a JSON file
+// holding {"procedures": [...]}. A procedure with an objective_id replaces
+// the catalog procedure for that objective, or is added when the catalog has
+// no such objective; one without an objective_id replaces every catalog
+// procedure of its control. Procedure text may refer to parameter values as
+// {{ param-id }}.
+func (l *TestProcedureLibrary) LoadOverrides(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read test procedures: %v", err)
+	}
+	var file testProcedureLibraryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse test procedures: %v", err)
+	}
+	for i, procedure := range file.Procedures {
+		if strings.TrimSpace(procedure.ControlID) == "" {
+			return fmt.Errorf("test procedure %d has no control_id", i+1)
+		}
+		if procedure.Objective == "" && len(procedure.TestSteps) == 0 {
+			return fmt.Errorf("test procedure %d for %s has no objective or test steps", i+1, procedure.ControlID)
+		}
+	}
+	l.Overrides = append(l.Overrides, file.Procedures...)
+	return nil
+}
+
+// Procedures returns the test procedures of a control: one per assessment
+// objective, with organization procedures applied. Controls the catalog does
+// not cover get the generic procedure.
+func (l *TestProcedureLibrary) Procedures(controlID string) []TestProcedure {
+	oscalID := OSCALControlID(controlID)
+	control := l.Controls[oscalID]
+
+	var overrides []TestProcedure
+	replaceAll := false
+	for _, procedure := range l.Overrides {
+		if OSCALControlID(procedure.ControlID) != oscalID {
+			continue
+		}
+		procedure.Source = ProcedureSourceOrganization
+		if control != nil {
+			procedure.ControlID = control.ControlID
+		}
+		if procedure.ObjectiveID == "" {
+			replaceAll = true
+		}
+		overrides = append(overrides, l.substituteProcedure(procedure, control))
+	}
+
+	var procedures []TestProcedure
+	switch {
+	case replaceAll:
+		return overrides
+	case control == nil || len(control.Objectives) == 0:
+		if len(overrides) > 0 {
+			return overrides
+		}
+		for _, procedure := range GenerateTestProcedures([]string{controlID}) {
+			procedure.Source = ProcedureSourceGeneric
+			procedures = append(procedures, procedure)
+		}
+		return procedures
+	}
+
+	used := make(map[int]bool)
+	for _, objective := range control.Objectives {
+		procedure := l.objectiveProcedure(control, objective)
+		for i, override := range overrides {
+			if sameObjectiveLabel(override.ObjectiveID, objective.Label) {
+				procedure = override
+				used[i] = true
+				break
+			}
+		}
+		procedures = append(procedures, procedure)
+	}
+	for i, override := range overrides {
+		if !used[i] {
+			procedures = append(procedures, override)
+		}
+	}
+	return procedures
+}
+
+// GenerateTestProcedures returns the test procedures of each control
+func (l *TestProcedureLibrary) GenerateTestProcedures(controlIDs []string) []TestProcedure {
+	procedures := make([]TestProcedure, 0)
+	for _, controlID := range controlIDs {
+		procedures = append(procedures, l.Procedures(controlID)...)
+	}
+	return procedures
+}
+
+func (l *TestProcedureLibrary) objectiveProcedure(control *ControlAssessment, objective AssessmentObjective) TestProcedure {
+	text, parameters := l.substitute(objective.Text, control)
+	claim := text
+	if strings.HasPrefix(claim, "Determine if ") {
+		claim = strings.TrimPrefix(claim, "Determine if ")
+	} else if claim != "" {
+		claim = strings.ToLower(claim[:1]) + claim[1:]
+		text = "Determine if " + claim
+	}
+
+	procedure := TestProcedure{
+		ControlID:         control.ControlID,
+		ObjectiveID:       objective.Label,
+		Objective:         text,
+		Methods:           objective.Methods,
+		AssessmentObjects: make(map[string][]string),
+		ExpectedResult:    sentence(claim) + ".",
+		Parameters:        parameters,
+		Source:            ProcedureSourceCatalog,
+	}
+	minutes := 0
+	for _, method := range objective.Methods {
+		if step, ok := assessmentMethodSteps[method]; ok {
+			procedure.TestSteps = append(procedure.TestSteps, fmt.Sprintf(step, claim))
+		}
+		if objects := control.Objects[method]; len(objects) > 0 {
+			procedure.AssessmentObjects[method] = objects
+		}
+		minutes += assessmentMethodMinutes[method]
+	}
+	procedure.TestSteps = append(procedure.TestSteps,
+		fmt.Sprintf("Record the determination for %s with references to the supporting evidence", objective.Label))
+	procedure.Duration = procedureDuration(minutes)
+	return procedure
+}
+
+// substituteProcedure substitutes parameter values into the text of an
+// organization procedure
+func (l *TestProcedureLibrary) substituteProcedure(procedure TestProcedure, control *ControlAssessment) TestProcedure {
+	parameters := make(map[string]string)
+	sub := func(text string) string {
+		result, used := l.substitute(text, control)
+		for id, value := range used {
+			parameters[id] = value
+		}
+		return result
+	}
+	procedure.Objective = sub(procedure.Objective)
+	steps := make([]string, len(procedure.TestSteps))
+	for i, step := range procedure.TestSteps {
+		steps[i] = sub(step)
+	}
+	procedure.TestSteps = steps
+	procedure.ExpectedResult = sub(procedure.ExpectedResult)
+	procedure.TestData = sub(procedure.TestData)
+	if len(parameters) > 0 {
+		procedure.Parameters = parameters
+	}
+	return procedure
+}
+
+// substitute fills the {{ param-id }} assignments of text with SSP values,
+// falling back to the FedRAMP constraint, and adds the values after the
+// "organization-defined ..." wording SP 800-53A uses for assignments. It
+// returns the values it used by parameter ID.
+func (l *TestProcedureLibrary) substitute(text string, control *ControlAssessment) (string, map[string]string) {
+	used := make(map[string]string)
+	value := func(id string) string {
+		if v := strings.TrimSpace(l.Parameters[id]); v != "" {
+			return v
+		}
+		if control != nil {
+			for _, param := range control.Parameters {
+				if param.ID == id {
+					return param.Value
+				}
+			}
+		}
+		return ""
+	}
+
+	if control != nil {
+		// Only labels that name a single value can be filled in
+		values := make(map[string]string)
+		ambiguous := make(map[string]bool)
+		for _, param := range control.Parameters {
+			v := value(param.ID)
+			if param.Label == "" || v == "" {
+				continue
+			}
+			if previous, ok := values[param.Label]; ok && previous != v {
+				ambiguous[param.Label] = true
+			}
+			values[param.Label] = v
+		}
+		for _, param := range control.Parameters {
+			v := values[param.Label]
+			if v == "" || ambiguous[param.Label] || !strings.Contains(text, param.Label) {
+				continue
+			}
+			text = strings.ReplaceAll(text, param.Label, fmt.Sprintf("%s ({{ %s }})", param.Label, param.ID))
+		}
+	}
+
+	text = placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		id := placeholderPattern.FindStringSubmatch(match)[1]
+		if v := value(id); v != "" {
+			used[id] = v
+			return v
+		}
+		if control != nil {
+			for _, param := range control.Parameters {
+				if param.ID == id && param.Label != "" {
+					return "[Assignment: " + param.Label + "]"
+				}
+			}
+		}
+		return "[Assignment: " + id + "]"
+	})
+	if len(used) == 0 {
+		used = nil
+	}
+	return text, used
+}
+
+func sameObjectiveLabel(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), ""), strings.Join(strings.Fields(b), ""))
+}
+
+func procedureDuration(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	if minutes%60 == 0 {
+		if minutes == 60 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", minutes/60)
+	}
+	return fmt.Sprintf("%.1f hours", float64(minutes)/60)
+}
+
+// SetTestProcedures replaces the test procedures of the SAP, numbering them
+// per control
+func (sap *SecurityAssessmentPlan) SetTestProcedures(procedures []TestProcedure) {
+	sap.TestProcedures = make([]TestProcedure, 0, len(procedures))
+	counts := make(map[string]int)
+	for _, procedure := range procedures {
+		counts[procedure.ControlID]++
+		procedure.ProcedureID = fmt.Sprintf("TP-%s-%d", procedure.ControlID, counts[procedure.ControlID])
+		sap.TestProcedures = append(sap.TestProcedures, procedure)
+	}
+}
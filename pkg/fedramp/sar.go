@@ -51,6 +51,8 @@ type ExecutiveSummary struct {
 	HighFindings      int      `json:"high_findings"`
 	ModerateFindings  int      `json:"moderate_findings"`
 	LowFindings       int      `json:"low_findings"`
+	TotalRiskScore    float64  `json:"total_risk_score"` // sum of residual CVSS scores
+	MaxRiskScore      float64  `json:"max_risk_score"`
 	RecommendedAction string   `json:"recommended_action"` // ATO, ATO with conditions, Denial
 }
 
@@ -92,6 +94,9 @@ type ControlFinding struct {
 	Impact           string    `json:"impact"`
 	Likelihood       string    `json:"likelihood"`
 	RiskRating       string    `json:"risk_rating"`
+	CVSSVector       string    `json:"cvss_vector,omitempty"`
+	MitigatingAdjustments []MitigatingFactor `json:"mitigating_adjustments,omitempty"`
+	RiskScore        *RiskScore `json:"risk_score,omitempty"`
 	Recommendation   string    `json:"recommendation"`
 	CSPResponse      string    `json:"csp_response,omitempty"`
 	RemediationPlan  string    `json:"remediation_plan,omitempty"`
@@ -120,6 +125,8 @@ type RiskSummary struct {
 	TrendAnalysis       string            `json:"trend_analysis"`
 	ComparisonPrevious  string            `json:"comparison_to_previous"`
 	SystematicIssues    []string          `json:"systematic_issues"`
+	RiskByControl       map[string]RiskRollup `json:"risk_by_control"`
+	ExposureTable       []RiskExposureEntry `json:"risk_exposure_table,omitempty"`
 }
 
 // Recommendation provides actionable recommendations
@@ -143,6 +150,7 @@ type SARAssessmentEvidence struct {
 	CollectedAt  time.Time `json:"collected_at"`
 	Location     string    `json:"location"` // file path or reference
 	Hash         string    `json:"hash,omitempty"`
+	LockerID     string    `json:"locker_id,omitempty"`
 }
 
 // ThreePAOStatement provides the 3PAO attestation
@@ -168,20 +176,52 @@ func NewSecurityAssessmentReport(serviceID, assessmentType string) *SecurityAsse
 	}
 }
 
-// AddControlFinding adds a finding to the SAR
+// AddControlFinding adds a finding to the SAR. Findings without an ID are
+// numbered; findings that carry one, such as MAS finding IDs, keep it. An
+// invalid CVSS vector leaves the finding scored by its severity label; use
+// AddControlFindingChecked to reject it instead.
 func (sar *SecurityAssessmentReport) AddControlFinding(finding ControlFinding) {
-	finding.FindingID = fmt.Sprintf("FIND-%s-%d", finding.ControlID, len(sar.ControlFindings)+1)
+	sar.addControlFinding(finding, false)
+}
+
+// AddControlFindingChecked adds a finding to the SAR and rejects findings
+// with an invalid CVSS vector
+func (sar *SecurityAssessmentReport) AddControlFindingChecked(finding ControlFinding) error {
+	return sar.addControlFinding(finding, true)
+}
+
+func (sar *SecurityAssessmentReport) addControlFinding(finding ControlFinding, strict bool) error {
+	if finding.FindingID == "" {
+		finding.FindingID = fmt.Sprintf("FIND-%s-%d", finding.ControlID, len(sar.ControlFindings)+1)
+	}
+	if err := finding.UpdateRiskScore(); err != nil {
+		if strict {
+			return fmt.Errorf("finding %s: %w", finding.FindingID, err)
+		}
+		finding.RiskScore = nil
+	}
 	sar.ControlFindings = append(sar.ControlFindings, finding)
 	sar.updateSummary()
+	return nil
 }
 
-// updateSummary recalculates the executive summary
+// Thresholds on the sum of residual CVSS scores used to rate overall risk.
+// They keep the count-based criteria this replaced: more than three High
+// findings rate High, more than ten Moderate findings rate Moderate, each
+// counted at the nominal score of its severity.
+const (
+	sarHighRiskScore     = 4 * 8.0  // four nominal High findings (32.0)
+	sarModerateRiskScore = 11 * 5.5 // eleven nominal Moderate findings (60.5)
+)
+
+// updateSummary recalculates the executive summary from the finding risk scores
 func (sar *SecurityAssessmentReport) updateSummary() {
 	critical, high, moderate, low := 0, 0, 0, 0
 	
 	for _, finding := range sar.ControlFindings {
 		if finding.Status == "Other Than Satisfied" {
-			switch finding.Severity {
+			_, residual := finding.Scores()
+			switch CVSSSeverity(residual) {
 			case "Critical":
 				critical++
 			case "High":
@@ -194,19 +234,24 @@ func (sar *SecurityAssessmentReport) updateSummary() {
 		}
 	}
 	
+	byControl, system := FindingRiskByControl(sar.ControlFindings)
 	sar.ExecutiveSummary.CriticalFindings = critical
 	sar.ExecutiveSummary.HighFindings = high
 	sar.ExecutiveSummary.ModerateFindings = moderate
 	sar.ExecutiveSummary.LowFindings = low
+	sar.ExecutiveSummary.TotalRiskScore = system.ResidualScore
+	sar.ExecutiveSummary.MaxRiskScore = system.MaxScore
+	sar.RiskSummary.RiskByControl = byControl
+	sar.RiskSummary.TotalRisk = system.Severity
 	
 	// Determine overall risk
-	if critical > 0 {
+	if system.MaxScore >= 9.0 {
 		sar.ExecutiveSummary.OverallRisk = "High"
 		sar.ExecutiveSummary.ComplianceStatus = "Non-Compliant"
-	} else if high > 3 {
+	} else if system.MaxScore >= 7.0 && system.ResidualScore >= sarHighRiskScore {
 		sar.ExecutiveSummary.OverallRisk = "High"
 		sar.ExecutiveSummary.ComplianceStatus = "Compliant with Findings"
-	} else if high > 0 || moderate > 10 {
+	} else if system.MaxScore >= 7.0 || system.ResidualScore >= sarModerateRiskScore {
 		sar.ExecutiveSummary.OverallRisk = "Moderate"
 		sar.ExecutiveSummary.ComplianceStatus = "Compliant with Findings"
 	} else {
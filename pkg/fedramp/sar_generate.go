@@ -0,0 +1,595 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SAR control finding statuses
+const (
+	SARSatisfied          = "Satisfied"
+	SAROtherThanSatisfied = "Other Than Satisfied"
+	SARNotApplicable      = "Not Applicable"
+)
+
+// Test procedure result statuses
+const (
+	TestPassed        = "passed"
+	TestFailed        = "failed"
+	TestNotApplicable = "not_applicable"
+)
+
+// Risk exposure statuses
+const (
+	RiskOpen                      = "Open"
+	RiskCorrectedDuringAssessment = "Corrected During Assessment"
+	RiskAccepted                  = "Risk Accepted"
+)
+
+// RiskExposureEntry is a row of the SAR risk exposure table
+type RiskExposureEntry struct {
+	RiskID       string   `json:"risk_id"` // control finding ID
+	ControlID    string   `json:"control_id"`
+	Weakness     string   `json:"weakness"`
+	Source       string   `json:"source"` // MAS finding or test procedure ID
+	InitialRisk  string   `json:"initial_risk"`
+	ResidualRisk string   `json:"residual_risk"`
+	RiskScore    float64  `json:"risk_score"` // residual score
+	Status       string   `json:"status"`     // Open, Corrected During Assessment, Risk Accepted
+	Evidence     []string `json:"evidence,omitempty"`
+	POAMItemID   string   `json:"poam_item_id,omitempty"`
+}
+
+// SARGenerationOptions adjust GenerateSARFromMAS
+type SARGenerationOptions struct {
+	// Controls supply control titles, e.g. from BundledControlAssessments
+	Controls []ControlAssessment
+	// Locker verifies evidence held in an evidence locker; it is required
+	// when the assessment cites locker evidence
+	Locker EvidenceLocker
+	// Actor is recorded in the chain of custody, by default the lead
+	// assessor
+	Actor string
+}
+
+// systematicIssueFindings is the number of Other Than Satisfied findings in
+// one control family reported as a systematic issue
+const systematicIssueFindings = 3
+
+// keyFindingCount is the number of findings listed in the executive summary
+const keyFindingCount = 5
+
+// GenerateSARFromMAS builds the SAR of a completed MAS assessment. MAS
+// findings become control findings; executed SAP test procedures become test
+// cases, and failed procedures without a MAS finding become findings of
+// their own. Controls whose procedures all passed are reported Satisfied.
+// Every evidence reference must name evidence collected in the assessment,
+// and evidence held in the locker must pass its integrity check.
+func GenerateSARFromMAS(assessment *MASAssessment, sap *SecurityAssessmentPlan, opts SARGenerationOptions) (*SecurityAssessmentReport, error) {
+	if assessment.Status != "complete" {
+		return nil, fmt.Errorf("assessment %s is %s, not complete", assessment.AssessmentID, assessment.Status)
+	}
+	if assessment.Attestation == nil {
+		return nil, fmt.Errorf("assessment %s has no attestation", assessment.AssessmentID)
+	}
+	if sap.ServiceOfferingID != "" && sap.ServiceOfferingID != assessment.ServiceOfferingID {
+		return nil, fmt.Errorf("SAP %s is for %s, not %s", sap.PlanID, sap.ServiceOfferingID, assessment.ServiceOfferingID)
+	}
+
+	evidenceIDs := make(map[string]bool)
+	for _, evidence := range assessment.Evidence {
+		evidenceIDs[evidence.EvidenceID] = true
+	}
+	checkEvidence := func(owner string, refs []string) error {
+		for _, ref := range refs {
+			if !evidenceIDs[ref] {
+				return fmt.Errorf("%s cites unknown evidence %s", owner, ref)
+			}
+		}
+		return nil
+	}
+	masFindings := make(map[string]AssessmentFinding)
+	for _, finding := range assessment.Findings {
+		if err := checkEvidence("finding "+finding.FindingID, finding.Evidence); err != nil {
+			return nil, err
+		}
+		masFindings[finding.FindingID] = finding
+	}
+	for _, procedure := range sap.TestProcedures {
+		if procedure.Result == nil {
+			continue
+		}
+		if err := checkEvidence("test procedure "+procedure.ProcedureID, procedure.Result.Evidence); err != nil {
+			return nil, err
+		}
+		if id := procedure.Result.FindingID; id != "" {
+			if _, ok := masFindings[id]; !ok {
+				return nil, fmt.Errorf("test procedure %s cites unknown finding %s", procedure.ProcedureID, id)
+			}
+		}
+	}
+
+	titles := make(map[string]string)
+	for _, control := range opts.Controls {
+		titles[control.OSCALID] = control.Title
+	}
+
+	sar := NewSecurityAssessmentReport(assessment.ServiceOfferingID, string(assessment.AssessmentType))
+	end := time.Now()
+	if assessment.EndDate != nil {
+		end = *assessment.EndDate
+	}
+	sar.AssessmentPeriod = AssessmentPeriod{
+		StartDate:      assessment.StartDate,
+		EndDate:        end,
+		TotalAssessors: len(assessmentTeam(assessment)),
+	}
+	sar.AssessmentTeam = AssessmentTeam{
+		LeadAssessor: TeamMember{Name: assessment.ThreePAO.LeadAssessor, Role: "Lead Assessor"},
+		TeamMembers:  make([]TeamMember, 0),
+		ThreePAOName: assessment.ThreePAO.Name,
+	}
+	for _, member := range assessmentTeam(assessment) {
+		if member != assessment.ThreePAO.LeadAssessor {
+			sar.AssessmentTeam.TeamMembers = append(sar.AssessmentTeam.TeamMembers, TeamMember{Name: member, Role: "Assessor"})
+		}
+	}
+	sar.Methodology = sarMethodology(assessment, sap)
+	sar.ThreePAOStatement = ThreePAOStatement{
+		Statement: assessment.Attestation.Statement,
+		SignedBy:  assessment.Attestation.AttestorName,
+		Title:     assessment.Attestation.AttestorTitle,
+		Date:      assessment.Attestation.Date,
+	}
+	for _, evidence := range assessment.Evidence {
+		sar.Evidence = append(sar.Evidence, SARAssessmentEvidence{
+			EvidenceID:  evidence.EvidenceID,
+			Type:        evidence.Type,
+			Description: evidence.Description,
+			ControlIDs:  evidence.ControlsCovered,
+			CollectedBy: evidence.CollectedBy,
+			CollectedAt: evidence.CollectionDate,
+			Location:    evidence.Location,
+			LockerID:    evidence.LockerID,
+		})
+	}
+	actor := opts.Actor
+	if actor == "" {
+		actor = assessment.ThreePAO.LeadAssessor
+	}
+	if err := sar.VerifyEvidence(opts.Locker, actor); err != nil {
+		return nil, err
+	}
+
+	// Report controls in the order the SAP tests them, then controls that
+	// only have MAS findings
+	var controls []string
+	procedures := make(map[string][]TestProcedure)
+	findings := make(map[string][]AssessmentFinding)
+	for _, procedure := range sap.TestProcedures {
+		key := OSCALControlID(procedure.ControlID)
+		if _, seen := procedures[key]; !seen {
+			controls = append(controls, key)
+		}
+		procedures[key] = append(procedures[key], procedure)
+	}
+	for _, finding := range assessment.Findings {
+		key := OSCALControlID(finding.ControlID)
+		if _, tested := procedures[key]; !tested && len(findings[key]) == 0 {
+			controls = append(controls, key)
+		}
+		findings[key] = append(findings[key], finding)
+	}
+
+	var untested []string
+	for _, key := range controls {
+		displayID := key
+		if len(procedures[key]) > 0 {
+			displayID = procedures[key][0].ControlID
+		} else if len(findings[key]) > 0 {
+			displayID = findings[key][0].ControlID
+		}
+		title := titles[key]
+
+		open := 0
+		for _, finding := range findings[key] {
+			controlFinding, exposure := sarFindingFromMAS(finding, displayID, title, assessment.ThreePAO.LeadAssessor)
+			if err := sar.AddControlFindingChecked(controlFinding); err != nil {
+				return nil, err
+			}
+			sar.addExposure(exposure)
+			if controlFinding.Status == SAROtherThanSatisfied {
+				open++
+			}
+		}
+
+		passed, notApplicable, notTested := 0, 0, 0
+		var evidence []string
+		for _, procedure := range procedures[key] {
+			sar.TestCases = append(sar.TestCases, sarTestCase(procedure))
+			result := procedure.Result
+			switch {
+			case result == nil:
+				notTested++
+				continue
+			case result.Status == TestPassed:
+				passed++
+				evidence = appendUnique(evidence, result.Evidence...)
+			case result.Status == TestNotApplicable:
+				notApplicable++
+			case result.Status == TestFailed && result.FindingID == "":
+				controlFinding := sarFindingFromProcedure(procedure, title)
+				if err := sar.AddControlFindingChecked(controlFinding); err != nil {
+					return nil, err
+				}
+				added := sar.ControlFindings[len(sar.ControlFindings)-1]
+				sar.addExposure(RiskExposureEntry{
+					RiskID:    added.FindingID,
+					ControlID: added.ControlID,
+					Weakness:  added.Description,
+					Source:    procedure.ProcedureID,
+					Status:    RiskOpen,
+					Evidence:  added.Evidence,
+				})
+				open++
+			case result.Status == TestFailed:
+				// Reported through the MAS finding it cites
+			default:
+				return nil, fmt.Errorf("test procedure %s has unknown result status %q", procedure.ProcedureID, result.Status)
+			}
+		}
+		if notTested > 0 {
+			untested = append(untested, displayID)
+		}
+		if open > 0 || notTested > 0 || len(procedures[key]) == 0 {
+			continue
+		}
+		status := SARSatisfied
+		description := fmt.Sprintf("All %d test procedures passed", passed)
+		if passed == 0 {
+			status = SARNotApplicable
+			description = "All test procedures are not applicable"
+		} else if notApplicable > 0 {
+			description += fmt.Sprintf(" (%d not applicable)", notApplicable)
+		}
+		if err := sar.AddControlFindingChecked(ControlFinding{
+			ControlID:    displayID,
+			ControlTitle: title,
+			Status:       status,
+			Description:  description,
+			Evidence:     evidence,
+			TestDate:     latestTestDate(procedures[key]),
+			Tester:       assessment.ThreePAO.LeadAssessor,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if len(untested) > 0 {
+		sar.Methodology.Limitations = append(sar.Methodology.Limitations,
+			fmt.Sprintf("Test procedures without results for %s", strings.Join(untested, ", ")))
+	}
+
+	sar.updateRiskExposure()
+	sar.updateRecommendations()
+	return sar, nil
+}
+
+// LoadTestProcedureResults reads results keyed by procedure ID from a JSON
+// file and records them in the SAP
+func (sap *SecurityAssessmentPlan) LoadTestProcedureResults(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read test results: %v", err)
+	}
+	var results map[string]TestProcedureResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return fmt.Errorf("failed to parse test results: %v", err)
+	}
+	return sap.RecordTestResults(results)
+}
+
+// RecordTestResults records results keyed by procedure ID
+func (sap *SecurityAssessmentPlan) RecordTestResults(results map[string]TestProcedureResult) error {
+	index := make(map[string]int)
+	for i, procedure := range sap.TestProcedures {
+		index[procedure.ProcedureID] = i
+	}
+	for id, result := range results {
+		i, ok := index[id]
+		if !ok {
+			return fmt.Errorf("SAP has no test procedure %s", id)
+		}
+		switch result.Status {
+		case TestPassed, TestFailed, TestNotApplicable:
+		default:
+			return fmt.Errorf("test procedure %s has unknown result status %q", id, result.Status)
+		}
+		result := result
+		sap.TestProcedures[i].Result = &result
+	}
+	return nil
+}
+
+// AddSARFindings opens POA&M items for the Other Than Satisfied findings of a
+// SAR that the POA&M does not track yet, and records the item of every
+// finding in the SAR risk exposure table. It returns the IDs of the new items.
+func (poam *PlanOfActionMilestones) AddSARFindings(sar *SecurityAssessmentReport) ([]string, error) {
+	accepted := make(map[string]bool)
+	for _, entry := range sar.RiskSummary.ExposureTable {
+		if entry.Status == RiskAccepted {
+			accepted[entry.RiskID] = true
+		}
+	}
+
+	added := make([]string, 0)
+	for _, item := range GeneratePOAMFromFindingsWithPolicy(sar.ControlFindings, poam.policy()) {
+		if poam.itemForFinding(item.FindingID) != nil {
+			continue
+		}
+		if accepted[item.FindingID] {
+			item.Status = "Risk Accepted"
+		}
+		if err := poam.AddItemChecked(item); err != nil {
+			return added, err
+		}
+		added = append(added, poam.POAMItems[len(poam.POAMItems)-1].ItemID)
+	}
+	for i, entry := range sar.RiskSummary.ExposureTable {
+		if item := poam.itemForFinding(entry.RiskID); item != nil {
+			sar.RiskSummary.ExposureTable[i].POAMItemID = item.ItemID
+		}
+	}
+	return added, nil
+}
+
+func sarFindingFromMAS(finding AssessmentFinding, controlID, title, tester string) (ControlFinding, RiskExposureEntry) {
+	severity := NormalizeSeverity(finding.Severity)
+	result := ControlFinding{
+		ControlID:      controlID,
+		ControlTitle:   title,
+		FindingID:      finding.FindingID,
+		Severity:       severity,
+		Status:         SAROtherThanSatisfied,
+		Description:    finding.Description,
+		Evidence:       finding.Evidence,
+		RiskRating:     severity,
+		Recommendation: finding.Recommendation,
+		TestDate:       finding.DateIdentified,
+		Tester:         tester,
+	}
+	exposure := RiskExposureEntry{
+		RiskID:    finding.FindingID,
+		ControlID: controlID,
+		Weakness:  finding.Description,
+		Source:    "MAS " + finding.FindingID,
+		Status:    RiskOpen,
+		Evidence:  finding.Evidence,
+	}
+	switch finding.Status {
+	case "remediated":
+		result.Status = SARSatisfied
+		exposure.Status = RiskCorrectedDuringAssessment
+		if finding.DateRemediated != nil {
+			result.RemediationPlan = fmt.Sprintf("Corrected during the assessment on %s", finding.DateRemediated.Format("2006-01-02"))
+		}
+	case "risk_accepted":
+		exposure.Status = RiskAccepted
+	}
+	return result, exposure
+}
+
+func sarFindingFromProcedure(procedure TestProcedure, title string) ControlFinding {
+	result := procedure.Result
+	severity := NormalizeSeverity(result.Severity)
+	if severity == "" && result.CVSSVector == "" {
+		severity = "Moderate"
+	}
+	description := fmt.Sprintf("Test procedure %s failed: %s", procedure.ProcedureID, procedure.Objective)
+	if result.ActualResult != "" {
+		description += ". Observed: " + result.ActualResult
+	}
+	return ControlFinding{
+		// Named after the procedure so that regenerating the SAR keeps the
+		// finding, and its POA&M item, under the same ID
+		FindingID:      "FIND-" + procedure.ProcedureID,
+		ControlID:      procedure.ControlID,
+		ControlTitle:   title,
+		Severity:       severity,
+		Status:         SAROtherThanSatisfied,
+		Description:    description,
+		Evidence:       result.Evidence,
+		RiskRating:     severity,
+		CVSSVector:     result.CVSSVector,
+		Recommendation: fmt.Sprintf("Remediate so that the expected result holds: %s", procedure.ExpectedResult),
+		TestDate:       result.TestedAt,
+		Tester:         result.Tester,
+	}
+}
+
+func sarTestCase(procedure TestProcedure) TestCase {
+	testCase := TestCase{
+		TestID:         procedure.ProcedureID,
+		ControlID:      procedure.ControlID,
+		TestObjective:  procedure.Objective,
+		TestProcedure:  strings.Join(procedure.TestSteps, "; "),
+		ExpectedResult: procedure.ExpectedResult,
+		TestEvidence:   make([]string, 0),
+		PassFail:       "Not Tested",
+	}
+	if result := procedure.Result; result != nil {
+		testCase.ActualResult = result.ActualResult
+		testCase.TestEvidence = append(testCase.TestEvidence, result.Evidence...)
+		testCase.TestDate = result.TestedAt
+		testCase.TesterName = result.Tester
+		switch result.Status {
+		case TestPassed:
+			testCase.PassFail = "Pass"
+		case TestFailed:
+			testCase.PassFail = "Fail"
+		case TestNotApplicable:
+			testCase.PassFail = "N/A"
+		}
+	}
+	return testCase
+}
+
+func sarMethodology(assessment *MASAssessment, sap *SecurityAssessmentPlan) SARAssessmentMethod {
+	method := SARAssessmentMethod{
+		Framework:        sap.Methodology.Framework,
+		SamplingApproach: sap.Methodology.SamplingStrategy.Approach,
+		TestingMethods:   make([]string, 0),
+		ToolsUsed:        make([]string, 0),
+		Limitations:      make([]string, 0),
+	}
+	if method.Framework == "" {
+		method.Framework = "NIST SP 800-53A Rev 5"
+	}
+	if rationale := sap.Methodology.SamplingStrategy.Rationale; rationale != "" {
+		method.SamplingApproach = strings.TrimSpace(method.SamplingApproach + ": " + rationale)
+	}
+	for _, m := range assessment.Methods {
+		method.TestingMethods = appendUnique(method.TestingMethods, strings.ToLower(m.MethodType))
+		method.ToolsUsed = appendUnique(method.ToolsUsed, m.ToolsUsed...)
+	}
+	for _, procedure := range sap.TestProcedures {
+		if procedure.Result != nil {
+			method.TestingMethods = appendUnique(method.TestingMethods, procedure.Methods...)
+		}
+	}
+	sortAssessmentMethods(method.TestingMethods)
+	for _, tool := range sap.Methodology.TestingTools {
+		method.ToolsUsed = appendUnique(method.ToolsUsed, tool.Name)
+	}
+	for _, component := range assessment.Scope.ExcludedComponents {
+		method.Limitations = append(method.Limitations, fmt.Sprintf("Component excluded from scope: %s", component))
+	}
+	return method
+}
+
+// addExposure adds a row to the risk exposure table
+func (sar *SecurityAssessmentReport) addExposure(entry RiskExposureEntry) {
+	sar.RiskSummary.ExposureTable = append(sar.RiskSummary.ExposureTable, entry)
+}
+
+// updateRiskExposure scores the risk exposure table and derives the risk by
+// control family, the systematic issues, the key findings and the
+// recommended authorization action
+func (sar *SecurityAssessmentReport) updateRiskExposure() {
+	findings := make(map[string]ControlFinding)
+	for _, finding := range sar.ControlFindings {
+		findings[finding.FindingID] = finding
+	}
+	for i, entry := range sar.RiskSummary.ExposureTable {
+		finding := findings[entry.RiskID]
+		base, residual := finding.Scores()
+		entry.InitialRisk = CVSSSeverity(base)
+		entry.ResidualRisk = CVSSSeverity(residual)
+		entry.RiskScore = roundScore(residual)
+		if entry.Status == RiskCorrectedDuringAssessment {
+			entry.ResidualRisk = CVSSSeverity(0)
+			entry.RiskScore = 0
+		}
+		sar.RiskSummary.ExposureTable[i] = entry
+	}
+
+	sar.RiskSummary.RiskByCategory = make(map[string]int)
+	var open []ControlFinding
+	for _, finding := range sar.ControlFindings {
+		if finding.Status != SAROtherThanSatisfied {
+			continue
+		}
+		family := strings.ToUpper(strings.SplitN(finding.ControlID, "-", 2)[0])
+		sar.RiskSummary.RiskByCategory[family]++
+		open = append(open, finding)
+	}
+
+	families := make([]string, 0, len(sar.RiskSummary.RiskByCategory))
+	for family := range sar.RiskSummary.RiskByCategory {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	sar.RiskSummary.SystematicIssues = make([]string, 0)
+	for _, family := range families {
+		if count := sar.RiskSummary.RiskByCategory[family]; count >= systematicIssueFindings {
+			sar.RiskSummary.SystematicIssues = append(sar.RiskSummary.SystematicIssues,
+				fmt.Sprintf("%d Other Than Satisfied findings in the %s family", count, family))
+		}
+	}
+
+	sort.SliceStable(open, func(i, j int) bool {
+		_, a := open[i].Scores()
+		_, b := open[j].Scores()
+		return a > b
+	})
+	sar.ExecutiveSummary.KeyFindings = make([]string, 0)
+	for i, finding := range open {
+		if i == keyFindingCount {
+			break
+		}
+		_, residual := finding.Scores()
+		sar.ExecutiveSummary.KeyFindings = append(sar.ExecutiveSummary.KeyFindings,
+			fmt.Sprintf("%s (%s, %s): %s", finding.FindingID, finding.ControlID, CVSSSeverity(residual), finding.Description))
+	}
+
+	switch {
+	case sar.ExecutiveSummary.ComplianceStatus == "Non-Compliant":
+		sar.ExecutiveSummary.RecommendedAction = "Denial"
+	case len(open) > 0:
+		sar.ExecutiveSummary.RecommendedAction = "ATO with conditions"
+	default:
+		sar.ExecutiveSummary.RecommendedAction = "ATO"
+	}
+}
+
+// updateRecommendations lists the recommendations of the Other Than
+// Satisfied findings
+func (sar *SecurityAssessmentReport) updateRecommendations() {
+	priorities := map[string]string{"Critical": "Critical", "High": "High", "Moderate": "Medium", "Low": "Low"}
+	for _, finding := range sar.ControlFindings {
+		if finding.Status != SAROtherThanSatisfied || finding.Recommendation == "" {
+			continue
+		}
+		priority := priorities[finding.Severity]
+		if priority == "" {
+			priority = "Low"
+		}
+		sar.Recommendations = append(sar.Recommendations, Recommendation{
+			ID:          fmt.Sprintf("REC-%d", len(sar.Recommendations)+1),
+			Priority:    priority,
+			Category:    strings.ToUpper(strings.SplitN(finding.ControlID, "-", 2)[0]),
+			Description: fmt.Sprintf("%s: %s", finding.FindingID, finding.Recommendation),
+		})
+	}
+}
+
+// assessmentTeam lists the lead assessor and team members once each
+func assessmentTeam(assessment *MASAssessment) []string {
+	var team []string
+	if assessment.ThreePAO.LeadAssessor != "" {
+		team = append(team, assessment.ThreePAO.LeadAssessor)
+	}
+	return appendUnique(team, assessment.ThreePAO.TeamMembers...)
+}
+
+func latestTestDate(procedures []TestProcedure) time.Time {
+	var latest time.Time
+	for _, procedure := range procedures {
+		if procedure.Result != nil && procedure.Result.TestedAt.After(latest) {
+			latest = procedure.Result.TestedAt
+		}
+	}
+	return latest
+}
+
+func appendUnique(values []string, items ...string) []string {
+	for _, item := range items {
+		if item != "" && !containsString(values, item) {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -14,10 +14,15 @@ const (
 	SCNAdaptive      SCNType = "adaptive"
 	SCNTransformative SCNType = "transformative"
 	SCNImpactChange   SCNType = "impact-change"
+	// SCNRoutineRecurring marks routine recurring operations, which do not
+	// require a notification
+	SCNRoutineRecurring SCNType = "routine-recurring"
 )
 
 // SignificantChangeNotification represents an SCN as defined in RFC-0007
 type SignificantChangeNotification struct {
+	ID                string    `json:"id,omitempty"`
+
 	// Required fields for all SCNs
 	ServiceOfferingID string    `json:"service_offering_id"`
 	ThreePAOName      string    `json:"3pao_name,omitempty"`
@@ -33,6 +38,9 @@ type SignificantChangeNotification struct {
 	
 	// SCN Type classification
 	SCNType           SCNType   `json:"scn_type"`
+	ChangeAttributes  *SCNChangeAttributes `json:"change_attributes,omitempty"`
+	Classification    *SCNClassification   `json:"classification,omitempty"`
+	DetectedChanges   []SCNDetectedChange  `json:"detected_changes,omitempty"`
 	
 	// Adaptive change specific fields
 	DateOfChange      *time.Time `json:"date_of_change,omitempty"`
@@ -52,7 +60,10 @@ type SignificantChangeNotification struct {
 	// Metadata
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
-	Status            string     `json:"status"` // draft, submitted, approved, etc.
+	Status            SCNStatus  `json:"status"`
+	History           []SCNTransition `json:"history,omitempty"`
+	DeadlineAlerts    map[string]time.Time `json:"deadline_alerts,omitempty"` // alert key -> when the reminder or alert was sent
+	Revision          int        `json:"revision,omitempty"` // incremented by stores on every save
 }
 
 // NewSCN creates a new Significant Change Notification
@@ -65,41 +76,16 @@ func NewSCN(serviceID, changeType, description, reason string) *SignificantChang
 		ReasonForChange:   reason,
 		CreatedAt:         now,
 		UpdatedAt:         now,
-		Status:           "draft",
+		Status:           SCNStatusDraft,
 		ComponentsAffected: make([]string, 0),
 		ControlsAffected:   make([]string, 0),
 	}
 }
 
-// ClassifySCNType automatically determines the SCN type based on change characteristics
+// ClassifySCNType determines the SCN type from the change attributes using
+// the built-in classification rules
 func (scn *SignificantChangeNotification) ClassifySCNType() error {
-	// This is a simplified classification logic
-	// In practice, this would be more sophisticated
-	
-	if scn.ChangeType == "impact-level-change" {
-		scn.SCNType = SCNImpactChange
-		return nil
-	}
-	
-	// Check for transformative indicators in change type
-	if scn.ChangeType == "new functionality" || scn.ChangeType == "major component" || 
-	   scn.ChangeType == "architecture change" || scn.ChangeType == "new service" {
-		scn.SCNType = SCNTransformative
-		return nil
-	}
-	
-	// Check for transformative indicators in description
-	transformativeKeywords := []string{"new functionality", "major component", "architecture change", "new service", "adding", "introducing"}
-	for _, keyword := range transformativeKeywords {
-		if containsIgnoreCase(scn.ShortDescription, keyword) || containsIgnoreCase(scn.ReasonForChange, keyword) {
-			scn.SCNType = SCNTransformative
-			return nil
-		}
-	}
-	
-	// Default to adaptive
-	scn.SCNType = SCNAdaptive
-	return nil
+	return scn.ClassifyWith(DefaultSCNClassifier())
 }
 
 // ValidateForSubmission checks if the SCN has all required fields for its type
@@ -147,6 +133,9 @@ func (scn *SignificantChangeNotification) ValidateForSubmission() error {
 		
 	case SCNImpactChange:
 		return fmt.Errorf("impact categorization changes require reauthorization, not SCN")
+
+	case SCNRoutineRecurring:
+		return fmt.Errorf("routine recurring changes do not require an SCN")
 	}
 	
 	return nil
@@ -178,12 +167,6 @@ func (scn *SignificantChangeNotification) AddAffectedComponent(component string)
 	}
 }
 
-// SetStatus updates the SCN status
-func (scn *SignificantChangeNotification) SetStatus(status string) {
-	scn.Status = status
-	scn.UpdatedAt = time.Now()
-}
-
 // Helper functions
 func contains(text, substring string) bool {
 	return len(text) >= len(substring) && text[:len(substring)] == substring
@@ -216,6 +199,9 @@ func NewSCNManager() *SCNManager {
 
 // AddNotification adds an SCN to the manager
 func (mgr *SCNManager) AddNotification(id string, scn *SignificantChangeNotification) {
+	if scn.ID == "" {
+		scn.ID = id
+	}
 	mgr.notifications[id] = scn
 }
 
@@ -261,7 +247,7 @@ func (mgr *SCNManager) GenerateSCNReport() map[string]interface{} {
 		
 		// Count by status
 		statusCounts := report["by_status"].(map[string]int)
-		statusCounts[scn.Status]++
+		statusCounts[string(scn.Status)]++
 	}
 	
 	return report
@@ -0,0 +1,398 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocomply/fedramp/pkg/fedramp/frmr"
+)
+
+// SCNChangeAttributes describes a change in structured form so it can be
+// classified without interpreting free text
+type SCNChangeAttributes struct {
+	NewExternalService   bool   `json:"new_external_service,omitempty"` // new leveraged service or interconnection
+	NewDataType          bool   `json:"new_data_type,omitempty"`
+	BoundaryChange       bool   `json:"boundary_change,omitempty"`
+	NewImpactLevel       string `json:"new_impact_level,omitempty"` // FIPS-199 level after the change
+	ArchitectureChange   bool   `json:"architecture_change,omitempty"`
+	NewMajorComponent    bool   `json:"new_major_component,omitempty"`
+	NewFunctionality     bool   `json:"new_functionality,omitempty"`
+	ComponentReplacement bool   `json:"component_replacement,omitempty"` // like-for-like replacement
+	CryptoModuleChange   bool   `json:"crypto_module_change,omitempty"`
+	SecurityConfigChange bool   `json:"security_config_change,omitempty"`
+	RoutinePatch         bool   `json:"routine_patch,omitempty"`
+}
+
+// SCNClassificationRule maps a set of change attribute conditions to an SCN
+// type. A rule fires when every condition in When matches; string conditions
+// of "*" match any non-empty value. Rules are evaluated by ascending priority.
+type SCNClassificationRule struct {
+	ID          string                 `json:"id"`
+	Description string                 `json:"description"`
+	Type        SCNType                `json:"type"`
+	Priority    int                    `json:"priority"`
+	When        map[string]interface{} `json:"when"`
+	Reference   string                 `json:"reference,omitempty"` // FRMR identifier the rule implements
+}
+
+// SCNClassifier evaluates change attributes against classification rules
+type SCNClassifier struct {
+	Source      string                  `json:"source"`
+	Rules       []SCNClassificationRule `json:"rules"`
+	Definitions map[SCNType]string      `json:"definitions,omitempty"`
+}
+
+// SCNClassification records how an SCN type was determined
+type SCNClassification struct {
+	Type              SCNType                    `json:"type"`
+	RuleID            string                     `json:"rule_id"`
+	Reference         string                     `json:"reference,omitempty"`
+	Explanation       string                     `json:"explanation"`
+	MatchedConditions []string                   `json:"matched_conditions,omitempty"`
+	Source            string                     `json:"source"`
+	ClassifiedAt      time.Time                  `json:"classified_at"`
+	Override          *SCNClassificationOverride `json:"override,omitempty"`
+}
+
+// SCNClassificationOverride records a manual change of the classified type
+type SCNClassificationOverride struct {
+	OriginalType  SCNType   `json:"original_type"`
+	Type          SCNType   `json:"type"`
+	Justification string    `json:"justification"`
+	OverriddenBy  string    `json:"overridden_by"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// DefaultSCNClassifier returns the built-in rules, which follow the change
+// categories of RFC-0007 and the FRMR SCN document
+func DefaultSCNClassifier() *SCNClassifier {
+	return &SCNClassifier{
+		Source: "built-in",
+		Rules: []SCNClassificationRule{
+			{ID: "impact-categorization", Priority: 10, Type: SCNImpactChange, When: map[string]interface{}{"new_impact_level": "*"},
+				Description: "Changes to the FIPS-199 security categorization require a new authorization"},
+			{ID: "transformative-external-service", Priority: 20, Type: SCNTransformative, When: map[string]interface{}{"new_external_service": true},
+				Description: "Adding a leveraged external service or interconnection is transformative"},
+			{ID: "transformative-data-type", Priority: 21, Type: SCNTransformative, When: map[string]interface{}{"new_data_type": true},
+				Description: "Processing a new type of federal information is transformative"},
+			{ID: "transformative-boundary", Priority: 22, Type: SCNTransformative, When: map[string]interface{}{"boundary_change": true},
+				Description: "Changes to the authorization boundary are transformative"},
+			{ID: "transformative-architecture", Priority: 23, Type: SCNTransformative, When: map[string]interface{}{"architecture_change": true},
+				Description: "Substantive changes to the system architecture are transformative"},
+			{ID: "transformative-major-component", Priority: 24, Type: SCNTransformative, When: map[string]interface{}{"new_major_component": true},
+				Description: "Adding or removing a major component is transformative"},
+			{ID: "transformative-functionality", Priority: 25, Type: SCNTransformative, When: map[string]interface{}{"new_functionality": true},
+				Description: "New customer-facing functionality that changes the security posture is transformative"},
+			{ID: "adaptive-component-replacement", Priority: 40, Type: SCNAdaptive, When: map[string]interface{}{"component_replacement": true},
+				Description: "Like-for-like replacement of a component is adaptive"},
+			{ID: "adaptive-crypto-module", Priority: 41, Type: SCNAdaptive, When: map[string]interface{}{"crypto_module_change": true},
+				Description: "Changing a cryptographic module is adaptive"},
+			{ID: "adaptive-security-configuration", Priority: 42, Type: SCNAdaptive, When: map[string]interface{}{"security_config_change": true},
+				Description: "Changes to security configuration are adaptive"},
+			{ID: "routine-recurring-patch", Priority: 60, Type: SCNRoutineRecurring, When: map[string]interface{}{"routine_patch": true},
+				Description: "Routine patching is part of routine recurring operations"},
+			{ID: "default-adaptive", Priority: 100, Type: SCNAdaptive, When: map[string]interface{}{},
+				Description: "Changes that match no other rule are treated as adaptive"},
+		},
+		Definitions: make(map[SCNType]string),
+	}
+}
+
+// LoadSCNRules reads a classifier from a local JSON rules file
+func LoadSCNRules(path string) (*SCNClassifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SCN rules: %w", err)
+	}
+
+	var classifier SCNClassifier
+	if err := json.Unmarshal(data, &classifier); err != nil {
+		return nil, fmt.Errorf("failed to parse SCN rules: %w", err)
+	}
+	if classifier.Source == "" {
+		classifier.Source = path
+	}
+	return &classifier, classifier.Validate()
+}
+
+// scnDefinitionTerms maps the FRMR SCN definition terms onto SCN types
+var scnDefinitionTerms = map[string]SCNType{
+	"routine recurring":     SCNRoutineRecurring,
+	"adaptive":              SCNAdaptive,
+	"transformative":        SCNTransformative,
+	"impact categorization": SCNImpactChange,
+}
+
+// AddFRMRDefinitions attaches the change type definitions of an FRMR SCN
+// document to the classifier rules so each classification cites the FRMR
+// definition it applies. The rules themselves are not derived from the
+// document: FRMR defines the change types in prose, so the conditions stay
+// those of the classifier.
+func (c *SCNClassifier) AddFRMRDefinitions(doc *frmr.FRMRDocument) error {
+	references := make(map[SCNType]string)
+	definitions := make(map[SCNType]string)
+	keys := make([]string, 0, len(doc.FRD))
+	for key := range doc.FRD {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, item := range doc.FRD[key] {
+			term := strings.ToLower(item.Term)
+			for prefix, scnType := range scnDefinitionTerms {
+				if strings.HasPrefix(term, prefix) {
+					references[scnType] = item.ID
+					definitions[scnType] = item.Definition
+				}
+			}
+		}
+	}
+	if len(references) == 0 {
+		return fmt.Errorf("FRMR document %s does not define SCN change types", doc.Info.ShortName)
+	}
+
+	if c.Definitions == nil {
+		c.Definitions = make(map[SCNType]string)
+	}
+	for scnType, definition := range definitions {
+		c.Definitions[scnType] = definition
+	}
+	for i := range c.Rules {
+		if ref, ok := references[c.Rules[i].Type]; ok {
+			c.Rules[i].Reference = ref
+		}
+	}
+	c.Source = fmt.Sprintf("%s with %s %s definitions", c.Source, doc.Info.ShortName, doc.Info.CurrentRelease)
+	return nil
+}
+
+// LoadFRMRDefinitions reads an FRMR SCN document and attaches its change type
+// definitions to the classifier rules
+func (c *SCNClassifier) LoadFRMRDefinitions(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open FRMR document: %w", err)
+	}
+	defer f.Close()
+
+	doc, err := frmr.ParseFRMR(f)
+	if err != nil {
+		return err
+	}
+	return c.AddFRMRDefinitions(doc)
+}
+
+// Validate checks that every rule targets a known type and attribute, and
+// that each condition has the type of its attribute
+func (c *SCNClassifier) Validate() error {
+	known := scnAttributeSamples()
+	for _, rule := range c.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("SCN rule without ID")
+		}
+		switch rule.Type {
+		case SCNAdaptive, SCNTransformative, SCNImpactChange, SCNRoutineRecurring:
+		default:
+			return fmt.Errorf("SCN rule %s has unknown type %s", rule.ID, rule.Type)
+		}
+		for attribute, want := range rule.When {
+			sample, ok := known[attribute]
+			if !ok {
+				return fmt.Errorf("SCN rule %s uses unknown attribute %s", rule.ID, attribute)
+			}
+			if fmt.Sprintf("%T", want) != fmt.Sprintf("%T", sample) {
+				return fmt.Errorf("SCN rule %s: %s must be a %T, not %v", rule.ID, attribute, sample, want)
+			}
+		}
+	}
+	return nil
+}
+
+// Classify evaluates the rules against the change attributes and explains
+// which rule fired
+func (c *SCNClassifier) Classify(attrs SCNChangeAttributes) (*SCNClassification, error) {
+	values, err := attributeValues(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := append([]SCNClassificationRule(nil), c.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+
+	for _, rule := range rules {
+		matched, ok := matchSCNRule(rule, values)
+		if !ok {
+			continue
+		}
+		explanation := fmt.Sprintf("Rule %s: %s", rule.ID, rule.Description)
+		if len(matched) > 0 {
+			explanation += fmt.Sprintf(" (matched %s)", strings.Join(matched, ", "))
+		}
+		if definition := c.Definitions[rule.Type]; definition != "" {
+			explanation += fmt.Sprintf(". Definition: %s", definition)
+		}
+		return &SCNClassification{
+			Type:              rule.Type,
+			RuleID:            rule.ID,
+			Reference:         rule.Reference,
+			Explanation:       explanation,
+			MatchedConditions: matched,
+			Source:            c.Source,
+			ClassifiedAt:      time.Now(),
+		}, nil
+	}
+	return nil, fmt.Errorf("no SCN classification rule matched")
+}
+
+func matchSCNRule(rule SCNClassificationRule, values map[string]interface{}) ([]string, bool) {
+	matched := make([]string, 0, len(rule.When))
+	conditions := make([]string, 0, len(rule.When))
+	for attribute := range rule.When {
+		conditions = append(conditions, attribute)
+	}
+	sort.Strings(conditions)
+
+	for _, attribute := range conditions {
+		want, got := rule.When[attribute], values[attribute]
+		switch w := want.(type) {
+		case bool:
+			b, _ := got.(bool)
+			if b != w {
+				return nil, false
+			}
+		case string:
+			s, _ := got.(string)
+			if (w == "*" && s == "") || (w != "*" && !strings.EqualFold(w, s)) {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+		matched = append(matched, fmt.Sprintf("%s=%v", attribute, got))
+	}
+	return matched, true
+}
+
+// attributeValues flattens the attributes into a map keyed by JSON name
+func attributeValues(attrs SCNChangeAttributes) (map[string]interface{}, error) {
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	return values, json.Unmarshal(data, &values)
+}
+
+// scnAttributeSamples returns a value of the right type for every change
+// attribute, keyed by JSON name
+func scnAttributeSamples() map[string]interface{} {
+	all := SCNChangeAttributes{
+		NewExternalService: true, NewDataType: true, BoundaryChange: true, NewImpactLevel: "*",
+		ArchitectureChange: true, NewMajorComponent: true, NewFunctionality: true, ComponentReplacement: true,
+		CryptoModuleChange: true, SecurityConfigChange: true, RoutinePatch: true,
+	}
+	values, _ := attributeValues(all)
+	return values
+}
+
+func scnAttributeNames() map[string]bool {
+	values := scnAttributeSamples()
+	names := make(map[string]bool, len(values))
+	for name := range values {
+		names[name] = true
+	}
+	return names
+}
+
+// ChangeTypeAttributes maps the legacy change type strings accepted by the
+// CLI onto structured change attributes
+func ChangeTypeAttributes(changeType string) SCNChangeAttributes {
+	var attrs SCNChangeAttributes
+	switch strings.ToLower(strings.TrimSpace(changeType)) {
+	case "impact-level-change":
+		attrs.NewImpactLevel = "changed"
+	case "new functionality", "new service":
+		attrs.NewFunctionality = true
+	case "major component":
+		attrs.NewMajorComponent = true
+	case "architecture change":
+		attrs.ArchitectureChange = true
+	case "boundary change":
+		attrs.BoundaryChange = true
+	case "new data type":
+		attrs.NewDataType = true
+	case "new external service", "new interconnection":
+		attrs.NewExternalService = true
+	case "component replacement":
+		attrs.ComponentReplacement = true
+	case "configuration change":
+		attrs.SecurityConfigChange = true
+	case "patch", "routine patch", "routine":
+		attrs.RoutinePatch = true
+	}
+	return attrs
+}
+
+// ClassifyWith classifies the SCN using the given classifier. The change
+// attributes are used when present, otherwise they are derived from the
+// change type. An existing override keeps its type.
+func (scn *SignificantChangeNotification) ClassifyWith(classifier *SCNClassifier) error {
+	attrs := ChangeTypeAttributes(scn.ChangeType)
+	if scn.ChangeAttributes != nil {
+		attrs = *scn.ChangeAttributes
+	}
+
+	classification, err := classifier.Classify(attrs)
+	if err != nil {
+		return err
+	}
+	if scn.Classification != nil && scn.Classification.Override != nil {
+		classification.Override = scn.Classification.Override
+		classification.Override.OriginalType = classification.Type
+		scn.SCNType = classification.Override.Type
+	} else {
+		scn.SCNType = classification.Type
+	}
+	scn.Classification = classification
+	scn.UpdatedAt = time.Now()
+	return nil
+}
+
+// OverrideClassification replaces the classified type, recording who made
+// the change and why
+func (scn *SignificantChangeNotification) OverrideClassification(scnType SCNType, justification, actor string) error {
+	switch scnType {
+	case SCNAdaptive, SCNTransformative, SCNImpactChange, SCNRoutineRecurring:
+	default:
+		return fmt.Errorf("unknown SCN type: %s", scnType)
+	}
+	if strings.TrimSpace(justification) == "" {
+		return fmt.Errorf("justification is required to override the SCN classification")
+	}
+	if actor == "" {
+		return fmt.Errorf("actor is required to override the SCN classification")
+	}
+	if scn.Classification == nil {
+		if err := scn.ClassifySCNType(); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	scn.Classification.Override = &SCNClassificationOverride{
+		OriginalType:  scn.Classification.Type,
+		Type:          scnType,
+		Justification: justification,
+		OverriddenBy:  actor,
+		Timestamp:     now,
+	}
+	scn.SCNType = scnType
+	scn.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,214 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SCNDeadlinePolicy defines the notification timelines for each SCN type. The
+// defaults follow RFC-0007 and the FRMR SCN rules, counted in business days:
+// adaptive changes are reported within 10 days after the change; transformative
+// changes need an initial notice 30 days and a final notice 10 days before the
+// planned change, a notice within 5 days after the change and a follow-up with
+// the assessment results within 5 days after verification.
+type SCNDeadlinePolicy struct {
+	AdaptiveNotificationDays        int  `json:"adaptive_notification_days"`
+	TransformativeInitialNoticeDays int  `json:"transformative_initial_notice_days"`
+	TransformativeFinalNoticeDays   int  `json:"transformative_final_notice_days"`
+	TransformativeAfterChangeDays   int  `json:"transformative_after_change_days"`
+	TransformativeAssessmentDays    int  `json:"transformative_assessment_days"`
+	BusinessDays                    bool `json:"business_days"`
+	UpcomingWindowDays              int  `json:"upcoming_window_days"` // calendar days
+}
+
+// DefaultSCNDeadlinePolicy returns the RFC-0007 notification timelines
+func DefaultSCNDeadlinePolicy() *SCNDeadlinePolicy {
+	return &SCNDeadlinePolicy{
+		AdaptiveNotificationDays:        10,
+		TransformativeInitialNoticeDays: 30,
+		TransformativeFinalNoticeDays:   10,
+		TransformativeAfterChangeDays:   5,
+		TransformativeAssessmentDays:    5,
+		BusinessDays:                    true,
+		UpcomingWindowDays:              14,
+	}
+}
+
+// LoadSCNDeadlinePolicy reads a deadline policy from a JSON file. Timelines
+// missing from the file keep their defaults.
+func LoadSCNDeadlinePolicy(path string) (*SCNDeadlinePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SCN deadline policy: %w", err)
+	}
+
+	policy := DefaultSCNDeadlinePolicy()
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse SCN deadline policy: %w", err)
+	}
+	return policy, policy.Validate()
+}
+
+// Validate checks that the policy timelines are usable
+func (p *SCNDeadlinePolicy) Validate() error {
+	for name, days := range map[string]int{
+		"adaptive notification":         p.AdaptiveNotificationDays,
+		"transformative initial notice": p.TransformativeInitialNoticeDays,
+		"transformative final notice":   p.TransformativeFinalNoticeDays,
+		"transformative after change":   p.TransformativeAfterChangeDays,
+		"transformative assessment":     p.TransformativeAssessmentDays,
+		"upcoming window":               p.UpcomingWindowDays,
+	} {
+		if days < 0 {
+			return fmt.Errorf("%s days cannot be negative", name)
+		}
+	}
+	if p.TransformativeFinalNoticeDays > p.TransformativeInitialNoticeDays {
+		return fmt.Errorf("final notice cannot be due before the initial notice")
+	}
+	return nil
+}
+
+// SCNObligationStatus is the state of a notification deadline
+type SCNObligationStatus string
+
+const (
+	SCNObligationPending  SCNObligationStatus = "pending"
+	SCNObligationUpcoming SCNObligationStatus = "upcoming"
+	SCNObligationOverdue  SCNObligationStatus = "overdue"
+	SCNObligationMet      SCNObligationStatus = "met"
+	SCNObligationLate     SCNObligationStatus = "late" // completed after the due date
+)
+
+// SCNObligation is a notification or artifact owed for an SCN
+type SCNObligation struct {
+	SCNID       string              `json:"scn_id"`
+	CSOID       string              `json:"cso_id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Artifact    string              `json:"artifact"`
+	DueDate     time.Time           `json:"due_date"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+	Status      SCNObligationStatus `json:"status"`
+}
+
+// IsDue reports whether the obligation is upcoming or overdue
+func (o SCNObligation) IsDue() bool {
+	return o.Status == SCNObligationUpcoming || o.Status == SCNObligationOverdue
+}
+
+// AlertKey identifies the alert for the obligation in its current status.
+// The due date is part of the key so a rescheduled deadline is alerted again.
+func (o SCNObligation) AlertKey() string {
+	return fmt.Sprintf("%s/%s/%s", o.Name, o.Status, o.DueDate.Format("2006-01-02"))
+}
+
+// addDays moves the date by the given number of days, skipping weekends when
+// the policy counts business days
+func (p *SCNDeadlinePolicy) addDays(t time.Time, days int) time.Time {
+	if !p.BusinessDays {
+		return t.AddDate(0, 0, days)
+	}
+	step := 1
+	if days < 0 {
+		step, days = -1, -days
+	}
+	for days > 0 {
+		t = t.AddDate(0, 0, step)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return t
+}
+
+// reachedAt returns when the SCN first moved to the given status
+func (scn *SignificantChangeNotification) reachedAt(status SCNStatus) *time.Time {
+	for _, transition := range scn.History {
+		if transition.To == status {
+			timestamp := transition.Timestamp
+			return &timestamp
+		}
+	}
+	return nil
+}
+
+// Obligations derives the notification deadlines of an SCN from its type and
+// dates. Deadlines whose anchor date is not known yet are omitted, as are all
+// obligations of withdrawn SCNs.
+func (p *SCNDeadlinePolicy) Obligations(scn *SignificantChangeNotification, now time.Time) []SCNObligation {
+	if scn.Status == SCNStatusWithdrawn {
+		return nil
+	}
+
+	var obligations []SCNObligation
+	add := func(name, description, artifact string, due time.Time, completed *time.Time) {
+		obligation := SCNObligation{
+			SCNID:       scn.ID,
+			CSOID:       scn.ServiceOfferingID,
+			Name:        name,
+			Description: description,
+			Artifact:    artifact,
+			DueDate:     due,
+			CompletedAt: completed,
+		}
+		switch {
+		case completed != nil && completed.After(due):
+			obligation.Status = SCNObligationLate
+		case completed != nil:
+			obligation.Status = SCNObligationMet
+		case now.After(due):
+			obligation.Status = SCNObligationOverdue
+		case !due.After(now.AddDate(0, 0, p.UpcomingWindowDays)):
+			obligation.Status = SCNObligationUpcoming
+		default:
+			obligation.Status = SCNObligationPending
+		}
+		obligations = append(obligations, obligation)
+	}
+
+	switch scn.SCNType {
+	case SCNAdaptive:
+		if scn.DateOfChange != nil {
+			add("adaptive-notification", "Notify agencies after finishing the adaptive change", "SCN",
+				p.addDays(*scn.DateOfChange, p.AdaptiveNotificationDays), scn.reachedAt(SCNStatusSubmitted))
+		}
+
+	case SCNTransformative:
+		if scn.PlannedChangeDate != nil {
+			add("transformative-initial-notice", "Notify agencies of the planned transformative change", "SCN with assessment plan",
+				p.addDays(*scn.PlannedChangeDate, -p.TransformativeInitialNoticeDays), scn.reachedAt(SCNStatusSubmitted))
+			add("transformative-final-notice", "Confirm the final plans for the transformative change", "SCN update",
+				p.addDays(*scn.PlannedChangeDate, -p.TransformativeFinalNoticeDays), scn.reachedAt(SCNStatusApproved))
+		}
+		if scn.DateOfChange != nil {
+			add("transformative-after-change", "Notify agencies that the transformative change was made", "SCN update",
+				p.addDays(*scn.DateOfChange, p.TransformativeAfterChangeDays), scn.reachedAt(SCNStatusImplemented))
+		}
+		if verified := scn.reachedAt(SCNStatusVerified); verified != nil {
+			add("transformative-assessment-follow-up", "Share the assessment results for the transformative change", "Assessment report",
+				p.addDays(*verified, p.TransformativeAssessmentDays), scn.reachedAt(SCNStatusClosed))
+		}
+	}
+	return obligations
+}
+
+// DueSCNObligations returns the upcoming and overdue obligations of the SCNs,
+// earliest due date first
+func (p *SCNDeadlinePolicy) DueSCNObligations(scns []*SignificantChangeNotification, now time.Time) []SCNObligation {
+	var due []SCNObligation
+	for _, scn := range scns {
+		for _, obligation := range p.Obligations(scn, now) {
+			if obligation.IsDue() {
+				due = append(due, obligation)
+			}
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DueDate.Before(due[j].DueDate)
+	})
+	return due
+}
@@ -0,0 +1,345 @@
+package fedramp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// SCNDetectedChange is a single changed file or infrastructure resource found
+// while drafting an SCN
+type SCNDetectedChange struct {
+	Source     string   `json:"source"` // git or terraform
+	Item       string   `json:"item"`   // file path or resource address
+	Type       string   `json:"type,omitempty"`
+	Action     string   `json:"action"` // create, update, delete or replace
+	Component  string   `json:"component,omitempty"`
+	Controls   []string `json:"controls,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// SCNComponentMapping maps file paths or resource types to an SSP component
+// and the controls it implements. Patterns use path.Match syntax; path
+// patterns ending in "/" or "/**" match everything below a directory, and
+// patterns without "/" also match the file name. Attributes are the change
+// attributes set when the mapping matches; Actions limits the mapping to
+// some actions.
+type SCNComponentMapping struct {
+	Pattern    string   `json:"pattern"`
+	Component  string   `json:"component,omitempty"`
+	Controls   []string `json:"controls,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+	Actions    []string `json:"actions,omitempty"`
+}
+
+// SCNDraftMapping holds the path-to-component and resource-type-to-control
+// mappings used to draft SCNs. The first matching entry wins.
+type SCNDraftMapping struct {
+	Paths     []SCNComponentMapping `json:"paths"`
+	Resources []SCNComponentMapping `json:"resources"`
+}
+
+// DefaultSCNDraftMapping returns mappings for common infrastructure files and
+// AWS Terraform resources
+func DefaultSCNDraftMapping() *SCNDraftMapping {
+	return &SCNDraftMapping{
+		Paths: []SCNComponentMapping{
+			{Pattern: "Dockerfile", Component: "Container Images", Controls: []string{"CM-2", "CM-6", "SI-2"}, Attributes: []string{"routine_patch"}},
+			{Pattern: "go.sum", Controls: []string{"SI-2", "SA-22"}, Attributes: []string{"routine_patch"}},
+			{Pattern: "package-lock.json", Controls: []string{"SI-2", "SA-22"}, Attributes: []string{"routine_patch"}},
+			{Pattern: "*.tf", Component: "Infrastructure", Controls: []string{"CM-2", "CM-3"}},
+			{Pattern: ".github/workflows/", Component: "CI/CD Pipeline", Controls: []string{"CM-3", "SA-10"}},
+		},
+		Resources: []SCNComponentMapping{
+			{Pattern: "aws_vpc", Component: "Network", Controls: []string{"SC-7"}, Attributes: []string{"boundary_change"}, Actions: []string{"create", "delete", "replace"}},
+			{Pattern: "aws_vpc_peering_connection", Component: "Network", Controls: []string{"SC-7", "CA-3"}, Attributes: []string{"new_external_service"}, Actions: []string{"create"}},
+			{Pattern: "aws_internet_gateway", Component: "Network", Controls: []string{"SC-7"}, Attributes: []string{"boundary_change"}, Actions: []string{"create", "delete"}},
+			{Pattern: "aws_security_group*", Component: "Network", Controls: []string{"SC-7", "AC-4"}, Attributes: []string{"security_config_change"}},
+			{Pattern: "aws_network_acl*", Component: "Network", Controls: []string{"SC-7", "AC-4"}, Attributes: []string{"security_config_change"}},
+			{Pattern: "aws_iam_*", Component: "Identity and Access Management", Controls: []string{"AC-2", "AC-6"}, Attributes: []string{"security_config_change"}},
+			{Pattern: "aws_kms_*", Component: "Key Management", Controls: []string{"SC-12", "SC-13"}, Attributes: []string{"crypto_module_change"}},
+			{Pattern: "aws_acm_certificate*", Component: "Key Management", Controls: []string{"SC-8", "SC-17"}},
+			{Pattern: "aws_db_instance", Component: "Database", Controls: []string{"SC-28", "CP-9"}, Attributes: []string{"new_major_component"}, Actions: []string{"create"}},
+			{Pattern: "aws_rds_cluster", Component: "Database", Controls: []string{"SC-28", "CP-9"}, Attributes: []string{"new_major_component"}, Actions: []string{"create"}},
+			{Pattern: "aws_s3_bucket*", Component: "Storage", Controls: []string{"SC-28", "AC-3"}},
+			{Pattern: "aws_cloudtrail", Component: "Logging", Controls: []string{"AU-2", "AU-12"}, Attributes: []string{"security_config_change"}},
+			{Pattern: "aws_cloudwatch_*", Component: "Logging", Controls: []string{"AU-6", "SI-4"}},
+			{Pattern: "aws_lb*", Component: "Load Balancing", Controls: []string{"SC-7", "SC-8"}},
+		},
+	}
+}
+
+// LoadSCNDraftMapping reads a draft mapping from a JSON file
+func LoadSCNDraftMapping(filename string) (*SCNDraftMapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SCN mapping: %w", err)
+	}
+
+	var mapping SCNDraftMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse SCN mapping: %w", err)
+	}
+	known := scnAttributeNames()
+	for _, m := range append(mapping.Paths, mapping.Resources...) {
+		for _, attribute := range m.Attributes {
+			if !known[attribute] {
+				return nil, fmt.Errorf("SCN mapping %s uses unknown attribute %s", m.Pattern, attribute)
+			}
+		}
+	}
+	return &mapping, nil
+}
+
+// gitStatusActions maps git name-status letters onto change actions
+var gitStatusActions = map[byte]string{
+	'A': "create",
+	'C': "create",
+	'M': "update",
+	'T': "update",
+	'D': "delete",
+	'R': "replace",
+}
+
+// ParseGitNameStatus reads the output of git diff --name-status -z, in which
+// paths are NUL-terminated and never quoted. Output without NUL separators is
+// read as the line-based format. Renamed and copied files are reported under
+// their new path.
+func ParseGitNameStatus(r io.Reader) ([]SCNDetectedChange, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) < 0 {
+		return parseGitNameStatusLines(data)
+	}
+
+	var changes []SCNDetectedChange
+	fields := strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+	for i := 0; i < len(fields); {
+		status := strings.TrimSpace(fields[i])
+		if status == "" {
+			return nil, fmt.Errorf("invalid git name-status entry at field %d", i+1)
+		}
+		paths := 1
+		if status[0] == 'R' || status[0] == 'C' {
+			paths = 2
+		}
+		if i+paths >= len(fields) {
+			return nil, fmt.Errorf("truncated git name-status entry: %s", status)
+		}
+		changes = append(changes, gitDetectedChange(status, fields[i+paths]))
+		i += paths + 1
+	}
+	return changes, nil
+}
+
+func gitDetectedChange(status, item string) SCNDetectedChange {
+	action, ok := gitStatusActions[status[0]]
+	if !ok {
+		action = "update"
+	}
+	return SCNDetectedChange{
+		Source: "git",
+		Item:   item,
+		Action: action,
+	}
+}
+
+func parseGitNameStatusLines(data []byte) ([]SCNDetectedChange, error) {
+	var changes []SCNDetectedChange
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid git name-status line: %s", line)
+		}
+		changes = append(changes, gitDetectedChange(fields[0], fields[len(fields)-1]))
+	}
+	return changes, scanner.Err()
+}
+
+// terraformPlan is the subset of the terraform show -json plan format used to
+// draft SCNs
+type terraformPlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Type    string `json:"type"`
+		Mode    string `json:"mode"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+// ParseTerraformPlan reads the resource changes of a JSON plan produced by
+// terraform show -json. Data sources and no-op changes are skipped.
+func ParseTerraformPlan(r io.Reader) ([]SCNDetectedChange, error) {
+	var plan terraformPlan
+	if err := json.NewDecoder(r).Decode(&plan); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform plan: %w", err)
+	}
+
+	var changes []SCNDetectedChange
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode == "data" {
+			continue
+		}
+		action := ""
+		switch actions := rc.Change.Actions; {
+		case len(actions) == 2:
+			action = "replace"
+		case len(actions) == 1 && actions[0] != "no-op" && actions[0] != "read":
+			action = actions[0]
+		}
+		if action == "" {
+			continue
+		}
+		changes = append(changes, SCNDetectedChange{
+			Source: "terraform",
+			Item:   rc.Address,
+			Type:   rc.Type,
+			Action: action,
+		})
+	}
+	return changes, nil
+}
+
+// Apply maps each detected change to a component, controls and change
+// attributes. Terraform resources are matched by type, files by path.
+func (m *SCNDraftMapping) Apply(changes []SCNDetectedChange) []SCNDetectedChange {
+	mapped := make([]SCNDetectedChange, len(changes))
+	for i, change := range changes {
+		var entry *SCNComponentMapping
+		if change.Type != "" {
+			entry = matchSCNMapping(m.Resources, change.Type, change.Action, false)
+		} else {
+			entry = matchSCNMapping(m.Paths, change.Item, change.Action, true)
+		}
+		if entry != nil {
+			change.Component = entry.Component
+			change.Controls = entry.Controls
+			change.Attributes = entry.Attributes
+		}
+		if change.Action == "replace" && change.Component != "" && len(change.Attributes) == 0 {
+			change.Attributes = []string{"component_replacement"}
+		}
+		mapped[i] = change
+	}
+	return mapped
+}
+
+func matchSCNMapping(mappings []SCNComponentMapping, name, action string, isPath bool) *SCNComponentMapping {
+	for i, m := range mappings {
+		if len(m.Actions) > 0 && !containsString(m.Actions, action) {
+			continue
+		}
+		if matchSCNPattern(m.Pattern, name, isPath) {
+			return &mappings[i]
+		}
+	}
+	return nil
+}
+
+func matchSCNPattern(pattern, name string, isPath bool) bool {
+	if isPath {
+		if dir := strings.TrimSuffix(pattern, "**"); strings.HasSuffix(dir, "/") {
+			return strings.HasPrefix(name, dir)
+		}
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(name)
+		}
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// scnAttributeChangeTypes names the change type reported for each attribute
+var scnAttributeChangeTypes = map[string]string{
+	"new_impact_level":       "impact-level-change",
+	"new_external_service":   "new external service",
+	"new_data_type":          "new data type",
+	"boundary_change":        "boundary change",
+	"architecture_change":    "architecture change",
+	"new_major_component":    "major component",
+	"new_functionality":      "new functionality",
+	"component_replacement":  "component replacement",
+	"crypto_module_change":   "crypto module change",
+	"security_config_change": "configuration change",
+	"routine_patch":          "routine patch",
+}
+
+// DraftSCN builds a draft SCN from detected changes. Components, controls and
+// change attributes come from the mapping, and the type is proposed by the
+// classifier. Unmapped changes are listed in the impact analysis for review.
+func DraftSCN(serviceID, description string, changes []SCNDetectedChange, mapping *SCNDraftMapping, classifier *SCNClassifier) (*SignificantChangeNotification, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no changes detected")
+	}
+	mapped := mapping.Apply(changes)
+
+	var attrs SCNChangeAttributes
+	values := make(map[string]interface{})
+	var unmapped []string
+	routine := true
+	scn := NewSCN(serviceID, "", description, "")
+	for _, change := range mapped {
+		if !containsString(change.Attributes, "routine_patch") {
+			routine = false
+		}
+		if change.Component == "" && len(change.Controls) == 0 && len(change.Attributes) == 0 {
+			unmapped = append(unmapped, fmt.Sprintf("%s (%s)", change.Item, change.Action))
+			continue
+		}
+		if change.Component != "" {
+			scn.AddAffectedComponent(change.Component)
+		}
+		for _, control := range change.Controls {
+			scn.AddAffectedControl(control)
+		}
+		for _, attribute := range change.Attributes {
+			values[attribute] = true
+		}
+	}
+	// A routine patch only stays routine when every change is a routine patch
+	if !routine {
+		delete(values, "routine_patch")
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return nil, fmt.Errorf("invalid change attributes: %w", err)
+	}
+	sort.Strings(scn.ControlsAffected)
+	sort.Strings(scn.ComponentsAffected)
+
+	scn.ChangeAttributes = &attrs
+	scn.DetectedChanges = mapped
+	if err := scn.ClassifyWith(classifier); err != nil {
+		return nil, err
+	}
+	scn.ChangeType = "other"
+	if len(scn.Classification.MatchedConditions) > 0 {
+		attribute := strings.SplitN(scn.Classification.MatchedConditions[0], "=", 2)[0]
+		scn.ChangeType = scnAttributeChangeTypes[attribute]
+	}
+
+	impact := fmt.Sprintf("DRAFT: %d change(s) detected; %d mapped to components, controls or change attributes.", len(mapped), len(mapped)-len(unmapped))
+	if len(unmapped) > 0 {
+		impact += "\nUnmapped changes requiring review:\n- " + strings.Join(unmapped, "\n- ")
+	}
+	scn.ImpactAnalysis = impact
+	return scn, nil
+}
@@ -0,0 +1,63 @@
+package fedramp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGitNameStatus(t *testing.T) {
+	want := []SCNDetectedChange{
+		{Source: "git", Item: "go.sum", Action: "update"},
+		{Source: "git", Item: "deploy/new\tname.tf", Action: "replace"},
+		{Source: "git", Item: "docs/line\nbreak.md", Action: "create"},
+		{Source: "git", Item: "old.tf", Action: "delete"},
+	}
+
+	z := "M\x00go.sum\x00R087\x00deploy/old.tf\x00deploy/new\tname.tf\x00A\x00docs/line\nbreak.md\x00D\x00old.tf\x00"
+	got, err := ParseGitNameStatus(strings.NewReader(z))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("-z output:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	lines := "M\tgo.sum\nR087\tdeploy/old.tf\tdeploy/new.tf\n"
+	got, err = ParseGitNameStatus(strings.NewReader(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Item != "deploy/new.tf" || got[1].Action != "replace" {
+		t.Errorf("line output: got %+v", got)
+	}
+
+	if _, err := ParseGitNameStatus(strings.NewReader("R100\x00only-old.tf\x00")); err == nil {
+		t.Error("truncated rename accepted")
+	}
+}
+
+func TestDraftSCNRoutinePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		routine bool
+	}{
+		{name: "dependency updates only", paths: []string{"go.sum", "web/package-lock.json"}, routine: true},
+		{name: "dependency update with infrastructure change", paths: []string{"go.sum", "deploy/main.tf"}},
+		{name: "dependency update with unmapped change", paths: []string{"go.sum", "cmd/server/main.go"}},
+	}
+	for _, tt := range tests {
+		var changes []SCNDetectedChange
+		for _, p := range tt.paths {
+			changes = append(changes, SCNDetectedChange{Source: "git", Item: p, Action: "update"})
+		}
+		scn, err := DraftSCN("CSO-1", tt.name, changes, DefaultSCNDraftMapping(), DefaultSCNClassifier())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := scn.ChangeAttributes.RoutinePatch; got != tt.routine {
+			t.Errorf("%s: routine_patch = %v, want %v", tt.name, got, tt.routine)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ssp "github.com/gocomply/oscalkit/types/oscal/system_security_plan"
+)
+
+// SCNImpactReport lists the SSP content touched by an SCN
+type SCNImpactReport struct {
+	SCNID             string          `json:"scn_id,omitempty"`
+	ServiceOfferingID string          `json:"service_offering_id"`
+	SCNDate           time.Time       `json:"scn_date"`
+	SSPLastModified   string          `json:"ssp_last_modified,omitempty"`
+	Controls          []SCNImpactItem `json:"controls"`
+	MissingControls   []string        `json:"missing_controls,omitempty"`   // listed in the SCN, absent from the SSP
+	MissingComponents []string        `json:"missing_components,omitempty"` // listed in the SCN, absent from the SSP
+	UnchangedCount    int             `json:"unchanged_count"`
+	GeneratedAt       time.Time       `json:"generated_at"`
+}
+
+// SCNImpactItem is an implemented requirement touched by an SCN
+type SCNImpactItem struct {
+	ControlID          string               `json:"control_id"`
+	AffectedBy         []string             `json:"affected_by"` // "control" and/or component titles
+	Statements         []SCNImpactStatement `json:"statements"`
+	Parameters         []SCNImpactParameter `json:"parameters,omitempty"`
+	ResponsibleRoles   []string             `json:"responsible_roles,omitempty"`
+	NarrativeUnchanged bool                 `json:"narrative_unchanged"`
+	NarrativeCheck     string               `json:"narrative_check"`
+}
+
+// SCNImpactStatement is a by-component narrative of an implemented requirement
+type SCNImpactStatement struct {
+	StatementID    string `json:"statement_id,omitempty"` // empty for requirement-level narratives
+	ComponentUUID  string `json:"component_uuid"`
+	ComponentTitle string `json:"component_title,omitempty"`
+	Narrative      string `json:"narrative"`
+	Affected       bool   `json:"affected"` // the component is listed in the SCN
+}
+
+// SCNImpactParameter is a parameter value set by an implemented requirement
+type SCNImpactParameter struct {
+	ParamID string `json:"param_id"`
+	Value   string `json:"value"`
+}
+
+// ToJSON exports the report as JSON
+func (r *SCNImpactReport) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+var scnControlEnhancement = regexp.MustCompile(`\s*\((\d+)\)`)
+
+// scnControlKey normalizes control IDs such as "AC-2 (1)", "AC-2(1)" and
+// "ac-2.1" to the OSCAL form
+func scnControlKey(controlID string) string {
+	key := strings.ToLower(strings.TrimSpace(controlID))
+	return scnControlEnhancement.ReplaceAllString(key, ".$1")
+}
+
+// AnalyzeSCNImpact lists every implemented requirement and by-component
+// statement that touches the controls or components of the SCN. Components are
+// matched by title or UUID. When a previous version of the SSP is given, a
+// narrative is unchanged if its text is identical in both versions; otherwise
+// all narratives are unchanged when the SSP was last modified before the SCN
+// date (the date of change, or the creation date when no change date is set).
+func (p *SSP) AnalyzeSCNImpact(scn *SignificantChangeNotification, previous *SSP) *SCNImpactReport {
+	report := &SCNImpactReport{
+		SCNID:             scn.ID,
+		ServiceOfferingID: scn.ServiceOfferingID,
+		SCNDate:           scn.CreatedAt,
+		Controls:          make([]SCNImpactItem, 0),
+		GeneratedAt:       time.Now(),
+	}
+	if scn.DateOfChange != nil {
+		report.SCNDate = *scn.DateOfChange
+	}
+	if p.plan.Metadata != nil {
+		report.SSPLastModified = string(p.plan.Metadata.LastModified)
+	}
+
+	titles := p.componentTitles()
+	affectedComponents := make(map[string]bool)
+	for _, component := range scn.ComponentsAffected {
+		found := false
+		for uuid, title := range titles {
+			if strings.EqualFold(title, component) || uuid == component {
+				affectedComponents[uuid] = true
+				found = true
+			}
+		}
+		if !found {
+			report.MissingComponents = append(report.MissingComponents, component)
+		}
+	}
+
+	affectedControls := make(map[string]bool)
+	for _, control := range scn.ControlsAffected {
+		affectedControls[scnControlKey(control)] = true
+	}
+
+	sspModifiedBefore := false
+	if modified, err := time.Parse(time.RFC3339, report.SSPLastModified); err == nil {
+		sspModifiedBefore = modified.Before(report.SCNDate)
+	}
+
+	// Previous requirements are matched on the normalized control ID so the
+	// two versions may spell enhancements differently
+	previousRequirements := make(map[string]ssp.ImplementedRequirement)
+	if previous != nil {
+		for _, ir := range previous.plan.ControlImplementation.ImplementedRequirements {
+			previousRequirements[scnControlKey(ir.ControlId)] = ir
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, ir := range p.plan.ControlImplementation.ImplementedRequirements {
+		key := scnControlKey(ir.ControlId)
+		item := SCNImpactItem{ControlID: ir.ControlId}
+		if affectedControls[key] {
+			item.AffectedBy = append(item.AffectedBy, "control")
+			found[key] = true
+		}
+
+		componentHits := make(map[string]bool)
+		addStatements := func(statementID string, byComponents ssp.ByComponentMultiplexer) {
+			for _, bc := range byComponents {
+				statement := SCNImpactStatement{
+					StatementID:    statementID,
+					ComponentUUID:  bc.ComponentUuid,
+					ComponentTitle: titles[bc.ComponentUuid],
+					Narrative:      byComponentNarrative(bc),
+					Affected:       affectedComponents[bc.ComponentUuid],
+				}
+				if statement.ComponentTitle == "" {
+					statement.ComponentTitle = bc.ComponentUuid
+				}
+				if statement.Affected {
+					componentHits[statement.ComponentTitle] = true
+				}
+				item.Statements = append(item.Statements, statement)
+			}
+		}
+		addStatements("", ir.ByComponents)
+		for _, stmt := range ir.Statements {
+			addStatements(stmt.StatementId, stmt.ByComponents)
+		}
+		var hitTitles []string
+		for title := range componentHits {
+			hitTitles = append(hitTitles, title)
+		}
+		sort.Strings(hitTitles)
+		item.AffectedBy = append(item.AffectedBy, hitTitles...)
+		if len(item.AffectedBy) == 0 {
+			continue
+		}
+
+		for _, param := range ir.ParameterSettings {
+			item.Parameters = append(item.Parameters, SCNImpactParameter{ParamID: param.ParamId, Value: string(param.Value)})
+		}
+		roles := make(map[string]bool)
+		for _, role := range ir.ResponsibleRoles {
+			roles[role.RoleId] = true
+		}
+		for _, stmt := range ir.Statements {
+			for _, role := range stmt.ResponsibleRoles {
+				roles[role.RoleId] = true
+			}
+		}
+		for role := range roles {
+			item.ResponsibleRoles = append(item.ResponsibleRoles, role)
+		}
+		sort.Strings(item.ResponsibleRoles)
+
+		switch {
+		case previous != nil:
+			before, ok := previousRequirements[key]
+			item.NarrativeUnchanged = ok && implementedRequirementNarrative(before) == implementedRequirementNarrative(ir)
+			item.NarrativeCheck = "compared with previous SSP"
+		case report.SSPLastModified != "":
+			item.NarrativeUnchanged = sspModifiedBefore
+			item.NarrativeCheck = "SSP last modified " + report.SSPLastModified
+		default:
+			item.NarrativeCheck = "unknown: SSP has no last-modified date"
+		}
+		if item.NarrativeUnchanged {
+			report.UnchangedCount++
+		}
+		report.Controls = append(report.Controls, item)
+	}
+
+	for _, control := range scn.ControlsAffected {
+		if !found[scnControlKey(control)] {
+			report.MissingControls = append(report.MissingControls, control)
+		}
+	}
+	return report
+}
+
+func (p *SSP) componentTitles() map[string]string {
+	titles := make(map[string]string)
+	if p.plan.SystemImplementation == nil {
+		return titles
+	}
+	for _, component := range p.plan.SystemImplementation.Components {
+		title := component.Uuid
+		if component.Title != nil {
+			title = component.Title.PlainString()
+		}
+		titles[component.Uuid] = title
+	}
+	return titles
+}
+
+func byComponentNarrative(bc ssp.ByComponent) string {
+	if bc.Description != nil {
+		return strings.TrimSpace(bc.Description.PlainString())
+	}
+	if bc.Remarks != nil {
+		return strings.TrimSpace(bc.Remarks.PlainString())
+	}
+	return ""
+}
+
+func implementedRequirementNarrative(ir ssp.ImplementedRequirement) string {
+	var parts []string
+	for _, bc := range ir.ByComponents {
+		parts = append(parts, bc.ComponentUuid+":"+byComponentNarrative(bc))
+	}
+	for _, stmt := range ir.Statements {
+		for _, bc := range stmt.ByComponents {
+			parts = append(parts, fmt.Sprintf("%s/%s:%s", stmt.StatementId, bc.ComponentUuid, byComponentNarrative(bc)))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n")
+}
+
+// Checklist renders the report as a Markdown review checklist. Controls whose
+// narrative has not changed are flagged for update.
+func (r *SCNImpactReport) Checklist() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# SSP Review Checklist for SCN %s\n\n", r.SCNID)
+	fmt.Fprintf(&b, "Service Offering: %s  \nSCN Date: %s  \nSSP Last Modified: %s\n\n",
+		r.ServiceOfferingID, r.SCNDate.Format("2006-01-02"), r.SSPLastModified)
+
+	for _, item := range r.Controls {
+		flag := ""
+		if item.NarrativeUnchanged {
+			flag = " **(narrative unchanged since SCN date)**"
+		}
+		fmt.Fprintf(&b, "## %s%s\n\n", strings.ToUpper(item.ControlID), flag)
+		fmt.Fprintf(&b, "Affected by: %s\n\n", strings.Join(item.AffectedBy, ", "))
+		for _, stmt := range item.Statements {
+			if !stmt.Affected && !containsString(item.AffectedBy, "control") {
+				continue
+			}
+			part := stmt.StatementID
+			if part == "" {
+				part = item.ControlID
+			}
+			fmt.Fprintf(&b, "- [ ] Review %s narrative for %s\n", part, stmt.ComponentTitle)
+		}
+		for _, param := range item.Parameters {
+			fmt.Fprintf(&b, "- [ ] Confirm parameter %s = %q\n", param.ParamID, param.Value)
+		}
+		if len(item.ResponsibleRoles) > 0 {
+			fmt.Fprintf(&b, "- [ ] Confirm responsible roles: %s\n", strings.Join(item.ResponsibleRoles, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.MissingControls) > 0 {
+		fmt.Fprintf(&b, "## Controls not in the SSP\n\n")
+		for _, control := range r.MissingControls {
+			fmt.Fprintf(&b, "- [ ] Add an implemented requirement for %s\n", control)
+		}
+		b.WriteString("\n")
+	}
+	if len(r.MissingComponents) > 0 {
+		fmt.Fprintf(&b, "## Components not in the SSP\n\n")
+		for _, component := range r.MissingComponents {
+			fmt.Fprintf(&b, "- [ ] Add component %s to the system implementation\n", component)
+		}
+	}
+	return b.String()
+}
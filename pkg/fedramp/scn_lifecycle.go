@@ -0,0 +1,168 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SCNStatus represents where an SCN is in its lifecycle
+type SCNStatus string
+
+const (
+	SCNStatusDraft       SCNStatus = "draft"
+	SCNStatusSubmitted   SCNStatus = "submitted"
+	SCNStatusUnderReview SCNStatus = "under-review"
+	SCNStatusApproved    SCNStatus = "approved"
+	SCNStatusImplemented SCNStatus = "implemented"
+	SCNStatusVerified    SCNStatus = "verified"
+	SCNStatusClosed      SCNStatus = "closed"
+	SCNStatusWithdrawn   SCNStatus = "withdrawn"
+)
+
+// legacySCNStatuses maps statuses written before the SCN lifecycle onto
+// their lifecycle equivalent. The API used to create SCNs as "pending"
+// review, which is now "submitted".
+var legacySCNStatuses = map[string]SCNStatus{
+	"pending": SCNStatusSubmitted,
+}
+
+// UnmarshalJSON reads a status, mapping legacy statuses onto the lifecycle
+func (s *SCNStatus) UnmarshalJSON(data []byte) error {
+	var status string
+	if err := json.Unmarshal(data, &status); err != nil {
+		return err
+	}
+	if mapped, ok := legacySCNStatuses[status]; ok {
+		*s = mapped
+		return nil
+	}
+	*s = SCNStatus(status)
+	return nil
+}
+
+// ErrInvalidSCNTransition is returned when the lifecycle does not allow an
+// SCN to move from its current status to the requested one
+var ErrInvalidSCNTransition = errors.New("invalid SCN transition")
+
+// SCNTransition records a single lifecycle transition of an SCN
+type SCNTransition struct {
+	From      SCNStatus `json:"from"`
+	To        SCNStatus `json:"to"`
+	Actor     string    `json:"actor"`
+	Comment   string    `json:"comment,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// scnTransitions lists the statuses each SCN status may move to. Submitted
+// and under-review SCNs may be returned to draft for changes.
+var scnTransitions = map[SCNStatus][]SCNStatus{
+	SCNStatusDraft:       {SCNStatusSubmitted, SCNStatusWithdrawn},
+	SCNStatusSubmitted:   {SCNStatusUnderReview, SCNStatusDraft, SCNStatusWithdrawn},
+	SCNStatusUnderReview: {SCNStatusApproved, SCNStatusDraft, SCNStatusWithdrawn},
+	SCNStatusApproved:    {SCNStatusImplemented, SCNStatusWithdrawn},
+	SCNStatusImplemented: {SCNStatusVerified},
+	SCNStatusVerified:    {SCNStatusClosed},
+	SCNStatusClosed:      {},
+	SCNStatusWithdrawn:   {},
+}
+
+// ParseSCNStatus validates a status string
+func ParseSCNStatus(status string) (SCNStatus, error) {
+	s := SCNStatus(status)
+	if _, ok := scnTransitions[s]; !ok {
+		return "", fmt.Errorf("unknown SCN status: %s", status)
+	}
+	return s, nil
+}
+
+// CanTransition reports whether the SCN may move to the given status, checking
+// both the lifecycle and the guards on the target status
+func (scn *SignificantChangeNotification) CanTransition(to SCNStatus) error {
+	from := scn.Status
+	if from == "" {
+		from = SCNStatusDraft
+	}
+	allowed, ok := scnTransitions[from]
+	if !ok {
+		return fmt.Errorf("%w: unknown SCN status %s", ErrInvalidSCNTransition, from)
+	}
+	permitted := false
+	for _, status := range allowed {
+		if status == to {
+			permitted = true
+			break
+		}
+	}
+	if !permitted {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidSCNTransition, from, to)
+	}
+
+	switch to {
+	case SCNStatusSubmitted:
+		if err := scn.ValidateForSubmission(); err != nil {
+			return fmt.Errorf("SCN cannot be submitted: %w", err)
+		}
+	case SCNStatusImplemented:
+		if scn.SCNType == SCNAdaptive && scn.DateOfChange == nil {
+			return fmt.Errorf("date of change is required to mark an adaptive change implemented")
+		}
+	case SCNStatusClosed:
+		if scn.SCNType == SCNTransformative && scn.AssessmentReport == "" {
+			return fmt.Errorf("assessment report is required to close a transformative change")
+		}
+	}
+	return nil
+}
+
+// Transition moves the SCN to a new status and records the transition
+func (scn *SignificantChangeNotification) Transition(to SCNStatus, actor, comment string) error {
+	if actor == "" {
+		return fmt.Errorf("actor is required for SCN transitions")
+	}
+	if err := scn.CanTransition(to); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	from := scn.Status
+	if from == "" {
+		from = SCNStatusDraft
+	}
+	scn.History = append(scn.History, SCNTransition{
+		From:      from,
+		To:        to,
+		Actor:     actor,
+		Comment:   comment,
+		Timestamp: now,
+	})
+	scn.Status = to
+	scn.UpdatedAt = now
+	return nil
+}
+
+// Approve moves an SCN under review to approved and records the approver.
+// SCNs that were only submitted are moved through review first so the history
+// shows both steps.
+func (scn *SignificantChangeNotification) Approve(approverName, approverTitle, comments string) error {
+	if approverName == "" || approverTitle == "" {
+		return fmt.Errorf("approver name and title are required to approve an SCN")
+	}
+	if scn.Status == SCNStatusSubmitted {
+		if err := scn.Transition(SCNStatusUnderReview, approverName, "review started"); err != nil {
+			return err
+		}
+	}
+	if err := scn.Transition(SCNStatusApproved, approverName, comments); err != nil {
+		return err
+	}
+	scn.ApproverName = approverName
+	scn.ApproverTitle = approverTitle
+	return nil
+}
+
+// IsOpen reports whether the SCN has not reached a terminal status
+func (scn *SignificantChangeNotification) IsOpen() bool {
+	return scn.Status != SCNStatusClosed && scn.Status != SCNStatusWithdrawn
+}
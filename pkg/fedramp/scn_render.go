@@ -0,0 +1,120 @@
+package fedramp
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gocomply/fedramp/bundled"
+)
+
+// SCN render formats
+const (
+	SCNFormatMarkdown = "markdown"
+	SCNFormatHTML     = "html"
+	SCNFormatDOCX     = "docx"
+)
+
+// scnTypeNames are the display names of the SCN types
+var scnTypeNames = map[SCNType]string{
+	SCNAdaptive:         "Adaptive",
+	SCNTransformative:   "Transformative",
+	SCNImpactChange:     "Impact Categorization Change",
+	SCNRoutineRecurring: "Routine Recurring",
+}
+
+// scnTemplateFuncs are available to SCN templates
+var scnTemplateFuncs = map[string]interface{}{
+	"date": func(value interface{}) string {
+		switch t := value.(type) {
+		case time.Time:
+			if !t.IsZero() {
+				return t.Format("January 2, 2006")
+			}
+		case *time.Time:
+			if t != nil && !t.IsZero() {
+				return t.Format("January 2, 2006")
+			}
+		}
+		return "Not set"
+	},
+	"join": strings.Join,
+	"cell": markdownCell,
+	"typeName": func(scnType SCNType) string {
+		if name, ok := scnTypeNames[scnType]; ok {
+			return name
+		}
+		return string(scnType)
+	},
+}
+
+// markdownCellEscaper keeps a value inside one Markdown table cell
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// markdownCell escapes a value for use in a Markdown table cell
+func markdownCell(value interface{}) string {
+	return markdownCellEscaper.Replace(fmt.Sprint(value))
+}
+
+// SCNTemplateData is the data passed to SCN templates
+type SCNTemplateData struct {
+	SCN         *SignificantChangeNotification
+	GeneratedAt time.Time
+}
+
+// LoadSCNTemplate returns the template text for the format, read from path
+// when given and from the bundled default otherwise
+func LoadSCNTemplate(format, path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read SCN template: %w", err)
+		}
+		return string(data), nil
+	}
+
+	file, err := bundled.TemplateSCN(format)
+	if err != nil {
+		return "", fmt.Errorf("no bundled SCN template for format %s: %w", format, err)
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RenderSCN executes an SCN template. HTML templates escape the SCN content;
+// Markdown and DOCX templates produce plain text.
+func RenderSCN(scn *SignificantChangeNotification, format, templateText string) ([]byte, error) {
+	data := SCNTemplateData{SCN: scn, GeneratedAt: time.Now()}
+	var buf bytes.Buffer
+
+	switch format {
+	case SCNFormatHTML:
+		tmpl, err := htmltemplate.New("scn").Funcs(scnTemplateFuncs).Parse(templateText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SCN template: %w", err)
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render SCN: %w", err)
+		}
+	case SCNFormatMarkdown, SCNFormatDOCX:
+		tmpl, err := template.New("scn").Funcs(scnTemplateFuncs).Parse(templateText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SCN template: %w", err)
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render SCN: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SCN format: %s", format)
+	}
+	return buf.Bytes(), nil
+}
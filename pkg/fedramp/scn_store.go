@@ -0,0 +1,153 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SCNStore persists Significant Change Notifications. SaveSCN rejects an SCN
+// whose revision is not the stored one with ErrSCNConflict, so a change based
+// on a stale copy cannot overwrite a concurrent one.
+type SCNStore interface {
+	SaveSCN(scn *SignificantChangeNotification) error
+	GetSCN(id string) (*SignificantChangeNotification, error)
+	GetSCNsByCSOID(csoID string) ([]*SignificantChangeNotification, error)
+}
+
+// ErrSCNNotFound is returned by stores when an SCN does not exist
+var ErrSCNNotFound = errors.New("SCN not found")
+
+// ErrSCNConflict is returned by stores when an SCN was saved by someone else
+// since it was read
+var ErrSCNConflict = errors.New("SCN was modified concurrently")
+
+// NewSCNID generates an identifier for a new SCN
+func NewSCNID(serviceID string) string {
+	return fmt.Sprintf("SCN-%s-%d", serviceID, time.Now().UnixNano())
+}
+
+// FileSCNStore stores each SCN as a JSON file in a directory
+type FileSCNStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileSCNStore creates a file store rooted at dir, creating it if needed
+func NewFileSCNStore(dir string) (*FileSCNStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create SCN store: %w", err)
+	}
+	return &FileSCNStore{dir: dir}, nil
+}
+
+func (s *FileSCNStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid SCN ID: %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// SaveSCN writes the SCN, assigning an ID if it has none and incrementing
+// its revision
+func (s *FileSCNStore) SaveSCN(scn *SignificantChangeNotification) error {
+	if scn.ID == "" {
+		scn.ID = NewSCNID(scn.ServiceOfferingID)
+	}
+	path, err := s.path(scn.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read SCN %s: %w", scn.ID, err)
+	}
+	if err == nil {
+		var current struct {
+			Revision int `json:"revision"`
+		}
+		if err := json.Unmarshal(stored, &current); err != nil {
+			return fmt.Errorf("failed to parse SCN %s: %w", scn.ID, err)
+		}
+		if current.Revision != scn.Revision {
+			return fmt.Errorf("%w: %s is at revision %d, not %d", ErrSCNConflict, scn.ID, current.Revision, scn.Revision)
+		}
+	}
+
+	scn.Revision++
+	data, err := scn.ToJSON()
+	if err != nil {
+		scn.Revision--
+		return err
+	}
+	// Write to a temporary file first so readers never see a partial SCN
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		scn.Revision--
+		return fmt.Errorf("failed to write SCN %s: %w", scn.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		scn.Revision--
+		return err
+	}
+	return nil
+}
+
+// GetSCN reads an SCN by ID
+func (s *FileSCNStore) GetSCN(id string) (*SignificantChangeNotification, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	data, err := os.ReadFile(path)
+	s.mu.RUnlock()
+	if os.IsNotExist(err) {
+		return nil, ErrSCNNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SCN %s: %w", id, err)
+	}
+
+	var scn SignificantChangeNotification
+	if err := json.Unmarshal(data, &scn); err != nil {
+		return nil, fmt.Errorf("failed to parse SCN %s: %w", id, err)
+	}
+	return &scn, nil
+}
+
+// GetSCNsByCSOID returns the SCNs of a service offering, newest first. An
+// empty csoID returns all SCNs.
+func (s *FileSCNStore) GetSCNsByCSOID(csoID string) ([]*SignificantChangeNotification, error) {
+	s.mu.RLock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	scns := make([]*SignificantChangeNotification, 0)
+	for _, path := range paths {
+		scn, err := s.GetSCN(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		if csoID == "" || scn.ServiceOfferingID == csoID {
+			scns = append(scns, scn)
+		}
+	}
+	sort.Slice(scns, func(i, j int) bool {
+		return scns[i].CreatedAt.After(scns[j].CreatedAt)
+	})
+	return scns, nil
+}
@@ -0,0 +1,63 @@
+package fedramp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFileSCNStoreRejectsStaleSaves(t *testing.T) {
+	store, err := NewFileSCNStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	scn := NewSCN("CSO-1", "infrastructure", "Move to new region", "Capacity")
+	if err := store.SaveSCN(scn); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := store.GetSCN(scn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.GetSCN(scn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.ShortDescription = "first"
+	if err := store.SaveSCN(first); err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	second.ShortDescription = "second"
+	if err := store.SaveSCN(second); !errors.Is(err, ErrSCNConflict) {
+		t.Fatalf("stale save: got %v, want ErrSCNConflict", err)
+	}
+	if second.Revision != 1 {
+		t.Errorf("rejected save changed the revision to %d", second.Revision)
+	}
+
+	stored, err := store.GetSCN(scn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ShortDescription != "first" || stored.Revision != 2 {
+		t.Errorf("stored SCN = %q at revision %d, want %q at revision 2", stored.ShortDescription, stored.Revision, "first")
+	}
+}
+
+func TestApproveRecordsApprover(t *testing.T) {
+	scn := NewSCN("CSO-1", "infrastructure", "Move to new region", "Capacity")
+	scn.Status = SCNStatusSubmitted
+	if err := scn.Approve("jdoe", "", "ok"); err == nil {
+		t.Error("approval without an approver title accepted")
+	}
+	if err := scn.Approve("jdoe", "ISSO", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if scn.Status != SCNStatusApproved || scn.ApproverName != "jdoe" || scn.ApproverTitle != "ISSO" {
+		t.Errorf("got status %s approver %q %q", scn.Status, scn.ApproverName, scn.ApproverTitle)
+	}
+	if len(scn.History) != 2 {
+		t.Errorf("history has %d transitions, want 2", len(scn.History))
+	}
+}
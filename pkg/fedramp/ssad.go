@@ -2,8 +2,14 @@ package fedramp
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -20,6 +26,7 @@ type SSADPackage struct {
 	AccessControl     SSADAccessControl      `json:"access_control"`
 	DistributionLog   []SSADDistribution     `json:"distribution_log"`
 	IntegrityCheck    SSADIntegrity          `json:"integrity_check"`
+	Parent            *SSADVersionLink       `json:"parent,omitempty"` // finalized version this one supersedes
 }
 
 // SSADMetadata contains package metadata
@@ -87,6 +94,7 @@ type SSADSharee struct {
 	SharedDate       time.Time `json:"shared_date"`
 	ExpirationDate   *time.Time `json:"expiration_date,omitempty"`
 	AccessConditions []string  `json:"access_conditions,omitempty"`
+	PublicKey        string    `json:"public_key,omitempty"` // PEM X25519 or RSA key content keys are wrapped for
 }
 
 // SSADDistribution logs package distribution
@@ -157,8 +165,18 @@ func NewSSADPackage(serviceID string, metadata SSADMetadata) *SSADPackage {
 
 // AddDocument adds a document to the package
 func (p *SSADPackage) AddDocument(docType string, doc SSADDocument) error {
-	// Calculate document hash
-	doc.Hash = calculateHash(doc)
+	if p.Status != "draft" {
+		return ErrSSADPackageImmutable
+	}
+	// Documents registered without a hash must be readable local files so
+	// the hash covers their content
+	if doc.Hash == "" {
+		hash, size, err := HashDocumentFile(doc.Location)
+		if err != nil {
+			return fmt.Errorf("document %s has no hash and cannot be hashed: %w", doc.DocumentID, err)
+		}
+		doc.Hash, doc.Size = hash, size
+	}
 	
 	switch docType {
 	case "ssp":
@@ -194,6 +212,66 @@ func (p *SSADPackage) ShareWith(sharee SSADSharee) {
 	p.UpdatedAt = time.Now()
 }
 
+// SSAD access levels, from least to most privileged
+const (
+	SSADAccessRead  = "read"
+	SSADAccessWrite = "write"
+	SSADAccessAdmin = "admin"
+)
+
+var ssadAccessRank = map[string]int{SSADAccessRead: 1, SSADAccessWrite: 2, SSADAccessAdmin: 3}
+
+// AccessLevelFor returns the access level an entity holds at the given time:
+// admin for the owner, the level of its unexpired shares, read for anyone when
+// the package is public, and "" without access
+func (p *SSADPackage) AccessLevelFor(entityID string, now time.Time) string {
+	if entityID == "" {
+		return ""
+	}
+	if entityID == p.AccessControl.Owner {
+		return SSADAccessAdmin
+	}
+	level := ""
+	for _, sharee := range p.AccessControl.SharedWith {
+		if sharee.EntityID != entityID {
+			continue
+		}
+		if sharee.ExpirationDate != nil && !sharee.ExpirationDate.After(now) {
+			continue
+		}
+		if ssadAccessRank[sharee.AccessLevel] > ssadAccessRank[level] {
+			level = sharee.AccessLevel
+		}
+	}
+	if level == "" && p.AccessControl.PublicAccess {
+		level = SSADAccessRead
+	}
+	return level
+}
+
+// CanAccess reports whether an entity holds at least the given access level
+func (p *SSADPackage) CanAccess(entityID, level string, now time.Time) bool {
+	held := ssadAccessRank[p.AccessLevelFor(entityID, now)]
+	return held > 0 && held >= ssadAccessRank[level]
+}
+
+// RevokeSharee removes every share with an entity and reports whether there
+// was one
+func (p *SSADPackage) RevokeSharee(entityID string) bool {
+	kept := p.AccessControl.SharedWith[:0]
+	for _, sharee := range p.AccessControl.SharedWith {
+		if sharee.EntityID != entityID {
+			kept = append(kept, sharee)
+		}
+	}
+	revoked := len(kept) != len(p.AccessControl.SharedWith)
+	p.AccessControl.SharedWith = kept
+	if revoked {
+		p.UpdatedAt = time.Now()
+	}
+	return revoked
+}
+
 // LogDistribution logs a distribution event
 func (p *SSADPackage) LogDistribution(recipient, purpose, method string) {
 	dist := SSADDistribution{
@@ -209,6 +287,59 @@ func (p *SSADPackage) LogDistribution(recipient, purpose, method string) {
 	p.DistributionLog = append(p.DistributionLog, dist)
 }
 
+// Distribution acknowledgment errors
+var (
+	ErrSSADDistributionNotFound = errors.New("distribution not found")
+	ErrSSADNotRecipient         = errors.New("not the recipient of the distribution")
+)
+
+// RecordAccess logs a distribution of the package to an entity and returns the
+// entry, whose ID the recipient uses to acknowledge receipt. A distribution to
+// the entity for the same purpose that is not yet acknowledged is returned
+// instead of logging another, so repeated reads do not grow the log.
+func (p *SSADPackage) RecordAccess(entityID, name, purpose, method string) SSADDistribution {
+	for _, dist := range p.DistributionLog {
+		if dist.RecipientID == entityID && dist.Purpose == purpose && dist.Method == method && !dist.Acknowledgment {
+			return dist
+		}
+	}
+	now := time.Now()
+	dist := SSADDistribution{
+		DistributionID:   fmt.Sprintf("DIST-%s-%s-%d", p.PackageID, now.Format("20060102-150405"), len(p.DistributionLog)+1),
+		RecipientID:      entityID,
+		RecipientName:    name,
+		DistributionDate: now,
+		Method:           method,
+		Purpose:          purpose,
+	}
+	if dist.RecipientName == "" {
+		dist.RecipientName = entityID
+	}
+	p.DistributionLog = append(p.DistributionLog, dist)
+	return dist
+}
+
+// AcknowledgeDistribution records that the recipient of a distribution
+// acknowledged receipt
+func (p *SSADPackage) AcknowledgeDistribution(distributionID, entityID string) (*SSADDistribution, error) {
+	for i := range p.DistributionLog {
+		dist := &p.DistributionLog[i]
+		if dist.DistributionID != distributionID {
+			continue
+		}
+		if dist.RecipientID != entityID {
+			return nil, fmt.Errorf("%w: %s", ErrSSADNotRecipient, distributionID)
+		}
+		if !dist.Acknowledgment {
+			now := time.Now()
+			dist.Acknowledgment = true
+			dist.AckDate = &now
+		}
+		return dist, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSSADDistributionNotFound, distributionID)
+}
+
 // Finalize prepares the package for distribution
 func (p *SSADPackage) Finalize(signedBy string) error {
 	if p.Status != "draft" {
@@ -220,13 +351,26 @@ func (p *SSADPackage) Finalize(signedBy string) error {
 		return fmt.Errorf("package must contain at least SSP and SAR")
 	}
 	
-	// Calculate package integrity
+	// New versions are numbered from their parent according to what changed
+	if p.Parent != nil {
+		version, err := BumpSSADVersion(p.Parent.Version, p.VersionBump())
+		if err != nil {
+			return err
+		}
+		p.Version = version
+	}
+	
+	// The package hash is the digest of the manifest of document hashes;
+	// VerificationKey is set when the manifest is signed
+	digest, err := p.Manifest().Digest()
+	if err != nil {
+		return err
+	}
 	p.IntegrityCheck = SSADIntegrity{
-		PackageHash:     p.calculatePackageHash(),
-		HashAlgorithm:   "SHA-256",
-		SignedBy:        signedBy,
-		SignatureDate:   time.Now(),
-		VerificationKey: fmt.Sprintf("verify-%s", p.PackageID),
+		PackageHash:   digest,
+		HashAlgorithm: "SHA-256",
+		SignedBy:      signedBy,
+		SignatureDate: time.Now(),
 	}
 	
 	p.Status = "final"
@@ -235,19 +379,58 @@ func (p *SSADPackage) Finalize(signedBy string) error {
 	return nil
 }
 
-// calculatePackageHash calculates hash of entire package
-func (p *SSADPackage) calculatePackageHash() string {
-	// In practice, this would hash all components
-	data, _ := json.Marshal(p.Components)
-	hash := sha256.Sum256(data)
-	return fmt.Sprintf("%x", hash)
+// HashDocumentFile returns the hex SHA-256 hash and size of a document file
+func HashDocumentFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
 }
 
-// calculateHash calculates hash for a document
-func calculateHash(doc SSADDocument) string {
-	data := fmt.Sprintf("%s-%s-%s-%d", doc.DocumentID, doc.Title, doc.Version, doc.Size)
-	hash := sha256.Sum256([]byte(data))
-	return fmt.Sprintf("%x", hash)
+// SSADDocumentID derives a document ID from the document type and location,
+// so a document keeps its ID when its content changes between versions
+func SSADDocumentID(docType, location string) string {
+	key := docType + "\x00" + filepath.ToSlash(filepath.Clean(location))
+	return fmt.Sprintf("DOC-%s-%s", docType, sha256Hex([]byte(key))[:12])
+}
+
+// NewSSADFileDocument describes a file for inclusion in a package, with the
+// SHA-256 hash and size of its content
+func NewSSADFileDocument(path, docType, title, author string) (SSADDocument, error) {
+	hash, size, err := HashDocumentFile(path)
+	if err != nil {
+		return SSADDocument{}, fmt.Errorf("failed to read document %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return SSADDocument{}, err
+	}
+
+	format := strings.ToUpper(strings.TrimPrefix(filepath.Ext(path), "."))
+	if format == "MD" {
+		format = "Markdown"
+	}
+	return SSADDocument{
+		DocumentID:   SSADDocumentID(docType, path),
+		Title:        title,
+		Type:         docType,
+		Format:       format,
+		Version:      "1.0",
+		CreatedDate:  info.ModTime(),
+		LastModified: info.ModTime(),
+		Author:       author,
+		Size:         size,
+		Hash:         hash,
+		Location:     path,
+		AccessLevel:  "restricted",
+	}, nil
 }
 
 // NewSSADRepository creates a new repository
@@ -318,6 +501,10 @@ func (r *SSADRepository) Search(criteria map[string]string) []*SSADPackage {
 			match = false
 		}
 		
+		if tag, ok := criteria["tag"]; ok && !containsString(r.Index.ByTag[tag], pkg.PackageID) {
+			match = false
+		}
+		
 		if match {
 			results = append(results, pkg)
 		}
@@ -326,6 +513,22 @@ func (r *SSADRepository) Search(criteria map[string]string) []*SSADPackage {
 	return results
 }
 
+// Query returns the packages matching a search query in the syntax of
+// ParseSSADQuery
+func (r *SSADRepository) Query(query string) ([]*SSADPackage, error) {
+	q, err := ParseSSADQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*SSADPackage, 0)
+	for _, pkg := range r.Packages {
+		if q.Match(pkg) {
+			results = append(results, pkg)
+		}
+	}
+	return results, nil
+}
+
 // ExportPackage exports a package for distribution
 func (r *SSADRepository) ExportPackage(packageID string) ([]byte, error) {
 	pkg, exists := r.Packages[packageID]
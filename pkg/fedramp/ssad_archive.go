@@ -0,0 +1,623 @@
+package fedramp
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SSAD archive format identifiers. The layout is documented in
+// docs/SSAD_ARCHIVE_FORMAT.md.
+const (
+	SSADArchiveFormat        = "fedramp-ssad-archive"
+	SSADArchiveFormatVersion = "1.0"
+
+	SSADArchiveZip   = "zip"
+	SSADArchiveTarGz = "tar.gz"
+)
+
+// Well-known paths inside an SSAD archive
+const (
+	SSADArchiveIndexPath     = "ssad-archive.json"
+	SSADArchivePackagePath   = "package.json"
+	SSADArchiveManifestPath  = "manifest.json"
+	SSADArchiveSignaturePath = "signature.json"
+	SSADArchiveDocumentsDir  = "documents"
+)
+
+// Roles of the files of an SSAD archive
+const (
+	SSADArchiveRolePackage   = "package"
+	SSADArchiveRoleManifest  = "manifest"
+	SSADArchiveRoleSignature = "signature"
+	SSADArchiveRoleDocument  = "document"
+)
+
+// SSADArchiveIndex is the first entry of an SSAD archive. It lists every other
+// file of the archive with its digest.
+type SSADArchiveIndex struct {
+	Format            string            `json:"format"`
+	FormatVersion     string            `json:"format_version"`
+	PackageID         string            `json:"package_id"`
+	ServiceOfferingID string            `json:"service_offering_id"`
+	CreatedAt         time.Time         `json:"created_at"`
+	ManifestDigest    string            `json:"manifest_digest"`
+	Signed            bool              `json:"signed"`
+	Files             []SSADArchiveFile `json:"files"`
+}
+
+// SSADArchiveFile is a file of an SSAD archive
+type SSADArchiveFile struct {
+	Path       string `json:"path"`
+	Role       string `json:"role"` // package, manifest, signature, document
+	Component  string `json:"component,omitempty"`
+	DocumentID string `json:"document_id,omitempty"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+}
+
+// SSADArchiveFormatFor returns the archive format implied by a file name
+func SSADArchiveFormatFor(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return SSADArchiveZip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return SSADArchiveTarGz, nil
+	}
+	return "", fmt.Errorf("unknown archive format for %s: use .zip, .tar.gz or .tgz", name)
+}
+
+// archiveSource is a file to write into an archive, read from a path on disk
+// or from memory
+type archiveSource struct {
+	file SSADArchiveFile
+	path string
+	data []byte
+}
+
+// BundleSSADPackage writes a finalized package, its documents, canonical
+// manifest and optional signature to w as a zip or tar.gz archive. Relative
+// document locations are resolved against baseDir. Inside the archive the
+// package locations point at the bundled documents.
+func BundleSSADPackage(pkg *SSADPackage, sig *SSADSignature, baseDir string, format string, w io.Writer) (*SSADArchiveIndex, error) {
+	if pkg.Status != "final" {
+		return nil, fmt.Errorf("only finalized packages can be bundled")
+	}
+	if format != SSADArchiveZip && format != SSADArchiveTarGz {
+		return nil, fmt.Errorf("unknown archive format: %s", format)
+	}
+
+	manifest, err := pkg.Manifest().Canonical()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256Hex(manifest)
+	if digest != pkg.IntegrityCheck.PackageHash {
+		return nil, fmt.Errorf("package hash does not match its manifest; finalize the package again")
+	}
+	if sig != nil && (sig.PackageID != pkg.PackageID || sig.ManifestDigest != digest) {
+		return nil, fmt.Errorf("signature does not cover this package")
+	}
+
+	// Copy the package so its document locations can point into the archive
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		return nil, err
+	}
+	var bundled SSADPackage
+	if err := json.Unmarshal(data, &bundled); err != nil {
+		return nil, err
+	}
+
+	var sources []archiveSource
+	seen := make(map[string]bool)
+	var docErr error
+	bundled.eachDocument(func(component string, doc *SSADDocument) {
+		if docErr != nil {
+			return
+		}
+		source := doc.Location
+		if !filepath.IsAbs(source) && baseDir != "" {
+			source = filepath.Join(baseDir, source)
+		}
+		hash, size, err := HashDocumentFile(source)
+		if err != nil {
+			docErr = fmt.Errorf("document %s cannot be bundled: %w", doc.DocumentID, err)
+			return
+		}
+		if hash != doc.Hash {
+			docErr = fmt.Errorf("document %s was modified since it was added: %s", doc.DocumentID, doc.Location)
+			return
+		}
+
+		// Content-derived names keep identical documents in one entry
+		name := path.Join(SSADArchiveDocumentsDir, component, hash[:12]+"-"+archiveBaseName(doc.Location))
+		doc.Location = name
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		sources = append(sources, archiveSource{
+			file: SSADArchiveFile{
+				Path:       name,
+				Role:       SSADArchiveRoleDocument,
+				Component:  component,
+				DocumentID: doc.DocumentID,
+				Size:       size,
+				SHA256:     hash,
+			},
+			path: source,
+		})
+	})
+	if docErr != nil {
+		return nil, docErr
+	}
+
+	packageData, err := json.MarshalIndent(&bundled, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	metadata := []archiveSource{
+		memorySource(SSADArchivePackagePath, SSADArchiveRolePackage, packageData),
+		memorySource(SSADArchiveManifestPath, SSADArchiveRoleManifest, manifest),
+	}
+	if sig != nil {
+		sigData, err := json.MarshalIndent(sig, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, memorySource(SSADArchiveSignaturePath, SSADArchiveRoleSignature, sigData))
+	}
+	sources = append(metadata, sources...)
+
+	index := &SSADArchiveIndex{
+		Format:            SSADArchiveFormat,
+		FormatVersion:     SSADArchiveFormatVersion,
+		PackageID:         pkg.PackageID,
+		ServiceOfferingID: pkg.ServiceOfferingID,
+		CreatedAt:         time.Now().UTC(),
+		ManifestDigest:    digest,
+		Signed:            sig != nil,
+	}
+	for _, source := range sources {
+		index.Files = append(index.Files, source.file)
+	}
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	aw := newArchiveWriter(format, w)
+	if err := aw.add(SSADArchiveIndexPath, index.CreatedAt, bytes.NewReader(indexData), int64(len(indexData))); err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if err := writeArchiveSource(aw, source, index.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := aw.close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return index, nil
+}
+
+func memorySource(name, role string, data []byte) archiveSource {
+	return archiveSource{
+		file: SSADArchiveFile{Path: name, Role: role, Size: int64(len(data)), SHA256: sha256Hex(data)},
+		data: data,
+	}
+}
+
+// writeArchiveSource copies a file into the archive, failing if its content
+// changed since it was hashed
+func writeArchiveSource(aw archiveWriter, source archiveSource, modTime time.Time) error {
+	if source.data != nil {
+		return aw.add(source.file.Path, modTime, bytes.NewReader(source.data), source.file.Size)
+	}
+	f, err := os.Open(source.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if err := aw.add(source.file.Path, modTime, io.TeeReader(io.LimitReader(f, source.file.Size), h), source.file.Size); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != source.file.SHA256 {
+		return fmt.Errorf("document %s changed while it was bundled", source.file.DocumentID)
+	}
+	return nil
+}
+
+// archiveBaseName returns a file name safe to use inside an archive
+func archiveBaseName(location string) string {
+	name := filepath.Base(filepath.FromSlash(location))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return "document"
+	}
+	return name
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type archiveWriter interface {
+	add(name string, modTime time.Time, r io.Reader, size int64) error
+	close() error
+}
+
+func newArchiveWriter(format string, w io.Writer) archiveWriter {
+	if format == SSADArchiveZip {
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}
+	}
+	gz := gzip.NewWriter(w)
+	return &tarArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiveWriter) add(name string, modTime time.Time, r io.Reader, size int64) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+	header.SetMode(0644)
+	fw, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+func (a *zipArchiveWriter) close() error {
+	return a.zw.Close()
+}
+
+type tarArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (a *tarArchiveWriter) add(name string, modTime time.Time, r io.Reader, size int64) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg, Format: tar.FormatPAX}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.Copy(a.tw, r)
+	return err
+}
+
+func (a *tarArchiveWriter) close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+// SSADImportOptions controls how archives are imported
+type SSADImportOptions struct {
+	SSADVerifyOptions
+	// AllowUntrusted imports packages that are unsigned or whose signing key
+	// is not trusted. Tampered documents and invalid signatures are always
+	// rejected.
+	AllowUntrusted bool
+}
+
+// ErrSSADPackageExists is returned when an imported package is already in the
+// repository
+var ErrSSADPackageExists = errors.New("package already exists in repository")
+
+// ImportSSADArchive extracts an SSAD archive into repoDir/<package-id>. Every
+// file is checked against the digests of the archive index and the package is
+// verified before it is moved into place, so a rejected archive leaves the
+// repository untouched. Document locations of the imported package are
+// relative to its directory.
+func ImportSSADArchive(archivePath, repoDir string, opts SSADImportOptions) (*SSADPackage, *SSADVerification, error) {
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+	staging, err := os.MkdirTemp(repoDir, ".import-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	index, err := extractSSADArchive(archivePath, staging)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(staging, SSADArchivePackagePath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("archive has no package: %w", err)
+	}
+	var pkg SSADPackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse package: %w", err)
+	}
+	if pkg.PackageID != index.PackageID {
+		return nil, nil, fmt.Errorf("archive index is for package %s, not %s", index.PackageID, pkg.PackageID)
+	}
+	var invalidLocation error
+	pkg.eachDocument(func(component string, doc *SSADDocument) {
+		if _, ok := cleanArchivePath(doc.Location); !ok && invalidLocation == nil {
+			invalidLocation = fmt.Errorf("document %s is not inside the archive: %s", doc.DocumentID, doc.Location)
+		}
+	})
+	if invalidLocation != nil {
+		return nil, nil, invalidLocation
+	}
+
+	manifest, err := pkg.Manifest().Canonical()
+	if err != nil {
+		return nil, nil, err
+	}
+	archived, err := os.ReadFile(filepath.Join(staging, SSADArchiveManifestPath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("archive has no manifest: %w", err)
+	}
+	if !bytes.Equal(manifest, archived) {
+		return nil, nil, fmt.Errorf("archived manifest does not match the package")
+	}
+
+	var sig *SSADSignature
+	if sigData, err := os.ReadFile(filepath.Join(staging, SSADArchiveSignaturePath)); err == nil {
+		sig = &SSADSignature{}
+		if err := json.Unmarshal(sigData, sig); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse signature: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	verifyOpts := opts.SSADVerifyOptions
+	verifyOpts.BaseDir = staging
+	result := VerifySSADPackage(&pkg, sig, verifyOpts)
+	for _, doc := range result.Documents {
+		if doc.Status != SSADDocumentOK {
+			return nil, result, fmt.Errorf("document %s is %s", doc.DocumentID, doc.Status)
+		}
+	}
+	switch {
+	case !result.ManifestMatches:
+		return nil, result, fmt.Errorf("package hash does not match its manifest")
+	case sig != nil && !result.SignatureValid:
+		return nil, result, fmt.Errorf("package signature is invalid")
+	case !result.OK() && !opts.AllowUntrusted:
+		return nil, result, fmt.Errorf("package is not signed by a trusted key: %s", strings.Join(result.Problems, "; "))
+	}
+
+	target, err := ssadPackageDir(repoDir, pkg.PackageID)
+	if err != nil {
+		return nil, result, err
+	}
+	if _, err := os.Stat(target); err == nil {
+		return nil, result, ErrSSADPackageExists
+	}
+	if err := os.Rename(staging, target); err != nil {
+		return nil, result, fmt.Errorf("failed to move package into repository: %w", err)
+	}
+	return &pkg, result, nil
+}
+
+// ssadPackageDir is the directory of an imported package in a repository. It
+// holds the files of the archive: package.json, manifest.json, an optional
+// signature.json and the documents directory.
+func ssadPackageDir(repoDir, packageID string) (string, error) {
+	if packageID == "" || strings.ContainsAny(packageID, `/\`) || strings.HasPrefix(packageID, ".") {
+		return "", fmt.Errorf("invalid package ID: %q", packageID)
+	}
+	return filepath.Join(repoDir, packageID), nil
+}
+
+// extractSSADArchive writes every file listed in the archive index below dir,
+// rejecting unlisted, duplicate, oversized or corrupted entries
+func extractSSADArchive(archivePath, dir string) (*SSADArchiveIndex, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ar, err := openArchiveReader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	name, r, err := ar.next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if name != SSADArchiveIndexPath {
+		return nil, fmt.Errorf("archive does not start with %s", SSADArchiveIndexPath)
+	}
+	var index SSADArchiveIndex
+	if err := json.NewDecoder(io.LimitReader(r, 16<<20)).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to parse archive index: %w", err)
+	}
+	if index.Format != SSADArchiveFormat {
+		return nil, fmt.Errorf("not an SSAD archive: format %q", index.Format)
+	}
+	if !strings.HasPrefix(index.FormatVersion, "1.") {
+		return nil, fmt.Errorf("unsupported SSAD archive version %s", index.FormatVersion)
+	}
+
+	expected := make(map[string]SSADArchiveFile)
+	for _, file := range index.Files {
+		clean, ok := cleanArchivePath(file.Path)
+		if !ok || clean != file.Path {
+			return nil, fmt.Errorf("archive index lists an unsafe path: %q", file.Path)
+		}
+		expected[file.Path] = file
+	}
+
+	extracted := make(map[string]bool)
+	for {
+		name, r, err := ar.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if strings.HasSuffix(name, "/") {
+			continue // directory entries carry no content
+		}
+		file, ok := expected[name]
+		if !ok {
+			return nil, fmt.Errorf("archive contains a file missing from its index: %s", name)
+		}
+		if extracted[name] {
+			return nil, fmt.Errorf("archive contains %s twice", name)
+		}
+		extracted[name] = true
+		if err := extractArchiveFile(r, filepath.Join(dir, filepath.FromSlash(name)), file); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range index.Files {
+		if !extracted[file.Path] {
+			return nil, fmt.Errorf("archive is missing %s", file.Path)
+		}
+	}
+	return &index, nil
+}
+
+// extractArchiveFile writes at most the indexed size of an entry and checks its
+// digest
+func extractArchiveFile(r io.Reader, dest string, file SSADArchiveFile) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), io.LimitReader(r, file.Size+1))
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", file.Path, err)
+	}
+	if n != file.Size {
+		return fmt.Errorf("%s is %d bytes, index says %d", file.Path, n, file.Size)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != file.SHA256 {
+		return fmt.Errorf("%s does not match its digest", file.Path)
+	}
+	return out.Close()
+}
+
+// cleanArchivePath reports whether an archive path stays below the archive
+// root
+func cleanArchivePath(name string) (string, bool) {
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) {
+		return "", false
+	}
+	clean := path.Clean(name)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return clean, true
+}
+
+type archiveReader interface {
+	next() (string, io.Reader, error)
+}
+
+// openArchiveReader detects zip and gzip-compressed tar archives by their
+// magic bytes
+func openArchiveReader(f *os.File) (archiveReader, error) {
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	switch {
+	case bytes.Equal(magic, []byte("PK\x03\x04")):
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip archive: %w", err)
+		}
+		return &zipArchiveReader{files: zr.File}, nil
+	case magic[0] == 0x1f && magic[1] == 0x8b:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tar.gz archive: %w", err)
+		}
+		return &tarArchiveReader{tr: tar.NewReader(gz)}, nil
+	}
+	return nil, fmt.Errorf("not a zip or tar.gz archive")
+}
+
+type zipArchiveReader struct {
+	files   []*zip.File
+	current io.ReadCloser
+}
+
+func (a *zipArchiveReader) next() (string, io.Reader, error) {
+	if a.current != nil {
+		a.current.Close()
+		a.current = nil
+	}
+	if len(a.files) == 0 {
+		return "", nil, io.EOF
+	}
+	file := a.files[0]
+	a.files = a.files[1:]
+	rc, err := file.Open()
+	if err != nil {
+		return "", nil, err
+	}
+	a.current = rc
+	return file.Name, rc, nil
+}
+
+type tarArchiveReader struct {
+	tr *tar.Reader
+}
+
+func (a *tarArchiveReader) next() (string, io.Reader, error) {
+	for {
+		header, err := a.tr.Next()
+		if err != nil {
+			return "", nil, err
+		}
+		switch header.Typeflag {
+		case tar.TypeReg:
+			return header.Name, a.tr, nil
+		case tar.TypeDir:
+			continue
+		}
+		return "", nil, fmt.Errorf("archive entry %s is not a regular file", header.Name)
+	}
+}
@@ -0,0 +1,300 @@
+package fedramp
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bundleSSADTestArchive bundles a signed test package into dir and returns the
+// archive path and the key trusted to verify it
+func bundleSSADTestArchive(t *testing.T, dir, format string) (string, *SSADPackage, crypto.PublicKey) {
+	t.Helper()
+	docs := filepath.Join(dir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := newFinalSSADTestPackage(t, docs)
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := pkg.Sign(&SSADSigningKey{Signer: key}, "isso")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "package."+format)
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := BundleSSADPackage(pkg, sig, docs, format, f); err != nil {
+		t.Fatal(err)
+	}
+	return archive, pkg, key.Public()
+}
+
+// assertEmptyRepository checks that a rejected import left nothing behind
+func assertEmptyRepository(t *testing.T, repo string) {
+	t.Helper()
+	entries, err := os.ReadDir(repo)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("rejected import left %s in the repository", entry.Name())
+	}
+}
+
+func TestSSADArchiveRoundTrip(t *testing.T) {
+	for _, format := range []string{SSADArchiveZip, SSADArchiveTarGz} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			archive, pkg, trusted := bundleSSADTestArchive(t, dir, format)
+			repo := filepath.Join(dir, "repo")
+			opts := SSADImportOptions{SSADVerifyOptions: SSADVerifyOptions{TrustedKeys: []crypto.PublicKey{trusted}}}
+
+			imported, result, err := ImportSSADArchive(archive, repo, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.OK() || imported.PackageID != pkg.PackageID || imported.IntegrityCheck.PackageHash != pkg.IntegrityCheck.PackageHash {
+				t.Fatalf("imported %s: problems %v", imported.PackageID, result.Problems)
+			}
+
+			// The imported package verifies in place from its repository directory
+			base := filepath.Join(repo, pkg.PackageID)
+			if check := VerifySSADPackage(imported, nil, SSADVerifyOptions{BaseDir: base}); len(check.Documents) != 2 || check.Documents[0].Status != SSADDocumentOK || check.Documents[1].Status != SSADDocumentOK {
+				t.Errorf("imported documents: %+v", check.Documents)
+			}
+
+			if _, _, err := ImportSSADArchive(archive, repo, opts); !errors.Is(err, ErrSSADPackageExists) {
+				t.Errorf("second import: got %v, want ErrSSADPackageExists", err)
+			}
+		})
+	}
+}
+
+func TestSSADArchiveRejectsUntrustedPackages(t *testing.T) {
+	dir := t.TempDir()
+	archive, _, _ := bundleSSADTestArchive(t, dir, SSADArchiveZip)
+	repo := filepath.Join(dir, "repo")
+
+	if _, _, err := ImportSSADArchive(archive, repo, SSADImportOptions{}); err == nil {
+		t.Fatal("package signed by an unknown key imported")
+	}
+	assertEmptyRepository(t, repo)
+
+	if _, _, err := ImportSSADArchive(archive, repo, SSADImportOptions{AllowUntrusted: true}); err != nil {
+		t.Errorf("import with AllowUntrusted: %v", err)
+	}
+}
+
+// rewriteZip copies a zip archive, letting edit change or drop entries, and
+// adds the extra entries at the end
+func rewriteZip(t *testing.T, src, dst string, edit func(name string, data []byte) ([]byte, bool), extra map[string][]byte) {
+	t.Helper()
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name string, data []byte) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, keep := edit(file.Name, data); keep {
+			write(file.Name, data)
+		}
+	}
+	for name, data := range extra {
+		write(name, data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSSADArchiveRejectsTamperedArchives(t *testing.T) {
+	dir := t.TempDir()
+	archive, _, trusted := bundleSSADTestArchive(t, dir, SSADArchiveZip)
+	opts := SSADImportOptions{SSADVerifyOptions: SSADVerifyOptions{TrustedKeys: []crypto.PublicKey{trusted}}}
+
+	tests := []struct {
+		name  string
+		edit  func(name string, data []byte) ([]byte, bool)
+		extra map[string][]byte
+		want  string
+	}{
+		{
+			name: "modified document",
+			edit: func(name string, data []byte) ([]byte, bool) {
+				if strings.HasPrefix(name, SSADArchiveDocumentsDir+"/sar/") {
+					data = bytes.Replace(data, []byte("{}"), []byte("[]"), 1)
+				}
+				return data, true
+			},
+			want: "does not match its digest",
+		},
+		{
+			name: "missing document",
+			edit: func(name string, data []byte) ([]byte, bool) {
+				return data, !strings.HasPrefix(name, SSADArchiveDocumentsDir+"/ssp/")
+			},
+			want: "archive is missing",
+		},
+		{
+			name:  "unlisted file",
+			edit:  func(name string, data []byte) ([]byte, bool) { return data, true },
+			extra: map[string][]byte{"documents/extra.txt": []byte("extra")},
+			want:  "missing from its index",
+		},
+		{
+			name: "modified package",
+			edit: func(name string, data []byte) ([]byte, bool) {
+				if name == SSADArchivePackagePath {
+					data = bytes.Replace(data, []byte(`"version": "1.0.0"`), []byte(`"version": "2.0.0"`), 1)
+				}
+				return data, true
+			},
+			want: "does not match its digest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := filepath.Join(t.TempDir(), "tampered.zip")
+			rewriteZip(t, archive, tampered, tt.edit, tt.extra)
+			repo := filepath.Join(t.TempDir(), "repo")
+			_, _, err := ImportSSADArchive(tampered, repo, opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %q", err, tt.want)
+			}
+			assertEmptyRepository(t, repo)
+		})
+	}
+}
+
+func TestSSADArchiveRejectsZipSlip(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "nested", "repo")
+	evil := []byte("evil")
+	sum := sha256Hex(evil)
+
+	for _, path := range []string{"../evil.txt", "../../evil.txt", "/tmp/evil.txt", `documents\..\..\evil.txt`, "documents/../../evil.txt"} {
+		index, err := json.Marshal(SSADArchiveIndex{
+			Format:        SSADArchiveFormat,
+			FormatVersion: SSADArchiveFormatVersion,
+			PackageID:     "SSAD-CSO-1-20260101-000000",
+			Files:         []SSADArchiveFile{{Path: path, Role: SSADArchiveRoleDocument, Size: int64(len(evil)), SHA256: sum}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, entry := range []struct {
+			name string
+			data []byte
+		}{{SSADArchiveIndexPath, index}, {path, evil}} {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Store})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(entry.data)
+		}
+		zw.Close()
+		archive := filepath.Join(dir, "slip.zip")
+		if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := ImportSSADArchive(archive, repo, SSADImportOptions{AllowUntrusted: true}); err == nil {
+			t.Errorf("archive with entry %q imported", path)
+		}
+		for _, escaped := range []string{filepath.Join(dir, "evil.txt"), filepath.Join(dir, "nested", "evil.txt"), "/tmp/evil.txt"} {
+			if _, err := os.Stat(escaped); err == nil {
+				os.Remove(escaped)
+				t.Errorf("entry %q was written to %s", path, escaped)
+			}
+		}
+		assertEmptyRepository(t, repo)
+	}
+}
+
+func TestSSADArchiveRejectsDocumentsOutsideArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive, _, trusted := bundleSSADTestArchive(t, dir, SSADArchiveZip)
+	tampered := filepath.Join(dir, "outside.zip")
+
+	// Point a document of the package outside the archive and fix up the index
+	// digests so only the location check can catch it
+	var packageData []byte
+	rewriteZip(t, archive, tampered, func(name string, data []byte) ([]byte, bool) {
+		if name == SSADArchivePackagePath {
+			var pkg SSADPackage
+			if err := json.Unmarshal(data, &pkg); err != nil {
+				t.Fatal(err)
+			}
+			pkg.Components.SSP.Location = "../../ssp.json"
+			data, _ = json.MarshalIndent(&pkg, "", "  ")
+			packageData = data
+		}
+		return data, true
+	}, nil)
+	rewriteZip(t, tampered, tampered, func(name string, data []byte) ([]byte, bool) {
+		if name == SSADArchiveIndexPath {
+			var index SSADArchiveIndex
+			if err := json.Unmarshal(data, &index); err != nil {
+				t.Fatal(err)
+			}
+			for i := range index.Files {
+				if index.Files[i].Path == SSADArchivePackagePath {
+					index.Files[i].Size = int64(len(packageData))
+					index.Files[i].SHA256 = sha256Hex(packageData)
+				}
+			}
+			data, _ = json.Marshal(index)
+		}
+		return data, true
+	}, nil)
+
+	repo := filepath.Join(dir, "repo")
+	opts := SSADImportOptions{SSADVerifyOptions: SSADVerifyOptions{TrustedKeys: []crypto.PublicKey{trusted}}}
+	if _, _, err := ImportSSADArchive(tampered, repo, opts); err == nil || !strings.Contains(err.Error(), "not inside the archive") {
+		t.Errorf("got %v, want a location error", err)
+	}
+	assertEmptyRepository(t, repo)
+}
@@ -0,0 +1,381 @@
+package fedramp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SSADPackageDiff describes what changed between two package versions
+type SSADPackageDiff struct {
+	From            SSADDiffVersion      `json:"from"`
+	To              SSADDiffVersion      `json:"to"`
+	DirectSuccessor bool                 `json:"direct_successor"` // To was derived from From
+	Metadata        []SSADFieldChange    `json:"metadata,omitempty"`
+	Documents       []SSADDocumentChange `json:"documents"`
+	Summary         map[string]int       `json:"summary"` // document changes by kind, including unchanged
+}
+
+// SSADDiffVersion identifies one side of a diff
+type SSADDiffVersion struct {
+	PackageID   string `json:"package_id"`
+	Version     string `json:"version"`
+	Status      string `json:"status"`
+	PackageHash string `json:"package_hash,omitempty"`
+}
+
+// SSADFieldChange is a changed metadata field
+type SSADFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Document change kinds
+const (
+	SSADDocumentAdded    = "added"
+	SSADDocumentRemoved  = "removed"
+	SSADDocumentModified = "modified"
+)
+
+// SSADDocumentChange is an added, removed or modified document. The SSP, SAP,
+// SAR and POA&M are compared by component, other documents by ID.
+type SSADDocumentChange struct {
+	Component      string           `json:"component"`
+	Change         string           `json:"change"`
+	DocumentID     string           `json:"document_id"`
+	FromDocumentID string           `json:"from_document_id,omitempty"` // when a replacement has a new ID
+	Title          string           `json:"title"`
+	FromHash       string           `json:"from_hash,omitempty"`
+	ToHash         string           `json:"to_hash,omitempty"`
+	Content        *SSADContentDiff `json:"content,omitempty"`
+	ContentError   string           `json:"content_error,omitempty"`
+}
+
+// SSADContentDiff is the structural diff of a JSON document
+type SSADContentDiff struct {
+	Kind    string           `json:"kind"` // ssp, poam or sar
+	Changes []SSADItemChange `json:"changes"`
+	Summary map[string]int   `json:"summary"`
+}
+
+// Item change kinds; closed and reopened apply to POA&M items
+const (
+	SSADItemAdded    = "added"
+	SSADItemRemoved  = "removed"
+	SSADItemModified = "modified"
+	SSADItemClosed   = "closed"
+	SSADItemReopened = "reopened"
+)
+
+// SSADItemChange is a changed control implementation, POA&M item or finding
+type SSADItemChange struct {
+	Change     string   `json:"change"`
+	ID         string   `json:"id"`
+	Title      string   `json:"title,omitempty"`
+	FromStatus string   `json:"from_status,omitempty"`
+	ToStatus   string   `json:"to_status,omitempty"`
+	Fields     []string `json:"fields,omitempty"` // changed fields of modified items
+}
+
+// SSADDiffOptions locates the documents of both packages for structural
+// diffs. Relative document locations are resolved against the base
+// directories; without them only hashes are compared.
+type SSADDiffOptions struct {
+	FromBaseDir string
+	ToBaseDir   string
+}
+
+// DiffSSADPackages compares two versions of a package
+func DiffSSADPackages(from, to *SSADPackage, opts SSADDiffOptions) *SSADPackageDiff {
+	diff := &SSADPackageDiff{
+		From:      ssadDiffVersion(from),
+		To:        ssadDiffVersion(to),
+		Documents: make([]SSADDocumentChange, 0),
+		Summary:   map[string]int{SSADDocumentAdded: 0, SSADDocumentRemoved: 0, SSADDocumentModified: 0, "unchanged": 0},
+	}
+	diff.DirectSuccessor = to.Parent != nil && to.Parent.PackageID == from.PackageID &&
+		to.Parent.PackageHash == from.IntegrityCheck.PackageHash
+	diff.Metadata = diffSSADMetadata(from, to)
+
+	previous := make(map[string]ssadComponentDocument)
+	var order []string
+	for _, cd := range from.componentDocuments() {
+		key := ssadDocumentKey(cd.Component, cd.Document.DocumentID)
+		previous[key] = cd
+		order = append(order, key)
+	}
+
+	for _, cd := range to.componentDocuments() {
+		key := ssadDocumentKey(cd.Component, cd.Document.DocumentID)
+		old, ok := previous[key]
+		delete(previous, key)
+		change := SSADDocumentChange{
+			Component:  cd.Component,
+			DocumentID: cd.Document.DocumentID,
+			Title:      cd.Document.Title,
+			ToHash:     cd.Document.Hash,
+		}
+		switch {
+		case !ok:
+			change.Change = SSADDocumentAdded
+		case old.Document.Hash == cd.Document.Hash:
+			diff.Summary["unchanged"]++
+			continue
+		default:
+			change.Change = SSADDocumentModified
+			change.FromHash = old.Document.Hash
+			if old.Document.DocumentID != cd.Document.DocumentID {
+				change.FromDocumentID = old.Document.DocumentID
+			}
+			if opts.FromBaseDir != "" && opts.ToBaseDir != "" {
+				change.Content, change.ContentError = diffSSADDocumentContent(
+					ssadDocumentPath(opts.FromBaseDir, old.Document),
+					ssadDocumentPath(opts.ToBaseDir, cd.Document),
+					cd.Document.Format == "JSON")
+			}
+		}
+		diff.Summary[change.Change]++
+		diff.Documents = append(diff.Documents, change)
+	}
+
+	for _, key := range order {
+		old, ok := previous[key]
+		if !ok {
+			continue
+		}
+		diff.Summary[SSADDocumentRemoved]++
+		diff.Documents = append(diff.Documents, SSADDocumentChange{
+			Component:  old.Component,
+			Change:     SSADDocumentRemoved,
+			DocumentID: old.Document.DocumentID,
+			Title:      old.Document.Title,
+			FromHash:   old.Document.Hash,
+		})
+	}
+	return diff
+}
+
+func ssadDiffVersion(p *SSADPackage) SSADDiffVersion {
+	return SSADDiffVersion{
+		PackageID:   p.PackageID,
+		Version:     p.Version,
+		Status:      p.Status,
+		PackageHash: p.IntegrityCheck.PackageHash,
+	}
+}
+
+func diffSSADMetadata(from, to *SSADPackage) []SSADFieldChange {
+	date := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"title", from.Metadata.Title, to.Metadata.Title},
+		{"description", from.Metadata.Description, to.Metadata.Description},
+		{"csp_name", from.Metadata.CSPName, to.Metadata.CSPName},
+		{"impact_level", from.Metadata.ImpactLevel, to.Metadata.ImpactLevel},
+		{"authorization_type", from.Metadata.AuthorizationType, to.Metadata.AuthorizationType},
+		{"authorization_date", date(from.Metadata.AuthorizationDate), date(to.Metadata.AuthorizationDate)},
+		{"expiration_date", date(from.Metadata.ExpirationDate), date(to.Metadata.ExpirationDate)},
+		{"package_format", from.Metadata.PackageFormat, to.Metadata.PackageFormat},
+		{"data_classification", from.AccessControl.DataClassification, to.AccessControl.DataClassification},
+	}
+	var changes []SSADFieldChange
+	for _, f := range fields {
+		if f.from != f.to {
+			changes = append(changes, SSADFieldChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	return changes
+}
+
+func ssadDocumentPath(baseDir string, doc SSADDocument) string {
+	if filepath.IsAbs(doc.Location) {
+		return doc.Location
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(doc.Location))
+}
+
+// diffSSADDocumentContent compares two versions of a JSON document. Documents
+// that are not JSON have no structural diff; that is only an error for
+// documents registered as JSON.
+func diffSSADDocumentContent(fromPath, toPath string, isJSON bool) (*SSADContentDiff, string) {
+	var docs [2]interface{}
+	for i, path := range []string{fromPath, toPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Sprintf("cannot read %s: %v", path, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&docs[i]); err != nil {
+			if isJSON {
+				return nil, fmt.Sprintf("cannot parse %s: %v", path, err)
+			}
+			return nil, ""
+		}
+	}
+
+	fromKind, fromItems := ssadContentItems(docs[0])
+	toKind, toItems := ssadContentItems(docs[1])
+	if fromKind == "" || fromKind != toKind {
+		if toKind != "" && fromKind != toKind {
+			return nil, fmt.Sprintf("document changed from %q to %q content", fromKind, toKind)
+		}
+		return nil, ""
+	}
+	return diffSSADItems(toKind, fromItems, toItems), ""
+}
+
+// ssadContentItem is a comparable element of a document
+type ssadContentItem struct {
+	id     string
+	title  string
+	status string
+	fields map[string]interface{}
+}
+
+// ssadContentItems extracts the controls of an OSCAL SSP, the items of a
+// POA&M or the findings of a SAR, in OSCAL or this tool's JSON formats
+func ssadContentItems(doc interface{}) (string, []ssadContentItem) {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return "", nil
+	}
+	if ssp, ok := root["system-security-plan"].(map[string]interface{}); ok {
+		impl, _ := ssp["control-implementation"].(map[string]interface{})
+		return "ssp", ssadItemsOf(impl["implemented-requirements"], "control-id", "control-id", "")
+	}
+	if poam, ok := root["plan-of-action-and-milestones"].(map[string]interface{}); ok {
+		return "poam", ssadItemsOf(poam["poam-items"], "uuid", "title", "")
+	}
+	if _, ok := root["poam_items"]; ok {
+		return "poam", ssadItemsOf(root["poam_items"], "item_id", "weakness", "status")
+	}
+	if results, ok := root["assessment-results"].(map[string]interface{}); ok {
+		var items []ssadContentItem
+		list, _ := results["results"].([]interface{})
+		for _, result := range list {
+			if r, ok := result.(map[string]interface{}); ok {
+				items = append(items, ssadItemsOf(r["findings"], "uuid", "title", "")...)
+			}
+		}
+		return "sar", items
+	}
+	if _, ok := root["control_findings"]; ok {
+		return "sar", ssadItemsOf(root["control_findings"], "finding_id", "control_id", "status")
+	}
+	return "", nil
+}
+
+func ssadItemsOf(list interface{}, idField, titleField, statusField string) []ssadContentItem {
+	elements, _ := list.([]interface{})
+	items := make([]ssadContentItem, 0, len(elements))
+	for i, element := range elements {
+		fields, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		item := ssadContentItem{fields: fields}
+		item.id, _ = fields[idField].(string)
+		if item.id == "" {
+			item.id = fmt.Sprintf("#%d", i+1)
+		}
+		item.title, _ = fields[titleField].(string)
+		if statusField != "" {
+			item.status, _ = fields[statusField].(string)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// ssadClosedStatuses are POA&M statuses of items that no longer need action
+var ssadClosedStatuses = map[string]bool{"Completed": true, "Cancelled": true, "Closed": true}
+
+func diffSSADItems(kind string, from, to []ssadContentItem) *SSADContentDiff {
+	diff := &SSADContentDiff{
+		Kind:    kind,
+		Changes: make([]SSADItemChange, 0),
+		Summary: make(map[string]int),
+	}
+	previous := make(map[string]ssadContentItem, len(from))
+	for _, item := range from {
+		previous[item.id] = item
+	}
+
+	for _, item := range to {
+		old, ok := previous[item.id]
+		delete(previous, item.id)
+		change := SSADItemChange{ID: item.id, Title: item.title, ToStatus: item.status}
+		if !ok {
+			change.Change = SSADItemAdded
+		} else {
+			change.Fields = ssadChangedFields(old.fields, item.fields)
+			if len(change.Fields) == 0 {
+				continue
+			}
+			change.FromStatus = old.status
+			change.Change = SSADItemModified
+			if kind == "poam" && ssadClosedStatuses[item.status] != ssadClosedStatuses[old.status] {
+				change.Change = SSADItemReopened
+				if ssadClosedStatuses[item.status] {
+					change.Change = SSADItemClosed
+				}
+			}
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+
+	// Open POA&M items that were dropped have been closed; OSCAL POA&Ms have
+	// no item status and only list open items
+	for _, item := range from {
+		if _, ok := previous[item.id]; !ok {
+			continue
+		}
+		change := SSADItemChange{Change: SSADItemRemoved, ID: item.id, Title: item.title, FromStatus: item.status}
+		if kind == "poam" && !ssadClosedStatuses[item.status] {
+			change.Change = SSADItemClosed
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+
+	for _, change := range diff.Changes {
+		diff.Summary[change.Change]++
+	}
+	return diff
+}
+
+// ssadChangedFields lists the top-level fields whose values differ
+func ssadChangedFields(from, to map[string]interface{}) []string {
+	var fields []string
+	for name, value := range to {
+		if !ssadSameJSON(from[name], value) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range from {
+		if _, ok := to[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func ssadSameJSON(a, b interface{}) bool {
+	// encoding/json sorts map keys, so equal values encode identically
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(x, y)
+}
@@ -0,0 +1,578 @@
+package fedramp
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SSAD envelope format identifiers
+const (
+	SSADEnvelopeFormat        = "fedramp-ssad-envelope"
+	SSADEnvelopeFormatVersion = "1.0"
+
+	// SSADContentCipher encrypts content in 64 KiB AES-256-GCM chunks. The
+	// nonce of each chunk is the envelope's nonce prefix, the chunk number and
+	// a final-chunk flag, so reordered or truncated content fails to decrypt.
+	SSADContentCipher = "AES-256-GCM-STREAM"
+
+	ssadChunkSize = 64 * 1024
+)
+
+// Content key wrapping algorithms
+const (
+	SSADWrapX25519  = "X25519-HKDF-SHA256-A256GCM"
+	SSADWrapRSAOAEP = "RSA-OAEP-SHA256"
+)
+
+// ssadWrapInfo labels content keys in the HKDF info and OAEP label
+var ssadWrapInfo = []byte("fedramp-ssad-content-key")
+
+// SSADEnvelope describes an encrypted package archive: how its content is
+// encrypted and the content key wrapped for each recipient. It is stored next
+// to the encrypted content so recipients can be changed without touching it.
+type SSADEnvelope struct {
+	Format             string           `json:"format"`
+	FormatVersion      string           `json:"format_version"`
+	PackageID          string           `json:"package_id"`
+	DataClassification string           `json:"data_classification,omitempty"`
+	ExportControl      bool             `json:"export_control"`
+	Cipher             string           `json:"cipher"`
+	ChunkSize          int              `json:"chunk_size"`
+	NoncePrefix        string           `json:"nonce_prefix"`   // base64, 7 bytes
+	ContentDigest      string           `json:"content_digest"` // SHA-256 of the ciphertext
+	ContentSize        int64            `json:"content_size"`   // plaintext bytes
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+	Recipients         []SSADWrappedKey `json:"recipients"`
+}
+
+// SSADWrappedKey is the content key wrapped for one recipient
+type SSADWrappedKey struct {
+	EntityID     string     `json:"entity_id"`
+	Name         string     `json:"name,omitempty"`
+	Owner        bool       `json:"owner,omitempty"`
+	Algorithm    string     `json:"algorithm"`
+	KeyID        string     `json:"key_id"`                  // SHA-256 fingerprint of the recipient key
+	EphemeralKey string     `json:"ephemeral_key,omitempty"` // base64 X25519 public key
+	WrappedKey   string     `json:"wrapped_key"`             // base64
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	WrappedAt    time.Time  `json:"wrapped_at"`
+}
+
+// SSADRecipient is an entity the content key of a package is wrapped for
+type SSADRecipient struct {
+	EntityID  string
+	Name      string
+	PublicKey crypto.PublicKey // *rsa.PublicKey or X25519 *ecdh.PublicKey
+	ExpiresAt *time.Time
+	Owner     bool // owners are kept when recipients are synchronized with sharees
+}
+
+// Recipients returns the sharees of the package that can receive its content
+// key at the given time. Sharees whose access expired or who have no public
+// key are skipped with the reason.
+func (p *SSADPackage) Recipients(now time.Time) ([]SSADRecipient, []string, error) {
+	var recipients []SSADRecipient
+	var skipped []string
+	index := make(map[string]int)
+	for _, sharee := range p.AccessControl.SharedWith {
+		switch {
+		case sharee.ExpirationDate != nil && !sharee.ExpirationDate.After(now):
+			skipped = append(skipped, fmt.Sprintf("%s: access expired %s", sharee.EntityID, sharee.ExpirationDate.Format("2006-01-02")))
+			continue
+		case sharee.PublicKey == "":
+			skipped = append(skipped, fmt.Sprintf("%s: no public key", sharee.EntityID))
+			continue
+		}
+		key, err := ParseSSADRecipientKey([]byte(sharee.PublicKey))
+		if err != nil {
+			return nil, nil, fmt.Errorf("sharee %s: %w", sharee.EntityID, err)
+		}
+		recipient := SSADRecipient{
+			EntityID:  sharee.EntityID,
+			Name:      sharee.Name,
+			PublicKey: key,
+			ExpiresAt: sharee.ExpirationDate,
+		}
+		// A later share of the same entity replaces the earlier one
+		if i, ok := index[sharee.EntityID]; ok {
+			recipients[i] = recipient
+			continue
+		}
+		index[sharee.EntityID] = len(recipients)
+		recipients = append(recipients, recipient)
+	}
+	return recipients, skipped, nil
+}
+
+// LoadSSADRecipientKey reads an X25519 or RSA public key, or a certificate
+// holding one, from a PEM file
+func LoadSSADRecipientKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	return ParseSSADRecipientKey(data)
+}
+
+// ParseSSADRecipientKey parses a PEM public key or certificate usable to wrap
+// content keys
+func ParseSSADRecipientKey(data []byte) (crypto.PublicKey, error) {
+	key, err := parsePublicKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wrapAlgorithm(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncodeSSADRecipientKey returns the PEM encoding of a recipient key, as stored
+// with sharees
+func EncodeSSADRecipientKey(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// LoadSSADDecryptionKey reads an X25519 or RSA private key from a PEM file
+func LoadSSADDecryptionKey(path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", path)
+	}
+	var key crypto.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+	if _, err := wrapAlgorithm(decryptionPublicKey(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// wrapAlgorithm names the wrapping algorithm used with a recipient key
+func wrapAlgorithm(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *ecdh.PublicKey:
+		if k.Curve() == ecdh.X25519() {
+			return SSADWrapX25519, nil
+		}
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return "", fmt.Errorf("RSA recipient keys must be at least 2048 bits")
+		}
+		return SSADWrapRSAOAEP, nil
+	}
+	return "", fmt.Errorf("unsupported recipient key type %T: use X25519 or RSA", key)
+}
+
+func decryptionPublicKey(key crypto.PrivateKey) crypto.PublicKey {
+	switch k := key.(type) {
+	case *ecdh.PrivateKey:
+		return k.PublicKey()
+	case *rsa.PrivateKey:
+		return &k.PublicKey
+	}
+	return nil
+}
+
+// EncryptSSADContent encrypts a package archive read from r to w with a fresh
+// content key and wraps that key for each recipient. Recipients whose access
+// has expired are rejected.
+func EncryptSSADContent(r io.Reader, w io.Writer, pkg *SSADPackage, recipients []SSADRecipient) (*SSADEnvelope, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients to encrypt for")
+	}
+	now := time.Now().UTC()
+	contentKey := make([]byte, 32)
+	prefix := make([]byte, 7)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	env := &SSADEnvelope{
+		Format:             SSADEnvelopeFormat,
+		FormatVersion:      SSADEnvelopeFormatVersion,
+		PackageID:          pkg.PackageID,
+		DataClassification: pkg.AccessControl.DataClassification,
+		ExportControl:      pkg.AccessControl.ExportControl,
+		Cipher:             SSADContentCipher,
+		ChunkSize:          ssadChunkSize,
+		NoncePrefix:        base64.StdEncoding.EncodeToString(prefix),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	for _, recipient := range recipients {
+		wrapped, err := wrapContentKey(contentKey, recipient, now)
+		if err != nil {
+			return nil, err
+		}
+		env.Recipients = append(env.Recipients, *wrapped)
+	}
+
+	aead, err := newContentAEAD(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.New()
+	size, err := sealChunks(aead, prefix, []byte(pkg.PackageID), r, io.MultiWriter(w, digest))
+	if err != nil {
+		return nil, err
+	}
+	env.ContentSize = size
+	env.ContentDigest = hex.EncodeToString(digest.Sum(nil))
+	return env, nil
+}
+
+// DecryptSSADContent decrypts content encrypted by EncryptSSADContent with the
+// private key of one of its recipients. Output written before an error must be
+// discarded.
+func DecryptSSADContent(env *SSADEnvelope, r io.Reader, w io.Writer, key crypto.PrivateKey, now time.Time) error {
+	if env.Format != SSADEnvelopeFormat || env.Cipher != SSADContentCipher {
+		return fmt.Errorf("unsupported envelope: %s %s", env.Format, env.Cipher)
+	}
+	if env.ChunkSize <= 0 || env.ChunkSize > 16<<20 {
+		return fmt.Errorf("invalid chunk size %d", env.ChunkSize)
+	}
+	contentKey, err := env.unwrap(key, now)
+	if err != nil {
+		return err
+	}
+	prefix, err := base64.StdEncoding.DecodeString(env.NoncePrefix)
+	if err != nil || len(prefix) != 7 {
+		return errors.New("invalid nonce prefix")
+	}
+	aead, err := newContentAEAD(contentKey)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.New()
+	size, err := openChunks(aead, prefix, []byte(env.PackageID), env.ChunkSize, io.TeeReader(r, digest), w)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(digest.Sum(nil)) != env.ContentDigest {
+		return errors.New("encrypted content does not match the envelope digest")
+	}
+	if size != env.ContentSize {
+		return fmt.Errorf("decrypted %d bytes, envelope says %d", size, env.ContentSize)
+	}
+	return nil
+}
+
+// Rewrap synchronizes the recipients of the envelope with the given ones
+// without re-encrypting the content. Recipients already holding the content
+// key under the same public key are kept, new or re-keyed recipients get the
+// key unwrapped with the private key of an existing recipient, and entries of
+// other recipients are removed, except owners. Revoked recipients lose access
+// to copies distributed from now on; a copy of the content key they already
+// unwrapped cannot be recalled.
+func (env *SSADEnvelope) Rewrap(key crypto.PrivateKey, recipients []SSADRecipient, now time.Time) (added, removed []string, err error) {
+	existing := make(map[string]SSADWrappedKey)
+	for _, wrapped := range env.Recipients {
+		existing[wrapped.EntityID] = wrapped
+	}
+
+	var contentKey []byte
+	var result []SSADWrappedKey
+	wanted := make(map[string]bool)
+	for _, recipient := range recipients {
+		if recipient.ExpiresAt != nil && !recipient.ExpiresAt.After(now) {
+			continue
+		}
+		wanted[recipient.EntityID] = true
+		keyID, err := KeyFingerprint(recipient.PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if current, ok := existing[recipient.EntityID]; ok && current.KeyID == keyID {
+			current.ExpiresAt = recipient.ExpiresAt
+			current.Owner = current.Owner || recipient.Owner
+			result = append(result, current)
+			continue
+		}
+		if contentKey == nil {
+			if key == nil {
+				return nil, nil, errors.New("a recipient private key is required to add recipients")
+			}
+			if contentKey, err = env.unwrap(key, now); err != nil {
+				return nil, nil, err
+			}
+		}
+		wrapped, err := wrapContentKey(contentKey, recipient, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, *wrapped)
+		added = append(added, recipient.EntityID)
+	}
+	for _, wrapped := range env.Recipients {
+		if wanted[wrapped.EntityID] {
+			continue
+		}
+		if wrapped.Owner {
+			result = append(result, wrapped)
+			continue
+		}
+		removed = append(removed, wrapped.EntityID)
+	}
+	if len(result) == 0 {
+		return nil, nil, errors.New("rewrapping would leave the content without recipients")
+	}
+
+	env.Recipients = result
+	env.UpdatedAt = now.UTC()
+	return added, removed, nil
+}
+
+// unwrap recovers the content key with the private key of a recipient
+func (env *SSADEnvelope) unwrap(key crypto.PrivateKey, now time.Time) ([]byte, error) {
+	public := decryptionPublicKey(key)
+	if public == nil {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	keyID, err := KeyFingerprint(public)
+	if err != nil {
+		return nil, err
+	}
+	for _, wrapped := range env.Recipients {
+		if wrapped.KeyID != keyID {
+			continue
+		}
+		if wrapped.ExpiresAt != nil && !wrapped.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("access of %s expired %s", wrapped.EntityID, wrapped.ExpiresAt.Format("2006-01-02"))
+		}
+		return unwrapContentKey(wrapped, key)
+	}
+	return nil, fmt.Errorf("key %s is not a recipient of package %s", keyID, env.PackageID)
+}
+
+func wrapContentKey(contentKey []byte, recipient SSADRecipient, now time.Time) (*SSADWrappedKey, error) {
+	if recipient.ExpiresAt != nil && !recipient.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("access of %s expired %s", recipient.EntityID, recipient.ExpiresAt.Format("2006-01-02"))
+	}
+	algorithm, err := wrapAlgorithm(recipient.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %s: %w", recipient.EntityID, err)
+	}
+	keyID, err := KeyFingerprint(recipient.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := &SSADWrappedKey{
+		EntityID:  recipient.EntityID,
+		Name:      recipient.Name,
+		Owner:     recipient.Owner,
+		Algorithm: algorithm,
+		KeyID:     keyID,
+		ExpiresAt: recipient.ExpiresAt,
+		WrappedAt: now.UTC(),
+	}
+
+	var out []byte
+	switch public := recipient.PublicKey.(type) {
+	case *rsa.PublicKey:
+		out, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, public, contentKey, ssadWrapInfo)
+	case *ecdh.PublicKey:
+		var ephemeral *ecdh.PrivateKey
+		ephemeral, err = ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		wrapped.EphemeralKey = base64.StdEncoding.EncodeToString(ephemeral.PublicKey().Bytes())
+		out, err = x25519Seal(ephemeral, public, ephemeral.PublicKey(), contentKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap content key for %s: %w", recipient.EntityID, err)
+	}
+	wrapped.WrappedKey = base64.StdEncoding.EncodeToString(out)
+	return wrapped, nil
+}
+
+func unwrapContentKey(wrapped SSADWrappedKey, key crypto.PrivateKey) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(wrapped.WrappedKey)
+	if err != nil {
+		return nil, errors.New("wrapped key is not valid base64")
+	}
+	var contentKey []byte
+	switch private := key.(type) {
+	case *rsa.PrivateKey:
+		if wrapped.Algorithm != SSADWrapRSAOAEP {
+			return nil, fmt.Errorf("key of %s is wrapped with %s", wrapped.EntityID, wrapped.Algorithm)
+		}
+		contentKey, err = rsa.DecryptOAEP(sha256.New(), nil, private, data, ssadWrapInfo)
+	case *ecdh.PrivateKey:
+		if wrapped.Algorithm != SSADWrapX25519 {
+			return nil, fmt.Errorf("key of %s is wrapped with %s", wrapped.EntityID, wrapped.Algorithm)
+		}
+		raw, decodeErr := base64.StdEncoding.DecodeString(wrapped.EphemeralKey)
+		if decodeErr != nil {
+			return nil, errors.New("ephemeral key is not valid base64")
+		}
+		ephemeral, parseErr := ecdh.X25519().NewPublicKey(raw)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid ephemeral key: %w", parseErr)
+		}
+		contentKey, err = x25519Open(private, ephemeral, private.PublicKey(), data)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if err != nil || len(contentKey) != 32 {
+		return nil, fmt.Errorf("failed to unwrap content key for %s", wrapped.EntityID)
+	}
+	return contentKey, nil
+}
+
+// x25519Seal encrypts the content key under a key derived from the X25519
+// shared secret of the ephemeral and recipient keys. The derived key is used
+// once, so a zero nonce is safe.
+func x25519Seal(private *ecdh.PrivateKey, peer, ephemeral *ecdh.PublicKey, contentKey []byte) ([]byte, error) {
+	aead, err := x25519AEAD(private, peer, ephemeral, peer)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, aead.NonceSize()), contentKey, nil), nil
+}
+
+func x25519Open(private *ecdh.PrivateKey, ephemeral, recipient *ecdh.PublicKey, data []byte) ([]byte, error) {
+	aead, err := x25519AEAD(private, ephemeral, ephemeral, recipient)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, aead.NonceSize()), data, nil)
+}
+
+// x25519AEAD derives the key-wrapping cipher with HKDF-SHA256 over the shared
+// secret, salted with the ephemeral and recipient public keys
+func x25519AEAD(private *ecdh.PrivateKey, peer, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	shared, err := private.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte(nil), ephemeral.Bytes()...), recipient.Bytes()...)
+	return newContentAEAD(hkdfSHA256(shared, salt, ssadWrapInfo))
+}
+
+// hkdfSHA256 derives a 32 byte key as in RFC 5869
+func hkdfSHA256(secret, salt, info []byte) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+func newContentAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce builds the nonce of a content chunk: prefix, chunk number and
+// final-chunk flag
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// sealChunks encrypts r in chunks, returning the number of plaintext bytes
+func sealChunks(aead cipher.AEAD, prefix, aad []byte, r io.Reader, w io.Writer) (int64, error) {
+	br := bufio.NewReaderSize(r, ssadChunkSize+1)
+	buf := make([]byte, ssadChunkSize)
+	var total int64
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return total, err
+		}
+		_, peekErr := br.Peek(1)
+		last := peekErr != nil
+		if last && peekErr != io.EOF {
+			return total, peekErr
+		}
+		if _, err := w.Write(aead.Seal(nil, chunkNonce(prefix, counter, last), buf[:n], aad)); err != nil {
+			return total, err
+		}
+		total += int64(n)
+		if last {
+			return total, nil
+		}
+		if counter == ^uint32(0) {
+			return total, errors.New("content is too large to encrypt")
+		}
+	}
+}
+
+// openChunks decrypts content sealed by sealChunks, returning the number of
+// plaintext bytes
+func openChunks(aead cipher.AEAD, prefix, aad []byte, chunkSize int, r io.Reader, w io.Writer) (int64, error) {
+	sealedSize := chunkSize + aead.Overhead()
+	br := bufio.NewReaderSize(r, sealedSize+1)
+	buf := make([]byte, sealedSize)
+	var total int64
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return total, err
+		}
+		_, peekErr := br.Peek(1)
+		last := peekErr != nil
+		if last && peekErr != io.EOF {
+			return total, peekErr
+		}
+		plain, err := aead.Open(nil, chunkNonce(prefix, counter, last), buf[:n], aad)
+		if err != nil {
+			return total, errors.New("encrypted content was modified or truncated")
+		}
+		if _, err := w.Write(plain); err != nil {
+			return total, err
+		}
+		total += int64(len(plain))
+		if last {
+			return total, nil
+		}
+	}
+}
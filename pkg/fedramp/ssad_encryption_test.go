@@ -0,0 +1,168 @@
+package fedramp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+)
+
+type ssadTestKeys struct {
+	x25519 *ecdh.PrivateKey
+	rsa    *rsa.PrivateKey
+	other  *ecdh.PrivateKey
+}
+
+func newSSADTestKeys(t *testing.T) ssadTestKeys {
+	t.Helper()
+	x, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ssadTestKeys{x25519: x, rsa: r, other: other}
+}
+
+// encryptSSADTestContent encrypts three and a half chunks of random content
+// for an X25519 and an RSA recipient
+func encryptSSADTestContent(t *testing.T, keys ssadTestKeys) (plain, sealed []byte, env *SSADEnvelope) {
+	t.Helper()
+	plain = make([]byte, 3*ssadChunkSize+ssadChunkSize/2)
+	if _, err := rand.Read(plain); err != nil {
+		t.Fatal(err)
+	}
+	pkg := NewSSADPackage("CSO-1", SSADMetadata{Title: "Test package"})
+	recipients := []SSADRecipient{
+		{EntityID: "agency-a", PublicKey: keys.x25519.PublicKey(), Owner: true},
+		{EntityID: "agency-b", PublicKey: &keys.rsa.PublicKey},
+	}
+	var out bytes.Buffer
+	env, err := EncryptSSADContent(bytes.NewReader(plain), &out, pkg, recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plain, out.Bytes(), env
+}
+
+func TestSSADEncryptionRoundTrip(t *testing.T) {
+	keys := newSSADTestKeys(t)
+	plain, sealed, env := encryptSSADTestContent(t, keys)
+	if env.ContentSize != int64(len(plain)) || len(env.Recipients) != 2 {
+		t.Fatalf("envelope: size %d, %d recipients", env.ContentSize, len(env.Recipients))
+	}
+
+	for name, key := range map[string]crypto.PrivateKey{"X25519": keys.x25519, "RSA": keys.rsa} {
+		var out bytes.Buffer
+		if err := DecryptSSADContent(env, bytes.NewReader(sealed), &out, key, time.Now()); err != nil {
+			t.Errorf("%s recipient: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(out.Bytes(), plain) {
+			t.Errorf("%s recipient: decrypted content differs", name)
+		}
+	}
+
+	if err := DecryptSSADContent(env, bytes.NewReader(sealed), &bytes.Buffer{}, keys.other, time.Now()); err == nil {
+		t.Error("content decrypted with the key of a non-recipient")
+	}
+}
+
+func TestSSADEncryptionTamper(t *testing.T) {
+	keys := newSSADTestKeys(t)
+	_, sealed, env := encryptSSADTestContent(t, keys)
+	decrypt := func(env *SSADEnvelope, data []byte) error {
+		return DecryptSSADContent(env, bytes.NewReader(data), &bytes.Buffer{}, keys.x25519, time.Now())
+	}
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[ssadChunkSize+10] ^= 0x01
+	if decrypt(env, flipped) == nil {
+		t.Error("modified ciphertext decrypted")
+	}
+
+	if decrypt(env, sealed[:len(sealed)/2]) == nil {
+		t.Error("truncated ciphertext decrypted")
+	}
+
+	renamed := *env
+	renamed.PackageID = "SSAD-CSO-2-20260101-000000"
+	if decrypt(&renamed, sealed) == nil {
+		t.Error("ciphertext decrypted under another package ID")
+	}
+
+	resized := *env
+	resized.ContentSize++
+	if decrypt(&resized, sealed) == nil {
+		t.Error("ciphertext decrypted with a wrong envelope size")
+	}
+}
+
+func TestSSADEncryptionExpiryAndRewrap(t *testing.T) {
+	keys := newSSADTestKeys(t)
+	plain, sealed, env := encryptSSADTestContent(t, keys)
+	now := time.Now()
+
+	// agency-b is revoked and agency-c added; the owner is kept
+	expires := now.Add(time.Hour)
+	added, removed, err := env.Rewrap(keys.x25519, []SSADRecipient{{EntityID: "agency-c", PublicKey: keys.other.PublicKey(), ExpiresAt: &expires}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0] != "agency-c" || len(removed) != 1 || removed[0] != "agency-b" {
+		t.Fatalf("added %v, removed %v", added, removed)
+	}
+
+	var out bytes.Buffer
+	if err := DecryptSSADContent(env, bytes.NewReader(sealed), &out, keys.other, now); err != nil || !bytes.Equal(out.Bytes(), plain) {
+		t.Errorf("added recipient cannot decrypt: %v", err)
+	}
+	if err := DecryptSSADContent(env, bytes.NewReader(sealed), &bytes.Buffer{}, keys.rsa, now); err == nil {
+		t.Error("revoked recipient can still decrypt")
+	}
+	if err := DecryptSSADContent(env, bytes.NewReader(sealed), &bytes.Buffer{}, keys.other, expires); err == nil {
+		t.Error("recipient can decrypt after its access expired")
+	}
+	if err := DecryptSSADContent(env, bytes.NewReader(sealed), &bytes.Buffer{}, keys.x25519, now); err != nil {
+		t.Errorf("owner cannot decrypt after rewrap: %v", err)
+	}
+}
+
+func TestSSADRecipientKeyEncoding(t *testing.T) {
+	keys := newSSADTestKeys(t)
+	for name, key := range map[string]crypto.PublicKey{"X25519": keys.x25519.PublicKey(), "RSA": &keys.rsa.PublicKey} {
+		encoded, err := EncodeSSADRecipientKey(key)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		parsed, err := ParseSSADRecipientKey([]byte(encoded))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want, _ := KeyFingerprint(key)
+		if got, _ := KeyFingerprint(parsed); got != want {
+			t.Errorf("%s: fingerprint %s after encoding, want %s", name, got, want)
+		}
+	}
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := EncodeSSADRecipientKey(&small.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSSADRecipientKey([]byte(encoded)); err == nil {
+		t.Error("1024-bit RSA recipient key accepted")
+	}
+}
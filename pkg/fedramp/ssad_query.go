@@ -0,0 +1,245 @@
+package fedramp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// SSADQuery is a parsed repository search. Every term must match.
+//
+// Terms are separated by spaces; values with spaces are quoted. A term is
+// either free text, matched against the ID, title, description, CSP, tags and
+// keywords, or field:value. Prefixing a term with - negates it. Values may list
+// alternatives separated by | and use * wildcards. Date fields take a year,
+// month or day and the comparisons >, >=, < and <=:
+//
+//	csp:"CloudNative Inc" impact:High|Moderate tag:pilot doc:sar
+//	authorized:>=2026-01 expires:<2027 -status:archived zero-trust
+//
+// Fields: id, service, csp, impact, auth, status, format, classification,
+// title, tag, keyword, doc (component or document type) and the dates
+// created, updated, authorized and expires.
+type SSADQuery struct {
+	terms []ssadQueryTerm
+}
+
+type ssadQueryTerm struct {
+	field  string
+	negate bool
+	values []string // lower case alternatives
+	op     string   // date comparison: =, >, >=, <, <=
+	start  time.Time
+	end    time.Time // dates match [start, end)
+}
+
+var ssadQueryStringFields = map[string]func(*SSADPackage) string{
+	"id":             func(p *SSADPackage) string { return p.PackageID },
+	"service":        func(p *SSADPackage) string { return p.ServiceOfferingID },
+	"csp":            func(p *SSADPackage) string { return p.Metadata.CSPName },
+	"impact":         func(p *SSADPackage) string { return p.Metadata.ImpactLevel },
+	"auth":           func(p *SSADPackage) string { return p.Metadata.AuthorizationType },
+	"status":         func(p *SSADPackage) string { return p.Status },
+	"format":         func(p *SSADPackage) string { return p.Metadata.PackageFormat },
+	"classification": func(p *SSADPackage) string { return p.AccessControl.DataClassification },
+}
+
+var ssadQueryDateFields = map[string]func(*SSADPackage) time.Time{
+	"created":    func(p *SSADPackage) time.Time { return p.CreatedAt },
+	"updated":    func(p *SSADPackage) time.Time { return p.UpdatedAt },
+	"authorized": func(p *SSADPackage) time.Time { return p.Metadata.AuthorizationDate },
+	"expires":    func(p *SSADPackage) time.Time { return p.Metadata.ExpirationDate },
+}
+
+// ParseSSADQuery parses a repository search. An empty query matches every
+// package.
+func ParseSSADQuery(query string) (*SSADQuery, error) {
+	tokens, err := splitSSADQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	q := &SSADQuery{}
+	for _, token := range tokens {
+		term := ssadQueryTerm{field: "text"}
+		if strings.HasPrefix(token, "-") && len(token) > 1 {
+			term.negate = true
+			token = token[1:]
+		}
+		value := token
+		if i := strings.Index(token, ":"); i > 0 {
+			field := strings.ToLower(token[:i])
+			if !isSSADQueryField(field) {
+				return nil, fmt.Errorf("unknown search field %q", field)
+			}
+			term.field = field
+			value = token[i+1:]
+		}
+		if value == "" {
+			return nil, fmt.Errorf("search term %q has no value", token)
+		}
+
+		if _, ok := ssadQueryDateFields[term.field]; ok {
+			term.op = "="
+			for _, op := range []string{">=", "<=", ">", "<", "="} {
+				if strings.HasPrefix(value, op) {
+					term.op, value = op, value[len(op):]
+					break
+				}
+			}
+			if term.start, term.end, err = parseSSADQueryDate(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", term.field, err)
+			}
+		} else {
+			for _, alternative := range strings.Split(strings.ToLower(value), "|") {
+				if alternative != "" {
+					term.values = append(term.values, alternative)
+				}
+			}
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+func isSSADQueryField(field string) bool {
+	if _, ok := ssadQueryStringFields[field]; ok {
+		return true
+	}
+	if _, ok := ssadQueryDateFields[field]; ok {
+		return true
+	}
+	switch field {
+	case "title", "tag", "keyword", "doc":
+		return true
+	}
+	return false
+}
+
+// splitSSADQuery splits a query on spaces outside double quotes
+func splitSSADQuery(query string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in search")
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// parseSSADQueryDate returns the period covered by a year, month or day
+func parseSSADQueryDate(value string) (time.Time, time.Time, error) {
+	for _, layout := range []struct {
+		format string
+		years  int
+		months int
+		days   int
+	}{{"2006-01-02", 0, 0, 1}, {"2006-01", 0, 1, 0}, {"2006", 1, 0, 0}} {
+		if start, err := time.Parse(layout.format, value); err == nil {
+			return start, start.AddDate(layout.years, layout.months, layout.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q: use YYYY, YYYY-MM or YYYY-MM-DD", value)
+}
+
+// Match reports whether a package matches every term of the query
+func (q *SSADQuery) Match(pkg *SSADPackage) bool {
+	for _, term := range q.terms {
+		if term.match(pkg) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t ssadQueryTerm) match(pkg *SSADPackage) bool {
+	if get, ok := ssadQueryDateFields[t.field]; ok {
+		date := get(pkg)
+		if date.IsZero() {
+			return false
+		}
+		switch t.op {
+		case ">":
+			return !date.Before(t.end)
+		case ">=":
+			return !date.Before(t.start)
+		case "<":
+			return date.Before(t.start)
+		case "<=":
+			return date.Before(t.end)
+		}
+		return !date.Before(t.start) && date.Before(t.end)
+	}
+	if get, ok := ssadQueryStringFields[t.field]; ok {
+		return t.matchAny(get(pkg))
+	}
+
+	switch t.field {
+	case "title":
+		return t.containsAny(pkg.Metadata.Title)
+	case "tag":
+		return t.matchAny(pkg.Metadata.Tags...)
+	case "keyword":
+		return t.matchAny(pkg.Metadata.Keywords...)
+	case "doc":
+		var kinds []string
+		pkg.eachDocument(func(component string, doc *SSADDocument) {
+			kinds = append(kinds, component, doc.Type)
+		})
+		return t.matchAny(kinds...)
+	}
+	text := []string{pkg.PackageID, pkg.Metadata.Title, pkg.Metadata.Description, pkg.Metadata.CSPName}
+	text = append(text, pkg.Metadata.Tags...)
+	text = append(text, pkg.Metadata.Keywords...)
+	return t.containsAny(text...)
+}
+
+// matchAny compares whole values, honoring * wildcards
+func (t ssadQueryTerm) matchAny(candidates ...string) bool {
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(candidate)
+		for _, value := range t.values {
+			if strings.Contains(value, "*") {
+				if ok, _ := path.Match(value, candidate); ok {
+					return true
+				}
+			} else if candidate == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsAny matches values anywhere in the candidates
+func (t ssadQueryTerm) containsAny(candidates ...string) bool {
+	for _, candidate := range candidates {
+		candidate = strings.ToLower(candidate)
+		for _, value := range t.values {
+			if strings.Contains(value, "*") {
+				if ok, _ := path.Match("*"+value+"*", candidate); ok {
+					return true
+				}
+			} else if strings.Contains(candidate, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
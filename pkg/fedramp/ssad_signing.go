@@ -0,0 +1,568 @@
+package fedramp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SSADManifest lists the documents of a package with their content hashes.
+// Its canonical form is what package signatures cover. Document locations are
+// left out so a package can be moved or archived without breaking signatures.
+type SSADManifest struct {
+	PackageID         string              `json:"package_id"`
+	ServiceOfferingID string              `json:"service_offering_id"`
+	Version           string              `json:"version"`
+	ParentHash        string              `json:"parent_hash,omitempty"` // package hash of the superseded version
+	HashAlgorithm     string              `json:"hash_algorithm"`
+	Documents         []SSADManifestEntry `json:"documents"`
+}
+
+// SSADManifestEntry is a document of the manifest
+type SSADManifestEntry struct {
+	Component  string `json:"component"` // ssp, sap, sar, poam, conmon, scn, incident, attachment, ksi
+	DocumentID string `json:"document_id"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+}
+
+// ssadComponentDocument is a document of a package with the component name
+// used by AddDocument
+type ssadComponentDocument struct {
+	Component string
+	Document  SSADDocument
+}
+
+// eachDocument calls fn with every document of the package and the component
+// name used by AddDocument. fn may modify the document in place.
+func (p *SSADPackage) eachDocument(fn func(component string, doc *SSADDocument)) {
+	for _, single := range []struct {
+		component string
+		doc       *SSADDocument
+	}{{"ssp", p.Components.SSP}, {"sap", p.Components.SAP}, {"sar", p.Components.SAR}, {"poam", p.Components.POAM}} {
+		if single.doc != nil {
+			fn(single.component, single.doc)
+		}
+	}
+	for _, list := range []struct {
+		component string
+		docs      []SSADDocument
+	}{
+		{"conmon", p.Components.ConMon},
+		{"scn", p.Components.SCNs},
+		{"incident", p.Components.IncidentReports},
+		{"attachment", p.Components.Attachments},
+		{"ksi", p.Components.KSIReports},
+	} {
+		for i := range list.docs {
+			fn(list.component, &list.docs[i])
+		}
+	}
+}
+
+// componentDocuments lists the documents of every component ordered by
+// component, document ID and hash
+func (p *SSADPackage) componentDocuments() []ssadComponentDocument {
+	var docs []ssadComponentDocument
+	p.eachDocument(func(component string, doc *SSADDocument) {
+		docs = append(docs, ssadComponentDocument{Component: component, Document: *doc})
+	})
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := docs[i], docs[j]
+		if a.Component != b.Component {
+			return a.Component < b.Component
+		}
+		if a.Document.DocumentID != b.Document.DocumentID {
+			return a.Document.DocumentID < b.Document.DocumentID
+		}
+		return a.Document.Hash < b.Document.Hash
+	})
+	return docs
+}
+
+// Manifest builds the manifest of the package
+func (p *SSADPackage) Manifest() *SSADManifest {
+	entries := make([]SSADManifestEntry, 0)
+	for _, cd := range p.componentDocuments() {
+		entries = append(entries, SSADManifestEntry{
+			Component:  cd.Component,
+			DocumentID: cd.Document.DocumentID,
+			Size:       cd.Document.Size,
+			SHA256:     cd.Document.Hash,
+		})
+	}
+	manifest := &SSADManifest{
+		PackageID:         p.PackageID,
+		ServiceOfferingID: p.ServiceOfferingID,
+		Version:           p.Version,
+		HashAlgorithm:     "SHA-256",
+		Documents:         entries,
+	}
+	if p.Parent != nil {
+		manifest.ParentHash = p.Parent.PackageHash
+	}
+	return manifest
+}
+
+// Canonical returns the canonical encoding of the manifest: compact JSON with
+// fields in declaration order and documents in manifest order
+func (m *SSADManifest) Canonical() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(m); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Digest returns the hex SHA-256 digest of the canonical manifest
+func (m *SSADManifest) Digest() (string, error) {
+	data, err := m.Canonical()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SSADSignature is a detached signature over the canonical manifest of a package
+type SSADSignature struct {
+	PackageID        string    `json:"package_id"`
+	ManifestDigest   string    `json:"manifest_digest"`
+	Algorithm        string    `json:"algorithm"` // Ed25519, ECDSA-SHA256 or RSA-SHA256
+	KeyID            string    `json:"key_id"`    // SHA-256 fingerprint of the public key
+	PublicKey        string    `json:"public_key"`
+	CertificateChain []string  `json:"certificate_chain,omitempty"` // PEM, signer first
+	SignedBy         string    `json:"signed_by"`
+	SignedAt         time.Time `json:"signed_at"`
+	Signature        string    `json:"signature"` // base64
+}
+
+// SSADSigningKey is a private key, optionally with the certificate chain
+// binding it to an identity
+type SSADSigningKey struct {
+	Signer       crypto.Signer
+	Certificates []*x509.Certificate // signer certificate first
+}
+
+// LoadSSADSigningKey reads a PEM private key (PKCS#8, PKCS#1 or SEC 1) and an
+// optional PEM file with the signer certificate followed by its intermediates
+func LoadSSADSigningKey(keyPath, certPath string) (*SSADSigningKey, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in %s", keyPath)
+	}
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing key type %T", key)
+	}
+	if _, err := signatureAlgorithm(signer.Public()); err != nil {
+		return nil, err
+	}
+
+	signingKey := &SSADSigningKey{Signer: signer}
+	if certPath == "" {
+		return signingKey, nil
+	}
+	signingKey.Certificates, err = loadCertificates(certPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(signingKey.Certificates) == 0 {
+		return nil, fmt.Errorf("no certificate in %s", certPath)
+	}
+	leafKey, err := x509.MarshalPKIXPublicKey(signingKey.Certificates[0].PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	ownKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(leafKey, ownKey) {
+		return nil, errors.New("the first certificate does not match the signing key")
+	}
+	return signingKey, nil
+}
+
+// LoadCertificatePool reads the PEM certificates of a file into a pool
+func LoadCertificatePool(path string) (*x509.CertPool, error) {
+	certs, err := loadCertificates(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
+
+// LoadPublicKey reads a PEM public key (PKIX) or the public key of a PEM
+// certificate
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	return parsePublicKeyPEM(data)
+}
+
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block in public key")
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func loadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificates: %w", err)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate in %s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// signatureAlgorithm names the algorithm used with a public key
+func signatureAlgorithm(key crypto.PublicKey) (string, error) {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		return "Ed25519", nil
+	case *ecdsa.PublicKey:
+		return "ECDSA-SHA256", nil
+	case *rsa.PublicKey:
+		return "RSA-SHA256", nil
+	}
+	return "", fmt.Errorf("unsupported public key type %T", key)
+}
+
+// KeyFingerprint returns the hex SHA-256 fingerprint of the PKIX encoding of a
+// public key
+func KeyFingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Sign signs the canonical manifest of a finalized package, recording the key
+// fingerprint as the package verification key
+func (p *SSADPackage) Sign(key *SSADSigningKey, signedBy string) (*SSADSignature, error) {
+	if p.Status != "final" {
+		return nil, fmt.Errorf("can only sign finalized packages")
+	}
+	manifest := p.Manifest()
+	data, err := manifest.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := manifest.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if digest != p.IntegrityCheck.PackageHash {
+		return nil, fmt.Errorf("package documents changed since the package was finalized")
+	}
+
+	public := key.Signer.Public()
+	algorithm, err := signatureAlgorithm(public)
+	if err != nil {
+		return nil, err
+	}
+	var signature []byte
+	if algorithm == "Ed25519" {
+		signature, err = key.Signer.Sign(rand.Reader, data, crypto.Hash(0))
+	} else {
+		sum := sha256.Sum256(data)
+		signature, err = key.Signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign manifest: %w", err)
+	}
+
+	keyID, err := KeyFingerprint(public)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	sig := &SSADSignature{
+		PackageID:      p.PackageID,
+		ManifestDigest: digest,
+		Algorithm:      algorithm,
+		KeyID:          keyID,
+		PublicKey:      string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		SignedBy:       signedBy,
+		SignedAt:       time.Now(),
+		Signature:      base64.StdEncoding.EncodeToString(signature),
+	}
+	for _, cert := range key.Certificates {
+		sig.CertificateChain = append(sig.CertificateChain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	}
+
+	p.IntegrityCheck.SignedBy = signedBy
+	p.IntegrityCheck.SignatureDate = sig.SignedAt
+	p.IntegrityCheck.VerificationKey = keyID
+	return sig, nil
+}
+
+// Document check statuses
+const (
+	SSADDocumentOK       = "ok"
+	SSADDocumentTampered = "tampered"
+	SSADDocumentMissing  = "missing"
+)
+
+// SSADDocumentCheck is the result of re-hashing a document
+type SSADDocumentCheck struct {
+	Component  string `json:"component"`
+	DocumentID string `json:"document_id"`
+	Location   string `json:"location"`
+	Status     string `json:"status"`
+	Expected   string `json:"expected"`
+	Actual     string `json:"actual,omitempty"`
+}
+
+// SSADVerifyOptions controls package verification
+type SSADVerifyOptions struct {
+	BaseDir     string             // relative document locations are resolved here
+	Roots       *x509.CertPool     // trusted CAs; the system pool when nil
+	TrustedKeys []crypto.PublicKey // keys trusted without a certificate
+}
+
+// SSADVerification reports the integrity and authenticity of a package
+type SSADVerification struct {
+	PackageID       string              `json:"package_id"`
+	Documents       []SSADDocumentCheck `json:"documents"`
+	ManifestDigest  string              `json:"manifest_digest"`
+	ManifestMatches bool                `json:"manifest_matches"`
+	SignatureValid  bool                `json:"signature_valid"`
+	Trusted         bool                `json:"trusted"`
+	Signer          string              `json:"signer,omitempty"`
+	Problems        []string            `json:"problems,omitempty"`
+}
+
+// OK reports whether every document is intact and the signature is valid and
+// trusted
+func (v *SSADVerification) OK() bool {
+	return len(v.Problems) == 0
+}
+
+func (v *SSADVerification) problem(format string, args ...interface{}) {
+	v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+}
+
+// VerifySSADPackage re-hashes every document of a package, checks that the
+// manifest digest matches the package hash and the signature, verifies the
+// signature over the canonical manifest and establishes trust in the signing
+// key through its certificate chain or the trusted keys
+func VerifySSADPackage(pkg *SSADPackage, sig *SSADSignature, opts SSADVerifyOptions) *SSADVerification {
+	manifest := pkg.Manifest()
+	result := &SSADVerification{PackageID: pkg.PackageID, Documents: make([]SSADDocumentCheck, 0)}
+
+	for _, cd := range pkg.componentDocuments() {
+		doc := cd.Document
+		check := SSADDocumentCheck{
+			Component:  cd.Component,
+			DocumentID: doc.DocumentID,
+			Location:   doc.Location,
+			Expected:   doc.Hash,
+		}
+		path := doc.Location
+		if !filepath.IsAbs(path) && opts.BaseDir != "" {
+			path = filepath.Join(opts.BaseDir, path)
+		}
+		hash, _, err := HashDocumentFile(path)
+		switch {
+		case err != nil:
+			check.Status = SSADDocumentMissing
+			result.problem("document %s is missing: %s", doc.DocumentID, doc.Location)
+		case hash != doc.Hash:
+			check.Status = SSADDocumentTampered
+			check.Actual = hash
+			result.problem("document %s was modified: %s", doc.DocumentID, doc.Location)
+		default:
+			check.Status = SSADDocumentOK
+			check.Actual = hash
+		}
+		result.Documents = append(result.Documents, check)
+	}
+
+	data, err := manifest.Canonical()
+	if err != nil {
+		result.problem("cannot encode manifest: %v", err)
+		return result
+	}
+	sum := sha256.Sum256(data)
+	result.ManifestDigest = hex.EncodeToString(sum[:])
+	result.ManifestMatches = result.ManifestDigest == pkg.IntegrityCheck.PackageHash
+	if !result.ManifestMatches {
+		result.problem("package hash %s does not match the manifest digest %s", pkg.IntegrityCheck.PackageHash, result.ManifestDigest)
+	}
+
+	if sig == nil {
+		result.problem("package is not signed")
+		return result
+	}
+	if sig.PackageID != pkg.PackageID {
+		result.problem("signature is for package %s", sig.PackageID)
+	}
+	if sig.ManifestDigest != result.ManifestDigest {
+		result.problem("signature covers manifest %s, not %s", sig.ManifestDigest, result.ManifestDigest)
+	}
+	verifySSADSignature(sig, data, opts, result)
+	return result
+}
+
+// verifySSADSignature checks the signature of the canonical manifest and the
+// trust in the signing key
+func verifySSADSignature(sig *SSADSignature, manifest []byte, opts SSADVerifyOptions, result *SSADVerification) {
+	var chain []*x509.Certificate
+	for _, encoded := range sig.CertificateChain {
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			result.problem("invalid certificate in signature")
+			return
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			result.problem("invalid certificate in signature: %v", err)
+			return
+		}
+		chain = append(chain, cert)
+	}
+
+	var public crypto.PublicKey
+	if len(chain) > 0 {
+		public = chain[0].PublicKey
+		result.Signer = chain[0].Subject.String()
+	} else {
+		key, err := parsePublicKeyPEM([]byte(sig.PublicKey))
+		if err != nil {
+			result.problem("invalid public key in signature: %v", err)
+			return
+		}
+		public = key
+	}
+	keyID, err := KeyFingerprint(public)
+	if err != nil {
+		result.problem("unsupported signing key: %v", err)
+		return
+	}
+	if result.Signer == "" {
+		result.Signer = "key " + keyID
+	}
+	if keyID != sig.KeyID {
+		result.problem("signature key ID %s does not match the signing key %s", sig.KeyID, keyID)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		result.problem("signature is not valid base64")
+		return
+	}
+	sum := sha256.Sum256(manifest)
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		result.SignatureValid = ed25519.Verify(key, manifest, signature)
+	case *ecdsa.PublicKey:
+		result.SignatureValid = ecdsa.VerifyASN1(key, sum[:], signature)
+	case *rsa.PublicKey:
+		result.SignatureValid = rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature) == nil
+	default:
+		result.problem("unsupported signing key type %T", public)
+		return
+	}
+	if !result.SignatureValid {
+		result.problem("signature does not match the manifest")
+	}
+
+	// A pinned key is trusted whether or not it came with a certificate
+	for _, trusted := range opts.TrustedKeys {
+		if id, err := KeyFingerprint(trusted); err == nil && id == keyID {
+			result.Trusted = true
+			return
+		}
+	}
+	if len(chain) > 0 {
+		intermediates := x509.NewCertPool()
+		for _, cert := range chain[1:] {
+			intermediates.AddCert(cert)
+		}
+		// The chain is checked at the current time: signed_at is not covered
+		// by the signature, so it cannot vouch for a certificate's validity
+		_, err := chain[0].Verify(x509.VerifyOptions{
+			Roots:         opts.Roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			result.problem("signer certificate is not trusted: %v", err)
+			return
+		}
+		result.Trusted = true
+		return
+	}
+	result.problem("signing key %s is not trusted", keyID)
+}
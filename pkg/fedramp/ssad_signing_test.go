@@ -0,0 +1,182 @@
+package fedramp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFinalSSADTestPackage finalizes a package with an SSP and a SAR written
+// to dir, referenced by locations relative to dir
+func newFinalSSADTestPackage(t *testing.T, dir string) *SSADPackage {
+	t.Helper()
+	pkg := NewSSADPackage("CSO-1", SSADMetadata{Title: "Test package"})
+	for _, doc := range []struct{ component, name, content string }{
+		{"ssp", "ssp.json", `{"system-security-plan":{}}`},
+		{"sar", "sar.json", `{"assessment-results":{}}`},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, doc.name), []byte(doc.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		document, err := NewSSADFileDocument(filepath.Join(dir, doc.name), doc.component, doc.name, "tester")
+		if err != nil {
+			t.Fatal(err)
+		}
+		document.Location = doc.name
+		if err := pkg.AddDocument(doc.component, document); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pkg.Finalize("tester"); err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestSSADSignAndVerify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for algorithm, signer := range map[string]crypto.Signer{"Ed25519": edKey, "ECDSA-SHA256": ecKey, "RSA-SHA256": rsaKey} {
+		dir := t.TempDir()
+		pkg := newFinalSSADTestPackage(t, dir)
+		sig, err := pkg.Sign(&SSADSigningKey{Signer: signer}, "isso")
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if sig.Algorithm != algorithm {
+			t.Errorf("algorithm = %s, want %s", sig.Algorithm, algorithm)
+		}
+
+		result := VerifySSADPackage(pkg, sig, SSADVerifyOptions{BaseDir: dir, TrustedKeys: []crypto.PublicKey{signer.Public()}})
+		if !result.OK() || !result.SignatureValid || !result.Trusted {
+			t.Errorf("%s: verification failed: %v", algorithm, result.Problems)
+		}
+
+		// Without a certificate chain or a pinned key the signer is not trusted
+		untrusted := VerifySSADPackage(pkg, sig, SSADVerifyOptions{BaseDir: dir})
+		if untrusted.OK() || untrusted.Trusted || !untrusted.SignatureValid {
+			t.Errorf("%s: unpinned key: trusted %v, valid %v", algorithm, untrusted.Trusted, untrusted.SignatureValid)
+		}
+	}
+}
+
+func TestSSADVerifyDetectsTampering(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := func(dir string) SSADVerifyOptions {
+		return SSADVerifyOptions{BaseDir: dir, TrustedKeys: []crypto.PublicKey{key.Public()}}
+	}
+
+	t.Run("modified document", func(t *testing.T) {
+		dir := t.TempDir()
+		pkg := newFinalSSADTestPackage(t, dir)
+		sig, err := pkg.Sign(&SSADSigningKey{Signer: key}, "isso")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "sar.json"), []byte(`{"assessment-results":{"tampered":true}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result := VerifySSADPackage(pkg, sig, opts(dir))
+		if result.OK() {
+			t.Fatal("package with a modified document verified")
+		}
+		statuses := make(map[string]string)
+		for _, check := range result.Documents {
+			statuses[check.Location] = check.Status
+		}
+		if statuses["sar.json"] != SSADDocumentTampered || statuses["ssp.json"] != SSADDocumentOK {
+			t.Errorf("document statuses = %v", statuses)
+		}
+	})
+
+	t.Run("missing document", func(t *testing.T) {
+		dir := t.TempDir()
+		pkg := newFinalSSADTestPackage(t, dir)
+		sig, err := pkg.Sign(&SSADSigningKey{Signer: key}, "isso")
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(filepath.Join(dir, "ssp.json"))
+		if result := VerifySSADPackage(pkg, sig, opts(dir)); result.OK() || result.Documents[1].Status != SSADDocumentMissing {
+			t.Errorf("missing document not reported: %+v", result.Documents)
+		}
+	})
+
+	t.Run("modified manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		pkg := newFinalSSADTestPackage(t, dir)
+		sig, err := pkg.Sign(&SSADSigningKey{Signer: key}, "isso")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg.Version = "9.9.9"
+		if result := VerifySSADPackage(pkg, sig, opts(dir)); result.OK() || result.ManifestMatches {
+			t.Error("package with a modified manifest verified")
+		}
+	})
+
+	t.Run("modified signature", func(t *testing.T) {
+		dir := t.TempDir()
+		pkg := newFinalSSADTestPackage(t, dir)
+		sig, err := pkg.Sign(&SSADSigningKey{Signer: key}, "isso")
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged := []byte(sig.Signature)
+		forged[5] ^= 0x01
+		if forged[5] == '+' || forged[5] == '/' || forged[5] == '=' {
+			forged[5] = 'A'
+		}
+		sig.Signature = string(forged)
+		if result := VerifySSADPackage(pkg, sig, opts(dir)); result.OK() || result.SignatureValid {
+			t.Error("forged signature verified")
+		}
+	})
+
+	t.Run("other signer", func(t *testing.T) {
+		dir := t.TempDir()
+		pkg := newFinalSSADTestPackage(t, dir)
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := pkg.Sign(&SSADSigningKey{Signer: other}, "mallory")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := VerifySSADPackage(pkg, sig, opts(dir)); result.OK() || result.Trusted {
+			t.Error("signature by an untrusted key verified")
+		}
+	})
+}
+
+func TestSSADSignRequiresFinalPackage(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := NewSSADPackage("CSO-1", SSADMetadata{})
+	if _, err := pkg.Sign(&SSADSigningKey{Signer: key}, "isso"); err == nil {
+		t.Error("draft package signed")
+	}
+}
@@ -0,0 +1,298 @@
+package fedramp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SSADStore persists authorization packages and their documents
+type SSADStore interface {
+	CreatePackage(pkg *SSADPackage) error
+	GetPackage(packageID string) (*SSADPackage, error)
+	ListPackages() ([]*SSADPackage, error)
+	// UpdatePackage applies fn to the stored package and saves the result
+	// unless fn fails
+	UpdatePackage(packageID string, fn func(*SSADPackage) error) (*SSADPackage, error)
+	// OpenDocument opens the content of a package document after checking it
+	// against the document hash
+	OpenDocument(packageID string, doc SSADDocument) (io.ReadCloser, error)
+	// LogAccess appends to the access log kept beside the package, which
+	// records every read without changing the package itself
+	LogAccess(packageID string, access SSADAccessRecord) error
+	AccessLog(packageID string) ([]SSADAccessRecord, error)
+}
+
+// SSADAccessRecord is an entry of a package access log
+type SSADAccessRecord struct {
+	Time           time.Time `json:"time"`
+	EntityID       string    `json:"entity_id"`
+	Purpose        string    `json:"purpose"`
+	Method         string    `json:"method"`
+	DocumentID     string    `json:"document_id,omitempty"`
+	DistributionID string    `json:"distribution_id,omitempty"`
+}
+
+// ssadAccessLogPath is the name of the access log in a package directory
+const ssadAccessLogPath = "access.jsonl"
+
+// ErrSSADPackageNotFound is returned by stores for unknown packages
+var ErrSSADPackageNotFound = errors.New("package not found")
+
+// FileSSADStore keeps each package in a directory named after its ID, in the
+// layout written by ImportSSADArchive: package.json next to the documents its
+// locations point to
+type FileSSADStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileSSADStore creates a file store rooted at dir, creating it if needed
+func NewFileSSADStore(dir string) (*FileSSADStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create SSAD repository: %w", err)
+	}
+	return &FileSSADStore{dir: dir}, nil
+}
+
+func (s *FileSSADStore) path(packageID string) (string, error) {
+	dir, err := ssadPackageDir(s.dir, packageID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SSADArchivePackagePath), nil
+}
+
+// CreatePackage adds a finalized package to the repository. A new version is
+// only accepted when the version it supersedes is stored unchanged.
+func (s *FileSSADStore) CreatePackage(pkg *SSADPackage) error {
+	if pkg.Status != "final" {
+		return fmt.Errorf("only finalized packages can be added to repository")
+	}
+	path, err := s.path(pkg.PackageID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return ErrSSADPackageExists
+	}
+	if pkg.Parent != nil {
+		parentPath, err := s.path(pkg.Parent.PackageID)
+		if err != nil {
+			return err
+		}
+		parent, err := readSSADPackageFile(parentPath)
+		if err != nil {
+			return fmt.Errorf("parent version %s: %w", pkg.Parent.PackageID, err)
+		}
+		if err := pkg.VerifyParent(parent); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeSSADPackageFile(path, pkg)
+}
+
+// GetPackage reads a package
+func (s *FileSSADStore) GetPackage(packageID string) (*SSADPackage, error) {
+	path, err := s.path(packageID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return readSSADPackageFile(path)
+}
+
+// ListPackages returns every package ordered by ID
+func (s *FileSSADStore) ListPackages() ([]*SSADPackage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*", SSADArchivePackagePath))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	packages := make([]*SSADPackage, 0, len(paths))
+	for _, path := range paths {
+		// Import staging directories are not packages yet
+		if filepath.Base(filepath.Dir(path))[0] == '.' {
+			continue
+		}
+		pkg, err := readSSADPackageFile(path)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// UpdatePackage applies fn to a package under the store lock
+func (s *FileSSADStore) UpdatePackage(packageID string, fn func(*SSADPackage) error) (*SSADPackage, error) {
+	path, err := s.path(packageID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pkg, err := readSSADPackageFile(path)
+	if err != nil {
+		return nil, err
+	}
+	before, err := pkg.Manifest().Digest()
+	if err != nil {
+		return nil, err
+	}
+	status := pkg.Status
+	if err := fn(pkg); err != nil {
+		return nil, err
+	}
+	if pkg.PackageID != packageID {
+		return nil, fmt.Errorf("package ID cannot change")
+	}
+	// Sharing and distribution records change; a finalized version's
+	// documents and status do not go back
+	if status != "draft" {
+		after, err := pkg.Manifest().Digest()
+		if err != nil {
+			return nil, err
+		}
+		if after != before || pkg.Status == "draft" {
+			return nil, ErrSSADPackageImmutable
+		}
+	}
+	if err := writeSSADPackageFile(path, pkg); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// OpenDocument opens a document stored with the package. Only locations
+// inside the package directory are served.
+func (s *FileSSADStore) OpenDocument(packageID string, doc SSADDocument) (io.ReadCloser, error) {
+	dir, err := ssadPackageDir(s.dir, packageID)
+	if err != nil {
+		return nil, err
+	}
+	location, ok := cleanArchivePath(doc.Location)
+	if !ok {
+		return nil, fmt.Errorf("document %s is not stored in the repository", doc.DocumentID)
+	}
+	path := filepath.Join(dir, filepath.FromSlash(location))
+	hash, _, err := HashDocumentFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("document %s is missing: %w", doc.DocumentID, err)
+	}
+	if hash != doc.Hash {
+		return nil, fmt.Errorf("document %s failed its integrity check", doc.DocumentID)
+	}
+	return os.Open(path)
+}
+
+// LogAccess appends an entry to the access log of a stored package
+func (s *FileSSADStore) LogAccess(packageID string, access SSADAccessRecord) error {
+	dir, err := ssadPackageDir(s.dir, packageID)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(access)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := os.Stat(filepath.Join(dir, SSADArchivePackagePath)); os.IsNotExist(err) {
+		return ErrSSADPackageNotFound
+	}
+	file, err := os.OpenFile(filepath.Join(dir, ssadAccessLogPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write access log of %s: %w", packageID, err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write access log of %s: %w", packageID, err)
+	}
+	return file.Close()
+}
+
+// AccessLog reads the access log of a stored package, oldest entry first
+func (s *FileSSADStore) AccessLog(packageID string) ([]SSADAccessRecord, error) {
+	dir, err := ssadPackageDir(s.dir, packageID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, err := os.Stat(filepath.Join(dir, SSADArchivePackagePath)); os.IsNotExist(err) {
+		return nil, ErrSSADPackageNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ssadAccessLogPath))
+	if os.IsNotExist(err) {
+		return []SSADAccessRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access log of %s: %w", packageID, err)
+	}
+	records := make([]SSADAccessRecord, 0)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var record SSADAccessRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("failed to parse access log of %s: %w", packageID, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func readSSADPackageFile(path string) (*SSADPackage, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrSSADPackageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package: %w", err)
+	}
+	var pkg SSADPackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package %s: %w", filepath.Base(filepath.Dir(path)), err)
+	}
+	return &pkg, nil
+}
+
+func writeSSADPackageFile(path string, pkg *SSADPackage) error {
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write package %s: %w", pkg.PackageID, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// FindDocument returns a document of the package and its component by ID
+func (p *SSADPackage) FindDocument(documentID string) (SSADDocument, string, bool) {
+	var found SSADDocument
+	var component string
+	p.eachDocument(func(c string, doc *SSADDocument) {
+		if component == "" && doc.DocumentID == documentID {
+			found, component = *doc, c
+		}
+	})
+	return found, component, component != ""
+}
@@ -0,0 +1,216 @@
+package fedramp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSADVersionLink records the finalized version a package supersedes. The
+// parent's package hash is part of the manifest, so signing a version also
+// signs its lineage.
+type SSADVersionLink struct {
+	PackageID   string              `json:"package_id"`
+	Version     string              `json:"version"`
+	PackageHash string              `json:"package_hash"`
+	Documents   []SSADManifestEntry `json:"documents"`
+}
+
+// ErrSSADPackageImmutable is returned when the content of a finalized package
+// would change
+var ErrSSADPackageImmutable = errors.New("finalized package versions are immutable; create a new version")
+
+// Version bump levels
+const (
+	SSADBumpMajor = "major" // new assessment: SAP or SAR changed
+	SSADBumpMinor = "minor" // SSP changed or documents were removed
+	SSADBumpPatch = "patch" // POA&M, ConMon, SCN and other updates
+)
+
+// NewVersion starts a draft successor of a finalized package. The draft keeps
+// the metadata, documents and sharing of p, and Finalize numbers it from p.
+func (p *SSADPackage) NewVersion() (*SSADPackage, error) {
+	if p.Status == "draft" {
+		return nil, fmt.Errorf("package %s is not finalized", p.PackageID)
+	}
+	if p.IntegrityCheck.PackageHash == "" {
+		return nil, fmt.Errorf("package %s has no package hash", p.PackageID)
+	}
+
+	// Copy through JSON so the draft shares no documents or slices with p
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var next SSADPackage
+	if err := json.Unmarshal(data, &next); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	next.PackageID = nextSSADVersionID(p)
+	next.CreatedAt = now
+	next.UpdatedAt = now
+	next.Status = "draft"
+	next.DistributionLog = make([]SSADDistribution, 0)
+	next.IntegrityCheck = SSADIntegrity{}
+	next.Parent = &SSADVersionLink{
+		PackageID:   p.PackageID,
+		Version:     p.Version,
+		PackageHash: p.IntegrityCheck.PackageHash,
+		Documents:   p.Manifest().Documents,
+	}
+	return &next, nil
+}
+
+// nextSSADVersionID numbers the successors of a package in sequence: the
+// versions of SSAD-X are SSAD-X-v2, SSAD-X-v3 and so on
+func nextSSADVersionID(p *SSADPackage) string {
+	base, sequence := p.PackageID, 1
+	if p.Parent != nil {
+		if i := strings.LastIndex(base, "-v"); i >= 0 {
+			if n, err := strconv.Atoi(base[i+2:]); err == nil && n > 1 {
+				base, sequence = base[:i], n
+			}
+		}
+	}
+	return fmt.Sprintf("%s-v%d", base, sequence+1)
+}
+
+// RemoveDocument removes a document from a draft package
+func (p *SSADPackage) RemoveDocument(documentID string) error {
+	if p.Status != "draft" {
+		return ErrSSADPackageImmutable
+	}
+	removed := false
+	for _, single := range []**SSADDocument{&p.Components.SSP, &p.Components.SAP, &p.Components.SAR, &p.Components.POAM} {
+		if *single != nil && (*single).DocumentID == documentID {
+			*single = nil
+			removed = true
+		}
+	}
+	for _, list := range []*[]SSADDocument{
+		&p.Components.ConMon,
+		&p.Components.SCNs,
+		&p.Components.IncidentReports,
+		&p.Components.Attachments,
+		&p.Components.KSIReports,
+	} {
+		kept := (*list)[:0]
+		for _, doc := range *list {
+			if doc.DocumentID == documentID {
+				removed = true
+				continue
+			}
+			kept = append(kept, doc)
+		}
+		*list = kept
+	}
+	if !removed {
+		return fmt.Errorf("document %s not found in package", documentID)
+	}
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// RelocateDocuments rewrites the location of every document, for example when
+// the package file moves. Locations are not part of the manifest.
+func (p *SSADPackage) RelocateDocuments(fn func(location string) string) {
+	p.eachDocument(func(_ string, doc *SSADDocument) {
+		doc.Location = fn(doc.Location)
+	})
+}
+
+// VersionBump reports how the version number changes from the parent: major
+// when the SAP or SAR changed, minor when the SSP changed or documents were
+// removed, patch otherwise
+func (p *SSADPackage) VersionBump() string {
+	if p.Parent == nil {
+		return ""
+	}
+	previous := make(map[string]string)
+	for _, entry := range p.Parent.Documents {
+		previous[ssadDocumentKey(entry.Component, entry.DocumentID)] = entry.SHA256
+	}
+
+	bump := SSADBumpPatch
+	current := make(map[string]bool)
+	for _, entry := range p.Manifest().Documents {
+		key := ssadDocumentKey(entry.Component, entry.DocumentID)
+		current[key] = true
+		if previous[key] == entry.SHA256 {
+			continue
+		}
+		switch entry.Component {
+		case "sap", "sar":
+			return SSADBumpMajor
+		case "ssp":
+			bump = SSADBumpMinor
+		}
+	}
+	for key := range previous {
+		if !current[key] {
+			bump = SSADBumpMinor
+		}
+	}
+	return bump
+}
+
+// ssadDocumentKey identifies a document across versions: the single SSP, SAP,
+// SAR and POA&M by their component, other documents by their ID
+func ssadDocumentKey(component, documentID string) string {
+	switch component {
+	case "ssp", "sap", "sar", "poam":
+		return component
+	}
+	return component + "/" + documentID
+}
+
+// BumpSSADVersion increments a MAJOR.MINOR.PATCH version at the given level
+func BumpSSADVersion(version, level string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) > 3 {
+		return "", fmt.Errorf("invalid package version %q", version)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid package version %q", version)
+		}
+		numbers[i] = n
+	}
+
+	switch level {
+	case SSADBumpMajor:
+		numbers = []int{numbers[0] + 1, 0, 0}
+	case SSADBumpMinor:
+		numbers = []int{numbers[0], numbers[1] + 1, 0}
+	case SSADBumpPatch:
+		numbers[2]++
+	default:
+		return "", fmt.Errorf("unknown version bump %q", level)
+	}
+	return fmt.Sprintf("%d.%d.%d", numbers[0], numbers[1], numbers[2]), nil
+}
+
+// VerifyParent checks that parent is the exact version p supersedes
+func (p *SSADPackage) VerifyParent(parent *SSADPackage) error {
+	if p.Parent == nil {
+		return fmt.Errorf("package %s has no parent version", p.PackageID)
+	}
+	if parent.PackageID != p.Parent.PackageID {
+		return fmt.Errorf("package %s supersedes %s, not %s", p.PackageID, p.Parent.PackageID, parent.PackageID)
+	}
+	digest, err := parent.Manifest().Digest()
+	if err != nil {
+		return err
+	}
+	if digest != p.Parent.PackageHash || parent.IntegrityCheck.PackageHash != p.Parent.PackageHash {
+		return fmt.Errorf("package %s does not match the version %s was derived from", parent.PackageID, p.PackageID)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package fedramp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSSADNewVersionIDs(t *testing.T) {
+	dir := t.TempDir()
+	first := newFinalSSADTestPackage(t, dir)
+
+	// Versions created within the same second still get distinct IDs
+	ids := []string{first.PackageID}
+	pkg := first
+	for i := 0; i < 3; i++ {
+		next, err := pkg.NewVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := next.Finalize("tester"); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, next.PackageID)
+		pkg = next
+	}
+	for i, want := range []string{first.PackageID + "-v2", first.PackageID + "-v3", first.PackageID + "-v4"} {
+		if ids[i+1] != want {
+			t.Errorf("version %d ID = %s, want %s", i+2, ids[i+1], want)
+		}
+	}
+}
+
+func TestSSADDocumentIdentityAcrossVersions(t *testing.T) {
+	dir := t.TempDir()
+	first := newFinalSSADTestPackage(t, dir)
+	addFile := func(pkg *SSADPackage, component, name, content string) SSADDocument {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		doc, err := NewSSADFileDocument(path, component, name, "tester")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.DocumentID != SSADDocumentID(component, path) {
+			t.Errorf("document ID %s is not derived from type and path", doc.DocumentID)
+		}
+		doc.Location = name
+		if err := pkg.AddDocument(component, doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	second, err := first.NewVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment := addFile(second, "attachment", "inventory.csv", "a,b\n")
+	if err := second.Finalize("tester"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An attachment whose content changes keeps its ID, so replacing it is a
+	// patch rather than the removal of a document
+	third, err := second.NewVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := third.RemoveDocument(attachment.DocumentID); err != nil {
+		t.Fatal(err)
+	}
+	if updated := addFile(third, "attachment", "inventory.csv", "a,b,c\n"); updated.DocumentID != attachment.DocumentID {
+		t.Errorf("updated attachment ID = %s, want %s", updated.DocumentID, attachment.DocumentID)
+	}
+	if bump := third.VersionBump(); bump != SSADBumpPatch {
+		t.Errorf("changed attachment bump = %s, want %s", bump, SSADBumpPatch)
+	}
+
+	if other := addFile(third, "attachment", "boundary.csv", "a,b\n"); other.DocumentID == attachment.DocumentID {
+		t.Error("attachments at different paths share an ID")
+	}
+}
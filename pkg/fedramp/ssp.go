@@ -7,6 +7,7 @@ import (
 	"github.com/gocomply/oscalkit/pkg/oscal/constants"
 	"github.com/gocomply/oscalkit/pkg/oscal_source"
 	ssp "github.com/gocomply/oscalkit/types/oscal/system_security_plan"
+	"strings"
 )
 
 type SSP struct {
@@ -46,6 +47,16 @@ func NewSSP(sspSource *oscal_source.OSCALSource) (*SSP, error) {
 	return &result, nil
 }
 
+// OpenSSP reads an OSCAL SSP from a file
+func OpenSSP(path string) (*SSP, error) {
+	source, err := oscal_source.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+	return NewSSP(source)
+}
+
 func (p *SSP) Level() common.BaselineLevel {
 	if p.plan.ImportProfile == nil {
 		return common.LevelUnknown
@@ -87,6 +98,20 @@ func (p *SSP) ParamValue(controlId string, index int) (string, error) {
 	return "", nil
 }
 
+// ParameterValues returns the parameter values the SSP sets in its
+// implemented requirements, by parameter ID
+func (p *SSP) ParameterValues() map[string]string {
+	values := make(map[string]string)
+	for _, ir := range p.plan.ControlImplementation.ImplementedRequirements {
+		for _, setting := range ir.ParameterSettings {
+			if value := strings.TrimSpace(string(setting.Value)); value != "" {
+				values[setting.ParamId] = value
+			}
+		}
+	}
+	return values
+}
+
 func (p *SSP) ImplementationStatusForControl(controlId string) ImplementationStatus {
 	ir, found := p.implementedRequirementsCache[utils.ControlKeyToOSCAL(controlId)]
 	if !found {
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+)
+
+// Default is the registry exposed by the API server
+var Default = NewRegistry()
+
+// Compliance metric families. Every per-CSO family carries a cso_id label.
+var (
+	ksiStatus = Default.NewGaugeVec("fedramp_ksi_status",
+		"Current validation status of each Key Security Indicator (1 for the current status)",
+		"cso_id", "ksi_id", "status")
+	ksiCompliance = Default.NewGaugeVec("fedramp_ksi_compliance_ratio",
+		"Share of Key Security Indicators validated as True",
+		"cso_id")
+
+	poamOpen = Default.NewGaugeVec("fedramp_poam_open_items",
+		"Open POA&M items by severity",
+		"cso_id", "severity")
+	poamOverdue = Default.NewGaugeVec("fedramp_poam_overdue_items",
+		"Overdue POA&M items by severity",
+		"cso_id", "severity")
+
+	scnCount = Default.NewGaugeVec("fedramp_scn_notifications",
+		"Significant Change Notifications by lifecycle state",
+		"cso_id", "status")
+
+	crsValue = Default.NewGaugeVec("fedramp_crs_metric_value",
+		"Latest value of each CRS Key Security Metric",
+		"cso_id", "metric_id")
+	crsStatus = Default.NewGaugeVec("fedramp_crs_metric_status",
+		"Current threshold status of each CRS Key Security Metric (1 for the current status)",
+		"cso_id", "metric_id", "status")
+
+	validatorScore = Default.NewGaugeVec("fedramp_validator_score",
+		"Score of the latest run of each continuous monitoring validator",
+		"cso_id", "validator")
+	validatorRuns = Default.NewCounterVec("fedramp_validator_runs",
+		"Continuous monitoring validator runs by result",
+		"cso_id", "validator", "result")
+
+	alerts = Default.NewCounterVec("fedramp_alerts",
+		"Alerts raised by severity",
+		"cso_id", "severity")
+
+	requestDuration = Default.NewHistogramVec("fedramp_api_request_duration_seconds",
+		"Latency of API requests", "seconds", DefaultBuckets,
+		"method", "route", "code")
+)
+
+var (
+	ksiStatuses  = []fedramp.KSIValidationStatus{fedramp.KSIStatusTrue, fedramp.KSIStatusPartial, fedramp.KSIStatusFalse}
+	poamSeverity = []string{"Critical", "High", "Moderate", "Low"}
+	scnStatuses  = []fedramp.SCNStatus{
+		fedramp.SCNStatusDraft, fedramp.SCNStatusSubmitted, fedramp.SCNStatusUnderReview, fedramp.SCNStatusApproved,
+		fedramp.SCNStatusImplemented, fedramp.SCNStatusVerified, fedramp.SCNStatusClosed, fedramp.SCNStatusWithdrawn,
+	}
+	crsStatuses = []fedramp.MetricStatus{fedramp.MetricStatusGreen, fedramp.MetricStatusYellow, fedramp.MetricStatusRed, fedramp.MetricStatusGray}
+)
+
+// RecordKSIReport replaces the KSI gauges of a service offering with the
+// statuses of a report
+func RecordKSIReport(report *fedramp.KSIReport) {
+	csoID := report.ServiceOfferingID
+	ksiStatus.DeleteMatching("cso_id", csoID)
+	compliant := 0
+	for ksiID, validation := range report.Validations {
+		for _, status := range ksiStatuses {
+			ksiStatus.Set(boolValue(validation.Status == status), csoID, ksiID, string(status))
+		}
+		if validation.Status == fedramp.KSIStatusTrue {
+			compliant++
+		}
+	}
+	if len(report.Validations) > 0 {
+		ksiCompliance.Set(float64(compliant)/float64(len(report.Validations)), csoID)
+	}
+}
+
+// RecordPOAM replaces the POA&M gauges of a service offering with the open and
+// overdue items of a POA&M
+func RecordPOAM(csoID string, poam *fedramp.PlanOfActionMilestones) {
+	open := make(map[string]int)
+	overdue := make(map[string]int)
+	for _, item := range poam.GetOpenItems() {
+		open[fedramp.NormalizeSeverity(item.Severity)]++
+	}
+	for _, item := range poam.GetOverdueItems() {
+		overdue[fedramp.NormalizeSeverity(item.Severity)]++
+	}
+
+	poamOpen.DeleteMatching("cso_id", csoID)
+	poamOverdue.DeleteMatching("cso_id", csoID)
+	for _, severity := range poamSeverity {
+		poamOpen.Set(float64(open[severity]), csoID, severity)
+		poamOverdue.Set(float64(overdue[severity]), csoID, severity)
+	}
+}
+
+// RecordSCNs replaces the SCN gauges of a service offering with the counts of
+// its notifications by state
+func RecordSCNs(csoID string, scns []*fedramp.SignificantChangeNotification) {
+	counts := make(map[fedramp.SCNStatus]int)
+	for _, scn := range scns {
+		status := scn.Status
+		if status == "" {
+			status = fedramp.SCNStatusDraft
+		}
+		counts[status]++
+	}
+	scnCount.DeleteMatching("cso_id", csoID)
+	for _, status := range scnStatuses {
+		scnCount.Set(float64(counts[status]), csoID, string(status))
+	}
+}
+
+// RecordCRSMetric sets the value and status gauges of an evaluated CRS metric.
+// Non-numeric values only update the status.
+func RecordCRSMetric(csoID string, metric fedramp.KeySecurityMetric) {
+	if value, ok := metric.NumericValue(); ok {
+		crsValue.Set(value, csoID, metric.MetricID)
+	}
+	for _, status := range crsStatuses {
+		crsStatus.Set(boolValue(metric.Status == status), csoID, metric.MetricID, string(status))
+	}
+}
+
+// RecordValidation records a run of a continuous monitoring validator
+func RecordValidation(csoID, validator string, score float64, valid bool) {
+	validatorScore.Set(score, csoID, validator)
+	result := "fail"
+	if valid {
+		result = "pass"
+	}
+	validatorRuns.Inc(csoID, validator, result)
+}
+
+// RecordValidationError records a validator run that could not complete
+func RecordValidationError(csoID, validator string) {
+	validatorRuns.Inc(csoID, validator, "error")
+}
+
+// RecordAlert counts an alert raised for a service offering
+func RecordAlert(csoID, severity string) {
+	alerts.Inc(csoID, severity)
+}
+
+// ObserveRequest records the latency of an API request. The route is the
+// matched path template so IDs do not create new series.
+func ObserveRequest(method, route string, code int, duration time.Duration) {
+	requestDuration.Observe(duration.Seconds(), method, route, strconv.Itoa(code))
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,266 @@
+// Package metrics keeps compliance and API metrics and exposes them in the
+// OpenMetrics text format. Values are updated as the underlying data changes
+// so a scrape only serializes the current state.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the OpenMetrics text exposition
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+const (
+	typeGauge     = "gauge"
+	typeCounter   = "counter"
+	typeHistogram = "histogram"
+)
+
+// Registry holds metric families
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	unit    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // gauge value, counter total or histogram sum
+	counts      []uint64 // per-bucket counts of a histogram
+	count       uint64
+}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[f.name]; exists {
+		panic(fmt.Sprintf("metrics: %s registered twice", f.name))
+	}
+	f.series = make(map[string]*series)
+	r.families[f.name] = f
+	return f
+}
+
+// seriesFor returns the series with the given label values, creating it if
+// needed. The family lock must be held.
+func (f *family) seriesFor(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.typ == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// deleteMatching removes the series whose label has the given value
+func (f *family) deleteMatching(label, value string) {
+	index := -1
+	for i, name := range f.labels {
+		if name == label {
+			index = i
+		}
+	}
+	if index < 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, s := range f.series {
+		if s.labelValues[index] == value {
+			delete(f.series, key)
+		}
+	}
+}
+
+// GaugeVec is a family of gauges partitioned by label values
+type GaugeVec struct{ f *family }
+
+// NewGaugeVec registers a gauge family
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(&family{name: name, help: help, typ: typeGauge, labels: labels})}
+}
+
+// Set sets the gauge with the given label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.seriesFor(labelValues).value = value
+}
+
+// DeleteMatching removes every gauge whose label has the given value, e.g. all
+// gauges of a service offering before its current values are set
+func (g *GaugeVec) DeleteMatching(label, value string) {
+	g.f.deleteMatching(label, value)
+}
+
+// CounterVec is a family of monotonically increasing counters
+type CounterVec struct{ f *family }
+
+// NewCounterVec registers a counter family. The name must not carry the
+// _total suffix, which is added to its samples.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(&family{name: name, help: help, typ: typeCounter, labels: labels})}
+}
+
+// Inc increments the counter with the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter with the given label values. Negative values are
+// ignored.
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.seriesFor(labelValues).value += value
+}
+
+// HistogramVec is a family of histograms partitioned by label values
+type HistogramVec struct{ f *family }
+
+// NewHistogramVec registers a histogram family with the given bucket upper
+// bounds and unit. The name must end with the unit, e.g. _seconds.
+func (r *Registry) NewHistogramVec(name, help, unit string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	return &HistogramVec{r.register(&family{name: name, help: help, typ: typeHistogram, unit: unit, labels: labels, buckets: bounds})}
+}
+
+// Observe adds a value to the histogram with the given label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.seriesFor(labelValues)
+	for i, bound := range h.f.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// WriteOpenMetrics writes every family in the OpenMetrics text format, sorted
+// by name and label values, terminated by # EOF
+func (r *Registry) WriteOpenMetrics(w io.Writer) error {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	b := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(b)
+	}
+	b.WriteString("# EOF\n")
+	return b.Flush()
+}
+
+// ServeHTTP serves the OpenMetrics exposition
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	r.WriteOpenMetrics(w)
+}
+
+func (f *family) write(b *bufio.Writer) {
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.typ)
+	if f.unit != "" {
+		fmt.Fprintf(b, "# UNIT %s %s\n", f.name, f.unit)
+	}
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escaper.Replace(f.help))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		switch f.typ {
+		case typeGauge:
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelSet(s, "", ""), formatValue(s.value))
+		case typeCounter:
+			fmt.Fprintf(b, "%s_total%s %s\n", f.name, f.labelSet(s, "", ""), formatValue(s.value))
+		case typeHistogram:
+			for i, bound := range f.buckets {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s, "le", formatValue(bound)), s.counts[i])
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelSet(s, "le", "+Inf"), s.count)
+			fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelSet(s, "", ""), s.count)
+			fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelSet(s, "", ""), formatValue(s.value))
+		}
+	}
+}
+
+// labelSet renders the labels of a series, with an optional extra label
+func (f *family) labelSet(s *series, extraName, extraValue string) string {
+	pairs := make([]string, 0, len(f.labels)+1)
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", name, escaper.Replace(s.labelValues[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extraName, escaper.Replace(extraValue)))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escaper escapes label values and help text as OpenMetrics requires
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
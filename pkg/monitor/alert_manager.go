@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gocomply/fedramp/pkg/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -72,6 +73,7 @@ func (am *AlertManager) RegisterHandler(name string, handler AlertHandler) {
 // SendAlert queues an alert for processing
 func (am *AlertManager) SendAlert(alert *Alert) {
 	alert.ID = fmt.Sprintf("ALERT-%d", time.Now().UnixNano())
+	metrics.RecordAlert(alert.CSOId, alert.Severity)
 	
 	select {
 	case am.alerts <- alert:
@@ -9,6 +9,7 @@ import (
 
 	"github.com/gocomply/fedramp/pkg/database"
 	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/gocomply/fedramp/pkg/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,6 +20,10 @@ type ContinuousMonitor struct {
 	collectors   map[string]MetricCollector
 	alertManager *AlertManager
 	config       *Config
+	scnStore     fedramp.SCNStore
+	scnPolicy    *fedramp.SCNDeadlinePolicy
+	scnCheckMu   sync.Mutex // serializes SCN deadline checks
+	poamStore    fedramp.POAMStore
 	mu           sync.RWMutex
 	ctx          context.Context
 	cancel       context.CancelFunc
@@ -153,6 +158,8 @@ func (cm *ContinuousMonitor) validationLoop() {
 		select {
 		case <-ticker.C:
 			cm.runValidations()
+			cm.checkSCNDeadlines(time.Now())
+			cm.expireDeviations(time.Now())
 		case <-cm.ctx.Done():
 			return
 		}
@@ -196,6 +203,7 @@ func (cm *ContinuousMonitor) runValidations() {
 				result, err := v.Validate(cm.ctx, cid)
 				if err != nil {
 					log.Errorf("Validation failed for %s/%s: %v", cid, n, err)
+					metrics.RecordValidationError(cid, n)
 					return
 				}
 
@@ -239,6 +247,7 @@ func (cm *ContinuousMonitor) processValidationResult(csoID, validatorName string
 	if err := cm.storeValidationResult(csoID, validatorName, result); err != nil {
 		log.Errorf("Failed to store validation result: %v", err)
 	}
+	metrics.RecordValidation(csoID, validatorName, result.Score, result.Valid)
 
 	// Check for violations
 	if !result.Valid || result.Score < cm.config.AlertThreshold {
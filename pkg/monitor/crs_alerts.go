@@ -0,0 +1,35 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+)
+
+// WatchCRS raises an alert whenever a metric evaluated by the CRS manager
+// turns red
+func (cm *ContinuousMonitor) WatchCRS(mgr *fedramp.CRSManager) {
+	mgr.OnRedTransition(cm.alertRedMetric)
+}
+
+func (cm *ContinuousMonitor) alertRedMetric(serviceID string, metric fedramp.KeySecurityMetric, previous fedramp.MetricStatus) {
+	metadata := map[string]interface{}{
+		"metric_id":        metric.MetricID,
+		"value":            metric.Value,
+		"previous_status":  previous,
+		"related_controls": metric.RelatedControls,
+	}
+	if metric.Threshold != nil {
+		metadata["threshold"] = metric.Threshold
+	}
+
+	cm.alertManager.SendAlert(&Alert{
+		Severity:    "critical",
+		Title:       fmt.Sprintf("CRS metric red: %s", metric.MetricName),
+		Description: fmt.Sprintf("%s changed from %s to red at %v %s", metric.MetricName, previous, metric.Value, metric.Unit),
+		CSOId:       serviceID,
+		Timestamp:   time.Now(),
+		Metadata:    metadata,
+	})
+}
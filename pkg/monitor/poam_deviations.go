@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetPOAMStore enables the expiry of approved POA&M deviation requests in the
+// given store
+func (cm *ContinuousMonitor) SetPOAMStore(store fedramp.POAMStore) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.poamStore = store
+}
+
+// expireDeviations expires the approved deviation requests that are past
+// their expiration date, reverting their adjustments, and raises an alert for
+// each POA&M that changed
+func (cm *ContinuousMonitor) expireDeviations(now time.Time) {
+	cm.mu.RLock()
+	store := cm.poamStore
+	cm.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	poams, err := store.ListPOAMs()
+	if err != nil {
+		log.Errorf("Failed to load POA&Ms for deviation expiry: %v", err)
+		return
+	}
+	for _, poam := range poams {
+		expired := poam.ExpireDeviationRequests(now)
+		if len(expired) == 0 {
+			continue
+		}
+		if err := store.SavePOAM(poam); err != nil {
+			log.Errorf("Failed to save POA&M %s after expiring deviations: %v", poam.ServiceOfferingID, err)
+			continue
+		}
+		cm.alertManager.SendAlert(&Alert{
+			Severity:    "medium",
+			Title:       fmt.Sprintf("POA&M deviations expired: %d", len(expired)),
+			Description: fmt.Sprintf("Deviation requests %s expired and their adjustments were reverted", strings.Join(expired, ", ")),
+			CSOId:       poam.ServiceOfferingID,
+			Timestamp:   now,
+			Metadata: map[string]interface{}{
+				"deviation_requests": expired,
+			},
+		})
+	}
+}
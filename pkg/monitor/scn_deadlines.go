@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetSCNStore enables SCN deadline checks against the given store
+func (cm *ContinuousMonitor) SetSCNStore(store fedramp.SCNStore, policy *fedramp.SCNDeadlinePolicy) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if policy == nil {
+		policy = fedramp.DefaultSCNDeadlinePolicy()
+	}
+	cm.scnStore = store
+	cm.scnPolicy = policy
+}
+
+// checkSCNDeadlines sends a reminder for each SCN obligation coming due and
+// an alert for each one that has passed its due date. Sent alerts are
+// recorded on the SCN so each is sent once, across restarts.
+func (cm *ContinuousMonitor) checkSCNDeadlines(now time.Time) {
+	cm.mu.RLock()
+	store, policy := cm.scnStore, cm.scnPolicy
+	cm.mu.RUnlock()
+	if store == nil {
+		return
+	}
+	cm.scnCheckMu.Lock()
+	defer cm.scnCheckMu.Unlock()
+
+	scns, err := store.GetSCNsByCSOID("")
+	if err != nil {
+		log.Errorf("Failed to load SCNs for deadline check: %v", err)
+		return
+	}
+	byID := make(map[string]*fedramp.SignificantChangeNotification, len(scns))
+	for _, scn := range scns {
+		byID[scn.ID] = scn
+	}
+
+	sent := make(map[string][]string)
+	var order []string
+	for _, obligation := range policy.DueSCNObligations(scns, now) {
+		key := obligation.AlertKey()
+		if _, done := byID[obligation.SCNID].DeadlineAlerts[key]; done {
+			continue
+		}
+		cm.alertManager.SendAlert(scnDeadlineAlert(obligation, now))
+		if len(sent[obligation.SCNID]) == 0 {
+			order = append(order, obligation.SCNID)
+		}
+		sent[obligation.SCNID] = append(sent[obligation.SCNID], key)
+	}
+
+	for _, id := range order {
+		if err := recordSCNDeadlineAlerts(store, id, sent[id], now); err != nil {
+			log.Errorf("Failed to record deadline alerts for SCN %s: %v", id, err)
+		}
+	}
+}
+
+// recordSCNDeadlineAlerts marks alerts as sent on the stored SCN. The SCN is
+// reloaded first so changes saved since the check are kept.
+func recordSCNDeadlineAlerts(store fedramp.SCNStore, scnID string, keys []string, now time.Time) error {
+	scn, err := store.GetSCN(scnID)
+	if err != nil {
+		return err
+	}
+	if scn.DeadlineAlerts == nil {
+		scn.DeadlineAlerts = make(map[string]time.Time)
+	}
+	for _, key := range keys {
+		scn.DeadlineAlerts[key] = now
+	}
+	return store.SaveSCN(scn)
+}
+
+func scnDeadlineAlert(obligation fedramp.SCNObligation, now time.Time) *Alert {
+	severity, title := "high", "SCN deadline missed"
+	description := fmt.Sprintf("%s for SCN %s was due %s", obligation.Description, obligation.SCNID, obligation.DueDate.Format("2006-01-02"))
+	if obligation.Status == fedramp.SCNObligationUpcoming {
+		severity, title = "medium", "SCN deadline approaching"
+		description = fmt.Sprintf("%s for SCN %s is due %s", obligation.Description, obligation.SCNID, obligation.DueDate.Format("2006-01-02"))
+	}
+	return &Alert{
+		Severity:    severity,
+		Title:       fmt.Sprintf("%s: %s", title, obligation.Name),
+		Description: description,
+		CSOId:       obligation.CSOID,
+		Timestamp:   now,
+		Metadata: map[string]interface{}{
+			"scn_id":   obligation.SCNID,
+			"artifact": obligation.Artifact,
+			"due_date": obligation.DueDate,
+			"status":   obligation.Status,
+		},
+	}
+}
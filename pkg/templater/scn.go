@@ -0,0 +1,35 @@
+package templater
+
+import (
+	"github.com/gocomply/fedramp/pkg/fedramp"
+	"github.com/gocomply/fedramp/pkg/templater/template"
+)
+
+// ConvertSCN renders the SCN into a Word document. templateText is the Go
+// template producing the document text and docxPath the Word document
+// holding the SCN content marker; the bundled default is used for either when
+// empty.
+func ConvertSCN(scn *fedramp.SignificantChangeNotification, templateText, docxPath, outputPath string) error {
+	if templateText == "" {
+		var err error
+		templateText, err = fedramp.LoadSCNTemplate(fedramp.SCNFormatDOCX, "")
+		if err != nil {
+			return err
+		}
+	}
+	content, err := fedramp.RenderSCN(scn, fedramp.SCNFormatDOCX, templateText)
+	if err != nil {
+		return err
+	}
+
+	doc, err := template.NewSCNTemplate(docxPath)
+	if err != nil {
+		return err
+	}
+	defer doc.Close()
+
+	if err = doc.SetSCNContent(string(content)); err != nil {
+		return err
+	}
+	return doc.Save(outputPath)
+}
@@ -0,0 +1,82 @@
+package template
+
+import (
+	"errors"
+	"github.com/gocomply/fedramp/bundled"
+	"github.com/gocomply/fedramp/pkg/docx_helper"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// SCNContentMarker is the text of the paragraph in the SCN Word template that
+// is replaced with the rendered notification
+const SCNContentMarker = "SCN-CONTENT"
+
+var scnHeadingStyles = []struct {
+	prefix string
+	style  string
+}{
+	{"### ", "Heading3"},
+	{"## ", "Heading2"},
+	{"# ", "Heading1"},
+}
+
+// NewSCNTemplate opens the Word document at filePath, or the bundled SCN
+// template when filePath is empty
+func NewSCNTemplate(filePath string) (*Template, error) {
+	if filePath != "" {
+		return NewTemplateFile(filePath)
+	}
+
+	in, err := bundled.TemplateSCNDOCX()
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile("/tmp", "FedRAMP-SCN")
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	defer os.Remove(out.Name())
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTemplateFile(out.Name())
+}
+
+// SetSCNContent replaces the content marker paragraph with the rendered SCN.
+// Blocks separated by blank lines become paragraphs, and blocks starting with
+// "# ", "## " or "### " use the matching heading style.
+func (t *Template) SetSCNContent(content string) error {
+	nodes, err := t.xmlDoc.Search("//w:p[normalize-space(.)='" + SCNContentMarker + "']")
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("Could not locate the " + SCNContentMarker + " paragraph in the SCN template")
+	}
+
+	var paragraphs []docx_helper.StyledParagraph
+	for _, block := range strings.Split(content, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		paragraph := docx_helper.StyledParagraph{Text: block}
+		for _, heading := range scnHeadingStyles {
+			if strings.HasPrefix(block, heading.prefix) {
+				paragraph = docx_helper.StyledParagraph{Style: heading.style, Text: strings.TrimPrefix(block, heading.prefix)}
+				break
+			}
+		}
+		paragraphs = append(paragraphs, paragraph)
+	}
+	return docx_helper.ParagraphReplaceWithParagraphs(nodes[0], paragraphs)
+}